@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxAliasDepth bounds how many aliases may expand into one another
+const maxAliasDepth = 10
+
+// builtinCommands lists every command executeCommand understands
+var builtinCommands = []string{
+	"alias",
+	"all_off",
+	"all_off!",
+	"all_on",
+	"all_on!",
+	"automations",
+	"away",
+	"brightness",
+	"bridge",
+	"clear",
+	"columns",
+	"connectivity",
+	"ct",
+	"delete",
+	"diagnostics",
+	"filter",
+	"gradient",
+	"help",
+	"macro",
+	"match",
+	"mirror",
+	"move",
+	"order",
+	"ping",
+	"profile",
+	"reconnect",
+	"refresh",
+	"rename",
+	"reveal-key",
+	"room",
+	"scene",
+	"search",
+	"select",
+	"set",
+	"signal",
+	"toggle",
+	"updates",
+	"version",
+	"zone",
+}
+
+func isBuiltinCommand(name string) bool {
+	for _, builtin := range builtinCommands {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expandAlias replaces a leading alias with its command, repeatedly, keeping
+// any arguments typed after the alias
+func expandAlias(command string, aliases map[string]string) (string, error) {
+	seen := []string{}
+	for depth := 0; depth <= maxAliasDepth; depth++ {
+		name, args, _ := strings.Cut(command, " ")
+		expansion, ok := aliases[name]
+		if !ok {
+			return command, nil
+		}
+
+		for _, previous := range seen {
+			if previous == name {
+				return "", fmt.Errorf(glyphText("alias loop: %s → %s"), strings.Join(seen, glyphText(" → ")), name)
+			}
+		}
+		seen = append(seen, name)
+
+		command = expansion
+		if args != "" {
+			command += " " + args
+		}
+	}
+	return "", fmt.Errorf("alias %q expands more than %d levels deep", seen[0], maxAliasDepth)
+}
+
+// describeAliases lists the configured aliases for :alias
+func describeAliases(aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return tr("status.aliases.none")
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf(glyphText("%s → %s"), name, aliases[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// completeCommand completes the command name being typed from the built-in
+// commands and aliases. It returns the new text and, when the prefix is
+// ambiguous, the candidates.
+func completeCommand(text string, aliases map[string]string) (string, []string) {
+	if strings.Contains(text, " ") {
+		return text, nil
+	}
+
+	var candidates []string
+	for _, builtin := range builtinCommands {
+		if strings.HasPrefix(builtin, text) {
+			candidates = append(candidates, builtin)
+		}
+	}
+	for alias := range aliases {
+		if strings.HasPrefix(alias, text) {
+			candidates = append(candidates, alias)
+		}
+	}
+	sort.Strings(candidates)
+
+	switch len(candidates) {
+	case 0:
+		return text, nil
+	case 1:
+		return candidates[0] + " ", nil
+	}
+
+	// Extend to the longest prefix every candidate shares
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix, candidates
+}
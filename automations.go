@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// behaviorInstance is the CLIP v2 behavior_instance resource: an automation
+// the bridge runs itself, such as a wake up, go to sleep or timer. Its
+// configuration depends on the automation's script, so it's kept as JSON.
+type behaviorInstance struct {
+	ID            string         `json:"id"`
+	Enabled       bool           `json:"enabled"`
+	Status        string         `json:"status"` // "initializing", "running", "disabled" or "errored"
+	Configuration map[string]any `json:"configuration"`
+	Metadata      struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+}
+
+// automation is a row of the automations view
+type automation struct {
+	id       string
+	name     string
+	enabled  bool
+	status   string
+	schedule string // when it runs, e.g. "07:00 weekdays"; "" when it can't be read
+}
+
+// automationPane is the :automations view of the bridge's automations
+type automationPane struct {
+	open        bool
+	cursor      int
+	automations []automation
+}
+
+// getAutomations lists the bridge's automations by name
+func getAutomations() ([]automation, error) {
+	var instances []behaviorInstance
+	if err := bridgeCache.list("behavior_instance", &instances); err != nil {
+		return nil, err
+	}
+	automations := make([]automation, 0, len(instances))
+	for _, instance := range instances {
+		a := automation{
+			id:       instance.ID,
+			name:     instance.Metadata.Name,
+			enabled:  instance.Enabled,
+			status:   instance.Status,
+			schedule: automationSchedule(instance.Configuration),
+		}
+		if a.name == "" {
+			a.name = instance.ID
+		}
+		automations = append(automations, a)
+	}
+	sort.Slice(automations, func(i, j int) bool { return automations[i].name < automations[j].name })
+	return automations, nil
+}
+
+// automationsCommand handles ":automations", opening the automations view
+func (m *lightModel) automationsCommand() error {
+	automations, err := getAutomations()
+	if err != nil {
+		return fmt.Errorf("loading automations: %v", err)
+	}
+	m.automationPane = automationPane{open: true, automations: automations}
+	return nil
+}
+
+// handleAutomationPaneKey processes a key while the automations view is open
+func (m *lightModel) handleAutomationPaneKey(key string) {
+	pane := &m.automationPane
+	switch key {
+	case "esc", "q":
+		pane.open = false
+	case "up", "k":
+		pane.cursor = max(0, pane.cursor-1)
+	case "down", "j":
+		pane.cursor = min(pane.cursor+1, max(0, len(pane.automations)-1))
+	case "enter":
+		m.toggleAutomation()
+	}
+}
+
+// toggleAutomation enables or disables the automation under the cursor
+func (m *lightModel) toggleAutomation() {
+	pane := &m.automationPane
+	if pane.cursor >= len(pane.automations) {
+		return
+	}
+	a := &pane.automations[pane.cursor]
+	enabled := !a.enabled
+	if _, err := clipWrite("PUT", "resource/behavior_instance/"+a.id, map[string]any{"enabled": enabled}); err != nil {
+		m.setStatusTr("status.change_failed", a.name, err)
+		return
+	}
+	a.enabled = enabled
+	if enabled {
+		m.setStatusTr("status.enabled", a.name)
+	} else {
+		m.setStatusTr("status.disabled", a.name)
+	}
+}
+
+// handleAutomationUpdate applies a behavior_instance SSE event to the
+// automations view, such as one enabled from the phone app
+func (m *lightModel) handleAutomationUpdate(item SSEDataItem) {
+	for i := range m.automationPane.automations {
+		a := &m.automationPane.automations[i]
+		if a.id != item.ID {
+			continue
+		}
+		if item.Enabled != nil {
+			a.enabled = *item.Enabled
+		}
+		if item.Status.State != "" {
+			a.status = item.Status.State
+		}
+	}
+}
+
+// automationSchedule describes when an automation runs from its
+// configuration, e.g. "07:00 weekdays", "sunset -30m" or "timer 10m". Scripts
+// shape their configuration differently, so this looks for the parts most
+// share and returns "" when it finds none.
+func automationSchedule(config map[string]any) string {
+	var parts []string
+	when := jsonObject(config, "when")
+	if when == nil {
+		when = jsonObject(config, "when_extended")
+	}
+	if when != nil {
+		point := jsonObject(when, "time_point")
+		if point == nil {
+			point = jsonObject(jsonObject(when, "start_at"), "time_point")
+		}
+		if at := timePointString(point); at != "" {
+			parts = append(parts, at)
+		}
+		if days, ok := when["recurrence_days"].([]any); ok {
+			parts = append(parts, recurrenceString(days))
+		}
+	}
+	if seconds, ok := jsonObject(config, "duration")["seconds"].(float64); ok {
+		parts = append(parts, "timer "+humanizeDuration(time.Duration(seconds)*time.Second))
+	}
+	return strings.Join(parts, " ")
+}
+
+// jsonObject returns the object under key in decoded JSON, or nil
+func jsonObject(obj map[string]any, key string) map[string]any {
+	child, _ := obj[key].(map[string]any)
+	return child
+}
+
+// timePointString renders a time_point: a clock time in the configured
+// format, or sunrise or sunset with any offset
+func timePointString(point map[string]any) string {
+	kind, _ := point["type"].(string)
+	switch kind {
+	case "time":
+		clock := jsonObject(point, "time")
+		hour, ok1 := clock["hour"].(float64)
+		minute, ok2 := clock["minute"].(float64)
+		if !ok1 || !ok2 {
+			return ""
+		}
+		t := time.Date(2000, 1, 1, int(hour), int(minute), 0, 0, time.Local)
+		return formatClock(t, t, appConfig.Units.Time)
+	case "sunrise", "sunset":
+		if minutes, ok := jsonObject(point, "offset")["minutes"].(float64); ok && minutes != 0 {
+			return fmt.Sprintf("%s %+dm", kind, int(minutes))
+		}
+		return kind
+	}
+	return ""
+}
+
+// recurrenceString shortens a list of weekdays, e.g. "weekdays" or "mon, sat"
+func recurrenceString(days []any) string {
+	set := make(map[string]bool, len(days))
+	for _, day := range days {
+		if name, ok := day.(string); ok {
+			set[name] = true
+		}
+	}
+	week := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	switch {
+	case len(set) == 7:
+		return "every day"
+	case len(set) == 5 && !set["saturday"] && !set["sunday"]:
+		return "weekdays"
+	case len(set) == 2 && set["saturday"] && set["sunday"]:
+		return "weekends"
+	}
+	var short []string
+	for _, day := range week {
+		if set[day] {
+			short = append(short, day[:3])
+		}
+	}
+	return strings.Join(short, ", ")
+}
+
+// renderAutomationPane draws the automations view
+func (m lightModel) renderAutomationPane() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	pane := m.automationPane
+	b.WriteString(titleStyle.Render(tr("automations.title")) + " " + faint.Render(fmt.Sprintf("%d", len(pane.automations))) + "\n\n")
+	if len(pane.automations) == 0 {
+		b.WriteString(faint.Render("  "+tr("automations.none")) + "\n")
+	}
+
+	visible := m.paneLines()
+	start := max(0, pane.cursor-visible+1)
+	end := min(start+visible, len(pane.automations))
+	for i := start; i < end; i++ {
+		a := pane.automations[i]
+		cursor := "  "
+		if i == pane.cursor {
+			cursor = cursorStyle.Render(glyphText("▶ "))
+		}
+		state := tr("state.enabled")
+		if !a.enabled {
+			state = tr("state.disabled")
+		}
+		if a.status == "errored" {
+			state = tr("state.errored")
+		}
+		line := fitCell(a.name, nameWidth) + " " + fitCell(state, 9) + " " + orDash(a.schedule)
+		if !a.enabled {
+			line = sensorDisabledStyle.Render(ansi.Strip(line))
+		}
+		b.WriteString(cursor + line + "\n")
+	}
+
+	b.WriteString("\n" + faint.Render(m.keyFooter(keysAutomations, m.width)))
+	if m.status != "" {
+		b.WriteString("\n" + m.status)
+	}
+	return viewText(b.String()) + "\n"
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/openhue/openhue-go"
+)
+
+// Away mode pacing: every awayCheckInterval the schedule is checked, and
+// within the window a new set of lights is switched on every 20-45 minutes
+const (
+	awayCheckInterval = time.Minute
+	awayMinSwitch     = 20 * time.Minute
+	awayMaxSwitch     = 45 * time.Minute
+	awayMinLit        = 2
+	awayMaxLit        = 3
+)
+
+var awayBannerStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFB86C")).MarginLeft(2)
+
+// AwayConfig is the saved :away setup
+type AwayConfig struct {
+	Lights []string `yaml:"lights,omitempty"` // light IDs taking part
+	Window string   `yaml:"window,omitempty"` // e.g. "18:00-23:00"
+}
+
+// pickAwayLights chooses which lights to have on for the next stretch
+func pickAwayLights(ids []string, r *rand.Rand) map[string]bool {
+	count := min(awayMinLit+r.IntN(awayMaxLit-awayMinLit+1), len(ids))
+	lit := make(map[string]bool, count)
+	for _, i := range r.Perm(len(ids))[:count] {
+		lit[ids[i]] = true
+	}
+	return lit
+}
+
+// awayMode is a running :away session
+type awayMode struct {
+	window     timeWindow
+	lights     []string         // light IDs taking part
+	snapshot   map[string]Light // state before away started, restored by :away stop
+	lit        map[string]bool  // lights away mode currently has on
+	next       time.Time        // when to pick new lights; zero until the window opens
+	generation int              // ignores ticks from an earlier session
+	rand       *rand.Rand
+}
+
+// awayTickMsg checks the away schedule
+type awayTickMsg struct {
+	generation int
+}
+
+func awayTick(generation int) tea.Cmd {
+	return tea.Tick(awayCheckInterval, func(time.Time) tea.Msg {
+		return awayTickMsg{generation: generation}
+	})
+}
+
+// awayCommand handles ":away start [window]" and ":away stop"
+func (m *lightModel) awayCommand(args string) error {
+	action, window, _ := strings.Cut(args, " ")
+	switch action {
+	case "start":
+		return m.startAway(strings.TrimSpace(window))
+	case "stop":
+		return m.stopAway()
+	default:
+		return fmt.Errorf("usage: away start [HH:MM-HH:MM] | away stop")
+	}
+}
+
+// startAway begins away mode with the selected lights, or the saved ones when
+// nothing is selected. A new window or set of lights is saved for next time.
+func (m *lightModel) startAway(windowArg string) error {
+	if m.away != nil {
+		return fmt.Errorf("away mode is already running")
+	}
+
+	config := appConfig.Away
+	if windowArg != "" {
+		config.Window = windowArg
+	}
+	if len(m.selected) > 0 {
+		config.Lights = nil
+		for _, light := range m.light {
+			if m.isSelectedID(light.ID) {
+				config.Lights = append(config.Lights, light.ID)
+			}
+		}
+	}
+	if config.Window == "" || len(config.Lights) == 0 {
+		return fmt.Errorf("select the lights to use and give a window, e.g. away start 18:00-23:00")
+	}
+	window, err := parseTimeWindow(config.Window)
+	if err != nil {
+		return err
+	}
+
+	if config.Window != appConfig.Away.Window || !slices.Equal(config.Lights, appConfig.Away.Lights) {
+		if err := setConfigValue("away", config); err != nil {
+			return fmt.Errorf("saving away settings: %v", err)
+		}
+		appConfig.Away = config
+	}
+
+	snapshot := make(map[string]Light, len(config.Lights))
+	for _, id := range config.Lights {
+		if light, ok := m.lightByID(id); ok {
+			snapshot[id] = light
+		}
+	}
+
+	m.awayGeneration++
+	generation := m.awayGeneration
+	m.away = &awayMode{
+		window:     window,
+		lights:     config.Lights,
+		snapshot:   snapshot,
+		lit:        make(map[string]bool),
+		generation: generation,
+		rand:       rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)),
+	}
+	m.setStatus("%s", trn("status.away.started", len(config.Lights), len(config.Lights), window))
+	m.stepAway(time.Now())
+	m.queue(awayTick(generation))
+	return nil
+}
+
+// stopAway ends away mode and puts the lights back as they were
+func (m *lightModel) stopAway() error {
+	if m.away == nil {
+		return fmt.Errorf("away mode is not running")
+	}
+	away := m.away
+	m.away = nil
+
+	failed := 0
+	for _, id := range away.lights {
+		before, ok := away.snapshot[id]
+		if !ok || !before.Reachable {
+			continue
+		}
+		on := before.Status == "on"
+		put := openhue.LightPut{On: &openhue.On{On: &on}}
+		outgoing.recordOn(id, on)
+		if on && before.can(capDimming) {
+			brightness := before.Brightness
+			put.Dimming = &openhue.Dimming{Brightness: &brightness}
+			outgoing.recordBrightness(id, brightness)
+		}
+		err := updateLight(id, put)
+		if err != nil {
+			logError("Away: error restoring light %s: %v", id, err)
+			failed++
+			continue
+		}
+		m.setLightOn(id, on)
+	}
+	if failed > 0 {
+		return fmt.Errorf(glyphText("away mode stopped · %d %s couldn't be restored"), failed, pluralize(failed, "light", "lights"))
+	}
+	m.setStatusTr("status.away.stopped")
+	return nil
+}
+
+// handleAwayTick advances the schedule and waits for the next check
+func (m *lightModel) handleAwayTick(msg awayTickMsg) tea.Cmd {
+	if m.away == nil || msg.generation != m.away.generation {
+		return nil
+	}
+	m.stepAway(time.Now())
+	return awayTick(msg.generation)
+}
+
+// stepAway switches lights when a new stretch is due, and turns everything off
+// once the window closes
+func (m *lightModel) stepAway(now time.Time) {
+	away := m.away
+	if !away.window.contains(now) {
+		if len(away.lit) > 0 {
+			m.switchAwayLights(map[string]bool{})
+		}
+		away.next = time.Time{}
+		return
+	}
+	if now.Before(away.next) {
+		return
+	}
+	m.switchAwayLights(pickAwayLights(away.lights, away.rand))
+	away.next = now.Add(awayMinSwitch + time.Duration(away.rand.Int64N(int64(awayMaxSwitch-awayMinSwitch))))
+}
+
+// switchAwayLights turns the lights in lit on and every other participant off
+func (m *lightModel) switchAwayLights(lit map[string]bool) {
+	for _, id := range m.away.lights {
+		light, ok := m.lightByID(id)
+		if !ok || !light.Reachable {
+			continue
+		}
+		on := lit[id]
+		if (light.Status == "on") == on {
+			continue
+		}
+		if err := toggleLight(id, !on); err != nil {
+			logError("Away: error switching light %s: %v", light.Name, err)
+			continue
+		}
+		m.setLightOn(id, on)
+	}
+	m.away.lit = lit
+}
+
+// renderAwayBanner shows that away mode is running, or "" when it isn't
+func (m lightModel) renderAwayBanner() string {
+	if m.away == nil {
+		return ""
+	}
+	return awayBannerStyle.Render(m.away.describe(time.Now())) + "\n"
+}
+
+// describe summarizes the session, e.g. "AWAY MODE · 18:00-23:00 · 2 on · next switch 19:42"
+func (a *awayMode) describe(now time.Time) string {
+	text := fmt.Sprintf(glyphText("AWAY MODE · %s"), a.window)
+	if a.next.IsZero() {
+		return text + glyphText(" · waiting for the window")
+	}
+	return text + fmt.Sprintf(glyphText(" · %d on · next switch %s"), len(a.lit), formatClock(a.next, now, appConfig.Units.Time))
+}
+
+// lightByID returns the light with the given ID
+func (m lightModel) lightByID(id string) (Light, bool) {
+	for _, light := range m.light {
+		if light.ID == id {
+			return light, true
+		}
+	}
+	return Light{}, false
+}
+
+// setLightOn updates the cached on/off status of a light
+func (m *lightModel) setLightOn(id string, on bool) {
+	for i := range m.light {
+		if m.light[i].ID == id {
+			if on {
+				m.light[i].Status = "on"
+			} else {
+				m.light[i].Status = "off"
+			}
+		}
+	}
+}
+
+// isSelectedID reports whether the light with the given ID is selected
+func (m lightModel) isSelectedID(id string) bool {
+	for index := range m.selected {
+		if m.light[index].ID == id {
+			return true
+		}
+	}
+	return false
+}
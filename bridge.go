@@ -3,11 +3,40 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sethchev/hue-control-tui/pkg/hueclient"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/openhue/openhue-go"
 )
 
+const (
+	// linkButtonWindow is how long setup keeps polling after the bridge is found
+	linkButtonWindow = 60 * time.Second
+
+	// linkButtonPollInterval is how often setup retries authentication
+	linkButtonPollInterval = 2 * time.Second
+
+	// setupTickInterval drives the countdown and spinner
+	setupTickInterval = 250 * time.Millisecond
+)
+
+var (
+	spinnerFrames      = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	asciiSpinnerFrames = []string{"|", "/", "-", "\\"}
+)
+
+// setupTickMsg advances the link-button countdown
+type setupTickMsg time.Time
+
+func setupTick() tea.Cmd {
+	return tea.Tick(setupTickInterval, func(t time.Time) tea.Msg {
+		return setupTickMsg(t)
+	})
+}
+
 // bridgeSetupModel represents the TUI state for bridge setup
 type bridgeSetupModel struct {
 	showDiscovery bool
@@ -15,7 +44,24 @@ type bridgeSetupModel struct {
 	bridgeIP      string
 	apiKey        string
 	error         string
-	step          int // 0: prompt, 1: discovering, 2: press button, 3: complete
+	step          int                // 0: prompt, 1: discovering, 2: press button, 3: verifying, 4: complete, 5: confirm overwrite, 6: choose bridge
+	bridges       []discoveredBridge // discovery results when there are several
+
+	existingIP string // bridge in the current config when re-running setup with --setup
+
+	configPath      string // where the config was, or would have been, saved
+	saveError       string // set when the config couldn't be written
+	showCredentials bool   // print the IP and key for saving by hand
+	bridgeName      string // from the verification step
+	bridgeID        string
+	lightCount      int
+
+	// Link-button polling
+	deadline    time.Time // when the countdown ends
+	nextPoll    time.Time // when to try authenticating again
+	authPending bool      // an Authenticate call is in flight
+	expired     bool      // the countdown ran out
+	frame       int       // spinner frame
 }
 
 func (m bridgeSetupModel) Init() tea.Cmd {
@@ -27,91 +73,310 @@ func (m bridgeSetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch m.step {
 		case 0: // Initial prompt
-			if msg.String() == "y" || msg.String() == "Y" {
+			if msg.String() == "enter" && m.existingIP != "" {
+				// Re-pair with the bridge at its known address
+				m.bridgeIP = m.existingIP
+				m.step = 2
+				cmd := m.startCountdown()
+				return m, cmd
+			}
+			if msg.String() == "y" || msg.String() == "Y" || (msg.String() == "d" && m.existingIP != "") {
 				m.step = 1
 				m.discovering = true
 				return m, tea.Cmd(func() tea.Msg {
-					bridge, err := openhue.NewBridgeDiscovery().Discover()
-					if err != nil {
-						return bridgeDiscoveryResult{err: err}
-					}
-					return bridgeDiscoveryResult{bridge: bridge}
+					bridges, err := discoverBridges(discoveryMethod)
+					return bridgeDiscoveryResult{bridges: bridges, err: err}
 				})
 			} else if msg.String() == "n" || msg.String() == "N" {
 				return m, tea.Quit
 			}
 		case 2: // Press button step
-			if msg.String() == " " || msg.String() == "enter" {
-				return m, tea.Cmd(func() tea.Msg {
-					authenticator, err := openhue.NewAuthenticator(m.bridgeIP)
-					if err != nil {
-						return authResult{err: err}
-					}
-					apiKey, retry, err := authenticator.Authenticate()
-					return authResult{apiKey: apiKey, retry: retry, err: err}
-				})
+			switch msg.String() {
+			case " ", "enter":
+				// Try now rather than waiting for the next poll
+				if !m.expired {
+					cmd := m.authenticate()
+					return m, cmd
+				}
+			case "r":
+				if m.expired {
+					cmd := m.startCountdown()
+					return m, cmd
+				}
+			}
+		case 6: // Choose between several bridges
+			key := msg.String()
+			if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+				if choice := int(key[0] - '1'); choice < len(m.bridges) {
+					m.bridgeIP = m.bridges[choice].IP
+					m.step = 2
+					cmd := m.startCountdown()
+					return m, cmd
+				}
+			}
+			if key == "n" || key == "esc" {
+				return m, tea.Quit
 			}
-		case 3: // Complete
+		case 5: // Confirm replacing the existing config
+			switch msg.String() {
+			case "y", "Y":
+				m.save()
+			case "n", "N", "esc":
+				return m, tea.Quit
+			}
+		case 4: // Complete
 			if msg.String() == "enter" {
 				return m, tea.Quit
 			}
+			if msg.String() == "p" && m.saveError != "" {
+				m.showCredentials = true
+			}
 		}
 	case bridgeDiscoveryResult:
 		if msg.err != nil {
 			m.error = msg.err.Error()
 			m.step = 0
+		} else if len(msg.bridges) > 1 {
+			m.bridges = msg.bridges
+			m.step = 6
 		} else {
-			m.bridgeIP = msg.bridge.IpAddress
+			m.bridgeIP = msg.bridges[0].IP
 			m.step = 2
+			m.discovering = false
+			cmd := m.startCountdown()
+			return m, cmd
 		}
 		m.discovering = false
+	case setupTickMsg:
+		if m.step != 2 || m.expired {
+			return m, nil
+		}
+		now := time.Time(msg)
+		m.frame++
+		if !now.Before(m.deadline) {
+			m.expired = true
+			return m, nil
+		}
+		if !m.authPending && !now.Before(m.nextPoll) {
+			cmd := m.authenticate()
+			return m, tea.Batch(setupTick(), cmd)
+		}
+		return m, setupTick()
 	case authResult:
+		m.authPending = false
+		m.nextPoll = time.Now().Add(linkButtonPollInterval)
 		if msg.err != nil && !msg.retry {
+			// Keep polling; the bridge may just be slow to answer
 			m.error = msg.err.Error()
 		} else if msg.retry {
-			m.error = "Link button not pressed. Press spacebar to try again."
+			m.error = ""
 		} else {
 			m.apiKey = msg.apiKey
 			m.step = 3
-			// Save config
-			saveConfig(m.bridgeIP, m.apiKey)
+			return m, verifyBridge(m.bridgeIP, m.apiKey)
+		}
+	case verifyResult:
+		if msg.err != nil {
+			// Don't save credentials the app can't use; start over instead
+			logError("Bridge verification failed: %v", msg.err)
+			m.error = fmt.Sprintf("Paired with %s but couldn't reach its API with the new key: %v", m.bridgeIP, msg.err)
+			m.apiKey = ""
+			m.step = 0
+			return m, nil
+		}
+		m.bridgeName = msg.bridgeName
+		m.bridgeID = msg.bridgeID
+		m.lightCount = msg.lightCount
+		if m.existingIP != "" {
+			m.step = 5
+			return m, nil
 		}
+		m.save()
 	}
 	return m, nil
 }
 
+// save writes the new credentials and moves to the completion screen
+func (m *bridgeSetupModel) save() {
+	m.step = 4
+	path, err := saveConfig(m.bridgeIP, m.apiKey, m.bridgeID)
+	m.configPath = path
+	if err != nil {
+		logError("Failed to save config: %v", err)
+		m.saveError = err.Error()
+	}
+}
+
+// complete reports whether setup finished with working credentials
+func (m bridgeSetupModel) complete() bool {
+	return m.step == 4
+}
+
 func (m bridgeSetupModel) View() string {
 	switch m.step {
 	case 0:
-		s := "No Hue Bridge configuration found.\n\n"
+		if m.existingIP != "" {
+			s := tr("setup.existing", m.existingIP) + "\n\n"
+			if m.error != "" {
+				s += tr("setup.error", m.error) + "\n\n"
+			}
+			s += tr("setup.existing.prompt")
+			return s
+		}
+		s := tr("setup.none") + "\n\n"
 		if m.error != "" {
-			s += fmt.Sprintf("Error: %s\n\n", m.error)
+			s += tr("setup.error", m.error) + "\n\n"
 		}
-		s += "Would you like to discover your Hue Bridge? (y/n): "
+		s += tr("setup.discover.prompt")
 		return s
 	case 1:
-		return "Discovering Hue Bridge on your network...\nPlease wait..."
+		return tr("setup.discovering")
 	case 2:
-		s := fmt.Sprintf("Found Hue Bridge at: %s\n\n", m.bridgeIP)
-		s += "Please press the link button on your Hue Bridge, then press SPACEBAR to continue.\n"
+		s := tr("setup.found", m.bridgeIP) + "\n\n"
+		if m.expired {
+			s += tr("setup.expired") + "\n"
+		} else {
+			frames := spinnerFrames
+			if asciiMode {
+				frames = asciiSpinnerFrames
+			}
+			remaining := time.Until(m.deadline).Round(time.Second)
+			s += tr("setup.press") + "\n\n"
+			s += tr("setup.waiting", frames[m.frame%len(frames)], remaining) + "\n"
+		}
 		if m.error != "" {
 			s += fmt.Sprintf("\n%s", m.error)
 		}
 		return s
 	case 3:
-		s := "Setup complete!\n\n"
-		s += fmt.Sprintf("Bridge IP: %s\n", m.bridgeIP)
-		s += fmt.Sprintf("API Key: %s\n\n", m.apiKey)
-		s += "Configuration saved to ~/.openhue/config.yaml\n"
-		s += "Press ENTER to start the application..."
+		return tr("setup.paired", m.bridgeIP)
+	case 6:
+		s := tr("setup.several") + "\n\n"
+		for i, bridge := range m.bridges {
+			if i == 9 {
+				break
+			}
+			id := bridge.ID
+			if id == "" {
+				id = tr("setup.unknown_id")
+			}
+			s += tr("setup.choice", i+1, bridge.IP, id, bridge.Source) + "\n"
+		}
+		s += "\n" + tr("setup.choose.prompt")
+		return s
+	case 5:
+		s := trn("setup.connected", m.lightCount, m.bridgeName, m.bridgeIP, m.lightCount) + "\n\n"
+		s += tr("setup.replaces", m.existingIP) + "\n"
+		s += tr("setup.overwrite")
+		return s
+	case 4:
+		s := tr("setup.complete") + "\n\n"
+		s += trn("setup.summary", m.lightCount, m.bridgeName, m.lightCount) + "\n\n"
+		s += tr("setup.ip", m.bridgeIP) + "\n"
+		if m.saveError == "" {
+			s += tr("setup.key.saved", maskKey(m.apiKey)) + "\n\n"
+			s += tr("setup.saved", m.configPath) + "\n"
+			s += tr("setup.reveal") + "\n"
+		} else {
+			s += tr("setup.key", maskKey(m.apiKey)) + "\n\n"
+			if m.configPath != "" {
+				s += tr("setup.save.failed", m.configPath, m.saveError) + "\n\n"
+			} else {
+				s += tr("setup.save.nowhere", m.saveError) + "\n\n"
+			}
+			s += tr("setup.session.only") + "\n"
+			if m.showCredentials {
+				s += "\n" + tr("setup.manual", m.bridgeIP, m.apiKey) + "\n\n"
+			} else {
+				s += tr("setup.print.prompt") + "\n"
+			}
+		}
+		s += tr("setup.start")
 		return s
 	}
 	return ""
 }
 
+// startCountdown begins polling for the link button press
+func (m *bridgeSetupModel) startCountdown() tea.Cmd {
+	now := time.Now()
+	m.deadline = now.Add(linkButtonWindow)
+	m.nextPoll = now
+	m.expired = false
+	m.error = ""
+	return setupTick()
+}
+
+// authenticate asks the bridge for a key, which succeeds once the link button has been pressed
+func (m *bridgeSetupModel) authenticate() tea.Cmd {
+	if m.authPending {
+		return nil
+	}
+	m.authPending = true
+	bridgeIP := m.bridgeIP
+	return func() tea.Msg {
+		authenticator, err := openhue.NewAuthenticator(bridgeIP)
+		if err != nil {
+			return authResult{err: err}
+		}
+		apiKey, retry, err := authenticator.Authenticate()
+		return authResult{apiKey: apiKey, retry: retry, err: err}
+	}
+}
+
+// verifyResult is the outcome of a first API call with new credentials
+type verifyResult struct {
+	bridgeName string
+	bridgeID   string
+	lightCount int
+	err        error
+}
+
+// verifyBridge checks that the new key works by fetching the lights and the bridge's name
+func verifyBridge(bridgeIP, apiKey string) tea.Cmd {
+	return func() tea.Msg {
+		client := hueclient.New(bridgeIP, apiKey, bridgeHTTPClient())
+		lights, err := client.ListLights(appCtx)
+		if err != nil {
+			return verifyResult{err: err}
+		}
+
+		// The bridge's own device carries the name set in the Hue app
+		name := bridgeIP
+		var devices struct {
+			Data []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Services []struct {
+					Rtype string `json:"rtype"`
+				} `json:"services"`
+			} `json:"data"`
+		}
+		if err := client.Do(appCtx, "GET", "clip/v2/resource/device", nil, &devices); err != nil {
+			return verifyResult{err: err}
+		}
+		for _, device := range devices.Data {
+			for _, service := range device.Services {
+				if service.Rtype == "bridge" && device.Metadata.Name != "" {
+					name = device.Metadata.Name
+				}
+			}
+		}
+		// The ID lets the app find the bridge again if its address changes
+		var bridgeID string
+		if config, err := getPublicBridgeConfig(bridgeIP); err != nil {
+			logError("Unable to read bridge ID: %v", err)
+		} else {
+			bridgeID = config.BridgeID
+		}
+		return verifyResult{bridgeName: name, bridgeID: bridgeID, lightCount: len(lights)}
+	}
+}
+
 type bridgeDiscoveryResult struct {
-	bridge *openhue.BridgeInfo
-	err    error
+	bridges []discoveredBridge
+	err     error
 }
 
 type authResult struct {
@@ -120,18 +385,26 @@ type authResult struct {
 	err    error
 }
 
-func saveConfig(bridgeIP, apiKey string) error {
-	homedir, err := os.UserHomeDir()
+// saveConfig writes the bridge IP and key to the config file, keeping any
+// other settings, and returns the path it wrote (or tried to write) so
+// failures can name it
+func saveConfig(bridgeIP, apiKey, bridgeID string) (string, error) {
+	dir, err := configDir()
 	if err != nil {
-		return err
+		return "", fmt.Errorf("unable to find your home directory (is $HOME set?): %v", err)
 	}
+	path := filepath.Join(dir, "config.yaml")
 
-	configDir := homedir + "/.openhue"
-	err = os.MkdirAll(configDir, 0755)
-	if err != nil {
-		return err
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return path, err
 	}
-
-	config := fmt.Sprintf("bridge: %s\nkey: %s\n", bridgeIP, apiKey)
-	return os.WriteFile(configDir+"/config.yaml", []byte(config), 0644)
+	if err := setConfigValue("bridge", bridgeIP); err != nil {
+		return path, err
+	}
+	if bridgeID != "" {
+		if err := setConfigValue("bridge_id", bridgeID); err != nil {
+			return path, err
+		}
+	}
+	return path, setConfigValue("key", apiKey)
 }
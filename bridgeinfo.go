@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// bridgeDetails collects what :bridge shows about the connected bridge
+type bridgeDetails struct {
+	Name           string
+	ID             string
+	IP             string
+	Software       string
+	APIVersion     string
+	ZigbeeChannel  int
+	ResourceCounts map[string]int
+	Limits         *bridgeLimits // nil when they couldn't be read
+}
+
+// fetchBridgeDetails gathers bridge details from the v2 bridge and device
+// resources, the v1 config and capabilities and the full resource list
+func fetchBridgeDetails() (*bridgeDetails, error) {
+	bridge, err := getBridge()
+	if err != nil {
+		return nil, err
+	}
+
+	details := &bridgeDetails{
+		ID: bridge.BridgeID,
+		IP: bridgeIP,
+	}
+
+	if device, err := getDevice(bridge.Owner.Rid); err != nil {
+		logError("Error fetching bridge device: %v", err)
+	} else {
+		details.Name = device.Metadata.Name
+		details.Software = device.ProductData.SoftwareVersion
+	}
+
+	if config, err := getBridgeConfigV1(); err != nil {
+		logError("Error fetching bridge config: %v", err)
+	} else {
+		if details.Name == "" {
+			details.Name = config.Name
+		}
+		details.APIVersion = config.APIVersion
+		details.ZigbeeChannel = config.ZigbeeChannel
+	}
+
+	details.ResourceCounts, err = getResourceCounts()
+	if err != nil {
+		logError("Error fetching resource counts: %v", err)
+	}
+
+	if details.Limits, err = getBridgeLimits(); err != nil {
+		logError("Error fetching bridge limits: %v", err)
+	}
+
+	return details, nil
+}
+
+// String renders the details as a few lines for the command box
+func (d *bridgeDetails) String() string {
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+
+	channel := "unknown"
+	if d.ZigbeeChannel > 0 {
+		channel = fmt.Sprintf("%d", d.ZigbeeChannel)
+	}
+
+	lines := []string{
+		fmt.Sprintf(glyphText("Bridge %s · ID %s · IP %s"), orUnknown(d.Name), orUnknown(d.ID), d.IP),
+		fmt.Sprintf(glyphText("Software %s · API %s · Zigbee channel %s"), orUnknown(d.Software), orUnknown(d.APIVersion), channel),
+	}
+
+	if len(d.ResourceCounts) > 0 {
+		types := make([]string, 0, len(d.ResourceCounts))
+		for t := range d.ResourceCounts {
+			types = append(types, t)
+		}
+		// Most common resource types first
+		sort.Slice(types, func(i, j int) bool {
+			if d.ResourceCounts[types[i]] != d.ResourceCounts[types[j]] {
+				return d.ResourceCounts[types[i]] > d.ResourceCounts[types[j]]
+			}
+			return types[i] < types[j]
+		})
+
+		counts := make([]string, 0, len(types))
+		for _, t := range types {
+			counts = append(counts, fmt.Sprintf("%d %s", d.ResourceCounts[t], t))
+		}
+		lines = append(lines, "Resources: "+strings.Join(counts, ", "))
+	}
+
+	if l := d.Limits; l != nil {
+		lines = append(lines, fmt.Sprintf("Limits: %d/%d lights, %d/%d rooms and zones, %d/%d scenes, %d/%d rules",
+			l.Lights.used(), l.Lights.Total, l.Groups.used(), l.Groups.Total, l.Scenes.used(), l.Scenes.Total, l.Rules.used(), l.Rules.Total))
+	}
+
+	return strings.Join(lines, "\n")
+}
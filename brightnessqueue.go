@@ -0,0 +1,203 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// brightnessFlushDelay is how long after the last brightness keypress the
+	// merged targets are written
+	brightnessFlushDelay = 150 * time.Millisecond
+
+	// brightnessSettle is how long after a write SSE brightness that doesn't
+	// match the target is put down to older writes still landing
+	brightnessSettle = time.Second
+)
+
+// brightnessIntent is where the user has asked a light's brightness to go.
+// Keypresses move it at once; one write per light follows when they stop.
+type brightnessIntent struct {
+	target      float32
+	minDimLevel float32
+	power       *bool     // switch the light on or off with the write; nil leaves it
+	sent        bool      // written to the bridge, waiting for its echo
+	sentAt      time.Time // when it was written
+}
+
+// brightnessFlushMsg writes the pending targets, unless a later keypress has
+// scheduled another flush
+type brightnessFlushMsg struct{ generation int }
+
+// adjustSelectedBrightness moves the brightness of every selected light by
+// change. The table shows the new value straight away; the write waits until
+// the keypresses stop, so mashing a key sends one absolute value per light.
+func (m *lightModel) adjustSelectedBrightness(change int) tea.Cmd {
+	if m.brightnessIntents == nil {
+		m.brightnessIntents = make(map[string]brightnessIntent)
+	}
+
+	changed, skipped, onOffOnly := 0, 0, 0
+	for index := range m.selected {
+		light := m.light[index]
+		if !light.Reachable {
+			logInfo("Skipping unreachable light %s", light.Name)
+			skipped++
+			continue
+		}
+		if !light.can(capDimming) {
+			onOffOnly++
+			continue
+		}
+
+		// The table already shows any earlier target, so steps add up
+		target, power := dimStep(light, change, appConfig.onOnDim())
+		m.brightnessIntents[light.ID] = brightnessIntent{target: target, minDimLevel: light.MinDimLevel, power: power}
+		m.light[index].Brightness = target
+		if power != nil {
+			m.light[index].Status = onOffStatus(*power)
+		}
+		changed++
+	}
+	m.setStatus("%s", summarizeAction("action.brightness.step", changed, skipped, 0, change)+onOffOnlyNote(onOffOnly, tr("what.brightness")))
+
+	m.brightnessGeneration++
+	return m.scheduleBrightnessFlush()
+}
+
+// scheduleBrightnessFlush writes the pending targets after
+// brightnessFlushDelay, unless the generation moves on first
+func (m *lightModel) scheduleBrightnessFlush() tea.Cmd {
+	generation := m.brightnessGeneration
+	return tea.Tick(brightnessFlushDelay, func(time.Time) tea.Msg {
+		return brightnessFlushMsg{generation: generation}
+	})
+}
+
+// rescheduleBrightnessFlush schedules a flush for targets still waiting to
+// be written, after the generation was moved on to drop the earlier flush
+func (m *lightModel) rescheduleBrightnessFlush() tea.Cmd {
+	for _, intent := range m.brightnessIntents {
+		if !intent.sent {
+			return m.scheduleBrightnessFlush()
+		}
+	}
+	return nil
+}
+
+// dimStep works out where a brightness keypress takes a light, the way a
+// dimmer switch behaves: stepping down past the bottom switches the light off,
+// and stepping up on a light that's off switches it on when onOnDim is set.
+// power says which way to switch the light, or is nil to leave it.
+func dimStep(light Light, change int, onOnDim bool) (target float32, power *bool) {
+	target = clampBrightness(light.Brightness+float32(change), light.MinDimLevel)
+	isOn := light.Status == "on"
+	switch {
+	case change < 0 && isOn && light.Brightness+float32(change) <= 0:
+		off := false
+		return target, &off
+	case change > 0 && !isOn && onOnDim:
+		on := true
+		return target, &on
+	}
+	return target, nil
+}
+
+// onOffStatus is the Status of a light that is switched on or off
+func onOffStatus(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// handleBrightnessFlush writes each light's final target once, in the
+// background. An intent counts as sent from the start, so SSE echoes of older
+// writes don't move the table while it is on its way.
+func (m *lightModel) handleBrightnessFlush(msg brightnessFlushMsg) {
+	if msg.generation != m.brightnessGeneration {
+		return
+	}
+
+	var writes []lightWrite
+	now := time.Now()
+	for id, intent := range m.brightnessIntents {
+		if intent.sent {
+			if now.Sub(intent.sentAt) > brightnessSettle {
+				delete(m.brightnessIntents, id)
+			}
+			continue
+		}
+		light, ok := m.lightByID(id)
+		if !ok {
+			light = Light{ID: id, Name: id}
+		}
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			return writeIntent(id, intent)
+		}})
+		intent.sent, intent.sentAt = true, now
+		m.brightnessIntents[id] = intent
+	}
+	if len(writes) == 0 {
+		return
+	}
+
+	m.startWrites(writes, func(m *lightModel, _, failed []lightWrite) {
+		if len(failed) == 0 {
+			return
+		}
+		for _, w := range failed {
+			// A later keypress has its own intent, which stays
+			if intent, ok := m.brightnessIntents[w.light.ID]; ok && intent.sentAt.Equal(now) {
+				delete(m.brightnessIntents, w.light.ID)
+			}
+		}
+		m.setStatus("%s", trn("status.brightness.failed", len(failed), len(failed))+m.syncHint(writeLights(failed)))
+	})
+}
+
+// writeIntent sends a brightness target, switching the light on or off with it
+// if the intent says to. A light switched off keeps its brightness, so only
+// the switch is sent.
+func writeIntent(lightID string, intent brightnessIntent) error {
+	switch {
+	case intent.power == nil:
+		_, err := writeBrightness(lightID, intent.target, intent.minDimLevel)
+		return err
+	case *intent.power:
+		_, err := writeBrightnessOn(lightID, intent.target, intent.minDimLevel)
+		return err
+	default:
+		return toggleLight(lightID, true)
+	}
+}
+
+// intendedPower is whether a pending brightness write switches a light on or
+// off, until it has settled
+func (m lightModel) intendedPower(lightID string) (bool, bool) {
+	intent, ok := m.brightnessIntents[lightID]
+	if !ok || intent.power == nil || (intent.sent && time.Since(intent.sentAt) > brightnessSettle) {
+		return false, false
+	}
+	return *intent.power, true
+}
+
+// intendedBrightness is the target a light's brightness is heading for, while
+// a write is pending or hasn't settled yet
+func (m lightModel) intendedBrightness(lightID string) (float32, bool) {
+	intent, ok := m.brightnessIntents[lightID]
+	if !ok || (intent.sent && time.Since(intent.sentAt) > brightnessSettle) {
+		return 0, false
+	}
+	return intent.target, true
+}
+
+// settleBrightness forgets the targets whose echo has arrived
+func (m *lightModel) settleBrightness(lightIDs []string) {
+	for _, id := range lightIDs {
+		if intent, ok := m.brightnessIntents[id]; ok && intent.sent {
+			delete(m.brightnessIntents, id)
+		}
+	}
+}
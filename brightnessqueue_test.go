@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDimStep(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name       string
+		status     string
+		brightness float32
+		change     int
+		onOnDim    bool
+		target     float32
+		power      *bool
+	}{
+		{"up on an off light switches it on", "off", 50, 10, true, 60, &on},
+		{"up on an off light without on_on_dim", "off", 50, 10, false, 60, nil},
+		{"up on a light that's on", "on", 50, 10, true, 60, nil},
+		{"up stops at 100", "on", 95, 10, false, 100, nil},
+		{"down past zero switches off", "on", 5, -10, false, 2, &off},
+		{"down to exactly zero switches off", "on", 10, -10, false, 2, &off},
+		{"down staying above zero", "on", 30, -10, false, 20, nil},
+		{"down stops at the minimum", "on", 3, -2, false, 2, nil},
+		{"down on an off light leaves it off", "off", 5, -10, true, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			light := testLight()
+			light.Status, light.Brightness, light.MinDimLevel = tt.status, tt.brightness, 2
+			target, power := dimStep(light, tt.change, tt.onOnDim)
+			if target != tt.target {
+				t.Errorf("target %.0f, want %.0f", target, tt.target)
+			}
+			switch {
+			case tt.power == nil && power != nil:
+				t.Errorf("power %t, want it left alone", *power)
+			case tt.power != nil && (power == nil || *power != *tt.power):
+				t.Errorf("power %v, want %t", power, *tt.power)
+			}
+		})
+	}
+}
+
+// brightnessEcho runs an SSE report of a light's brightness through the model
+func brightnessEcho(t *testing.T, m lightModel, brightness float32) lightModel {
+	t.Helper()
+	payload := fmt.Sprintf(`[{"type":"update","data":[{"id":%q,"type":"light","dimming":{"brightness":%g}}]}]`, testLightID, brightness)
+	events, err := parseSSEEvents([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		m, _ = m.handleSSEEvent(event)
+	}
+	return m
+}
+
+// Keypresses merge into one write of the final target; until the bridge
+// reports that target, reports of earlier values don't move the table
+func TestBrightnessFlushReconciles(t *testing.T) {
+	bridge := newTestBridge(t)
+	light := testLight()
+	light.Status = "on"
+	m := initialModel([]Light{light}, nil)
+	m.selected = map[int]struct{}{0: {}}
+
+	m.adjustSelectedBrightness(10)
+	stale := m.brightnessGeneration
+	m.adjustSelectedBrightness(10)
+	if got := m.light[0].Brightness; got != 70 {
+		t.Fatalf("table shows %.0f before the write, want 70", got)
+	}
+
+	// The first keypress's flush was superseded by the second's
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: stale})
+	if m = settle(m); len(bridge.recorded()) != 0 {
+		t.Fatalf("a superseded flush wrote %+v", bridge.recorded())
+	}
+
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: m.brightnessGeneration})
+	m = settle(m)
+	writes := bridge.recorded()
+	if len(writes) != 1 {
+		t.Fatalf("got %d writes, want 1", len(writes))
+	}
+	if got := writes[0].body["dimming"].(map[string]any)["brightness"]; got != 70.0 {
+		t.Errorf("wrote brightness %v, want 70", got)
+	}
+
+	// An echo of an older value is put down to writes still landing
+	m = brightnessEcho(t, m, 60)
+	if got := m.light[0].Brightness; got != 70 {
+		t.Errorf("a stale report moved the table to %.0f", got)
+	}
+	if _, ok := m.brightnessIntents[testLightID]; !ok {
+		t.Fatal("a stale report settled the intent")
+	}
+
+	m = brightnessEcho(t, m, 70)
+	if _, ok := m.brightnessIntents[testLightID]; ok {
+		t.Error("the matching report didn't settle the intent")
+	}
+
+	// Once settled, reports are taken as they come
+	m = brightnessEcho(t, m, 40)
+	if got := m.light[0].Brightness; got != 40 {
+		t.Errorf("brightness %.0f after a change from elsewhere, want 40", got)
+	}
+}
+
+// A write that fails drops its intent, so reports aren't held back waiting
+// for a value that won't come
+func TestBrightnessFlushFailure(t *testing.T) {
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors":[{"description":"device is unreachable"}],"data":[]}`)
+	}))
+	light := testLight()
+	light.Status = "on"
+	m := initialModel([]Light{light}, nil)
+	m.selected = map[int]struct{}{0: {}}
+
+	m.adjustSelectedBrightness(10)
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: m.brightnessGeneration})
+	m = settle(m)
+	if _, ok := m.brightnessIntents[testLightID]; ok {
+		t.Error("the failed write's intent is still pending")
+	}
+	if want := trn("status.brightness.failed", 1, 1); !strings.HasPrefix(m.status, want) {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+}
+
+// Stepping down past the bottom sends only the switch, so the light keeps
+// its brightness for next time
+func TestBrightnessFlushSwitchesOff(t *testing.T) {
+	bridge := newTestBridge(t)
+	light := testLight()
+	light.Status, light.Brightness = "on", 5
+	m := initialModel([]Light{light}, nil)
+	m.selected = map[int]struct{}{0: {}}
+
+	m.adjustSelectedBrightness(-10)
+	if m.light[0].Status != "off" {
+		t.Errorf("table shows %s, want off straight away", m.light[0].Status)
+	}
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: m.brightnessGeneration})
+	m = settle(m)
+	writes := bridge.recorded()
+	if len(writes) != 1 {
+		t.Fatalf("got %d writes, want 1", len(writes))
+	}
+	if _, ok := writes[0].body["dimming"]; ok {
+		t.Errorf("switching off also wrote brightness: %v", writes[0].body)
+	}
+	if got := writes[0].body["on"].(map[string]any)["on"]; got != false {
+		t.Errorf("on = %v, want false", got)
+	}
+}
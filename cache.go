@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sethchev/hue-control-tui/pkg/hueclient"
+
+	"github.com/openhue/openhue-go"
+)
+
+// bridgeCache holds the bridge resources the app reads, so each type is
+// fetched once rather than by every feature that needs it. The event stream
+// keeps it current: additions and deletions are applied as they come, and an
+// update is merged into the cached resource field by field, which works
+// because the bridge sends changes in the resource's own shape. :refresh and
+// a reconnected event stream, which may have missed changes, empty it.
+var bridgeCache = &resourceCache{types: make(map[string]map[string]map[string]any)}
+
+// cachedTypes are the resource types kept in bridgeCache. Others are always
+// fetched.
+var cachedTypes = map[string]bool{
+	"light":                  true,
+	"device":                 true,
+	"room":                   true,
+	"zone":                   true,
+	"grouped_light":          true,
+	"scene":                  true,
+	"device_software_update": true,
+	"motion":                 true,
+	"contact":                true,
+	"tamper":                 true,
+	"button":                 true,
+	"behavior_instance":      true,
+	"geofence_client":        true,
+	"device_power":           true,
+}
+
+// cacheFetchAttempts bounds how often a type is fetched again because it
+// changed while being fetched
+const cacheFetchAttempts = 3
+
+// resourceCache maps a resource type to its resources by ID, each kept as
+// decoded JSON. A type that's missing hasn't been fetched since the last
+// invalidation.
+type resourceCache struct {
+	mu    sync.Mutex
+	types map[string]map[string]map[string]any
+
+	// changes counts the events and writes seen for each type, cached or
+	// not, and generation the invalidations. A fetch that sees either move
+	// raced a change it may not include, so it isn't kept.
+	changes    map[string]uint64
+	generation uint64
+}
+
+// cacheStamp is what a fetch compares before and after to tell whether it
+// raced a change
+type cacheStamp struct {
+	generation uint64
+	changes    uint64
+}
+
+// stamp returns rtype's current stamp; c.mu must be held
+func (c *resourceCache) stamp(rtype string) cacheStamp {
+	return cacheStamp{generation: c.generation, changes: c.changes[rtype]}
+}
+
+// changed records a change to rtype; c.mu must be held
+func (c *resourceCache) changed(rtype string) {
+	if c.changes == nil {
+		c.changes = make(map[string]uint64)
+	}
+	c.changes[rtype]++
+}
+
+// list decodes every resource of rtype into out, a pointer to a slice, in
+// ID order. The type is fetched first if it isn't cached, and kept if it is
+// one of cachedTypes.
+func (c *resourceCache) list(rtype string, out any) error {
+	resources, err := c.resources(rtype)
+	if err != nil {
+		return err
+	}
+
+	// Encoding while locked keeps a concurrent merge from changing a
+	// resource halfway through
+	c.mu.Lock()
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	ordered := make([]map[string]any, len(ids))
+	for i, id := range ids {
+		ordered[i] = resources[id]
+	}
+	data, err := json.Marshal(ordered)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding cached %s resources: %v", rtype, err)
+	}
+	return nil
+}
+
+// resources returns rtype's resources from the cache, or fetches them. A
+// fetch that an event or write overtook is thrown away and made again, since
+// the bridge may have answered before the change; after cacheFetchAttempts
+// the last answer is used but not kept.
+func (c *resourceCache) resources(rtype string) (map[string]map[string]any, error) {
+	for attempt := 1; ; attempt++ {
+		c.mu.Lock()
+		if resources, ok := c.types[rtype]; ok {
+			c.mu.Unlock()
+			return resources, nil
+		}
+		before := c.stamp(rtype)
+		c.mu.Unlock()
+
+		var resp struct {
+			Data []map[string]any `json:"data"`
+		}
+		if err := clipGet("resource/"+rtype, &resp); err != nil {
+			return nil, err
+		}
+		resources := make(map[string]map[string]any, len(resp.Data))
+		for _, resource := range resp.Data {
+			if id, ok := resource["id"].(string); ok {
+				resources[id] = resource
+			}
+		}
+
+		c.mu.Lock()
+		current := c.stamp(rtype) == before
+		if current && cachedTypes[rtype] {
+			c.types[rtype] = resources
+		}
+		c.mu.Unlock()
+		if current {
+			logDebug("Cache: fetched %d %s resources", len(resources), rtype)
+			return resources, nil
+		}
+		if attempt == cacheFetchAttempts {
+			logDebug("Cache: %s kept changing while fetched, not caching it", rtype)
+			return resources, nil
+		}
+		logDebug("Cache: %s changed while fetched, fetching again", rtype)
+	}
+}
+
+// apply updates the cache from one event stream payload
+func (c *resourceCache) apply(data []byte) {
+	events, err := hueclient.ParseEvents(data)
+	if err != nil {
+		return
+	}
+	for _, event := range events {
+		var item map[string]any
+		if err := json.Unmarshal(event.Raw, &item); err != nil {
+			continue
+		}
+		switch event.Kind {
+		case "add":
+			c.set(event.ResourceType, event.ResourceID, item)
+		case "delete":
+			c.remove(event.ResourceType, event.ResourceID)
+		case "update":
+			c.merge(event.ResourceType, event.ResourceID, item)
+		}
+	}
+}
+
+// set stores a whole resource, if its type is cached
+func (c *resourceCache) set(rtype, id string, resource map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changed(rtype)
+	if resources, ok := c.types[rtype]; ok {
+		resources[id] = resource
+	}
+}
+
+// remove drops a deleted resource
+func (c *resourceCache) remove(rtype, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changed(rtype)
+	delete(c.types[rtype], id)
+}
+
+// merge applies a partial update to a cached resource. An update for a
+// resource the cache doesn't know about empties its type, since the update
+// alone isn't the whole resource. An update to a type that isn't cached
+// still counts, so a fetch it overtakes isn't kept.
+func (c *resourceCache) merge(rtype, id string, patch map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changed(rtype)
+	resources, ok := c.types[rtype]
+	if !ok {
+		return
+	}
+	resource, ok := resources[id]
+	if !ok {
+		delete(c.types, rtype)
+		return
+	}
+	mergeJSON(resource, patch)
+}
+
+// mergeWrite applies a successful write to the cached resource, so reads
+// before its event arrives see it. body is what was sent, such as an
+// openhue.LightPut, whose fields mirror the resource's.
+func (c *resourceCache) mergeWrite(rtype, id string, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	var patch map[string]any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return
+	}
+	c.merge(rtype, id, patch)
+}
+
+// invalidate empties the cache, so every type is fetched again when next read
+func (c *resourceCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.types)
+	c.generation++
+	logDebug("Cache: invalidated")
+}
+
+// mergeJSON copies src into dst, descending into objects that both have.
+// Arrays and other values in src replace dst's.
+func mergeJSON(dst, src map[string]any) {
+	for key, value := range src {
+		if child, ok := value.(map[string]any); ok {
+			if existing, ok := dst[key].(map[string]any); ok {
+				mergeJSON(existing, child)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// cachedLights returns the bridge's lights by ID, in openhue's LightGet form
+func cachedLights() (map[string]openhue.LightGet, error) {
+	var lights []openhue.LightGet
+	if err := bridgeCache.list("light", &lights); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]openhue.LightGet, len(lights))
+	for _, light := range lights {
+		if light.Id != nil {
+			byID[*light.Id] = light
+		}
+	}
+	return byID, nil
+}
+
+// cachedScenes returns the bridge's scenes by ID, in openhue's SceneGet form
+func cachedScenes() (map[string]openhue.SceneGet, error) {
+	var scenes []openhue.SceneGet
+	if err := bridgeCache.list("scene", &scenes); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]openhue.SceneGet, len(scenes))
+	for _, scene := range scenes {
+		if scene.Id != nil {
+			byID[*scene.Id] = scene
+		}
+	}
+	return byID, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+const cacheTestLight = "3f1a2b4c-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+
+// lightReply is a light list with the one test light on or off
+func lightReply(on bool) string {
+	return fmt.Sprintf(`{"errors":[],"data":[{"id":%q,"type":"light","metadata":{"name":"Hall"},"on":{"on":%t}}]}`, cacheTestLight, on)
+}
+
+// lightOnEvent is an event stream payload switching the test light
+func lightOnEvent(on bool) []byte {
+	return fmt.Appendf(nil, `[{"type":"update","data":[{"id":%q,"type":"light","on":{"on":%t}}]}]`, cacheTestLight, on)
+}
+
+// cachedOn reads the test light's on state through the cache
+func cachedOn(t *testing.T) bool {
+	t.Helper()
+	lights, err := cachedLights()
+	if err != nil {
+		t.Fatalf("cachedLights: %v", err)
+	}
+	light, ok := lights[cacheTestLight]
+	if !ok || light.On == nil || light.On.On == nil {
+		t.Fatalf("test light missing from %v", lights)
+	}
+	return *light.On.On
+}
+
+// An event that arrives while the light list is being fetched may not be in
+// the bridge's answer. That answer mustn't be cached, or the light would
+// show its old state until the next event for it.
+func TestCacheDiscardsFetchOvertakenByEvent(t *testing.T) {
+	var fetches atomic.Int32
+	fetching := make(chan struct{})
+	proceed := make(chan struct{})
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 1 {
+			// The bridge answers from before the light was switched on
+			close(fetching)
+			<-proceed
+			fmt.Fprint(w, lightReply(false))
+			return
+		}
+		fmt.Fprint(w, lightReply(true))
+	}))
+
+	result := make(chan bool)
+	go func() {
+		lights, err := cachedLights()
+		if err != nil {
+			t.Errorf("cachedLights: %v", err)
+			result <- false
+			return
+		}
+		result <- *lights[cacheTestLight].On.On
+	}()
+
+	<-fetching
+	// The type isn't cached yet, so the update has nothing to merge into
+	bridgeCache.apply(lightOnEvent(true))
+	close(proceed)
+
+	if on := <-result; !on {
+		t.Error("the list fetched before the event was returned")
+	}
+	if !cachedOn(t) {
+		t.Error("the list fetched before the event was cached")
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("light list fetched %d times, want 2", n)
+	}
+}
+
+// A fetch that an invalidation overtook isn't kept either
+func TestCacheDiscardsFetchOvertakenByInvalidate(t *testing.T) {
+	var fetches atomic.Int32
+	fetching := make(chan struct{})
+	proceed := make(chan struct{})
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 1 {
+			close(fetching)
+			<-proceed
+		}
+		fmt.Fprint(w, lightReply(true))
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := cachedLights(); err != nil {
+			t.Errorf("cachedLights: %v", err)
+		}
+	}()
+	<-fetching
+	bridgeCache.invalidate()
+	close(proceed)
+	<-done
+
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("light list fetched %d times, want 2", n)
+	}
+}
+
+// A bridge that keeps changing still gets an answer, just not a cached one
+func TestCacheGivesUpOnBusyType(t *testing.T) {
+	var fetches atomic.Int32
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		bridgeCache.apply(lightOnEvent(true))
+		fmt.Fprint(w, lightReply(true))
+	}))
+
+	if !cachedOn(t) {
+		t.Error("light off")
+	}
+	if n := fetches.Load(); n != cacheFetchAttempts {
+		t.Errorf("light list fetched %d times, want %d", n, cacheFetchAttempts)
+	}
+	bridgeCache.mu.Lock()
+	_, cached := bridgeCache.types["light"]
+	bridgeCache.mu.Unlock()
+	if cached {
+		t.Error("a fetch that never settled was cached")
+	}
+}
+
+// Once cached, events and writes keep the cache current without fetching
+func TestCacheFollowsEventsAndWrites(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/light", lightReply(false))
+
+	if cachedOn(t) {
+		t.Fatal("light on before any event")
+	}
+	bridgeCache.apply(lightOnEvent(true))
+	if !cachedOn(t) {
+		t.Error("event not merged")
+	}
+	off := false
+	bridgeCache.mergeWrite("light", cacheTestLight, map[string]any{"on": map[string]bool{"on": off}})
+	if cachedOn(t) {
+		t.Error("write not merged")
+	}
+	if n := bridge.served("/clip/v2/resource/light"); n != 1 {
+		t.Errorf("light list fetched %d times, want 1", n)
+	}
+}
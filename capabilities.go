@@ -0,0 +1,77 @@
+package main
+
+import "encoding/json"
+
+// capability is a set of features a light supports, read once from the
+// bridge's light resource. Commands check Light.Caps instead of testing the
+// resource's optional fields themselves.
+type capability uint8
+
+const (
+	capOnOff capability = 1 << iota
+	capDimming
+	capColorTemperature
+	capColor
+	capGradient
+)
+
+// capabilityNames are the names /lights reports, in bit order
+var capabilityNames = []string{"on_off", "dimming", "color_temperature", "color", "gradient"}
+
+// has reports whether every capability in want is in c
+func (c capability) has(want capability) bool {
+	return c&want == want
+}
+
+// names lists the capabilities in c
+func (c capability) names() []string {
+	names := []string{}
+	for i, name := range capabilityNames {
+		if c.has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// capabilities turns what readCaps found into a capability set
+func (caps lightCaps) capabilities() capability {
+	c := capOnOff
+	if caps.dimmable {
+		c |= capDimming
+	}
+	if caps.ct {
+		c |= capColorTemperature
+	}
+	if caps.color {
+		c |= capColor
+	}
+	return c
+}
+
+// can reports whether a light supports want
+func (l Light) can(want capability) bool {
+	return l.Caps.has(want)
+}
+
+// MarshalJSON adds the capabilities to a light's JSON, along with "dimmable",
+// which /lights reported before capabilities were
+func (l Light) MarshalJSON() ([]byte, error) {
+	type plain Light
+	return json.Marshal(struct {
+		plain
+		Dimmable     bool     `json:"dimmable"`
+		Capabilities []string `json:"capabilities"`
+	}{plain(l), l.can(capDimming), l.Caps.names()})
+}
+
+// onOffOnlyNote reports lights left out of a brightness, color temperature
+// or color change because they can only switch on and off, e.g.
+// " · brightness skipped for 2 on/off devices"; "" when there were none.
+// what is already translated.
+func onOffOnlyNote(n int, what string) string {
+	if n == 0 {
+		return ""
+	}
+	return trn("action.onoff_only", n, what, n)
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sethchev/hue-control-tui/pkg/hueclient"
+)
+
+// BridgeResource is the CLIP v2 bridge resource
+type BridgeResource struct {
+	ID       string `json:"id"`
+	BridgeID string `json:"bridge_id"`
+	Owner    struct {
+		Rid   string `json:"rid"`
+		Rtype string `json:"rtype"`
+	} `json:"owner"`
+	TimeZone struct {
+		TimeZone string `json:"time_zone"`
+	} `json:"time_zone"`
+	Type string `json:"type"`
+}
+
+// BridgeResourceResponse wraps the API response
+type BridgeResourceResponse struct {
+	Errors []interface{}    `json:"errors"`
+	Data   []BridgeResource `json:"data"`
+}
+
+// DeviceResource is the CLIP v2 device resource, trimmed to what the TUI uses
+type DeviceResource struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Archetype string `json:"archetype"`
+	} `json:"metadata"`
+	ProductData struct {
+		ModelID              string `json:"model_id"`
+		ManufacturerName     string `json:"manufacturer_name"`
+		ProductName          string `json:"product_name"`
+		ProductArchetype     string `json:"product_archetype"`
+		Certified            bool   `json:"certified"`
+		SoftwareVersion      string `json:"software_version"`
+		HardwarePlatformType string `json:"hardware_platform_type"`
+	} `json:"product_data"`
+	Services []struct {
+		Rid   string `json:"rid"`
+		Rtype string `json:"rtype"`
+	} `json:"services"`
+	Type string `json:"type"`
+}
+
+// DeviceResourceResponse wraps the API response
+type DeviceResourceResponse struct {
+	Errors []interface{}    `json:"errors"`
+	Data   []DeviceResource `json:"data"`
+}
+
+// BridgeConfigV1 is the CLIP v1 config resource, which still carries details
+// (zigbee channel, API version) the v2 API doesn't expose
+type BridgeConfigV1 struct {
+	Name          string `json:"name"`
+	BridgeID      string `json:"bridgeid"`
+	IPAddress     string `json:"ipaddress"`
+	ModelID       string `json:"modelid"`
+	SWVersion     string `json:"swversion"`
+	APIVersion    string `json:"apiversion"`
+	ZigbeeChannel int    `json:"zigbeechannel"`
+}
+
+// ResourceSummary is the minimal shape shared by every CLIP v2 resource
+type ResourceSummary struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// ResourceSummaryResponse wraps the API response
+type ResourceSummaryResponse struct {
+	Errors []interface{}     `json:"errors"`
+	Data   []ResourceSummary `json:"data"`
+}
+
+// GroupResource is a CLIP v2 room or zone
+type GroupResource struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Archetype string `json:"archetype"`
+	} `json:"metadata"`
+	Children []resourceRef `json:"children"`
+	Services []resourceRef `json:"services"`
+	Type     string        `json:"type"`
+}
+
+// resourceRef points at another CLIP v2 resource
+type resourceRef struct {
+	Rid   string `json:"rid"`
+	Rtype string `json:"rtype"`
+}
+
+// GroupResourceResponse wraps the API response
+type GroupResourceResponse struct {
+	Errors []interface{}   `json:"errors"`
+	Data   []GroupResource `json:"data"`
+}
+
+// GroupedLightResource is the combined light service of a room, zone or the whole home
+type GroupedLightResource struct {
+	ID    string `json:"id"`
+	Owner struct {
+		Rid   string `json:"rid"`
+		Rtype string `json:"rtype"`
+	} `json:"owner"`
+	On *struct {
+		On bool `json:"on"`
+	} `json:"on,omitempty"`
+	Dimming *struct {
+		Brightness float64 `json:"brightness"`
+	} `json:"dimming,omitempty"`
+	Type string `json:"type"`
+}
+
+// GroupedLightResourceResponse wraps the API response
+type GroupedLightResourceResponse struct {
+	Errors []interface{}          `json:"errors"`
+	Data   []GroupedLightResource `json:"data"`
+}
+
+var (
+	bridgeHTTPOnce sync.Once
+	bridgeHTTP     *http.Client
+	remoteHTTPOnce sync.Once
+	remoteHTTP     *http.Client
+)
+
+// bridgeHTTPClient is the HTTP client every bridge request shares, so
+// connections are reused. It measures latency. On the LAN it accepts the
+// bridge's self-signed certificate; in remote mode it is remoteHTTPClient.
+func bridgeHTTPClient() *http.Client {
+	if remoteMode {
+		return remoteHTTPClient()
+	}
+	bridgeHTTPOnce.Do(func() {
+		bridgeHTTP = hueclient.InsecureHTTPClient()
+		bridgeHTTP.Transport = &latencyTransport{base: bridgeHTTP.Transport}
+	})
+	return bridgeHTTP
+}
+
+// remoteHTTPClient is the client for the Remote API. It goes over the public
+// internet with cloud credentials, so certificates are verified as usual, and
+// it adds the access token.
+func remoteHTTPClient() *http.Client {
+	remoteHTTPOnce.Do(func() {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		remoteHTTP = &http.Client{
+			Transport: &latencyTransport{base: &bearerTransport{base: transport, session: remoteToken}},
+		}
+	})
+	return remoteHTTP
+}
+
+// bridgeClient returns a client for the configured bridge. The address and
+// key can change while running, so it is cheap to call for each request.
+func bridgeClient() *hueclient.Client {
+	return hueclient.New(bridgeIP, apiKey, bridgeHTTPClient())
+}
+
+// updateResource sends a CLIP v2 update such as an openhue.LightPut to one
+// resource
+func updateResource(rtype, id string, body any) error {
+	return bridgeClient().Update(appCtx, rtype, id, body)
+}
+
+// clipGet fetches a CLIP v2 path such as "resource/bridge" and decodes the body into out
+func clipGet(path string, out any) error {
+	return bridgeGet("clip/v2/"+path, out)
+}
+
+// bridgeGet performs an authenticated GET against the bridge and decodes the JSON body into out
+func bridgeGet(path string, out any) error {
+	return doRequest("GET", path, nil, out)
+}
+
+// doRequest sends an authenticated request to a bridge path such as
+// "clip/v2/resource/light" and decodes the JSON reply into out, if given.
+// Errors the bridge reports are returned with the bridge's own wording.
+// Writes are tracked for shutdown and network failures retried.
+func doRequest(method, path string, body, out any) error {
+	// Use global bridgeIP and apiKey
+	if bridgeIP == "" || apiKey == "" {
+		return fmt.Errorf("bridge configuration not initialized")
+	}
+
+	client := bridgeClient()
+	send := func() error {
+		return client.Do(appCtx, method, path, body, out)
+	}
+
+	if method != "GET" {
+		defer trackWrite()()
+	}
+	// A POST that timed out may still have created something, so it isn't repeated
+	if method == "POST" {
+		return send()
+	}
+	return withRetry("bridge request", send)
+}
+
+// clipWriteResponse is the CLIP v2 reply to a POST, PUT or DELETE
+type clipWriteResponse struct {
+	Data []struct {
+		Rid   string `json:"rid"`
+		Rtype string `json:"rtype"`
+	} `json:"data"`
+}
+
+// clipWrite sends body as JSON to a CLIP v2 path and returns the affected
+// resources. Errors reported by the bridge are returned as they were worded.
+func clipWrite(method, path string, body any) (*clipWriteResponse, error) {
+	var result clipWriteResponse
+	if err := doRequest(method, "clip/v2/"+path, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SmartSceneResource is a CLIP v2 smart scene, such as a Natural Light schedule
+type SmartSceneResource struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Group resourceRef `json:"group"`
+	State string      `json:"state"` // "active" or "inactive"
+	Type  string      `json:"type"`
+}
+
+// SmartSceneResourceResponse wraps the API response
+type SmartSceneResourceResponse struct {
+	Errors []interface{}        `json:"errors"`
+	Data   []SmartSceneResource `json:"data"`
+}
+
+// getSmartScenes returns every smart scene
+func getSmartScenes() ([]SmartSceneResource, error) {
+	var smartResp SmartSceneResourceResponse
+	if err := clipGet("resource/smart_scene", &smartResp); err != nil {
+		return nil, err
+	}
+	return smartResp.Data, nil
+}
+
+// setSmartSceneActive starts or stops a smart scene
+func setSmartSceneActive(id string, active bool) error {
+	action := "deactivate"
+	if active {
+		action = "activate"
+	}
+	outgoing.recordBulk()
+	_, err := clipWrite("PUT", "resource/smart_scene/"+id, map[string]any{
+		"recall": map[string]string{"action": action},
+	})
+	return err
+}
+
+// EntertainmentConfigurationResource is a CLIP v2 entertainment area
+type EntertainmentConfigurationResource struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status        string        `json:"status"` // "active" while streaming
+	LightServices []resourceRef `json:"light_services"`
+	Type          string        `json:"type"`
+}
+
+// EntertainmentConfigurationResourceResponse wraps the API response
+type EntertainmentConfigurationResourceResponse struct {
+	Errors []interface{}                        `json:"errors"`
+	Data   []EntertainmentConfigurationResource `json:"data"`
+}
+
+// getEntertainmentConfigurations returns every entertainment area
+func getEntertainmentConfigurations() ([]EntertainmentConfigurationResource, error) {
+	var configResp EntertainmentConfigurationResourceResponse
+	if err := clipGet("resource/entertainment_configuration", &configResp); err != nil {
+		return nil, err
+	}
+	return configResp.Data, nil
+}
+
+// GradientState is the gradient feature of a CLIP v2 light
+type GradientState struct {
+	Points []struct {
+		Color struct {
+			XY struct {
+				X float64 `json:"x"`
+				Y float64 `json:"y"`
+			} `json:"xy"`
+		} `json:"color"`
+	} `json:"points"`
+	PointsCapable int `json:"points_capable"`
+}
+
+// colors returns the gradient's points in order
+func (g GradientState) colors() []xyColor {
+	colors := make([]xyColor, len(g.Points))
+	for i, point := range g.Points {
+		colors[i] = xyColor{x: point.Color.XY.X, y: point.Color.XY.Y}
+	}
+	return colors
+}
+
+// getGradients maps light IDs to their gradients, for the lights that have one
+func getGradients() (map[string]GradientState, error) {
+	var lights []struct {
+		ID       string         `json:"id"`
+		Gradient *GradientState `json:"gradient"`
+	}
+	if err := bridgeCache.list("light", &lights); err != nil {
+		return nil, err
+	}
+
+	gradients := make(map[string]GradientState)
+	for _, light := range lights {
+		if light.Gradient != nil {
+			gradients[light.ID] = *light.Gradient
+		}
+	}
+	return gradients, nil
+}
+
+// getBridge returns the bridge resource; every bridge exposes exactly one
+func getBridge() (*BridgeResource, error) {
+	var bridgeResp BridgeResourceResponse
+	if err := clipGet("resource/bridge", &bridgeResp); err != nil {
+		return nil, err
+	}
+	if len(bridgeResp.Data) == 0 {
+		return nil, fmt.Errorf("bridge resource not found")
+	}
+	return &bridgeResp.Data[0], nil
+}
+
+// getDevice returns a single device resource by ID
+func getDevice(deviceID string) (*DeviceResource, error) {
+	var deviceResp DeviceResourceResponse
+	if err := clipGet("resource/device/"+deviceID, &deviceResp); err != nil {
+		return nil, err
+	}
+	if len(deviceResp.Data) == 0 {
+		return nil, fmt.Errorf("device not found: %s", deviceID)
+	}
+	return &deviceResp.Data[0], nil
+}
+
+// getBridgeSoftwareVersion looks up the firmware version from the bridge's owning device
+func getBridgeSoftwareVersion() (string, error) {
+	bridge, err := getBridge()
+	if err != nil {
+		return "", err
+	}
+	device, err := getDevice(bridge.Owner.Rid)
+	if err != nil {
+		return "", err
+	}
+	return device.ProductData.SoftwareVersion, nil
+}
+
+// getBridgeConfigV1 fetches the v1 config resource
+func getBridgeConfigV1() (*BridgeConfigV1, error) {
+	var config BridgeConfigV1
+	if err := bridgeGet("api/"+apiKey+"/config", &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// getResourceCounts returns the number of bridge resources of each type
+func getResourceCounts() (map[string]int, error) {
+	var resourceResp ResourceSummaryResponse
+	if err := clipGet("resource", &resourceResp); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, resource := range resourceResp.Data {
+		counts[resource.Type]++
+	}
+	return counts, nil
+}
+
+// getGroups returns every room and zone
+func getGroups() ([]GroupResource, error) {
+	var groups []GroupResource
+	for _, rtype := range []string{"room", "zone"} {
+		var ofType []GroupResource
+		if err := bridgeCache.list(rtype, &ofType); err != nil {
+			return nil, err
+		}
+		groups = append(groups, ofType...)
+	}
+	return groups, nil
+}
+
+// getGroupNames maps room and zone IDs to their names
+func getGroupNames() (map[string]string, error) {
+	groups, err := getGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(groups))
+	for _, group := range groups {
+		names[group.ID] = group.Metadata.Name
+	}
+	return names, nil
+}
+
+// getGroupedLight returns one grouped_light resource
+func getGroupedLight(id string) (*GroupedLightResource, error) {
+	var groupedResp GroupedLightResourceResponse
+	if err := clipGet("resource/grouped_light/"+id, &groupedResp); err != nil {
+		return nil, err
+	}
+	if len(groupedResp.Data) == 0 {
+		return nil, fmt.Errorf("grouped light not found: %s", id)
+	}
+	return &groupedResp.Data[0], nil
+}
+
+// getGroupedLights returns every grouped_light with its on state and brightness
+func getGroupedLights() ([]GroupedLightResource, error) {
+	var grouped []GroupedLightResource
+	if err := bridgeCache.list("grouped_light", &grouped); err != nil {
+		return nil, err
+	}
+	return grouped, nil
+}
+
+// getGroup returns one room or zone
+func getGroup(rtype, id string) (*GroupResource, error) {
+	var groupResp GroupResourceResponse
+	if err := clipGet("resource/"+rtype+"/"+id, &groupResp); err != nil {
+		return nil, err
+	}
+	if len(groupResp.Data) == 0 {
+		return nil, fmt.Errorf("%s not found: %s", rtype, id)
+	}
+	return &groupResp.Data[0], nil
+}
+
+// getPublicBridgeConfig reads the unauthenticated part of a bridge's v1
+// config, which includes its ID, from any address
+func getPublicBridgeConfig(ip string) (*BridgeConfigV1, error) {
+	ctx, cancel := context.WithTimeout(appCtx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/api/0/config", ip), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := hueclient.InsecureHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var config BridgeConfigV1
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return &config, nil
+}
+
+// v1Write sends a CLIP v1 request under api/<key>/, for the few features with no
+// v2 equivalent. v1 reports errors as a list of {"error": {...}} objects.
+func v1Write(method, path string, body any) error {
+	var results []struct {
+		Error *struct {
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	if err := doRequest(method, "api/"+apiKey+"/"+path, body, &results); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			return errors.New(r.Error.Description)
+		}
+	}
+	return nil
+}
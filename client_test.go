@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDoRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		status  int
+		body    string
+		out     bool   // decode the reply
+		wantErr string // "" for success
+	}{
+		{
+			name:    "errors array",
+			method:  "PUT",
+			status:  http.StatusBadRequest,
+			body:    `{"errors":[{"description":"device (light) is \"soft off\", command (.on) may not have effect"},{"description":"invalid value"}],"data":[]}`,
+			wantErr: `device (light) is "soft off", command (.on) may not have effect; invalid value`,
+		},
+		{
+			name:    "errors array on 200",
+			method:  "PUT",
+			status:  http.StatusOK,
+			body:    `{"errors":[{"description":"resource not writable"}],"data":[]}`,
+			wantErr: "resource not writable",
+		},
+		{
+			name:    "non-JSON 5xx",
+			method:  "GET",
+			status:  http.StatusServiceUnavailable,
+			body:    "<html><body>503 Service Unavailable</body></html>",
+			out:     true,
+			wantErr: "bridge returned 503 Service Unavailable",
+		},
+		{
+			name:    "empty 5xx",
+			method:  "PUT",
+			status:  http.StatusInternalServerError,
+			wantErr: "bridge returned 500 Internal Server Error",
+		},
+		{
+			name:    "empty body decoded",
+			method:  "GET",
+			status:  http.StatusOK,
+			out:     true,
+			wantErr: "failed to decode response",
+		},
+		{
+			name:   "empty body not decoded",
+			method: "PUT",
+			status: http.StatusOK,
+		},
+		{
+			name:   "success",
+			method: "GET",
+			status: http.StatusOK,
+			body:   `{"errors":[],"data":[{"id":"x"}]}`,
+			out:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.Header.Get("hue-application-key") != apiKey {
+					t.Errorf("hue-application-key = %q", r.Header.Get("hue-application-key"))
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+
+			var out *clipWriteResponse
+			if tt.out {
+				out = &clipWriteResponse{}
+			}
+			var err error
+			if out != nil {
+				err = doRequest(tt.method, "clip/v2/resource/light", nil, out)
+			} else {
+				err = doRequest(tt.method, "clip/v2/resource/light", map[string]any{}, nil)
+			}
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("err = %v, want success", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			// The bridge answered, so nothing is retried
+			if requests != 1 {
+				t.Errorf("bridge got %d requests, want 1", requests)
+			}
+			if tt.wantErr == "" && tt.out && len(out.Data) != 1 {
+				t.Errorf("decoded %+v", out)
+			}
+		})
+	}
+}
+
+func TestDoRequestNotConfigured(t *testing.T) {
+	oldIP := bridgeIP
+	bridgeIP = ""
+	defer func() { bridgeIP = oldIP }()
+	if err := doRequest("GET", "clip/v2/resource/light", nil, nil); err == nil {
+		t.Error("doRequest without a bridge succeeded")
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxLocusDistance is how far a color may sit from the black-body curve and
+// still pass as a color temperature; anything further is too saturated
+const maxLocusDistance = 0.05
+
+// xyColor is a CIE 1931 chromaticity, the form the bridge uses for color
+type xyColor struct {
+	x, y float64
+}
+
+// The color temperature range of Hue white ambiance lights, used for lights
+// that don't report their own mirek_schema
+const (
+	defaultMirekMin = 153 // 6500K
+	defaultMirekMax = 500 // 2000K
+)
+
+// kelvinToMirek converts a color temperature in Kelvin to the bridge's mirek,
+// rounded to the nearest whole mirek
+func kelvinToMirek(kelvin int) int {
+	if kelvin <= 0 {
+		return 0
+	}
+	return int(math.Round(1e6 / float64(kelvin)))
+}
+
+// mirekToKelvin converts mirek to Kelvin, rounded to the nearest 100K the way
+// lights are labelled, so 153 mirek reads as 6500K rather than 6536K
+func mirekToKelvin(mirek int) int {
+	if mirek <= 0 {
+		return 0
+	}
+	return int(math.Round(1e6/float64(mirek)/100)) * 100
+}
+
+// mirekRange is the color temperature range a light with caps can show.
+// Lights without a usable mirek_schema get the usual Hue range; a schema
+// whose bounds are swapped is read the right way round.
+func (caps lightCaps) mirekRange() (low, high int) {
+	low, high = caps.mirekMin, caps.mirekMax
+	if low > high {
+		low, high = high, low
+	}
+	if low <= 0 || high <= 0 {
+		return defaultMirekMin, defaultMirekMax
+	}
+	return low, high
+}
+
+// clampMirek limits mirek to what a light with caps can show
+func (caps lightCaps) clampMirek(mirek int) int {
+	low, high := caps.mirekRange()
+	return min(max(mirek, low), high)
+}
+
+// mirekToXY returns the point on the black-body curve for a color temperature,
+// using Kim et al.'s cubic spline (valid from 1667K to 25000K)
+func mirekToXY(mirek int) xyColor {
+	t := min(max(1e6/float64(mirek), 1667), 25000)
+
+	var x float64
+	if t <= 4000 {
+		x = -0.2661239e9/(t*t*t) - 0.2343589e6/(t*t) + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/(t*t*t) + 2.1070379e6/(t*t) + 0.2226347e3/t + 0.240390
+	}
+
+	var y float64
+	switch {
+	case t <= 2222:
+		y = -1.1063814*x*x*x - 1.34811020*x*x + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*x*x*x - 1.37418593*x*x + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x*x*x - 5.87338670*x*x + 3.75112997*x - 0.37001483
+	}
+	return xyColor{x: x, y: y}
+}
+
+// xyToMirek approximates a color as a color temperature with McCamy's formula.
+// ok is false when the color is too far from white to be shown as one.
+func xyToMirek(c xyColor) (mirek int, ok bool) {
+	n := (c.x - 0.3320) / (0.1858 - c.y)
+	cct := 449*n*n*n + 3525*n*n + 6823.3*n + 5520.33
+	if math.IsNaN(cct) || cct < 1000 || cct > 25000 {
+		return 0, false
+	}
+
+	mirek = int(math.Round(1e6 / cct))
+	locus := mirekToXY(mirek)
+	if math.Hypot(c.x-locus.x, c.y-locus.y) > maxLocusDistance {
+		return mirek, false
+	}
+	return mirek, true
+}
+
+// namedColors are the color names accepted wherever a color is typed
+var namedColors = map[string]string{
+	"red":     "ff0000",
+	"orange":  "ff8000",
+	"yellow":  "ffd000",
+	"green":   "00ff00",
+	"cyan":    "00ffff",
+	"blue":    "0000ff",
+	"purple":  "8000ff",
+	"magenta": "ff00ff",
+	"pink":    "ff60a0",
+	"white":   "ffffff",
+}
+
+// parseColor reads a color name from namedColors or a hex code like "#ff8000"
+func parseColor(s string) (xyColor, error) {
+	hex := strings.ToLower(strings.TrimPrefix(s, "#"))
+	if named, ok := namedColors[hex]; ok {
+		hex = named
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return xyColor{}, fmt.Errorf("unknown color %q; use a name like red or a hex code like #ff8000", s)
+	}
+	return rgbToXY(uint8(value>>16), uint8(value>>8), uint8(value)), nil
+}
+
+// rgbToXY converts an sRGB color to a chromaticity using the wide-gamut
+// conversion Philips recommends for Hue. Black has no chromaticity and maps
+// to the white point.
+func rgbToXY(r, g, b uint8) xyColor {
+	linear := func(c uint8) float64 {
+		v := float64(c) / 255
+		if v > 0.04045 {
+			return math.Pow((v+0.055)/1.055, 2.4)
+		}
+		return v / 12.92
+	}
+	red, green, blue := linear(r), linear(g), linear(b)
+
+	x := red*0.664511 + green*0.154324 + blue*0.162028
+	y := red*0.283881 + green*0.668433 + blue*0.047685
+	z := red*0.000088 + green*0.072310 + blue*0.986039
+	sum := x + y + z
+	if sum == 0 {
+		return xyColor{x: 0.3127, y: 0.3290}
+	}
+	return xyColor{x: x / sum, y: y / sum}
+}
+
+// xyJSON is a chromaticity in the bridge's JSON shape
+func (c xyColor) xyJSON() map[string]any {
+	return map[string]any{"xy": map[string]float64{"x": c.x, "y": c.y}}
+}
+
+// xyToHex converts a chromaticity at full brightness to an sRGB hex code for
+// drawing, the inverse of rgbToXY
+func xyToHex(c xyColor) string {
+	if c.y == 0 {
+		return "#ffffff"
+	}
+	x, y, z := c.x/c.y, 1.0, (1-c.x-c.y)/c.y
+
+	r := x*1.656492 - y*0.354851 - z*0.255038
+	g := -x*0.707196 + y*1.655397 + z*0.036152
+	b := x*0.051713 - y*0.121364 + z*1.011530
+
+	// Scale so the brightest channel is full, keeping the hue
+	peak := max(r, g, b)
+	if peak <= 0 {
+		return "#ffffff"
+	}
+	gamma := func(v float64) int {
+		v = max(v/peak, 0)
+		if v <= 0.0031308 {
+			v *= 12.92
+		} else {
+			v = 1.055*math.Pow(v, 1/2.4) - 0.055
+		}
+		return int(math.Round(min(v, 1) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", gamma(r), gamma(g), gamma(b))
+}
+
+// gamut is the triangle of chromaticities a color light can show, as red,
+// green and blue corners
+type gamut [3]xyColor
+
+// contains reports whether c lies inside the triangle
+func (g gamut) contains(c xyColor) bool {
+	side := func(a, b xyColor) float64 {
+		return (b.x-a.x)*(c.y-a.y) - (b.y-a.y)*(c.x-a.x)
+	}
+	d1, d2, d3 := side(g[0], g[1]), side(g[1], g[2]), side(g[2], g[0])
+	negative := d1 < 0 || d2 < 0 || d3 < 0
+	positive := d1 > 0 || d2 > 0 || d3 > 0
+	return !(negative && positive)
+}
+
+// clamp moves a color the light can't show to the nearest one it can, on
+// the edge of the triangle
+func (g gamut) clamp(c xyColor) xyColor {
+	if g.contains(c) {
+		return c
+	}
+	closest := func(a, b xyColor) xyColor {
+		dx, dy := b.x-a.x, b.y-a.y
+		t := ((c.x-a.x)*dx + (c.y-a.y)*dy) / (dx*dx + dy*dy)
+		t = min(max(t, 0), 1)
+		return xyColor{x: a.x + t*dx, y: a.y + t*dy}
+	}
+	best := closest(g[0], g[1])
+	for _, p := range []xyColor{closest(g[1], g[2]), closest(g[2], g[0])} {
+		if math.Hypot(p.x-c.x, p.y-c.y) < math.Hypot(best.x-c.x, best.y-c.y) {
+			best = p
+		}
+	}
+	return best
+}
+
+// hsvToRGB converts a hue in degrees and saturation and value from 0 to 1 to sRGB
+func hsvToRGB(hue, saturation, value float64) (r, g, b uint8) {
+	hue = math.Mod(hue, 360)
+	c := value * saturation
+	x := c * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var red, green, blue float64
+	switch {
+	case hue < 60:
+		red, green = c, x
+	case hue < 120:
+		red, green = x, c
+	case hue < 180:
+		green, blue = c, x
+	case hue < 240:
+		green, blue = x, c
+	case hue < 300:
+		red, blue = x, c
+	default:
+		red, blue = c, x
+	}
+	m := value - c
+	channel := func(v float64) uint8 { return uint8(math.Round((v + m) * 255)) }
+	return channel(red), channel(green), channel(blue)
+}
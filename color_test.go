@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestKelvinToMirek(t *testing.T) {
+	tests := []struct {
+		kelvin, want int
+	}{
+		{6500, 154},
+		{6536, 153},
+		{2000, 500},
+		{2700, 370},
+		{1_000_000, 1},
+		{0, 0},
+		{-2700, 0},
+	}
+	for _, tt := range tests {
+		if got := kelvinToMirek(tt.kelvin); got != tt.want {
+			t.Errorf("kelvinToMirek(%d) = %d, want %d", tt.kelvin, got, tt.want)
+		}
+	}
+}
+
+func TestMirekToKelvin(t *testing.T) {
+	tests := []struct {
+		mirek, want int
+	}{
+		{153, 6500}, // 6536K, labelled 6500K
+		{500, 2000},
+		{370, 2700},
+		{1, 1_000_000},
+		{0, 0},
+		{-153, 0},
+	}
+	for _, tt := range tests {
+		if got := mirekToKelvin(tt.mirek); got != tt.want {
+			t.Errorf("mirekToKelvin(%d) = %d, want %d", tt.mirek, got, tt.want)
+		}
+	}
+}
+
+func TestKelvinRoundTrip(t *testing.T) {
+	// Every label a Hue white light shows reads back the same
+	for kelvin := 2000; kelvin <= 6500; kelvin += 100 {
+		if got := mirekToKelvin(kelvinToMirek(kelvin)); got != kelvin {
+			t.Errorf("%dK read back as %dK", kelvin, got)
+		}
+	}
+
+	// Going through Kelvin loses at most the 100K rounding, which is more
+	// mirek at the warm end: 50K is m²·50/10⁶ mirek
+	for mirek := defaultMirekMin; mirek <= defaultMirekMax; mirek++ {
+		got := kelvinToMirek(mirekToKelvin(mirek))
+		if diff, limit := max(got-mirek, mirek-got), mirek*mirek*50/1e6+1; diff > limit {
+			t.Errorf("%d mirek read back as %d, off by more than %d", mirek, got, limit)
+		}
+	}
+}
+
+func TestMirekRange(t *testing.T) {
+	tests := []struct {
+		name          string
+		caps          lightCaps
+		low, high     int
+		clampIn, want []int // clampMirek(clampIn[i]) == want[i]
+	}{
+		{"no schema", lightCaps{}, 153, 500,
+			[]int{0, 100, 153, 300, 500, 501, -1}, []int{153, 153, 153, 300, 500, 500, 153}},
+		{"own schema", lightCaps{mirekMin: 200, mirekMax: 454}, 200, 454,
+			[]int{153, 200, 300, 454, 500}, []int{200, 200, 300, 454, 454}},
+		{"swapped schema", lightCaps{mirekMin: 454, mirekMax: 200}, 200, 454,
+			[]int{153, 300, 500}, []int{200, 300, 454}},
+		{"half a schema", lightCaps{mirekMin: 0, mirekMax: 454}, 153, 500,
+			[]int{100, 500}, []int{153, 500}},
+		{"negative schema", lightCaps{mirekMin: -1, mirekMax: 454}, 153, 500,
+			[]int{100}, []int{153}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if low, high := tt.caps.mirekRange(); low != tt.low || high != tt.high {
+				t.Errorf("mirekRange = %d-%d, want %d-%d", low, high, tt.low, tt.high)
+			}
+			for i, in := range tt.clampIn {
+				if got := tt.caps.clampMirek(in); got != tt.want[i] {
+					t.Errorf("clampMirek(%d) = %d, want %d", in, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// dynamicsStyle colors the marker on lights playing a palette or transition
+var dynamicsStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#8BE9FD"))
+
+// column is one column of the lights table: its header, width and how a row
+// fills it
+type column struct {
+	key   string // name used by :columns and the columns config key
+	title string // catalog key of the header
+	width int
+	cell  func(m lightModel, tr tableRow, now time.Time) string
+}
+
+// tableColumns lists every column that can be shown
+var tableColumns = []column{
+	{key: "name", title: "column.name", width: nameWidth, cell: nameCell},
+	{key: "status", title: "column.status", width: statusWidth, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		if tr.device {
+			return deviceStatusCell(m.light, tr.lights)
+		}
+		return lightStatusCell(m.light[tr.lights[0]])
+	}},
+	{key: "brightness", title: "column.brightness", width: brightnessWidth, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		if tr.device {
+			return deviceBrightnessCell(m.light, tr.lights)
+		}
+		return lightBrightnessCell(m.light[tr.lights[0]])
+	}},
+	{key: "room", title: "column.room", width: 16, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		return orDash(m.roomOf(m.light[tr.lights[0]].ID))
+	}},
+	{key: "type", title: "column.type", width: 16, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		if tr.device {
+			return ""
+		}
+		return strings.ReplaceAll(m.light[tr.lights[0]].Type, "_", " ")
+	}},
+	{key: "model", title: "column.model", width: 20, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		if tr.member {
+			return ""
+		}
+		return orDash(m.light[tr.lights[0]].Product.model())
+	}},
+	{key: "color", title: "column.color", width: 10, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		if tr.device {
+			return ""
+		}
+		return colorCell(m.light[tr.lights[0]])
+	}},
+	{key: "last_seen", title: "column.last_seen", width: 10, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		light := m.light[tr.lights[0]]
+		if tr.device || light.LastSeen.IsZero() {
+			return orDash("")
+		}
+		return formatClock(light.LastSeen, now, appConfig.Units.Time)
+	}},
+	{key: "changed", title: "column.changed", width: 18, cell: func(m lightModel, tr tableRow, now time.Time) string {
+		if tr.device {
+			return ""
+		}
+		return changedByCell(m.light[tr.lights[0]], now)
+	}},
+}
+
+// defaultColumns are shown when the columns config key is unset
+var defaultColumns = []string{"name", "status", "brightness"}
+
+// parseColumns checks a column list, given as keys separated by commas or
+// spaces, and returns the keys in order
+func parseColumns(list string) ([]string, error) {
+	keys := strings.FieldsFunc(strings.ToLower(list), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if err := validateColumns(keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// validateColumns rejects unknown or repeated columns and lists missing NAME or STATUS
+func validateColumns(keys []string) error {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if _, ok := findColumn(key); !ok {
+			return fmt.Errorf("unknown column %q; choose from %s", key, strings.Join(columnKeys(), ", "))
+		}
+		if seen[key] {
+			return fmt.Errorf("column %q is listed twice", key)
+		}
+		seen[key] = true
+	}
+	if !seen["name"] || !seen["status"] {
+		return fmt.Errorf("columns must include name and status")
+	}
+	return nil
+}
+
+func findColumn(key string) (column, bool) {
+	for _, c := range tableColumns {
+		if c.key == key {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// columnKeys lists every column's key, for messages
+func columnKeys() []string {
+	keys := make([]string, len(tableColumns))
+	for i, c := range tableColumns {
+		keys[i] = c.key
+	}
+	return keys
+}
+
+// columnList returns the configured column keys, or the defaults
+func (c *Config) columnList() []string {
+	if len(c.Columns) == 0 {
+		return defaultColumns
+	}
+	return c.Columns
+}
+
+// visibleColumns returns the columns to render, in order
+func (c *Config) visibleColumns() []column {
+	var columns []column
+	for _, key := range c.columnList() {
+		if col, ok := findColumn(key); ok {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// showsColumn reports whether a column is configured to render
+func (c *Config) showsColumn(key string) bool {
+	return slices.Contains(c.columnList(), key)
+}
+
+// columnsCommand handles :columns: no argument lists the current columns,
+// "reset" restores the defaults, and a list replaces them
+func (m *lightModel) columnsCommand(args string) error {
+	var keys []string
+	switch args {
+	case "":
+		m.setStatusTr("status.columns.available", strings.Join(appConfig.columnList(), ", "), strings.Join(columnKeys(), ", "))
+		return nil
+	case "reset":
+		keys = []string{}
+	default:
+		var err error
+		if keys, err = parseColumns(args); err != nil {
+			return err
+		}
+	}
+
+	appConfig.Columns = keys
+	if err := setConfigValue("columns", keys); err != nil {
+		return fmt.Errorf("columns changed, but saving them failed: %v", err)
+	}
+	m.setStatusTr("status.columns", strings.Join(appConfig.columnList(), ", "))
+	return nil
+}
+
+// nameCell renders a row's name with its markers and gradient swatch, which
+// get room before the name is truncated
+func nameCell(m lightModel, tr tableRow, now time.Time) string {
+	// Member services are indented beneath their device
+	name := tr.name
+	if tr.member {
+		name = "  " + name
+	}
+
+	suffix := glyphText(m.mirror.marker(tr, m.light))
+	if !tr.member {
+		suffix += updateMarker(m.light[tr.lights[0]])
+	}
+	if !tr.device && m.light[tr.lights[0]].Dynamics != "" {
+		suffix += " " + dynamicsStyle.Render(glyphText("↻"))
+	}
+	if m.rowSyncing(tr) {
+		suffix += " " + syncStyle.Render("SYNC")
+	}
+	if !tr.device {
+		if swatch := gradientSwatch(m.light[tr.lights[0]].Gradient); swatch != "" {
+			suffix += " " + swatch
+		}
+	}
+
+	// Truncate long names/types
+	room := m.nameColumnWidth() - lipgloss.Width(suffix)
+	if lipgloss.Width(name) > room {
+		name = ansi.Truncate(name, room, "...")
+	}
+	if tr.device {
+		name = lipgloss.NewStyle().Bold(true).Render(name)
+	}
+	return name + suffix
+}
+
+// colorCell shows a light's current color as a swatch and its color
+// temperature or hex code
+func colorCell(light Light) string {
+	switch {
+	case light.Mirek > 0:
+		swatch := lipgloss.NewStyle().Foreground(lipgloss.Color(xyToHex(mirekToXY(light.Mirek)))).Render(glyphText("■"))
+		return fmt.Sprintf("%s %dK", swatch, mirekToKelvin(light.Mirek))
+	case light.Color != nil:
+		hex := xyToHex(*light.Color)
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(hex)).Render(glyphText("■")) + " " + hex
+	}
+	return orDash("")
+}
+
+// changedByCell describes who last changed a light, e.g. "external 14:02"
+func changedByCell(light Light, now time.Time) string {
+	if light.ChangedBy == "" {
+		return orDash("")
+	}
+	return light.ChangedBy + " " + formatClock(light.ChangedAt, now, appConfig.Units.Time)
+}
+
+// fitCell pads or truncates an already styled cell to exactly width columns.
+// Widths are measured without escape codes, so styling never shifts the
+// columns after it; wrapping a styled string in a Width style would.
+func fitCell(cell string, width int) string {
+	if lipgloss.Width(cell) > width {
+		cell = ansi.Truncate(cell, width, "...")
+	}
+	return cell + strings.Repeat(" ", width-lipgloss.Width(cell))
+}
+
+// orDash shows a faint dash for an empty cell
+func orDash(s string) string {
+	if s == "" {
+		return lipgloss.NewStyle().Faint(true).Render("-")
+	}
+	return s
+}
+
+// roomOf returns the name of the room holding a light, or ""
+func (m lightModel) roomOf(lightID string) string {
+	for _, group := range m.groups {
+		if group.ownerType == "room" && slices.Contains(group.lightIDs, lightID) {
+			return group.name
+		}
+	}
+	return ""
+}
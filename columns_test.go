@@ -0,0 +1,118 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestFitCell(t *testing.T) {
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+	tests := []struct {
+		name  string
+		cell  string
+		width int
+		want  string // without styling
+	}{
+		{"pads", "Desk", 8, "Desk    "},
+		{"exact", "Kitchen", 7, "Kitchen"},
+		{"truncates", "Living Room Lamp", 10, "Living ..."},
+		{"empty", "", 3, "   "},
+		{"styled pads", red.Render("ON"), 5, "ON   "},
+		{"styled truncates", red.Render("UNREACHABLE"), 8, "UNREA..."},
+		{"wide runes pad", "日本", 6, "日本  "},
+		// A wide rune that doesn't fit whole is dropped, and the gap padded
+		{"wide runes truncate", "日本語の照明", 8, "日本... "},
+		{"wide rune split", "日本語の照明", 6, "日... "},
+		{"emoji", "💡 Desk lamp", 9, "💡 Des..."},
+		{"accents", "Küchenlicht", 8, "Küche..."},
+	}
+	for _, tt := range tests {
+		got := fitCell(tt.cell, tt.width)
+		if w := lipgloss.Width(got); w != tt.width {
+			t.Errorf("%s: fitCell(%q, %d) is %d columns wide", tt.name, tt.cell, tt.width, w)
+		}
+		if plain := ansi.Strip(got); plain != tt.want {
+			t.Errorf("%s: fitCell(%q, %d) = %q, want %q", tt.name, tt.cell, tt.width, plain, tt.want)
+		}
+	}
+}
+
+func TestOrDash(t *testing.T) {
+	if got := ansi.Strip(orDash("")); got != "-" {
+		t.Errorf("orDash(\"\") = %q, want a dash", got)
+	}
+	for _, s := range []string{"Living Room", " ", "0"} {
+		if got := orDash(s); got != s {
+			t.Errorf("orDash(%q) = %q", s, got)
+		}
+	}
+}
+
+// Styled cells (bold, colored ON, OFF and UNREACHABLE) mustn't shift the
+// columns after them: every row's cells start at the offsets the column
+// widths give, measured on screen with the styling stripped
+func TestColumnOffsetsWithStyledCells(t *testing.T) {
+	lights := testLights(3)
+	lights[0].Status, lights[0].Brightness = "on", 80
+	lights[1].Status = "off"
+	lights[2].Reachable = false
+	m := initialModel(lights, nil)
+	m.width, m.height = 200, 40
+
+	columns := m.layoutColumns()
+	offsets := make(map[string]int, len(columns))
+	left := columnsLeft
+	for _, col := range columns {
+		offsets[col.key] = left
+		left += col.width + 2
+	}
+	cell := func(line, key string) string {
+		for _, col := range columns {
+			if col.key == key {
+				return strings.TrimSpace(ansi.Cut(line, offsets[key], offsets[key]+col.width))
+			}
+		}
+		t.Fatalf("no %s column in %v", key, columns)
+		return ""
+	}
+
+	// Clicks land in the same cells
+	for key, x := range offsets {
+		if _, column, ok := m.hitTest(x, m.firstRowY()); !ok || column != tableColumn(key) {
+			t.Errorf("a click at column %d hit %q, want %q", x, column, key)
+		}
+	}
+
+	lines := strings.Split(tableRenderer{}.render(m), "\n")
+	tests := []struct {
+		name, status, brightness string
+	}{
+		{"Light 00", "ON", "80%"},
+		{"Light 01", "OFF", "50%"},
+		{"Light 02", "UNREACHABLE", "N/A"},
+	}
+	for _, tt := range tests {
+		i := slices.IndexFunc(lines, func(line string) bool { return strings.Contains(ansi.Strip(line), tt.name) })
+		if i < 0 {
+			t.Fatalf("no row for %s", tt.name)
+		}
+		line := lines[i]
+		if got := cell(line, "name"); !strings.HasPrefix(got, tt.name) {
+			t.Errorf("%s: name cell = %q", tt.name, got)
+		}
+		if got := cell(line, "status"); got != tt.status {
+			t.Errorf("%s: status cell = %q, want %q", tt.name, got, tt.status)
+		}
+		if got := cell(line, "brightness"); got != tt.brightness {
+			t.Errorf("%s: brightness cell = %q, want %q", tt.name, got, tt.brightness)
+		}
+		// Every row is as wide as the header, styled or not
+		if got, want := lipgloss.Width(line), lipgloss.Width(lines[i-1]); got != want {
+			t.Errorf("%s: row is %d columns wide, the line above %d", tt.name, got, want)
+		}
+	}
+}
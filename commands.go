@@ -0,0 +1,475 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxMacroDepth bounds macros that run other macros
+const maxMacroDepth = 5
+
+// executeCommand runs a command line from the command box or --exec. Commands
+// separated by ";" run left to right, stopping at the first one that fails;
+// each step's result is added to the status line. The line and its result are
+// also kept in the command box's output. The returned error, also shown on the
+// status line, reports the first failure.
+func (m *lightModel) executeCommand(line string) error {
+	logDebug("Executing command: %s", line)
+	m.appendOutput(":" + line)
+	m.inCommand = true
+	defer func() { m.inCommand = false }()
+
+	// macro save takes the rest of the line verbatim, semicolons included
+	if name, body, ok := strings.Cut(strings.TrimSpace(line), " "); ok && name == "macro" {
+		if sub, rest, _ := strings.Cut(strings.TrimSpace(body), " "); sub == "save" {
+			err := m.saveMacro(rest)
+			if err != nil {
+				m.setStatusTr("status.error", err)
+			}
+			m.appendOutput(m.status)
+			return err
+		}
+	}
+
+	err := m.runCommandLine(line)
+	if err != nil {
+		m.setStatus("%s", err)
+	}
+	m.appendOutput(m.status)
+	return err
+}
+
+// queue schedules a tea.Cmd from a command, for commands that start
+// something running in the background
+func (m *lightModel) queue(cmd tea.Cmd) {
+	m.queued = append(m.queued, cmd)
+}
+
+// takeQueued returns the commands queued since the last call
+func (m *lightModel) takeQueued() tea.Cmd {
+	if len(m.queued) == 0 {
+		return nil
+	}
+	cmd := tea.Batch(m.queued...)
+	m.queued = nil
+	return cmd
+}
+
+// runCommandLine runs each ";"-separated step of a line, collecting their
+// results into the status line. The returned error includes the results of
+// the steps that ran before the failure.
+func (m *lightModel) runCommandLine(line string) error {
+	steps := splitCommands(line)
+	if len(steps) == 0 {
+		return nil
+	}
+
+	var results []string
+	for i, step := range steps {
+		m.status = ""
+		if err := m.runCommand(step); err != nil {
+			if len(steps) > 1 {
+				err = fmt.Errorf("stopped at step %d (%s): %v", i+1, step, err)
+			}
+			results = append(results, "Error: "+err.Error())
+			return fmt.Errorf("%s", strings.Join(results, "\n"))
+		}
+		if m.status != "" {
+			results = append(results, m.status)
+		}
+	}
+	m.status = strings.Join(results, "\n")
+	return nil
+}
+
+// runCommand executes a single command, reporting success on the status line
+func (m *lightModel) runCommand(command string) error {
+	command, err := expandAlias(command, appConfig.Aliases)
+	if err != nil {
+		return err
+	}
+
+	// An alias may expand into a chain of its own
+	if len(splitCommands(command)) > 1 {
+		return m.runCommandLine(command)
+	}
+
+	parts := strings.SplitN(command, " ", 2)
+	args := ""
+	if len(parts) == 2 {
+		args = strings.TrimSpace(parts[1])
+	}
+
+	switch parts[0] {
+	case "help":
+		m.setStatusTr("status.commands", strings.Join(builtinCommands, ", "))
+		if len(m.sceneKeys) > 0 {
+			m.status += "\nScene keys: " + describeSceneKeys(m.sceneKeys)
+		}
+	case "alias":
+		m.setStatus("%s", describeAliases(appConfig.Aliases))
+	case "clear":
+		m.clearCommand()
+	case "macro":
+		return m.macroCommand(args)
+	case "reveal-key":
+		// Shown on screen only; setStatus would otherwise write it to the log
+		m.status = "API key: " + apiKey
+	case "room", "zone":
+		return m.groupCommand(parts[0], args)
+	case "gradient":
+		return m.gradientCommand(args)
+	case "ct":
+		return m.ctCommand(args)
+	case "ping":
+		return m.pingCommand()
+	case "connectivity":
+		return m.connectivityCommand(args)
+	case "columns":
+		return m.columnsCommand(args)
+	case "signal":
+		return m.signalCommand(args)
+	case "away":
+		return m.awayCommand(args)
+	case "mirror":
+		return m.mirrorCommand(args)
+	case "match":
+		return m.matchCommand()
+	case "delete":
+		return m.deleteCommand()
+	case "search":
+		return m.searchCommand()
+	case "set":
+		return m.setCommand(args)
+	case "move":
+		return m.moveCommand(args)
+	case "order":
+		return m.orderCommand(args)
+	case "bridge":
+		details, err := fetchBridgeDetails()
+		if err != nil {
+			return fmt.Errorf("fetching bridge details: %v", err)
+		}
+		m.setStatus("%s", details)
+	case "version":
+		bridgeVersion, err := getBridgeSoftwareVersion()
+		if err != nil {
+			logError("Error fetching bridge version: %v", err)
+			bridgeVersion = "unknown"
+		}
+		m.setStatusTr("status.version", versionString(), bridgeVersion)
+	case "refresh":
+		// A refresh is for when the app seems out of step, so nothing cached is trusted
+		bridgeCache.invalidate()
+		freshLights, err := returnLights()
+		if err != nil {
+			return fmt.Errorf("refreshing lights: %v", err)
+		}
+		// Kept apart from m.light, which replaceLights swaps out, for the diff
+		before := append([]Light(nil), m.light...)
+		m.replaceLights(freshLights)
+		groups, err := loadGroups(m.light)
+		if err != nil {
+			return fmt.Errorf("lights refreshed, but rooms and zones couldn't be reloaded: %v", err)
+		}
+		m.setGroups(groups)
+		if connectivityError != nil {
+			return fmt.Errorf("lights refreshed, but reachability is unknown: %v", connectivityError)
+		}
+		m.setStatusTr("status.refreshed", diffLights(before, m.light))
+	case "select":
+		return m.selectByPattern(unquote(args))
+	case "brightness":
+		return m.setSelectedBrightness(args)
+	case "all_on":
+		return m.switchAll(true, false)
+	case "all_off":
+		return m.switchAll(false, false)
+	case "all_on!":
+		return m.switchAll(true, true)
+	case "all_off!":
+		return m.switchAll(false, true)
+	case "filter":
+		return m.filterCommand(args)
+	case "updates":
+		return m.updatesCommand()
+	case "toggle":
+		return m.toggleCommand(args)
+	case "rename":
+		return m.renameCommand(args)
+	case "diagnostics":
+		return m.diagnosticsCommand()
+	case "reconnect":
+		return m.reconnectCommand()
+	case "profile":
+		return m.profileCommand(args)
+	case "automations":
+		return m.automationsCommand()
+	case "scene":
+		if args == "" {
+			return fmt.Errorf("usage: scene <scene name> | scene speed <0-100>")
+		}
+		if speed, ok := strings.CutPrefix(args, "speed "); ok {
+			return m.sceneSpeedCommand(strings.TrimSpace(speed))
+		}
+		sceneName := unquote(args)
+		if err := setScene(sceneName); err != nil {
+			return err
+		}
+		m.setStatusTr("status.scene.activated", sceneName)
+	default:
+		return fmt.Errorf("unknown command: %s", command)
+	}
+	return nil
+}
+
+// selectByPattern replaces the selection with the lights whose names match a
+// case-insensitive glob such as "kitchen*". An empty pattern clears the selection.
+func (m *lightModel) selectByPattern(pattern string) error {
+	m.selected = make(map[int]struct{})
+	if pattern == "" {
+		m.setStatusTr("status.selection.cleared")
+		return nil
+	}
+
+	// A plain name picks one light, telling duplicates apart
+	if !hasGlob(pattern) {
+		index, err := resolveLightName(m.light, pattern, m.roomOf)
+		if err != nil {
+			return err
+		}
+		m.selected[index] = struct{}{}
+		m.setStatusTr("status.selected.name", m.light[index].Name)
+		return nil
+	}
+
+	pattern = strings.ToLower(pattern)
+	for i, light := range m.light {
+		matched, err := path.Match(pattern, strings.ToLower(light.Name))
+		if err != nil {
+			return fmt.Errorf("bad pattern %q: %v", pattern, err)
+		}
+		if matched {
+			m.selected[i] = struct{}{}
+		}
+	}
+
+	if len(m.selected) == 0 {
+		return fmt.Errorf("no lights match %q", pattern)
+	}
+	m.setStatus("%s", trn("status.selected", len(m.selected), len(m.selected)))
+	return nil
+}
+
+// setSelectedBrightness sets an absolute brightness on every selected light,
+// or on the light named after the value. Each light is clamped to its own
+// minimum dim level; 0 turns lights off.
+func (m *lightModel) setSelectedBrightness(args string) error {
+	level, name, _ := strings.Cut(args, " ")
+	value, err := strconv.Atoi(strings.TrimSuffix(level, "%"))
+	if err != nil || value < 0 || value > 100 {
+		return fmt.Errorf("usage: brightness <0-100> [light]")
+	}
+	targets := m.selected
+	if name = unquote(name); name != "" {
+		index, err := resolveLightName(m.light, name, m.roomOf)
+		if err != nil {
+			return err
+		}
+		targets = map[int]struct{}{index: {}}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no lights selected")
+	}
+
+	// On/off devices take part in 0, which switches them off, but have no
+	// brightness to set otherwise
+	skipped, already, onOffOnly := 0, 0, 0
+	var writes []lightWrite
+	// What each light was set to, filled in as the writes go out
+	written := make(map[string]float32)
+	for index := range targets {
+		light := m.light[index]
+		if !light.Reachable {
+			skipped++
+			continue
+		}
+		if !light.can(capDimming) && value > 0 {
+			onOffOnly++
+			continue
+		}
+
+		// 0 means "off" rather than the dimmest the bulb can go
+		if value == 0 {
+			if light.Status == "off" {
+				already++
+				continue
+			}
+			writes = append(writes, lightWrite{light: light, send: func() error {
+				return toggleLight(light.ID, true)
+			}})
+			continue
+		}
+		if sameBrightness(light, float32(value)) {
+			already++
+			continue
+		}
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			brightness, err := writeBrightness(light.ID, float32(value), light.MinDimLevel)
+			written[light.ID] = brightness
+			return err
+		}})
+	}
+
+	m.startWrites(writes, func(m *lightModel, done, failed []lightWrite) {
+		clamped := 0
+		for _, w := range done {
+			if value == 0 {
+				m.setLightStatus(w.light.ID, "off")
+				continue
+			}
+			if written[w.light.ID] != float32(value) {
+				clamped++
+			}
+			m.setLightBrightness(w.light.ID, written[w.light.ID])
+		}
+
+		summary := summarizeAction("action.brightness", len(done), skipped, len(failed), value)
+		if value == 0 {
+			summary += alreadyNote(already, tr("state.off"))
+		} else {
+			summary += alreadyNote(already, tr("state.at_percent", value))
+		}
+		if clamped > 0 {
+			summary += tr("action.clamped", clamped)
+		}
+		summary += onOffOnlyNote(onOffOnly, tr("what.brightness"))
+		summary += m.syncHint(writeLights(failed))
+		m.setStatus("%s", summary)
+	})
+	return nil
+}
+
+// macroCommand handles "macro run <name>", "macro delete <name>" and "macro list"
+func (m *lightModel) macroCommand(args string) error {
+	sub, name, _ := strings.Cut(args, " ")
+	name = strings.TrimSpace(name)
+
+	switch sub {
+	case "", "list":
+		m.setStatus("%s", describeMacros(appConfig.Macros))
+	case "run":
+		body, ok := appConfig.Macros[name]
+		if !ok {
+			return fmt.Errorf("no macro named %q", name)
+		}
+		if m.macroDepth >= maxMacroDepth {
+			return fmt.Errorf("macros nested more than %d deep", maxMacroDepth)
+		}
+		m.macroDepth++
+		defer func() { m.macroDepth-- }()
+		return m.runCommandLine(body)
+	case "delete":
+		if _, ok := appConfig.Macros[name]; !ok {
+			return fmt.Errorf("no macro named %q", name)
+		}
+		delete(appConfig.Macros, name)
+		if err := setConfigValue("macros", appConfig.Macros); err != nil {
+			return fmt.Errorf("saving config: %v", err)
+		}
+		m.setStatusTr("status.macro.deleted", name)
+	default:
+		return fmt.Errorf("usage: macro save <name> <commands> | macro run <name> | macro delete <name> | macro list")
+	}
+	return nil
+}
+
+// saveMacro stores "<name> <commands>" in the config file
+func (m *lightModel) saveMacro(args string) error {
+	name, body, _ := strings.Cut(strings.TrimSpace(args), " ")
+	body = strings.TrimSpace(body)
+	if name == "" || body == "" {
+		return fmt.Errorf("usage: macro save <name> <commands>")
+	}
+	if strings.ContainsAny(name, ".;\"'") {
+		return fmt.Errorf("macro names can't contain . ; or quotes")
+	}
+
+	if appConfig.Macros == nil {
+		appConfig.Macros = make(map[string]string)
+	}
+	appConfig.Macros[name] = body
+	if err := setConfigValue("macros", appConfig.Macros); err != nil {
+		return fmt.Errorf("saving config: %v", err)
+	}
+	m.setStatusTr("status.macro.saved", name)
+	return nil
+}
+
+// describeMacros lists the saved macros for :macro list
+func describeMacros(macros map[string]string) string {
+	if len(macros) == 0 {
+		return tr("status.macros.none")
+	}
+
+	names := make([]string, 0, len(macros))
+	for name := range macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, macros[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitCommands splits a line on ";" outside of single or double quotes,
+// dropping empty steps
+func splitCommands(line string) []string {
+	var steps []string
+	var current strings.Builder
+	var quote rune
+
+	flush := func() {
+		if step := strings.TrimSpace(current.String()); step != "" {
+			steps = append(steps, step)
+		}
+		current.Reset()
+	}
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			current.WriteRune(r)
+		case r == ';':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return steps
+}
+
+// unquote strips one pair of matching surrounding quotes, so scene names with
+// spaces or semicolons can be written as "Movie; Night"
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
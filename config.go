@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the TUI's view of ~/.openhue/config.yaml. The bridge and key
+// fields are shared with other openhue tools; everything else is TUI settings.
+type Config struct {
+	Bridge   string            `yaml:"bridge"`
+	Key      string            `yaml:"key"`
+	BridgeID string            `yaml:"bridge_id,omitempty"` // Lets setup find the bridge again if its IP changes
+	Aliases  map[string]string `yaml:"aliases,omitempty"`
+	Macros   map[string]string `yaml:"macros,omitempty"`
+
+	// BrightnessStep is the percentage change per left/right keypress
+	BrightnessStep int `yaml:"brightness_step,omitempty"`
+
+	// SceneKeys binds key names (e.g. "f1") to scene names, optionally room-scoped as "Room/Scene"
+	SceneKeys map[string]string `yaml:"scene_keys,omitempty"`
+
+	// Order lists light IDs in display order; lights not listed follow in ID order
+	Order []string `yaml:"order,omitempty"`
+
+	// ASCII draws the UI with ASCII characters only, like --ascii
+	ASCII bool `yaml:"ascii,omitempty"`
+
+	// Remote holds Hue Remote API credentials for --remote
+	Remote RemoteConfig `yaml:"remote,omitempty"`
+
+	// Units controls how temperatures and clock times are shown
+	Units UnitsConfig `yaml:"units,omitempty"`
+
+	// ConnectivityInterval is how often reachability is re-checked, e.g. "5m", or "off"
+	ConnectivityInterval string `yaml:"connectivity_interval,omitempty"`
+
+	// SSETimeout is how long the event stream may be silent before it's reconnected, e.g. "90s", or "off"
+	SSETimeout string `yaml:"sse_timeout,omitempty"`
+
+	// Away is the light set and time window :away start reuses
+	Away AwayConfig `yaml:"away,omitempty"`
+
+	// Columns lists the lights table's columns in order; name and status are required
+	Columns []string `yaml:"columns,omitempty"`
+
+	// SkipUnreachableQuietly acts on selections with unreachable lights
+	// without first warning that they'll be skipped
+	SkipUnreachableQuietly bool `yaml:"skip_unreachable_quietly,omitempty"`
+
+	// ExitSummary lists the lights left on at quit: "off", "show", or "ask" to offer turning them off
+	ExitSummary string `yaml:"exit_summary,omitempty"`
+
+	// RowFlash tints a row briefly when an event changes it; nil means the
+	// default, on
+	RowFlash *bool `yaml:"row_flash,omitempty"`
+
+	// OnOnDim switches a light that's off on when its brightness is raised,
+	// like a dimmer switch; nil means the default, on
+	OnOnDim *bool `yaml:"on_on_dim,omitempty"`
+
+	// Startup is the view, filter and room the TUI opens with
+	Startup StartupConfig `yaml:"startup,omitempty"`
+
+	// NotifyContactOpen raises a notification when a contact sensor opens
+	NotifyContactOpen bool `yaml:"notify_contact_open,omitempty"`
+}
+
+// Defaults for unset config values
+const (
+	defaultBrightnessStep = 10
+	fineBrightnessStep    = 1
+)
+
+// rowFlash reports whether rows flash when an event changes them, which
+// they do unless turned off in the config
+func (c *Config) rowFlash() bool {
+	return c.RowFlash == nil || *c.RowFlash
+}
+
+// onOnDim reports whether raising the brightness of a light that's off
+// switches it on, which it does unless turned off in the config
+func (c *Config) onOnDim() bool {
+	return c.OnOnDim == nil || *c.OnOnDim
+}
+
+// brightnessStep returns the configured coarse step, or the default when unset
+func (c *Config) brightnessStep() int {
+	if c.BrightnessStep <= 0 {
+		return defaultBrightnessStep
+	}
+	return c.BrightnessStep
+}
+
+// Global configuration, loaded at startup
+var appConfig = &Config{}
+
+// configDir returns ~/.openhue
+func configDir() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homedir, ".openhue"), nil
+}
+
+// configPath returns ~/.openhue/config.yaml
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// loadConfig reads the config file. A missing file is not an error and yields
+// an empty config. The returned warnings describe entries that were ignored.
+func loadConfig() (*Config, []string, error) {
+	config := &Config{}
+
+	path, err := configPath()
+	if err != nil {
+		return config, nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil, nil
+	} else if err != nil {
+		return config, nil, err
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return config, nil, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+
+	warnings := config.validate()
+	return config, warnings, nil
+}
+
+// validate drops invalid entries and describes each one it dropped
+func (c *Config) validate() []string {
+	var warnings []string
+	if c.BrightnessStep < 0 || c.BrightnessStep > 100 {
+		warnings = append(warnings, fmt.Sprintf("brightness_step %d is outside 1-100, using %d", c.BrightnessStep, defaultBrightnessStep))
+		c.BrightnessStep = 0
+	}
+	if _, err := parseConnectivityInterval(c.ConnectivityInterval); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v, using %s", err, defaultConnectivityInterval))
+		c.ConnectivityInterval = ""
+	}
+	if _, err := parseSSETimeout(c.SSETimeout); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v, using %s", err, defaultSSETimeout))
+		c.SSETimeout = ""
+	}
+	warnings = append(warnings, c.Units.validate()...)
+	if _, err := parseExitSummary(c.ExitSummary); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v, using off", err))
+		c.ExitSummary = ""
+	}
+	if view, err := parseStartupView(c.Startup.View); err != nil {
+		warnings = append(warnings, fmt.Sprintf("startup.view: %v, using the last view", err))
+		c.Startup.View = ""
+	} else {
+		c.Startup.View = view
+	}
+	if len(c.Columns) > 0 {
+		for i := range c.Columns {
+			c.Columns[i] = strings.ToLower(c.Columns[i])
+		}
+		if err := validateColumns(c.Columns); err != nil {
+			warnings = append(warnings, fmt.Sprintf("columns: %v, using the default columns", err))
+			c.Columns = nil
+		}
+	}
+	for name := range c.Aliases {
+		if isBuiltinCommand(name) {
+			warnings = append(warnings, fmt.Sprintf("alias %q shadows a built-in command and was ignored", name))
+			delete(c.Aliases, name)
+		}
+	}
+	return warnings
+}
+
+// setConfigValue writes value under a dotted key path (e.g. "macros.desk") in
+// the config file, keeping comments and keys the TUI doesn't know about
+func setConfigValue(keyPath string, value any) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	node := doc.Content[0]
+	keys := strings.Split(keyPath, ".")
+	for i, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("config key %s is not a mapping", strings.Join(keys[:i], "."))
+		}
+
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				child = node.Content[j+1]
+				break
+			}
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		}
+
+		if i == len(keys)-1 {
+			if err := child.Encode(value); err != nil {
+				return err
+			}
+		}
+		node = child
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+
+	// The file holds the bridge key, so keep it private. WriteFile only
+	// applies the mode to new files, hence the explicit chmod.
+	if err := os.WriteFile(path, out, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultConnectivityInterval is how often reachability is re-checked when
+// connectivity_interval isn't set
+const defaultConnectivityInterval = 5 * time.Minute
+
+// parseConnectivityInterval reads connectivity_interval: a duration such as
+// "5m", or "off" to disable the periodic check
+func parseConnectivityInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultConnectivityInterval, nil
+	}
+	if value == "off" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 10*time.Second {
+		return 0, fmt.Errorf("connectivity_interval must be off or a duration of at least 10s, such as 5m")
+	}
+	return interval, nil
+}
+
+// connectivityInterval returns the periodic check interval, 0 when it's off
+func (c *Config) connectivityInterval() time.Duration {
+	// validate has already replaced invalid values
+	interval, _ := parseConnectivityInterval(c.ConnectivityInterval)
+	return interval
+}
+
+// connectivityTickMsg starts a periodic connectivity check
+type connectivityTickMsg struct {
+	generation int
+}
+
+// connectivityCheckedMsg delivers the result of a connectivity check
+type connectivityCheckedMsg struct {
+	status map[string]string // device ID -> "connected" or "disconnected"
+	err    error
+	manual bool // started by :connectivity rather than the timer
+}
+
+// connectivityTick schedules the next periodic check, or nothing when it's off
+func connectivityTick(generation int) tea.Cmd {
+	interval := appConfig.connectivityInterval()
+	if interval == 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return connectivityTickMsg{generation: generation}
+	})
+}
+
+// checkConnectivityCmd fetches zigbee_connectivity in the background
+func checkConnectivityCmd(manual bool) tea.Cmd {
+	return func() tea.Msg {
+		status, err := getZigbeeConnectivity()
+		return connectivityCheckedMsg{status: status, err: err, manual: manual}
+	}
+}
+
+// connectivityCommand handles ":connectivity", ":connectivity pause" and ":connectivity resume"
+func (m *lightModel) connectivityCommand(args string) error {
+	switch args {
+	case "":
+		m.queue(checkConnectivityCmd(true))
+		m.setStatusTr("status.connectivity.checking")
+	case "pause":
+		if m.connectivityPaused {
+			return fmt.Errorf("connectivity checks are already paused")
+		}
+		m.connectivityPaused = true
+		m.setStatusTr("status.connectivity.paused")
+	case "resume":
+		if !m.connectivityPaused {
+			return fmt.Errorf("connectivity checks aren't paused")
+		}
+		m.connectivityPaused = false
+		if appConfig.connectivityInterval() == 0 {
+			return fmt.Errorf("connectivity_interval is off in the config")
+		}
+		// A fresh generation drops the tick already pending from before the pause
+		m.connectivityGeneration++
+		m.queue(connectivityTick(m.connectivityGeneration))
+		m.setStatusTr("status.connectivity.resumed", appConfig.connectivityInterval())
+	default:
+		return fmt.Errorf("usage: connectivity [pause|resume]")
+	}
+	return nil
+}
+
+// handleConnectivityTick runs a periodic check unless paused
+func (m *lightModel) handleConnectivityTick(msg connectivityTickMsg) tea.Cmd {
+	if msg.generation != m.connectivityGeneration || m.connectivityPaused {
+		return nil
+	}
+	return tea.Batch(checkConnectivityCmd(false), connectivityTick(msg.generation))
+}
+
+// handleConnectivityChecked patches Reachable from a check, notifying about
+// lights whose reachability changed
+func (m *lightModel) handleConnectivityChecked(msg connectivityCheckedMsg) tea.Cmd {
+	connectivityError = msg.err
+	if msg.err != nil {
+		if msg.manual {
+			m.setStatusTr("status.connectivity.failed", msg.err)
+		} else {
+			logError("Periodic connectivity check failed: %v", msg.err)
+		}
+		return nil
+	}
+
+	notified := len(m.notifications) + m.notificationOverflow
+	for deviceID, status := range msg.status {
+		m.setDeviceReachable(deviceID, status == "connected")
+	}
+	if msg.manual {
+		summary := summarizeLights(m.light)
+		m.setStatusTr("status.connectivity.checked", summary.unreachable)
+	}
+	if len(m.notifications)+m.notificationOverflow != notified {
+		return expireNotificationsAfter(notificationTTL)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openhue/openhue-go"
+)
+
+// The color temperatures :ct accepts, in Kelvin
+const (
+	minCTKelvin = 2000
+	maxCTKelvin = 6500
+)
+
+// ctStepMirek is how far [ and ] move the color temperature; even steps in
+// mirek look even to the eye, unlike steps in Kelvin
+const ctStepMirek = 25
+
+// ctPresets are the named whites :ct accepts
+var ctPresets = map[string]int{
+	"warm":    2700,
+	"neutral": 4000,
+	"cool":    6500,
+}
+
+// parseKelvin reads a color temperature such as "2700", "2700k" or "warm"
+func parseKelvin(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if kelvin, ok := ctPresets[s]; ok {
+		return kelvin, nil
+	}
+	kelvin, err := strconv.Atoi(strings.TrimSuffix(s, "k"))
+	if err != nil || kelvin < minCTKelvin || kelvin > maxCTKelvin {
+		return 0, fmt.Errorf("usage: ct <%d-%d>[k] or warm, neutral or cool", minCTKelvin, maxCTKelvin)
+	}
+	return kelvin, nil
+}
+
+// ctCommand handles ":ct <kelvin>", setting the selected lights' color temperature
+func (m *lightModel) ctCommand(args string) error {
+	kelvin, err := parseKelvin(args)
+	if err != nil {
+		return err
+	}
+	mirek := kelvinToMirek(kelvin)
+	return m.setSelectedCT("action.ct", func(Light) (int, bool) {
+		return mirek, true
+	}, kelvin)
+}
+
+// stepSelectedCT makes the selected lights warmer (direction +1) or cooler
+// (-1), starting from each light's own color temperature
+func (m *lightModel) stepSelectedCT(direction int) error {
+	done := "action.warmer"
+	if direction < 0 {
+		done = "action.cooler"
+	}
+	return m.setSelectedCT(done, func(light Light) (int, bool) {
+		current := light.Mirek
+		if current == 0 && light.Color != nil {
+			// In color mode; start from the nearest white if there is one
+			approx, ok := xyToMirek(*light.Color)
+			if !ok {
+				return 0, false
+			}
+			current = approx
+		}
+		if current == 0 {
+			return 0, false
+		}
+		return current + direction*ctStepMirek, true
+	})
+}
+
+// setSelectedCT writes a color temperature to each selected light in the
+// background, clamped to the light's own range. target picks the mirek for a light, or reports
+// that it has no starting point. The color column follows the SSE event that
+// answers the write, so any clamping done by the bridge shows as it is.
+func (m *lightModel) setSelectedCT(done string, target func(light Light) (int, bool), args ...any) error {
+	if len(m.selected) == 0 {
+		return fmt.Errorf("no lights selected")
+	}
+
+	skipped, already, onOffOnly := 0, 0, 0
+	var problems []string
+	var writes []lightWrite
+	for index := range m.selected {
+		light := m.light[index]
+		switch {
+		case !light.can(capDimming):
+			onOffOnly++
+			continue
+		case !light.can(capColorTemperature):
+			problems = append(problems, light.Name+" has no color temperature")
+			skipped++
+			continue
+		case !light.Reachable:
+			skipped++
+			continue
+		}
+		mirek, ok := target(light)
+		if !ok {
+			problems = append(problems, light.Name+" is showing a color")
+			skipped++
+			continue
+		}
+
+		caps := lightCaps{ct: true, mirekMin: light.MirekMin, mirekMax: light.MirekMax}
+		clamped := caps.clampMirek(mirek)
+		if light.Mirek == clamped {
+			already++
+			continue
+		}
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			return updateLight(light.ID, openhue.LightPut{
+				ColorTemperature: &openhue.ColorTemperature{Mirek: &clamped},
+			})
+		}})
+	}
+
+	// Nothing to do at all is a usage error rather than a partial result
+	sort.Strings(problems)
+	if len(writes) == 0 && already == 0 {
+		switch {
+		case len(problems) > 0:
+			return fmt.Errorf("%s", strings.Join(problems, "; "))
+		case onOffOnly > 0 && skipped == 0:
+			return fmt.Errorf("the selected lights can only switch on and off")
+		}
+	}
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		summary := summarizeAction(done, len(written), skipped, len(failed), args...) + alreadyNote(already, tr("state.at_ct")) + onOffOnlyNote(onOffOnly, tr("what.ct"))
+		if len(problems) > 0 {
+			summary += glyphText(" · ") + strings.Join(problems, "; ")
+		}
+		m.setStatus("%s", summary)
+	})
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+)
+
+// deleteRequest is a :delete waiting for the user to type the light's name
+type deleteRequest struct {
+	name     string
+	deviceID string
+}
+
+// deleteCommand handles ":delete", asking for the cursor light's name before
+// anything is removed
+func (m *lightModel) deleteCommand() error {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return fmt.Errorf("no light under the cursor")
+	}
+	light := m.light[m.rows[m.cursor].lights[0]]
+	if light.DeviceOwner == "" {
+		return fmt.Errorf("%s has no owning device to delete", light.Name)
+	}
+
+	name := m.rows[m.cursor].name
+	m.pendingDelete = &deleteRequest{name: name, deviceID: light.DeviceOwner}
+	m.setStatusTr("status.delete.confirm", name)
+	return nil
+}
+
+// confirmDelete deletes the pending device if typed matches its name exactly
+func (m *lightModel) confirmDelete(typed string) {
+	req := m.pendingDelete
+	m.pendingDelete = nil
+	if typed != req.name {
+		m.setStatusTr("status.delete.mismatch")
+		return
+	}
+
+	if _, err := clipWrite("DELETE", "resource/device/"+req.deviceID, nil); err != nil {
+		// The bridge's own wording says why, e.g. the device is in an entertainment area
+		m.setStatusTr("status.delete.failed", req.name, err)
+		return
+	}
+
+	removed := make(map[string]bool)
+	for _, light := range m.light {
+		if light.DeviceOwner == req.deviceID {
+			removed[light.ID] = true
+		}
+	}
+	m.removeLights(removed)
+	m.setStatusTr("status.delete.done", req.name)
+}
+
+// removeLights drops lights from the table, carrying the selection across
+// since it is keyed by index
+func (m *lightModel) removeLights(ids map[string]bool) {
+	var kept []Light
+	for _, light := range m.light {
+		if !ids[light.ID] {
+			kept = append(kept, light)
+		}
+	}
+	m.setLights(kept)
+}
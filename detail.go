@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// detailPane is the i pane describing the light under the cursor. It holds
+// the light's ID rather than its index, so the pane follows the light
+// through list changes.
+type detailPane struct {
+	open    bool
+	lightID string
+}
+
+// openDetail shows the detail pane for the light under the cursor
+func (m *lightModel) openDetail() {
+	if m.cursor < 0 || m.cursor >= len(m.rows) || m.rows[m.cursor].room {
+		return
+	}
+	m.detail = detailPane{open: true, lightID: m.light[m.rows[m.cursor].lights[0]].ID}
+}
+
+// handleDetailKey processes a key while the detail pane is open
+func (m *lightModel) handleDetailKey(key string) {
+	switch key {
+	case "i", "esc", "q":
+		m.detail = detailPane{}
+	}
+}
+
+// detailLines lists what the detail pane shows about a light, as label and
+// value pairs. Product data the bulb leaves out shows as a dash, which
+// third-party bulbs often do.
+func (m lightModel) detailLines(light Light) [][2]string {
+	dash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	capabilities := strings.ReplaceAll(strings.Join(light.Caps.names(), ", "), "_", " ")
+	lines := [][2]string{
+		{tr("detail.name"), light.Name},
+		{tr("detail.device"), dash(light.DeviceName)},
+		{tr("detail.room"), dash(m.roomOf(light.ID))},
+		{tr("detail.type"), dash(strings.ReplaceAll(light.Type, "_", " "))},
+		{tr("detail.capabilities"), dash(capabilities)},
+		{tr("detail.product"), dash(light.Product.Name)},
+		{tr("detail.model"), dash(light.Product.ModelID)},
+		{tr("detail.manufacturer"), dash(light.Product.Manufacturer)},
+		{tr("detail.software"), dash(light.Product.Software)},
+		{tr("detail.hardware"), dash(light.Product.Hardware)},
+		{tr("detail.firmware"), dash(strings.ReplaceAll(light.Update, "_", " "))},
+	}
+	if light.MirekMax > 0 {
+		lines = append(lines, [2]string{tr("detail.ct"), fmt.Sprintf("%dK-%dK", mirekToKelvin(light.MirekMax), mirekToKelvin(light.MirekMin))})
+	}
+	if light.GradientPoints > 0 {
+		lines = append(lines, [2]string{tr("detail.gradient"), fmt.Sprintf("%d", light.GradientPoints)})
+	}
+	return append(lines, [2]string{tr("detail.light_id"), light.ID}, [2]string{tr("detail.device_id"), dash(light.DeviceOwner)})
+}
+
+// renderDetail draws the detail pane
+func (m lightModel) renderDetail() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	light, ok := m.lightByID(m.detail.lightID)
+	if !ok {
+		b.WriteString(titleStyle.Render(tr("detail.title")) + " " + faint.Render(tr("detail.gone")) + "\n")
+	} else {
+		b.WriteString(titleStyle.Render(light.Name) + " " + lightStatusCell(light) + "\n\n")
+		for _, line := range m.detailLines(light) {
+			fmt.Fprintf(&b, "  %s %s\n", faint.Render(fmt.Sprintf("%-18s", line[0])), line[1])
+		}
+	}
+
+	b.WriteString("\n" + faint.Render("i: "+tr("key.close")))
+	if m.status != "" {
+		b.WriteString("\n" + m.status)
+	}
+	return viewText(b.String()) + "\n"
+}
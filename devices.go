@@ -0,0 +1,183 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tableRow is one line of the light table: either a single light, or a device
+// header grouping the light services of a multi-channel fixture, whose members
+// follow it as their own indented rows
+type tableRow struct {
+	lights []int  // indexes into lightModel.light covered by this row
+	device bool   // header row for a device with several light services
+	member bool   // light listed beneath its device header
+	room   bool   // room header in the rooms view; also a device row, so it acts on all its lights
+	group  string // grouped_light ID of a room header, "" for the lights in no room
+	name   string
+}
+
+// buildRows lays out the table, grouping services that share a DeviceOwner
+// under a device row. Single-service devices stay plain light rows.
+func buildRows(lights []Light) []tableRow {
+	byDevice := make(map[string][]int)
+	for i, light := range lights {
+		if light.DeviceOwner != "" {
+			byDevice[light.DeviceOwner] = append(byDevice[light.DeviceOwner], i)
+		}
+	}
+
+	var rows []tableRow
+	emitted := make(map[string]bool)
+	for i, light := range lights {
+		members := byDevice[light.DeviceOwner]
+		if len(members) < 2 {
+			rows = append(rows, tableRow{lights: []int{i}, name: light.Name})
+			continue
+		}
+		if emitted[light.DeviceOwner] {
+			continue
+		}
+		emitted[light.DeviceOwner] = true
+
+		name := light.DeviceName
+		if name == "" {
+			name = light.Name
+		}
+		rows = append(rows, tableRow{lights: members, device: true, name: name})
+		for _, member := range members {
+			rows = append(rows, tableRow{lights: []int{member}, member: true, name: lights[member].Name})
+		}
+	}
+	return rows
+}
+
+// rowSelected reports whether every light covered by a row is selected
+func (m lightModel) rowSelected(row tableRow) bool {
+	for _, index := range row.lights {
+		if _, ok := m.selected[index]; !ok {
+			return false
+		}
+	}
+	return len(row.lights) > 0
+}
+
+// toggleRowSelection selects every light in a row, or deselects them if all were selected
+func (m *lightModel) toggleRowSelection(row int) {
+	if row < 0 || row >= len(m.rows) {
+		return
+	}
+	if m.rowSelected(m.rows[row]) {
+		for _, index := range m.rows[row].lights {
+			delete(m.selected, index)
+		}
+	} else {
+		for _, index := range m.rows[row].lights {
+			m.selected[index] = struct{}{}
+		}
+	}
+}
+
+// lightStatusCell renders the STATUS cell for a single light
+func lightStatusCell(light Light) string {
+	// A device being flashed drops off the network; that isn't a fault
+	if light.Update == updateInstalling {
+		return updateStyle.Render(tr("cell.updating"))
+	}
+	if !light.Reachable {
+		label := tr("cell.unreachable")
+		if since := light.offlineSince(); !since.IsZero() {
+			label = tr("cell.unreachable.since", humanizeDuration(time.Since(since)))
+		}
+		return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF8C00")).Render(label)
+	}
+	if light.Status == "on" {
+		return statusOnStyle.Render(tr("cell.on"))
+	}
+	return statusOffStyle.Render(tr("cell.off"))
+}
+
+// lightBrightnessCell renders the BRIGHTNESS cell for a single light
+func lightBrightnessCell(light Light) string {
+	if !light.Reachable {
+		return lipgloss.NewStyle().Faint(true).Render(tr("cell.na"))
+	}
+	if !light.can(capDimming) {
+		return lipgloss.NewStyle().Faint(true).Render(glyphText("—"))
+	}
+	return formatPercent(light.Brightness)
+}
+
+// deviceStatusCell summarises the member lights of a device row
+func deviceStatusCell(lights []Light, members []int) string {
+	on, reachable := 0, 0
+	for _, index := range members {
+		if lights[index].Reachable {
+			reachable++
+			if lights[index].Status == "on" {
+				on++
+			}
+		}
+	}
+
+	switch {
+	case reachable == 0:
+		return lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF8C00")).Render(tr("cell.unreachable"))
+	case on == len(members):
+		return statusOnStyle.Render(tr("cell.on"))
+	case on == 0 && reachable == len(members):
+		return statusOffStyle.Render(tr("cell.off"))
+	case on == 0:
+		return statusOffStyle.Render(tr("cell.off.partial", reachable, len(members)))
+	default:
+		return statusOnStyle.Render(tr("cell.on.partial", on, len(members)))
+	}
+}
+
+// deviceBrightnessCell averages the brightness of the reachable, dimmable member lights
+func deviceBrightnessCell(lights []Light, members []int) string {
+	var total float32
+	count := 0
+	for _, index := range members {
+		if lights[index].Reachable && lights[index].can(capDimming) {
+			total += lights[index].Brightness
+			count++
+		}
+	}
+	if count == 0 {
+		return lipgloss.NewStyle().Faint(true).Render(glyphText("—"))
+	}
+	return formatPercent(total / float32(count))
+}
+
+// productInfo is a device's product_data, as shown in the detail pane, the
+// model column and /lights
+type productInfo struct {
+	ModelID      string `json:"model_id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Software     string `json:"software_version,omitempty"`
+	Hardware     string `json:"hardware_platform,omitempty"`
+}
+
+// productFromDevice reads a device's product data
+func productFromDevice(device DeviceResource) productInfo {
+	data := device.ProductData
+	return productInfo{
+		ModelID:      data.ModelID,
+		Name:         data.ProductName,
+		Manufacturer: data.ManufacturerName,
+		Software:     data.SoftwareVersion,
+		Hardware:     data.HardwarePlatformType,
+	}
+}
+
+// model names the product for the model column: the product name, such as
+// "Hue color lamp", or the model ID when a third-party bulb leaves it out
+func (p productInfo) model() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.ModelID
+}
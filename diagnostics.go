@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// diagnosticsSSEPayloads is how many recent SSE payloads a diagnostics bundle holds
+const diagnosticsSSEPayloads = 50
+
+// redactNames also hides light, device, room and scene names in diagnostics
+// bundles, set by --redact-names
+var redactNames bool
+
+// resourceIDPattern matches the UUIDs the bridge uses as resource IDs
+var resourceIDPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// ipv4Pattern matches IPv4 addresses, such as other bridges in an error
+var ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+
+// redactor sanitizes a diagnostics bundle. Secrets, the bridge's address and
+// its ID are replaced outright; resource IDs become short hashes, so the same
+// ID still reads the same throughout; names, when given, become numbered
+// placeholders.
+type redactor struct {
+	secrets []string
+	names   []string
+}
+
+// newRedactor collects what the running app must keep out of a bundle: the
+// bridge key, address and ID and the Remote API credentials, and with
+// redactNames the names of the lights, their devices and rooms, and scenes
+func (m lightModel) newRedactor() redactor {
+	r := redactor{secrets: []string{
+		apiKey, appConfig.Key, appConfig.Remote.ClientSecret, appConfig.Remote.RefreshToken,
+		bridgeIP, appConfig.Bridge, appConfig.BridgeID,
+	}}
+	if !redactNames {
+		return r
+	}
+	for _, light := range m.light {
+		r.names = append(r.names, light.Name, light.DeviceName)
+	}
+	for _, group := range m.groups {
+		r.names = append(r.names, group.name)
+	}
+	if scenes, err := cachedScenes(); err == nil {
+		for _, scene := range scenes {
+			if scene.Metadata != nil && scene.Metadata.Name != nil {
+				r.names = append(r.names, *scene.Metadata.Name)
+			}
+		}
+	}
+	return r
+}
+
+// apply sanitizes text. Secrets match in any case, since the bridge spells
+// its ID in both. Names are replaced only as whole words, longest first, in
+// one pass, so "Hall" leaves "Hallway" alone unless that's a name too, and a
+// placeholder is never matched again.
+func (r redactor) apply(text string) string {
+	secrets := slices.Clone(r.secrets)
+	sort.SliceStable(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, secret := range secrets {
+		if secret != "" {
+			pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(secret))
+			text = pattern.ReplaceAllLiteralString(text, "<redacted>")
+		}
+	}
+	text = resourceIDPattern.ReplaceAllStringFunc(text, hashID)
+	text = ipv4Pattern.ReplaceAllLiteralString(text, "<ip>")
+
+	names := make([]string, 0, len(r.names))
+	seen := make(map[string]bool)
+	for _, name := range r.names {
+		if strings.TrimSpace(name) != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	return replaceWords(text, names, func(i int) string { return fmt.Sprintf("<name %d>", i+1) })
+}
+
+// replaceWords replaces each whole-word occurrence of words[i] with
+// replacement(i), trying the words in order at each position. A match counts
+// when it isn't preceded or followed by a letter or digit.
+func replaceWords(text string, words []string, replacement func(i int) string) string {
+	var b strings.Builder
+	for pos := 0; pos < len(text); {
+		matched := false
+		if pos == 0 || !isWordRune(lastRune(text[:pos])) {
+			for i, word := range words {
+				end := pos + len(word)
+				if strings.HasPrefix(text[pos:], word) && (end == len(text) || !isWordRune(firstRune(text[end:]))) {
+					b.WriteString(replacement(i))
+					pos = end
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			b.WriteString(text[pos : pos+size])
+			pos += size
+		}
+	}
+	return b.String()
+}
+
+// isWordRune reports whether r is part of a word, for replaceWords
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// hashID shortens a resource ID to a hash of it, e.g. "id-3fa2b1c0"
+func hashID(id string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(id)))
+	return "id-" + hex.EncodeToString(sum[:4])
+}
+
+// redactedConfig renders the config as YAML with its credentials blanked
+func redactedConfig(c Config) string {
+	if c.Key != "" {
+		c.Key = "<redacted>"
+	}
+	if c.Bridge != "" {
+		c.Bridge = "<redacted>"
+	}
+	if c.BridgeID != "" {
+		c.BridgeID = "<redacted>"
+	}
+	if c.Remote.ClientSecret != "" {
+		c.Remote.ClientSecret = "<redacted>"
+	}
+	if c.Remote.RefreshToken != "" {
+		c.Remote.RefreshToken = "<redacted>"
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("unavailable: %v\n", err)
+	}
+	return string(data)
+}
+
+// diagnosticsBundle gathers the state a bug report needs, before sanitizing
+func (m lightModel) diagnosticsBundle(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "hue-control-tui diagnostics, %s\n\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "App: %s\n", versionString())
+	bridgeVersion, err := getBridgeSoftwareVersion()
+	if err != nil {
+		bridgeVersion = fmt.Sprintf("unknown (%v)", err)
+	}
+	fmt.Fprintf(&b, "Bridge software: %s\n", bridgeVersion)
+	fmt.Fprintf(&b, "Terminal: %dx%d\n", m.width, m.height)
+	fmt.Fprintf(&b, "Lights: %s\n", summarizeLights(m.light))
+	if scenes, err := cachedScenes(); err == nil {
+		fmt.Fprintf(&b, "Scenes: %d\n", len(scenes))
+	}
+	fmt.Fprintf(&b, "Remote mode: %t\n", remoteMode)
+
+	b.WriteString("\n== Config ==\n")
+	b.WriteString(redactedConfig(*appConfig))
+
+	b.WriteString("\n== Recent errors ==\n")
+	errors := recentErrors.snapshot()
+	if len(errors) == 0 {
+		b.WriteString("none\n")
+	}
+	for _, line := range errors {
+		b.WriteString(line + "\n")
+	}
+
+	entries := m.sseLog[max(0, len(m.sseLog)-diagnosticsSSEPayloads):]
+	fmt.Fprintf(&b, "\n== Last %d SSE payloads ==\n", len(entries))
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s %s\n", entry.at.Format(time.RFC3339Nano), strings.TrimSpace(string(entry.data)))
+		if entry.err != nil {
+			fmt.Fprintf(&b, "  parse error: %v\n", entry.err)
+		}
+	}
+	return b.String()
+}
+
+// diagnosticsCommand handles ":diagnostics", writing a sanitized bundle to
+// a timestamped file in the config directory
+func (m *lightModel) diagnosticsCommand() error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	bundle := m.newRedactor().apply(m.diagnosticsBundle(now))
+	path := filepath.Join(dir, "diagnostics-"+now.Format("20060102-150405")+".txt")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("writing diagnostics: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(bundle), 0600); err != nil {
+		return fmt.Errorf("writing diagnostics: %v", err)
+	}
+	m.setStatusTr("status.diagnostics.written", path)
+	return nil
+}
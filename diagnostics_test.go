@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplaceWords(t *testing.T) {
+	words := []string{"Desk Lamp", "Hallway", "Hall", "Küche", "name"}
+	placeholder := func(i int) string { return fmt.Sprintf("<name %d>", i+1) }
+	tests := []struct {
+		text, want string
+	}{
+		{"Hall and Hallway", "<name 3> and <name 2>"},
+		{"Hallmark Hall", "Hallmark <name 3>"},
+		{"Desk Lamp, Desk Lamps, Desk", "<name 1>, Desk Lamps, Desk"},
+		{`"Hall":"Küche"`, `"<name 3>":"<name 4>"`},
+		{"Küchen Küche", "Küchen <name 4>"},
+		// Placeholders aren't matched again, though they contain a name
+		{"name Hall", "<name 5> <name 3>"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := replaceWords(tt.text, words, placeholder); got != tt.want {
+			t.Errorf("replaceWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDiagnosticsBundleRedacts(t *testing.T) {
+	const (
+		key      = "Ab3dEf6hIj9kLm2nOp5qRs8tUv1wXy4z"
+		bridgeID = "001788FFFE6A1B2C"
+		otherIP  = "192.168.1.77"
+	)
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/scene", `{"errors":[],"data":[
+		{"id":"5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d","type":"scene","metadata":{"name":"Evening Glow"}}]}`)
+
+	oldKey, oldConfig, oldRedact := apiKey, appConfig, redactNames
+	t.Cleanup(func() { apiKey, appConfig, redactNames = oldKey, oldConfig, oldRedact })
+	apiKey = key
+	ip := strings.Split(bridgeIP, ":")[0]
+	appConfig = &Config{Bridge: ip, Key: key, BridgeID: bridgeID}
+	redactNames = true
+
+	hall, hallway, lamp := testLight(), testLight(), testLight()
+	hall.ID, hall.Name, hall.DeviceName = "hall", "Hall", "Hall bulb"
+	hallway.ID, hallway.Name = "hallway", "Hallway"
+	lamp.Name, lamp.DeviceName = "Desk Lamp", "Hue go"
+	m := initialModel([]Light{hall, hallway, lamp}, nil)
+	m.groups = map[string]lightGroup{"room": {name: "Living Room"}}
+
+	payload := fmt.Sprintf(`[{"type":"update","data":[{"id":%q,"type":"light","metadata":{"name":"Desk Lamp"}}],"bridge":%q,"key":%q}]`,
+		testLightID, strings.ToLower(bridgeID), key)
+	m.sseLog = []sseLogEntry{
+		{at: time.Now(), data: []byte(payload)},
+		{at: time.Now(), data: []byte(`{"light":"Hallway","room":"Living Room","scene":"Evening Glow"}`), err: fmt.Errorf("Hall bulb at %s:443 timed out", ip)},
+	}
+	logError("Bridge %s unreachable, found %s instead (key %s)", ip, otherIP, key)
+
+	bundle := m.newRedactor().apply(m.diagnosticsBundle(time.Now()))
+
+	secret := []string{key, ip, otherIP, bridgeID, strings.ToLower(bridgeID), testLightID}
+	for _, s := range secret {
+		if strings.Contains(bundle, s) {
+			t.Errorf("bundle contains %q:\n%s", s, bundle)
+		}
+	}
+	for _, name := range []string{"Hall", "Hallway", "Hall bulb", "Desk Lamp", "Hue go", "Living Room", "Evening Glow"} {
+		if strings.Contains(bundle, `"`+name+`"`) || strings.Contains(bundle, name+" ") {
+			t.Errorf("bundle contains the name %q:\n%s", name, bundle)
+		}
+	}
+	if !strings.Contains(bundle, "<redacted>") || !strings.Contains(bundle, "<ip>") || !strings.Contains(bundle, hashID(testLightID)) {
+		t.Errorf("bundle lacks the placeholders:\n%s", bundle)
+	}
+}
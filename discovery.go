@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// isNetworkError reports whether err means the bridge couldn't be reached at
+// all, as opposed to the bridge answering with an error
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sameBridgeID compares bridge IDs, which the v1 and v2 APIs spell in different cases
+func sameBridgeID(a, b string) bool {
+	return a != "" && strings.EqualFold(a, b)
+}
+
+// Discovery methods for --discovery
+const (
+	discoveryMDNS  = "mdns"
+	discoveryCloud = "cloud"
+	discoveryBoth  = "both"
+)
+
+// discoveryTimeout bounds each discovery method; they run concurrently
+const discoveryTimeout = 5 * time.Second
+
+// cloudDiscoveryURL is Philips' service listing the bridges on the caller's network
+const cloudDiscoveryURL = "https://discovery.meethue.com"
+
+// discoveryMethod is set from --discovery
+var discoveryMethod = discoveryBoth
+
+// discoveredBridge is a bridge found on the network
+type discoveredBridge struct {
+	ID     string // bridge ID, lower case; may be empty if the bridge didn't say
+	IP     string
+	Source string // "mdns", "cloud" or "mdns+cloud"
+}
+
+func parseDiscoveryMethod(s string) (string, error) {
+	switch s {
+	case discoveryMDNS, discoveryCloud, discoveryBoth:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown discovery method %q (want mdns, cloud or both)", s)
+}
+
+// discoverBridges runs the chosen discovery methods in parallel and merges
+// their results by bridge ID. It only fails if every method failed.
+func discoverBridges(method string) ([]discoveredBridge, error) {
+	ctx, cancel := context.WithTimeout(appCtx, discoveryTimeout)
+	defer cancel()
+
+	type result struct {
+		bridges []discoveredBridge
+		err     error
+	}
+	var methods []func(context.Context) ([]discoveredBridge, error)
+	if method != discoveryCloud {
+		methods = append(methods, discoverMDNS)
+	}
+	if method != discoveryMDNS {
+		methods = append(methods, discoverCloud)
+	}
+
+	results := make(chan result, len(methods))
+	for _, discover := range methods {
+		go func() {
+			bridges, err := discover(ctx)
+			results <- result{bridges, err}
+		}()
+	}
+
+	var all []discoveredBridge
+	var errs []error
+	for range methods {
+		r := <-results
+		if r.err != nil {
+			logError("Bridge discovery: %v", r.err)
+			errs = append(errs, r.err)
+		}
+		all = append(all, r.bridges...)
+	}
+
+	bridges := mergeBridges(all)
+	if len(bridges) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("no bridge found")
+	}
+	return bridges, nil
+}
+
+// mergeBridges de-duplicates bridges found by several methods, by ID when
+// known and by address otherwise
+func mergeBridges(found []discoveredBridge) []discoveredBridge {
+	var merged []discoveredBridge
+	index := make(map[string]int)
+	for _, bridge := range found {
+		key := bridge.ID
+		if key == "" {
+			key = "ip:" + bridge.IP
+		}
+		if i, ok := index[key]; ok {
+			if !strings.Contains(merged[i].Source, bridge.Source) {
+				merged[i].Source += "+" + bridge.Source
+			}
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, bridge)
+	}
+	return merged
+}
+
+// discoverMDNS browses for _hue._tcp on the local network. Bridges advertise
+// their ID in a bridgeid TXT record.
+func discoverMDNS(ctx context.Context) ([]discoveredBridge, error) {
+	resolver, err := zeroconf.NewResolver(nil)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %v", err)
+	}
+
+	entries := make(chan *zeroconf.ServiceEntry, 8)
+	if err := resolver.Browse(ctx, "_hue._tcp", "local.", entries); err != nil {
+		return nil, fmt.Errorf("mdns: %v", err)
+	}
+
+	var bridges []discoveredBridge
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return bridges, nil
+			}
+			if len(entry.AddrIPv4) == 0 {
+				continue
+			}
+			bridge := discoveredBridge{IP: entry.AddrIPv4[0].String(), Source: discoveryMDNS}
+			for _, txt := range entry.Text {
+				if id, ok := strings.CutPrefix(txt, "bridgeid="); ok {
+					bridge.ID = strings.ToLower(id)
+				}
+			}
+			bridges = append(bridges, bridge)
+		case <-ctx.Done():
+			return bridges, nil
+		}
+	}
+}
+
+// discoverCloud asks the Hue discovery service, which needs internet access
+func discoverCloud(ctx context.Context) ([]discoveredBridge, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", cloudDiscoveryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cloud: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cloud: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloud: %s", resp.Status)
+	}
+
+	var found []struct {
+		ID                string `json:"id"`
+		InternalIPAddress string `json:"internalipaddress"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return nil, fmt.Errorf("cloud: %v", err)
+	}
+
+	bridges := make([]discoveredBridge, 0, len(found))
+	for _, f := range found {
+		bridges = append(bridges, discoveredBridge{ID: strings.ToLower(f.ID), IP: f.InternalIPAddress, Source: discoveryCloud})
+	}
+	return bridges, nil
+}
+
+// rediscoverBridge looks for the configured bridge at a new address after the
+// saved one stopped answering. It returns the new address, or "" when the
+// configured bridge wasn't among those found.
+func rediscoverBridge(bridgeID string) (string, error) {
+	bridges, err := discoverBridges(discoveryMethod)
+	if err != nil {
+		return "", err
+	}
+
+	for _, bridge := range bridges {
+		id := bridge.ID
+		if id == "" {
+			// Ask the bridge itself when discovery didn't say
+			config, err := getPublicBridgeConfig(bridge.IP)
+			if err != nil {
+				logError("Found a bridge at %s but couldn't identify it: %v", bridge.IP, err)
+				continue
+			}
+			id = config.BridgeID
+		}
+		if sameBridgeID(id, bridgeID) {
+			return bridge.IP, nil
+		}
+		logInfo("Discovered bridge %s at %s is not the configured bridge %s", id, bridge.IP, bridgeID)
+	}
+	return "", nil
+}
+
+// confirm asks a yes/no question on the terminal before the TUI starts
+func confirm(question string) bool {
+	fmt.Printf("%s (y/n): ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// recoverMovedBridge handles a bridge whose DHCP address changed: it finds
+// the bridge again by ID and, once the user agrees, saves the new address
+// and reconnects with the existing key. It reports whether it reconnected.
+func recoverMovedBridge() bool {
+	if appConfig.BridgeID == "" {
+		fmt.Println("The config has no bridge ID to look for; run with --setup to pair again.")
+		return false
+	}
+
+	fmt.Printf("Can't reach the bridge at %s, looking for it on the network...\n", bridgeIP)
+	newIP, err := rediscoverBridge(appConfig.BridgeID)
+	if err != nil {
+		logError("Bridge rediscovery failed: %v", err)
+		fmt.Printf("Bridge discovery failed: %v\n", err)
+		return false
+	}
+	if newIP == "" || newIP == bridgeIP {
+		fmt.Println("Couldn't find the configured bridge at another address.")
+		return false
+	}
+
+	if !confirm(fmt.Sprintf("Found your bridge at %s (was %s). Update the config and continue?", newIP, bridgeIP)) {
+		return false
+	}
+	if err := setConfigValue("bridge", newIP); err != nil {
+		logError("Failed to save new bridge address: %v", err)
+		fmt.Printf("Couldn't save the new address, continuing for this session: %v\n", err)
+	}
+
+	logInfo("Bridge moved from %s to %s", bridgeIP, newIP)
+	bridgeIP = newIP
+	return true
+}
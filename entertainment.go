@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	syncStyle          = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFB86C"))
+	entertainmentStyle = lipgloss.NewStyle().Faint(true).MarginLeft(2)
+)
+
+// entertainmentArea is an entertainment configuration, the set of lights a
+// sync app streams to. Lights in a streaming area ignore normal commands.
+type entertainmentArea struct {
+	id     string
+	name   string
+	status string   // "active" while streaming, otherwise "inactive"
+	lights []string // light IDs
+}
+
+func (a entertainmentArea) streaming() bool {
+	return a.status == "active"
+}
+
+// loadEntertainmentAreas fetches every entertainment configuration
+func loadEntertainmentAreas() ([]entertainmentArea, error) {
+	configs, err := getEntertainmentConfigurations()
+	if err != nil {
+		return nil, err
+	}
+
+	areas := make([]entertainmentArea, len(configs))
+	for i, config := range configs {
+		area := entertainmentArea{id: config.ID, name: config.Metadata.Name, status: config.Status}
+		for _, service := range config.LightServices {
+			if service.Rtype == "light" {
+				area.lights = append(area.lights, service.Rid)
+			}
+		}
+		areas[i] = area
+	}
+	return areas, nil
+}
+
+// streamingArea returns the name of the streaming area a light belongs to
+func (m lightModel) streamingArea(lightID string) (string, bool) {
+	for _, area := range m.entertainment {
+		if !area.streaming() {
+			continue
+		}
+		for _, id := range area.lights {
+			if id == lightID {
+				return area.name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// rowSyncing reports whether any light in a table row is locked by streaming
+func (m lightModel) rowSyncing(tr tableRow) bool {
+	for _, index := range tr.lights {
+		if _, ok := m.streamingArea(m.light[index].ID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// syncHint explains failed writes to lights locked by a streaming area, or
+// returns "" when none of them are
+func (m lightModel) syncHint(failed []Light) string {
+	var locked []string
+	area := ""
+	for _, light := range failed {
+		if name, ok := m.streamingArea(light.ID); ok {
+			locked = append(locked, light.Name)
+			area = name
+		}
+	}
+	switch len(locked) {
+	case 0:
+		return ""
+	case 1:
+		return tr("action.sync_locked.one", locked[0], area)
+	default:
+		return tr("action.sync_locked.other", strings.Join(locked, ", "))
+	}
+}
+
+// handleEntertainmentUpdate applies an entertainment_configuration SSE event
+func (m *lightModel) handleEntertainmentUpdate(item SSEDataItem) {
+	for i := range m.entertainment {
+		if m.entertainment[i].id == item.ID && item.Status.State != "" {
+			m.entertainment[i].status = item.Status.State
+		}
+	}
+}
+
+// renderEntertainment lists the entertainment areas under the table, or
+// returns "" when the bridge has none
+func (m lightModel) renderEntertainment() string {
+	if len(m.entertainment) == 0 {
+		return ""
+	}
+
+	names := make(map[string]string, len(m.light))
+	for _, light := range m.light {
+		names[light.ID] = light.Name
+	}
+
+	var lines []string
+	for _, area := range m.entertainment {
+		var members []string
+		for _, id := range area.lights {
+			if name, ok := names[id]; ok {
+				members = append(members, name)
+			}
+		}
+		status := "inactive"
+		if area.streaming() {
+			status = syncStyle.Render("streaming")
+		}
+		lines = append(lines, fmt.Sprintf("Entertainment area %s: %s (%s)", area.name, status, strings.Join(members, ", ")))
+	}
+	return entertainmentStyle.Render(strings.Join(lines, "\n")) + "\n"
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Values of the exit_summary config key
+const (
+	exitSummaryOff  = "off"  // say nothing (the default)
+	exitSummaryShow = "show" // list the lights left on
+	exitSummaryAsk  = "ask"  // list them and offer to turn them off
+)
+
+// exitOffTimeout bounds the final off at exit, so quitting never hangs on an
+// unreachable bridge
+const exitOffTimeout = 5 * time.Second
+
+// maxExitSummaryNames is how many lights the exit summary names before "…"
+const maxExitSummaryNames = 6
+
+// parseExitSummary validates an exit_summary value; empty means off
+func parseExitSummary(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", exitSummaryOff:
+		return exitSummaryOff, nil
+	case exitSummaryShow:
+		return exitSummaryShow, nil
+	case exitSummaryAsk:
+		return exitSummaryAsk, nil
+	}
+	return "", fmt.Errorf("exit_summary must be off, show or ask")
+}
+
+// lightsLeftOn returns the reachable lights that are on
+func lightsLeftOn(lights []Light) []Light {
+	var on []Light
+	for _, light := range lights {
+		if light.Reachable && light.Status == "on" {
+			on = append(on, light)
+		}
+	}
+	return on
+}
+
+// describeLeftOn renders "Leaving 4 lights on: Kitchen (80%), Desk (100%), …"
+func describeLeftOn(lights []Light) string {
+	var names []string
+	for i, light := range lights {
+		if i == maxExitSummaryNames {
+			names = append(names, glyphText("…"))
+			break
+		}
+		if light.can(capDimming) {
+			names = append(names, fmt.Sprintf("%s (%s)", light.Name, formatPercent(light.Brightness)))
+		} else {
+			names = append(names, light.Name)
+		}
+	}
+	return trn("exit.left_on", len(lights), len(lights), strings.Join(names, ", "))
+}
+
+// askYesNo reads an answer from in, treating anything but y or yes as no
+func askYesNo(in io.Reader) bool {
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// showExitSummary runs after the TUI has released the terminal: it lists the
+// lights left on and, in ask mode, offers to turn them off
+func showExitSummary(m lightModel, mode string, in io.Reader, out io.Writer) {
+	if mode == exitSummaryOff {
+		return
+	}
+	on := lightsLeftOn(m.light)
+	if len(on) == 0 {
+		return
+	}
+	fmt.Fprintln(out, describeLeftOn(on))
+	if mode != exitSummaryAsk {
+		return
+	}
+
+	fmt.Fprint(out, tr("exit.ask"))
+	if !askYesNo(in) {
+		return
+	}
+	if err := turnOffAtExit(m, on); err != nil {
+		logError("Turning lights off at exit: %v", err)
+		fmt.Fprintln(out, tr("exit.failed", err))
+		return
+	}
+	fmt.Fprintln(out, tr("exit.done"))
+}
+
+// turnOffAtExit switches lights off within exitOffTimeout. When every light
+// that's on is being switched off, one write to the home group does it;
+// otherwise each light gets its own write, sent together.
+func turnOffAtExit(m lightModel, lights []Light) error {
+	ctx, cancel := context.WithTimeout(context.Background(), exitOffTimeout)
+	defer cancel()
+	client := bridgeClient()
+	off := map[string]any{"on": map[string]bool{"on": false}}
+
+	for id, group := range m.groups {
+		if group.ownerType == "bridge_home" {
+			return client.Do(ctx, "PUT", "clip/v2/resource/grouped_light/"+id, off, nil)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(lights))
+	for _, light := range lights {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if err := client.SetOn(ctx, id, false); err != nil {
+				errs <- err
+			}
+		}(light.ID)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
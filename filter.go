@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lightMatchesFilter reports whether a light belongs in the filtered view:
+// the filter appears, ignoring case, in its name, its device's name or its
+// room's name. An empty filter matches everything.
+func lightMatchesFilter(light Light, filter, room string) bool {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return true
+	}
+	for _, name := range []string{light.Name, light.DeviceName, room} {
+		if strings.Contains(strings.ToLower(name), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLights returns the indexes of the lights that match filter, in
+// list order. roomOf names the room a light is in.
+func filterLights(lights []Light, filter string, roomOf func(id string) string) []int {
+	var indexes []int
+	for i, light := range lights {
+		if lightMatchesFilter(light, filter, roomOf(light.ID)) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// filterRows drops the lights outside visible from each row, and rows left
+// with none. Headers keep only their visible members, so a room header
+// counts what is shown.
+func filterRows(rows []tableRow, visible map[int]bool) []tableRow {
+	var result []tableRow
+	for _, row := range rows {
+		var kept []int
+		for _, index := range row.lights {
+			if visible[index] {
+				kept = append(kept, index)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		row.lights = kept
+		result = append(result, row)
+	}
+	return result
+}
+
+// visibleLights returns the indexes of the lights the table shows: those
+// matching the filter and in the room scope, or every light when there is
+// neither
+func (m lightModel) visibleLights() []int {
+	indexes := filterLights(m.light, m.filter, m.roomOf)
+	if m.roomScope == "" {
+		return indexes
+	}
+	var scoped []int
+	for _, index := range indexes {
+		if strings.EqualFold(m.roomOf(m.light[index].ID), m.roomScope) {
+			scoped = append(scoped, index)
+		}
+	}
+	return scoped
+}
+
+// filtered reports whether the table shows only some lights
+func (m lightModel) filtered() bool {
+	return m.filter != "" || m.roomScope != ""
+}
+
+// filterCommand handles ":filter <text>", showing only the lights whose
+// name, device or room contains text; ":filter" alone shows every light
+// again, leaving any room scope too. Selected lights the filter hides are
+// deselected, so nothing acts on lights that aren't on screen.
+func (m *lightModel) filterCommand(args string) error {
+	filter := unquote(args)
+	if filter == "" {
+		m.filter, m.roomScope = "", ""
+		m.rows = m.layoutRows()
+		m.moveCursorTo(m.cursor)
+		m.setStatusTr("status.filter.cleared")
+		return nil
+	}
+
+	previous := m.filter
+	m.filter = filter
+	visible := m.visibleLights()
+	if len(visible) == 0 {
+		m.filter = previous
+		return fmt.Errorf("no lights match %q", filter)
+	}
+	m.applyVisible(visible)
+	m.setStatus("%s", trn("status.filter.showing", len(visible), len(visible), filter))
+	return nil
+}
+
+// scopeToRoom limits the table to the lights in room, matched ignoring case
+func (m *lightModel) scopeToRoom(room string) error {
+	for _, group := range m.groups {
+		if group.ownerType == "room" && strings.EqualFold(group.name, room) {
+			m.roomScope = group.name
+			m.applyVisible(m.visibleLights())
+			return nil
+		}
+	}
+	return fmt.Errorf("no room named %q", room)
+}
+
+// applyVisible lays the table out again after the visible lights changed,
+// deselecting the lights no longer shown
+func (m *lightModel) applyVisible(visible []int) {
+	m.rows = m.layoutRows()
+	m.moveCursorTo(m.cursor)
+
+	shown := make(map[int]bool, len(visible))
+	for _, index := range visible {
+		shown[index] = true
+	}
+	for index := range m.selected {
+		if !shown[index] {
+			delete(m.selected, index)
+		}
+	}
+}
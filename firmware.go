@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// updateStyle marks rows whose device has a firmware update waiting or running
+var updateStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#BD93F9"))
+
+// Firmware update states from the device_software_update resource. The
+// bridge also reports "no_update", which Light.Update stores as "".
+const (
+	updatePending    = "update_pending"   // downloading to the bridge or waiting its turn
+	updateReady      = "ready_to_install" // downloaded, waiting for the bridge to install it
+	updateInstalling = "installing"       // the device is being flashed and drops off the network
+)
+
+// softwareUpdate is the CLIP v2 device_software_update resource
+type softwareUpdate struct {
+	ID    string      `json:"id"`
+	Owner resourceRef `json:"owner"`
+	State string      `json:"state"`
+}
+
+// getSoftwareUpdates maps device IDs to their firmware update state,
+// leaving out devices with none
+func getSoftwareUpdates() (map[string]string, error) {
+	var updates []softwareUpdate
+	if err := bridgeCache.list("device_software_update", &updates); err != nil {
+		return nil, err
+	}
+	states := make(map[string]string)
+	for _, update := range updates {
+		if update.State != "" && update.State != "no_update" {
+			states[update.Owner.Rid] = update.State
+		}
+	}
+	return states, nil
+}
+
+// applySoftwareUpdates sets each light's Update from its device's state
+func applySoftwareUpdates(lights []Light, states map[string]string) {
+	for i := range lights {
+		lights[i].Update = states[lights[i].DeviceOwner]
+	}
+}
+
+// handleSoftwareUpdate re-reads the update states after a
+// device_software_update event. The event names the update resource, not
+// its device, and the cache has already applied it, so reading the states
+// afresh is simplest.
+func (m *lightModel) handleSoftwareUpdate() {
+	states, err := getSoftwareUpdates()
+	if err != nil {
+		logError("Failed to read firmware update states: %v", err)
+		return
+	}
+	applySoftwareUpdates(m.light, states)
+}
+
+// updateMarker is the badge shown after the name of a row whose device has
+// a firmware update waiting; a running one shows in the status column
+func updateMarker(light Light) string {
+	if light.Update == updatePending || light.Update == updateReady {
+		return " " + updateStyle.Render("UPD")
+	}
+	return ""
+}
+
+// updatesCommand handles ":updates", listing the devices with a firmware
+// update waiting or being installed
+func (m *lightModel) updatesCommand() error {
+	byDevice := make(map[string][]string)
+	states := make(map[string]string)
+	for _, light := range m.light {
+		if light.Update == "" {
+			continue
+		}
+		device := light.DeviceName
+		if device == "" {
+			device = light.Name
+		}
+		byDevice[device] = append(byDevice[device], light.Name)
+		states[device] = light.Update
+	}
+	if len(byDevice) == 0 {
+		m.setStatusTr("status.firmware.none")
+		return nil
+	}
+
+	devices := make([]string, 0, len(byDevice))
+	for device := range byDevice {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	lines := []string{trn("status.firmware.pending", len(devices), len(devices))}
+	for _, device := range devices {
+		lines = append(lines, fmt.Sprintf("  %s: %s", device, strings.ReplaceAll(states[device], "_", " ")))
+	}
+	m.setStatus("%s", strings.Join(lines, "\n"))
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// flashDuration is how long a row stays tinted after an event changes it
+const flashDuration = time.Second
+
+// Row tints: changes made elsewhere stand out more than this app's own echoes
+var (
+	flashExternalStyle = lipgloss.NewStyle().Background(lipgloss.Color("#6272A4")).Foreground(lipgloss.Color("#F8F8F2"))
+	flashOwnStyle      = lipgloss.NewStyle().Background(lipgloss.Color("#343746"))
+)
+
+// rowFlash is a light's row tint after a change
+type rowFlash struct {
+	until    time.Time
+	external bool
+}
+
+// flashExpiredMsg prompts the model to drop the flashes that have run out.
+// Only one is pending at a time, however many rows are flashing.
+type flashExpiredMsg struct{}
+
+// flashState is the part of a light whose changes make its row flash
+type flashState struct {
+	status     string
+	brightness float32
+	reachable  bool
+}
+
+// snapshotLights records each light's state by ID, before events apply
+func snapshotLights(lights []Light) map[string]flashState {
+	snapshot := make(map[string]flashState, len(lights))
+	for _, light := range lights {
+		snapshot[light.ID] = flashState{light.Status, light.Brightness, light.Reachable}
+	}
+	return snapshot
+}
+
+// flashChanged tints the rows of the lights whose status, brightness or
+// reachability differs from before, and schedules their expiry if nothing
+// is scheduled yet
+func (m *lightModel) flashChanged(before map[string]flashState, now time.Time) tea.Cmd {
+	for _, light := range m.light {
+		old, ok := before[light.ID]
+		if !ok || old == (flashState{light.Status, light.Brightness, light.Reachable}) {
+			continue
+		}
+		if m.flashes == nil {
+			m.flashes = make(map[string]rowFlash)
+		}
+		m.flashes[light.ID] = rowFlash{until: now.Add(flashDuration), external: light.ChangedBy != changedByMe}
+	}
+	if len(m.flashes) == 0 || m.flashPending {
+		return nil
+	}
+	m.flashPending = true
+	return expireFlashesAfter(flashDuration)
+}
+
+// handleFlashExpired drops the flashes that have run out, and waits for the
+// next one to if any remain
+func (m *lightModel) handleFlashExpired(now time.Time) tea.Cmd {
+	m.flashPending = false
+	var next time.Time
+	for id, flash := range m.flashes {
+		if !flash.until.After(now) {
+			delete(m.flashes, id)
+			continue
+		}
+		if next.IsZero() || flash.until.Before(next) {
+			next = flash.until
+		}
+	}
+	if next.IsZero() {
+		return nil
+	}
+	m.flashPending = true
+	return expireFlashesAfter(next.Sub(now))
+}
+
+func expireFlashesAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return flashExpiredMsg{}
+	})
+}
+
+// rowFlashStyle returns the tint for a row, if any of its lights is flashing.
+// A row with an external change shows that tint.
+func (m lightModel) rowFlashStyle(tr tableRow) (lipgloss.Style, bool) {
+	flashing, external := false, false
+	for _, index := range tr.lights {
+		if flash, ok := m.flashes[m.light[index].ID]; ok {
+			flashing = true
+			external = external || flash.external
+		}
+	}
+	if external {
+		return flashExternalStyle, true
+	}
+	return flashOwnStyle, flashing
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// asciiMode swaps box-drawing and other non-ASCII glyphs for plain ASCII, for
+// consoles whose fonts or encodings can't show them
+var asciiMode bool
+
+// asciiBorder draws boxes with -, | and + only
+var asciiBorder = lipgloss.Border{
+	Top:         "-",
+	Bottom:      "-",
+	Left:        "|",
+	Right:       "|",
+	TopLeft:     "+",
+	TopRight:    "+",
+	BottomLeft:  "+",
+	BottomRight: "+",
+}
+
+// asciiReplacer maps every non-ASCII glyph the TUI draws to an ASCII stand-in.
+// Glyphs shown inside the table map to a single character so columns stay aligned.
+var asciiReplacer = strings.NewReplacer(
+	"▶", ">",
+	"✓", "*",
+	"─", "-",
+	"—", "-",
+	"•", "*",
+	"·", "|",
+	"…", "...",
+	"→", "->",
+	"←", "<-",
+	"±", "+/-",
+	"°", "",
+	"◆", "@",
+	"◇", "~",
+	"■", "#",
+	"↻", "~",
+	"↑", "^",
+	"↓", "v",
+)
+
+// boxBorder is the border used for the table and command box
+func boxBorder() lipgloss.Border {
+	if asciiMode {
+		return asciiBorder
+	}
+	return lipgloss.RoundedBorder()
+}
+
+// plainReplacer rewrites the glyphs the panes draw for plain mode: the cursor
+// becomes the arrow plainRenderer uses, and separators become commas, which
+// screen readers pause on instead of naming the symbol
+var plainReplacer = strings.NewReplacer(
+	"▶", "->",
+	" • ", ", ",
+	" · ", ", ",
+	"─", "-",
+	"…", "...",
+)
+
+// glyphText rewrites the glyphs in s for plain and ASCII mode, and returns it
+// unchanged otherwise. s is the TUI's own text, such as a catalog message or
+// a marker, and is rewritten where it is produced: names and other text from
+// the bridge never pass through here, and a table cell is already its final
+// width when the table is laid out.
+func glyphText(s string) string {
+	if plainMode() {
+		s = plainReplacer.Replace(s)
+	}
+	if asciiMode {
+		s = asciiReplacer.Replace(s)
+	}
+	return s
+}
+
+// viewText finishes a view for output. In plain mode, colors are dropped the
+// way plainRenderer leaves them out.
+func viewText(s string) string {
+	if plainMode() {
+		return ansi.Strip(s)
+	}
+	return s
+}
+
+// setASCIIMode switches glyph sets, updating the shared styles
+func setASCIIMode(on bool) {
+	asciiMode = on
+	tableStyle = tableStyle.Border(boxBorder())
+}
+
+// terminalLacksUnicode guesses whether the terminal can't show UTF-8: a dumb
+// terminal, or a locale that is set but isn't UTF-8
+func terminalLacksUnicode() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return true
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// gradientSwatch draws one colored block per gradient point, or "" for lights
+// without a gradient
+func gradientSwatch(points []xyColor) string {
+	var b strings.Builder
+	for _, point := range points {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(xyToHex(point))).Render(glyphText("■")))
+	}
+	return b.String()
+}
+
+// gradientBody is the write that sets a light's gradient points
+func gradientBody(colors []xyColor) map[string]any {
+	points := make([]any, len(colors))
+	for i, color := range colors {
+		points[i] = map[string]any{"color": color.xyJSON()}
+	}
+	return map[string]any{"gradient": map[string]any{"points": points}}
+}
+
+// gradientCommand handles ":gradient <color> <color> [color…]" on the selected lights
+func (m *lightModel) gradientCommand(args string) error {
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return fmt.Errorf("%s", glyphText("usage: gradient <color> <color> [color…]"))
+	}
+	colors := make([]xyColor, len(fields))
+	for i, field := range fields {
+		color, err := parseColor(field)
+		if err != nil {
+			return err
+		}
+		colors[i] = color
+	}
+	if len(m.selected) == 0 {
+		return fmt.Errorf("no lights selected")
+	}
+
+	skipped := 0
+	var problems []string
+	var writes []lightWrite
+	for index := range m.selected {
+		light := m.light[index]
+		switch {
+		case light.GradientPoints == 0:
+			problems = append(problems, light.Name+" has no gradient")
+			skipped++
+			continue
+		case len(colors) > light.GradientPoints:
+			problems = append(problems, fmt.Sprintf("%s shows at most %d colors", light.Name, light.GradientPoints))
+			skipped++
+			continue
+		case !light.Reachable:
+			skipped++
+			continue
+		}
+
+		outgoing.recordBulk()
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			_, err := clipWrite("PUT", "resource/light/"+light.ID, gradientBody(colors))
+			return err
+		}})
+	}
+
+	// Nothing to do at all is a usage error rather than a partial result
+	if len(writes) == 0 && len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		for _, w := range written {
+			for i := range m.light {
+				if m.light[i].ID == w.light.ID {
+					m.light[i].Gradient = colors
+				}
+			}
+		}
+		summary := summarizeAction("action.gradient", len(written), skipped, len(failed))
+		if len(problems) > 0 {
+			summary += glyphText(" · ") + strings.Join(problems, "; ")
+		}
+		m.setStatus("%s", summary)
+	})
+	return nil
+}
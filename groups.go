@@ -0,0 +1,232 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lightGroup is a room, zone or the whole home, tracked by its grouped_light
+// service so on/off and brightness changes made to the group stay in sync
+type lightGroup struct {
+	groupedLightID string
+	ownerID        string
+	ownerType      string // "room", "zone" or "bridge_home"
+	name           string
+	lightIDs       []string // member light services
+	on             bool
+	brightness     float32
+}
+
+// groupLoadedMsg delivers a group fetched after an SSE event named an unknown grouped_light
+type groupLoadedMsg struct {
+	group lightGroup
+	err   error
+	id    string
+}
+
+// groupMembers resolves a room or zone's children to light service IDs. Rooms
+// list devices and zones list lights, so both are matched.
+func groupMembers(group GroupResource, lights []Light) []string {
+	children := make(map[string]bool)
+	for _, child := range group.Children {
+		children[child.Rid] = true
+	}
+
+	var ids []string
+	for _, light := range lights {
+		if children[light.ID] || children[light.DeviceOwner] {
+			ids = append(ids, light.ID)
+		}
+	}
+	return ids
+}
+
+// newLightGroup builds a group from its room or zone resource
+func newLightGroup(groupedLightID string, group GroupResource, lights []Light) lightGroup {
+	return lightGroup{
+		groupedLightID: groupedLightID,
+		ownerID:        group.ID,
+		ownerType:      group.Type,
+		name:           group.Metadata.Name,
+		lightIDs:       groupMembers(group, lights),
+	}
+}
+
+// loadGroups fetches every room and zone with a grouped_light service
+func loadGroups(lights []Light) (map[string]lightGroup, error) {
+	groups, err := getGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]lightGroup)
+	for _, group := range groups {
+		for _, service := range group.Services {
+			if service.Rtype == "grouped_light" {
+				result[service.Rid] = newLightGroup(service.Rid, group, lights)
+			}
+		}
+	}
+
+	// Brightness comes from the bridge's own aggregate, so it matches other
+	// Hue apps; SSE keeps it current from here on
+	grouped, err := getGroupedLights()
+	if err != nil {
+		logError("Failed to fetch grouped light states: %v", err)
+		return result, nil
+	}
+	for _, state := range grouped {
+		group, ok := result[state.ID]
+		if !ok {
+			continue
+		}
+		if state.On != nil {
+			group.on = state.On.On
+		}
+		if state.Dimming != nil {
+			group.brightness = float32(state.Dimming.Brightness)
+		}
+		result[state.ID] = group
+	}
+	return result, nil
+}
+
+// groupsLoadedMsg delivers every room and zone fetched again, after their
+// membership changed or events about them may have been missed
+type groupsLoadedMsg struct {
+	groups map[string]lightGroup
+	err    error
+}
+
+// reloadGroups fetches every room and zone again, resolving members against lights
+func reloadGroups(lights []Light) tea.Cmd {
+	lights = append([]Light(nil), lights...)
+	return func() tea.Msg {
+		groups, err := loadGroups(lights)
+		return groupsLoadedMsg{groups: groups, err: err}
+	}
+}
+
+// handleGroupsLoaded swaps in reloaded groups
+func (m *lightModel) handleGroupsLoaded(msg groupsLoadedMsg) {
+	if msg.err != nil {
+		logError("Failed to reload rooms and zones: %v", msg.err)
+		return
+	}
+	m.setGroups(msg.groups)
+}
+
+// setGroups replaces the rooms and zones, laying the table out again since
+// rows and the room scope follow their members
+func (m *lightModel) setGroups(groups map[string]lightGroup) {
+	m.groups = groups
+	m.applyVisible(m.visibleLights())
+}
+
+// handleGroupDeleted drops the groups a deleted room or zone owned
+func (m *lightModel) handleGroupDeleted(ownerID string) {
+	for id, group := range m.groups {
+		if group.ownerID == ownerID {
+			delete(m.groups, id)
+		}
+	}
+	m.applyVisible(m.visibleLights())
+}
+
+// fetchGroup looks up a grouped_light the model hasn't seen and its owner
+func fetchGroup(id string, lights []Light) tea.Cmd {
+	return func() tea.Msg {
+		grouped, err := getGroupedLight(id)
+		if err != nil {
+			return groupLoadedMsg{id: id, err: err}
+		}
+
+		// The bridge_home group covers every light and has no room resource
+		if grouped.Owner.Rtype == "bridge_home" {
+			group := lightGroup{groupedLightID: id, ownerID: grouped.Owner.Rid, ownerType: "bridge_home", name: "All lights"}
+			for _, light := range lights {
+				group.lightIDs = append(group.lightIDs, light.ID)
+			}
+			return groupLoadedMsg{id: id, group: group}
+		}
+
+		owner, err := getGroup(grouped.Owner.Rtype, grouped.Owner.Rid)
+		if err != nil {
+			return groupLoadedMsg{id: id, err: err}
+		}
+		return groupLoadedMsg{id: id, group: newLightGroup(id, *owner, lights)}
+	}
+}
+
+// handleGroupedLightUpdate applies a grouped_light SSE item to the group's
+// aggregate state. Unknown groups are fetched and the event is applied again
+// once they arrive.
+func (m lightModel) handleGroupedLightUpdate(item SSEDataItem) (lightModel, tea.Cmd) {
+	if m.groups == nil {
+		m.groups = make(map[string]lightGroup)
+	}
+
+	group, ok := m.groups[item.ID]
+	if !ok {
+		if m.pendingGroups == nil {
+			m.pendingGroups = make(map[string][]SSEDataItem)
+		}
+		first := len(m.pendingGroups[item.ID]) == 0
+		m.pendingGroups[item.ID] = append(m.pendingGroups[item.ID], item)
+		if first {
+			logDebug("SSE grouped_light %s is unknown, fetching it", item.ID)
+			return m, fetchGroup(item.ID, m.light)
+		}
+		return m, nil
+	}
+
+	if item.On != nil {
+		group.on = item.On.On
+	}
+	if item.Dimming != nil {
+		group.brightness = float32(item.Dimming.Brightness)
+	}
+	m.groups[item.ID] = group
+	logDebug("SSE grouped_light event: %s on=%t brightness=%.0f", group.name, group.on, group.brightness)
+
+	// A group is off only when every member is, so an off event settles each
+	// member light even if its own event was missed. An on event only means
+	// at least one member is on, so it's left to the per-light events.
+	if item.On != nil && !item.On.On {
+		members := make(map[string]bool, len(group.lightIDs))
+		for _, id := range group.lightIDs {
+			members[id] = true
+		}
+		for i := range m.light {
+			if members[m.light[i].ID] && m.light[i].Reachable {
+				m.light[i].Status = "off"
+				m.light[i].LastSeen = time.Now()
+			}
+		}
+	}
+	return m, nil
+}
+
+// handleGroupLoaded stores a lazily fetched group and replays the events that were waiting for it
+func (m lightModel) handleGroupLoaded(msg groupLoadedMsg) (lightModel, tea.Cmd) {
+	pending := m.pendingGroups[msg.id]
+	delete(m.pendingGroups, msg.id)
+	if msg.err != nil {
+		logError("Failed to fetch grouped light %s: %v", msg.id, msg.err)
+		return m, nil
+	}
+
+	if m.groups == nil {
+		m.groups = make(map[string]lightGroup)
+	}
+	m.groups[msg.id] = msg.group
+	for _, item := range pending {
+		m, _ = m.handleGroupedLightUpdate(item)
+	}
+	if m.roomsView {
+		m.rows = m.layoutRows()
+		m.moveCursorTo(m.cursor)
+	}
+	return m, nil
+}
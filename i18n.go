@@ -0,0 +1,794 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultLanguage is used when neither --lang nor the environment names a
+// language with a catalog, and for keys a catalog leaves out
+const defaultLanguage = "en"
+
+// language is the active catalog's language
+var language = defaultLanguage
+
+// catalogs holds the user-facing strings of each language by key. Formats use
+// explicit argument indexes (%[1]s) so a translation can put them in any
+// order. Plural forms are separate keys ending in .one and .other.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"title":              "Your Hue Lights",
+		"title.reconnecting": "reconnecting",
+
+		"column.name":       "NAME",
+		"column.status":     "STATUS",
+		"column.brightness": "BRIGHTNESS",
+		"column.room":       "ROOM",
+		"column.type":       "TYPE",
+		"column.model":      "MODEL",
+		"column.color":      "COLOR",
+		"column.last_seen":  "LAST SEEN",
+		"column.changed":    "CHANGED BY",
+
+		// Cells are at most statusWidth wide, with a duration of up to 3 characters
+		"cell.on":                "ON",
+		"cell.off":               "OFF",
+		"cell.on.partial":        "ON (%[1]d/%[2]d)",
+		"cell.off.partial":       "OFF (%[1]d/%[2]d)",
+		"cell.unreachable":       "UNREACHABLE",
+		"cell.unreachable.since": "UNREACHABLE (%[1]s)",
+		"cell.updating":          "UPDATING",
+		"cell.na":                "N/A",
+
+		"footer.notes": "• Unreachable lights will be skipped  • :refresh to update connectivity status",
+
+		"empty.none":     "No lights found — :refresh to reload, ? for help",
+		"empty.filtered": "No lights match the filter — :filter to clear it",
+
+		"key.all":              "all keys",
+		"key.filter.clear":     "clear the filter",
+		"key.move":             "move",
+		"key.select":           "select",
+		"key.brightness":       "brightness ±%[1]d%%",
+		"key.brightness.fine":  "brightness ±%[1]d%%",
+		"key.toggle.selected":  "toggle selected",
+		"key.toggle.cursor":    "toggle cursor",
+		"key.command":          "commands",
+		"key.quit":             "quit",
+		"key.quit.now":         "quit, even from another view",
+		"key.scenes":           "scenes",
+		"key.rooms":            "group by room",
+		"key.ct":               "warmer/cooler",
+		"key.color":            "pick a color",
+		"key.detail":           "light details",
+		"key.jump":             "jump to a name",
+		"key.sensors":          "sensors",
+		"key.reorder":          "move the row",
+		"key.ends":             "first/last row",
+		"key.halfpage":         "half page down/up",
+		"key.output":           "scroll command output",
+		"key.events":           "event stream debug view",
+		"key.command.run":      "run",
+		"key.command.complete": "complete",
+		"key.command.cursor":   "move in the line",
+		"key.command.ends":     "start/end of the line",
+		"key.cancel":           "cancel",
+		"key.jump.next":        "next match",
+		"key.close":            "close",
+		"key.scene.activate":   "activate (smart scenes: on/off)",
+		"key.scene.dynamic":    "play dynamically",
+		"key.enable":           "enable/disable",
+		"key.sensitivity":      "sensitivity",
+		"key.back":             "back",
+		"keys.title":           "Keys",
+		"keys.close":           "Press any key to close",
+
+		"layout.too_small": "Terminal too small (need %[1]dx%[2]d)",
+
+		"summary.lights.one":    "%[1]d light",
+		"summary.lights.other":  "%[1]d lights",
+		"summary.on":            "%[1]d on",
+		"summary.unreachable":   "%[1]d unreachable",
+		"summary.average":       "avg %.0[1]f%%",
+		"summary.updated":       "updated %[1]s",
+		"summary.dropped.one":   "%[1]d event dropped",
+		"summary.dropped.other": "%[1]d events dropped",
+		"summary.latency":       "bridge %[1]dms",
+		"summary.filter":        "filter %[1]q: %[2]d shown",
+		"summary.room":          "room %[1]s: %[2]d shown",
+		"summary.room.filter":   "room %[1]s, filter %[2]q: %[3]d shown",
+
+		"box.hint":          "Press : to open command mode",
+		"box.hint.scroll":   "Press : to open command mode • PgUp to scroll back",
+		"box.more":          "%[1]d more below • PgDn to scroll",
+		"box.command.help":  "Commands: help, refresh, all_on, all_off • ESC to cancel • ENTER to execute",
+		"box.delete.prompt": "Type %[1]q to delete: ",
+		"box.jump.prompt":   "jump: ",
+		"box.jump.help":     "Type the start of a name • TAB for the next match • ESC to close",
+
+		"plain.heading": "Hue lights. %[1]s.",
+		"plain.timers":  "Timers: %[1]s",
+		"plain.notice":  "Notice: %[1]s",
+		"plain.command": "Command: %[1]s",
+		"plain.jump":    "Jump to: %[1]s",
+		"plain.status":  "Status: %[1]s",
+
+		"setup.existing":        "Currently configured bridge: %[1]s",
+		"setup.error":           "Error: %[1]s",
+		"setup.existing.prompt": "Press ENTER to pair with it again, d to discover it again if its address changed, or n to cancel: ",
+		"setup.none":            "No Hue Bridge configuration found.",
+		"setup.discover.prompt": "Would you like to discover your Hue Bridge? (y/n): ",
+		"setup.discovering":     "Discovering Hue Bridge on your network...\nPlease wait...",
+		"setup.found":           "Found Hue Bridge at: %[1]s",
+		"setup.expired":         "The button press window expired. Press r to restart the countdown.",
+		"setup.press":           "Please press the link button on your Hue Bridge.",
+		"setup.waiting":         "%[1]s Waiting for the button… %[2]s left (SPACEBAR to try now)",
+		"setup.paired":          "Paired with %[1]s. Checking the connection...",
+		"setup.several":         "Found several Hue Bridges:",
+		"setup.unknown_id":      "unknown ID",
+		"setup.choice":          "  %[1]d. %[2]s (%[3]s, via %[4]s)",
+		"setup.choose.prompt":   "Press the number of the bridge to pair with, or n to cancel: ",
+		"setup.connected.one":   "Connected to '%[1]s' at %[2]s, %[3]d light found.",
+		"setup.connected.other": "Connected to '%[1]s' at %[2]s, %[3]d lights found.",
+		"setup.replaces":        "This replaces the configured bridge at %[1]s. Other settings are kept.",
+		"setup.overwrite":       "Overwrite the configuration? (y/n): ",
+		"setup.complete":        "Setup complete!",
+		"setup.summary.one":     "Connected to '%[1]s', %[2]d light found",
+		"setup.summary.other":   "Connected to '%[1]s', %[2]d lights found",
+		"setup.ip":              "Bridge IP: %[1]s",
+		"setup.key.saved":       "API Key: %[1]s, saved to config",
+		"setup.saved":           "Configuration saved to %[1]s",
+		"setup.reveal":          "Use :reveal-key in the app if you need the full key.",
+		"setup.key":             "API Key: %[1]s",
+		"setup.save.failed":     "Could not save the configuration to %[1]s:\n%[2]s",
+		"setup.save.nowhere":    "Could not find a place to save the configuration:\n%[1]s",
+		"setup.session.only":    "The app will work for this session, but setup will run again next time.",
+		"setup.manual":          "To save them yourself, put this in ~/.openhue/config.yaml:\n\nbridge: %[1]s\nkey: %[2]s",
+		"setup.print.prompt":    "Press p to print the bridge IP and key so you can save them manually.",
+		"setup.start":           "Press ENTER to start the application...",
+
+		"exit.left_on.one":   "Leaving %[1]d light on: %[2]s",
+		"exit.left_on.other": "Leaving %[1]d lights on: %[2]s",
+		"exit.ask":           "Turn them off before exit? (y/N): ",
+		"exit.failed":        "Couldn't turn them all off: %[1]v",
+		"exit.done":          "Turned them off.",
+
+		"action.skipped":                   " · %[1]d skipped",
+		"action.failed":                    " · %[1]d failed",
+		"action.toggled.one":               "Toggled %[1]d light",
+		"action.toggled.other":             "Toggled %[1]d lights",
+		"action.already":                   " · %[1]d already %[2]s",
+		"action.turned_on.one":             "Turned on %[1]d light",
+		"action.turned_on.other":           "Turned on %[1]d lights",
+		"action.turned_on.filtered.one":    "Turned on %[1]d filtered light",
+		"action.turned_on.filtered.other":  "Turned on %[1]d filtered lights",
+		"action.turned_off.one":            "Turned off %[1]d light",
+		"action.turned_off.other":          "Turned off %[1]d lights",
+		"action.turned_off.filtered.one":   "Turned off %[1]d filtered light",
+		"action.turned_off.filtered.other": "Turned off %[1]d filtered lights",
+		"action.onoff_only.one":            " · %[1]s skipped for %[2]d on/off device",
+		"action.onoff_only.other":          " · %[1]s skipped for %[2]d on/off devices",
+		"action.ct.one":                    "%[2]dK on %[1]d light",
+		"action.ct.other":                  "%[2]dK on %[1]d lights",
+		"action.warmer.one":                "Warmer on %[1]d light",
+		"action.warmer.other":              "Warmer on %[1]d lights",
+		"action.cooler.one":                "Cooler on %[1]d light",
+		"action.cooler.other":              "Cooler on %[1]d lights",
+		"action.brightness.one":            "Brightness %[2]d%% on %[1]d light",
+		"action.brightness.other":          "Brightness %[2]d%% on %[1]d lights",
+		"action.clamped":                   " · %[1]d raised to their minimum",
+		"action.brightness.step.one":       "Brightness %+[2]d%% on %[1]d light",
+		"action.brightness.step.other":     "Brightness %+[2]d%% on %[1]d lights",
+		"action.color.one":                 "Set the color of %[1]d light",
+		"action.color.other":               "Set the color of %[1]d lights",
+		"action.restored.one":              "Restored %[1]d light",
+		"action.restored.other":            "Restored %[1]d lights",
+		"action.gradient.one":              "Gradient set on %[1]d light",
+		"action.gradient.other":            "Gradient set on %[1]d lights",
+		"action.match.one":                 "Matched %[2]s on %[1]d light",
+		"action.match.other":               "Matched %[2]s on %[1]d lights",
+		"action.match.approximated":        " · %[1]d as color temperature",
+		"action.match.incompatible":        " · can't show its color: %[1]s",
+		"action.signal.one":                "Signaling for %[2]ds on %[1]d light",
+		"action.signal.other":              "Signaling for %[2]ds on %[1]d lights",
+		"action.signal.colorless":          " · %[1]d without color",
+		"action.sync_locked.one":           " · %[1]s is locked by entertainment area %[2]q streaming; stop the sync app to control it",
+		"action.sync_locked.other":         " · %[1]s are locked by entertainment streaming; stop the sync app to control them",
+
+		"state.on":         "on",
+		"state.off":        "off",
+		"state.at_ct":      "at that temperature",
+		"state.at_percent": "at %[1]d%%",
+		"state.enabled":    "enabled",
+		"state.disabled":   "disabled",
+		"state.errored":    "errored",
+
+		"what.brightness": "brightness",
+		"what.ct":         "color temperature",
+
+		"status.brightness.failed.one":     "Brightness failed on %[1]d light",
+		"status.brightness.failed.other":   "Brightness failed on %[1]d lights",
+		"status.color.unchanged":           "Color unchanged",
+		"status.none_selected":             "No lights selected",
+		"status.lights.fetch_failed":       "Error fetching lights: %[1]v",
+		"status.color.none":                "None of the selected lights can change color",
+		"status.color.skipped.one":         "%[1]d selected light can't change color and is left alone",
+		"status.color.skipped.other":       "%[1]d selected lights can't change color and are left alone",
+		"status.signal.unrestored.one":     "Signal finished · %[1]d light couldn't be restored",
+		"status.signal.unrestored.other":   "Signal finished · %[1]d lights couldn't be restored",
+		"status.signal.done":               "Signal finished, lights restored",
+		"status.scene.recall_failed":       "Error recalling scene %[1]s: %[2]v",
+		"status.scene.activated_key":       "Scene %[1]s activated (%[2]s)",
+		"status.change_failed":             "Couldn't change %[1]s: %[2]v",
+		"status.enabled":                   "%[1]s enabled",
+		"status.disabled":                  "%[1]s disabled",
+		"status.delete.cancelled":          "Delete cancelled",
+		"status.command.too_long":          "Commands are limited to %[1]d characters",
+		"status.error":                     "Error: %[1]v",
+		"status.internal_error":            "Internal error: %[1]v. Details are in the log; please report it",
+		"status.commands":                  "Commands: %[1]s",
+		"status.version":                   "%[1]s · bridge software %[2]s",
+		"status.refreshed":                 "Lights refreshed: %[1]s",
+		"status.scene.activated":           "Scene %[1]s activated",
+		"status.selection.cleared":         "Selection cleared",
+		"status.selected.name":             "Selected %[1]s",
+		"status.selected.one":              "Selected %[1]d light",
+		"status.selected.other":            "Selected %[1]d lights",
+		"status.macro.deleted":             "Macro %[1]s deleted",
+		"status.macro.saved":               "Macro %[1]s saved",
+		"status.sse.none":                  "No SSE events yet",
+		"status.sse.dump_failed":           "Error dumping SSE payload: %[1]v",
+		"status.sse.dumped":                "Dumped to %[1]s",
+		"status.search.started":            "Searching for new lights… power them on now",
+		"status.search.refresh_failed":     "Search finished, but refreshing lights failed: %[1]v",
+		"status.search.none":               "Search finished: no new lights found",
+		"status.search.found.one":          "Found %[1]d new light: %[2]s",
+		"status.search.found.other":        "Found %[1]d new lights: %[2]s",
+		"status.filter.cleared":            "Filter cleared",
+		"status.filter.showing.one":        "Showing %[1]d light matching %[2]q",
+		"status.filter.showing.other":      "Showing %[1]d lights matching %[2]q",
+		"status.firmware.none":             "No firmware updates pending",
+		"status.firmware.pending.one":      "%[1]d device with a firmware update:",
+		"status.firmware.pending.other":    "%[1]d devices with a firmware update:",
+		"status.connectivity.checking":     "Checking connectivity…",
+		"status.connectivity.paused":       "Periodic connectivity checks paused",
+		"status.connectivity.resumed":      "Periodic connectivity checks resumed, every %[1]s",
+		"status.connectivity.failed":       "Couldn't check connectivity: %[1]v",
+		"status.connectivity.checked":      "Connectivity checked · %[1]d unreachable",
+		"status.sensors.load_failed":       "Couldn't load sensors: %[1]v",
+		"status.sensor.listed_only":        "%[1]s is a %[2]s; %[2]ss are only listed here",
+		"status.sensor.no_sensitivity":     "%[1]s has no adjustable sensitivity",
+		"status.sensor.sensitivity":        "%[1]s sensitivity %[2]d/%[3]d",
+		"status.group.created.one":         "Created %[1]s %[2]s with %[3]d member",
+		"status.group.created.other":       "Created %[1]s %[2]s with %[3]d members",
+		"status.group.added.one":           "Added %[1]d member to %[2]s",
+		"status.group.added.other":         "Added %[1]d members to %[2]s",
+		"status.group.removed.one":         "Removed %[1]d member from %[2]s",
+		"status.group.removed.other":       "Removed %[1]d members from %[2]s",
+		"status.room.off":                  "%[1]s off",
+		"status.room.brightness":           "%[1]s %[2]s",
+		"status.scenes.load_failed":        "Couldn't load scenes: %[1]v",
+		"status.scene.no_dynamics":         "%[1]s has no dynamics; it can only be recalled statically",
+		"status.scene.activate_failed":     "Couldn't activate %[1]s: %[2]v",
+		"status.scene.dynamic":             "Scene %[1]s playing dynamically",
+		"status.scene.smart_no_dynamics":   "%[1]s is a smart scene; dynamics aren't available",
+		"status.smart_scene.activated":     "Smart scene %[1]s activated for %[2]s",
+		"status.smart_scene.deactivated":   "Smart scene %[1]s deactivated",
+		"status.scene.speed":               "Speed %[1]d%% on %[2]s",
+		"status.timer.set":                 "%[1]s turns off at %[2]s",
+		"status.timer.cancelled":           "Timer for %[1]s cancelled",
+		"status.timer.failed":              "Couldn't switch off %[1]s: %[2]v",
+		"status.timer.done":                "Timer done, %[1]s switched off",
+		"status.set":                       "Set %[1]s to %[2]v",
+		"status.ramp.up.one":               "Brightening %[1]d light…",
+		"status.ramp.up.other":             "Brightening %[1]d lights…",
+		"status.ramp.down.one":             "Dimming %[1]d light…",
+		"status.ramp.down.other":           "Dimming %[1]d lights…",
+		"status.ramp.stopped":              "Brightness ramp stopped",
+		"status.delete.confirm":            "Deleting removes the device from the bridge. Type %[1]q and press ENTER to confirm, ESC to cancel",
+		"status.delete.mismatch":           "Name didn't match; nothing was deleted",
+		"status.delete.failed":             "Couldn't delete %[1]s: %[2]v",
+		"status.delete.done":               "Deleted %[1]s from the bridge",
+		"status.renamed":                   "Renamed %[1]s to %[2]s",
+		"status.away.started.one":          "Away mode started for %[1]d light, %[2]s",
+		"status.away.started.other":        "Away mode started for %[1]d lights, %[2]s",
+		"status.away.stopped":              "Away mode stopped, lights restored",
+		"status.mirror.stopped":            "Mirroring stopped",
+		"status.mirror.started.one":        "Mirroring %[1]s onto %[2]d light",
+		"status.mirror.started.other":      "Mirroring %[1]s onto %[2]d lights",
+		"status.columns.available":         "Columns: %[1]s (available: %[2]s)",
+		"status.columns":                   "Columns: %[1]s",
+		"status.sse.reconnecting":          "Reconnecting the event stream",
+		"status.order.reset":               "Light order reset",
+		"status.unreachable.confirm.one":   "%[1]d of %[2]d selected lights is unreachable and will be skipped (press again to continue)",
+		"status.unreachable.confirm.other": "%[1]d of %[2]d selected lights are unreachable and will be skipped (press again to continue)",
+		"status.diagnostics.written":       "Diagnostics written to %[1]s",
+		"status.profile.exported":          "Profile exported to %[1]s",
+		"status.ping":                      "Bridge answered in %[1]dms",
+		"status.ping.average":              " · recent average %[1]dms",
+		"status.profile.imported.one":      "Imported %[1]d setting from %[2]s",
+		"status.profile.imported.other":    "Imported %[1]d settings from %[2]s",
+		"status.profile.rejected":          " · %[1]d rejected:\n  %[2]s",
+		"status.aliases.none":              "No aliases configured (add an aliases: section to the config file)",
+		"status.macros.none":               "No macros saved (use macro save <name> <commands>)",
+		"status.turned":                    "%[1]s turned %[2]s",
+
+		"detail.name":         "Name",
+		"detail.device":       "Device",
+		"detail.room":         "Room",
+		"detail.type":         "Type",
+		"detail.capabilities": "Capabilities",
+		"detail.product":      "Product",
+		"detail.model":        "Model ID",
+		"detail.manufacturer": "Manufacturer",
+		"detail.software":     "Software",
+		"detail.hardware":     "Hardware",
+		"detail.firmware":     "Firmware update",
+		"detail.ct":           "Color temperature",
+		"detail.gradient":     "Gradient points",
+		"detail.light_id":     "Light ID",
+		"detail.device_id":    "Device ID",
+		"detail.title":        "Light details",
+		"detail.gone":         "the light is gone",
+
+		"time.ago": "%[1]s ago",
+
+		"scenes.limit":           "%[1]d/%[2]d scenes",
+		"scenes.title":           "Scenes",
+		"scenes.loading":         "loading…",
+		"scenes.loading.first":   "Loading scenes…",
+		"scenes.column.name":     "Name",
+		"scenes.column.room":     "Room",
+		"scenes.column.lights":   "Lights",
+		"scenes.column.recalled": "Recalled",
+		"scenes.smart.active":    "smart · active",
+		"scenes.smart.inactive":  "smart · inactive",
+		"scenes.dynamic":         "dynamic %.0[1]f%%",
+		"scenes.active":          "active",
+
+		"sensors.button":        "button %[1]d %[2]s %[3]s",
+		"sensors.motion":        "motion now",
+		"sensors.closed":        "closed",
+		"sensors.open":          "open",
+		"sensors.tampered":      "TAMPERED",
+		"sensors.title":         "Sensors",
+		"sensors.none":          "No sensors or switches on this bridge",
+		"sensors.home":          "home",
+		"sensors.away":          "away",
+		"sensors.buttons.one":   "%[1]d button",
+		"sensors.buttons.other": "%[1]d buttons",
+		"sensors.sensitivity":   "sensitivity %[1]d/%[2]d",
+
+		"automations.title": "Automations",
+		"automations.none":  "No automations on this bridge",
+
+		"refresh.unreachable":   "%[1]s now unreachable",
+		"refresh.reachable":     "%[1]s reachable again",
+		"refresh.added.one":     "+%[1]d new light",
+		"refresh.added.other":   "+%[1]d new lights",
+		"refresh.removed.one":   "-%[1]d removed light",
+		"refresh.removed.other": "-%[1]d removed lights",
+		"refresh.none":          "no changes",
+	},
+	"de": {
+		"title":              "Deine Hue-Lampen",
+		"title.reconnecting": "verbinde neu",
+
+		"column.name":       "NAME",
+		"column.status":     "STATUS",
+		"column.brightness": "HELLIGKEIT",
+		"column.room":       "RAUM",
+		"column.type":       "TYP",
+		"column.model":      "MODELL",
+		"column.color":      "FARBE",
+		"column.last_seen":  "ZULETZT",
+		"column.changed":    "GEÄNDERT VON",
+
+		"cell.on":                "AN",
+		"cell.off":               "AUS",
+		"cell.on.partial":        "AN (%[1]d/%[2]d)",
+		"cell.off.partial":       "AUS (%[1]d/%[2]d)",
+		"cell.unreachable":       "UNERREICHBAR",
+		"cell.unreachable.since": "UNERREICHBAR (%[1]s)",
+		"cell.updating":          "UPDATE LÄUFT",
+		"cell.na":                "k. A.",
+
+		"footer.notes": "• Nicht erreichbare Lampen werden übersprungen  • :refresh aktualisiert die Erreichbarkeit",
+
+		"empty.none":     "Keine Lampen gefunden — :refresh lädt neu, ? für Hilfe",
+		"empty.filtered": "Keine Lampe passt zum Filter — :filter hebt ihn auf",
+
+		"key.all":              "alle Tasten",
+		"key.filter.clear":     "Filter aufheben",
+		"key.move":             "bewegen",
+		"key.select":           "auswählen",
+		"key.brightness":       "Helligkeit ±%[1]d%%",
+		"key.brightness.fine":  "Helligkeit ±%[1]d%%",
+		"key.toggle.selected":  "Auswahl schalten",
+		"key.toggle.cursor":    "Cursor schalten",
+		"key.command":          "Befehle",
+		"key.quit":             "beenden",
+		"key.quit.now":         "beenden, auch aus anderen Ansichten",
+		"key.scenes":           "Szenen",
+		"key.rooms":            "nach Raum gruppieren",
+		"key.ct":               "wärmer/kälter",
+		"key.color":            "Farbe wählen",
+		"key.detail":           "Lampendetails",
+		"key.jump":             "zu einem Namen springen",
+		"key.sensors":          "Sensoren",
+		"key.reorder":          "Zeile verschieben",
+		"key.ends":             "erste/letzte Zeile",
+		"key.halfpage":         "halbe Seite runter/hoch",
+		"key.output":           "Befehlsausgabe blättern",
+		"key.events":           "Ereignisstrom-Debugansicht",
+		"key.command.run":      "ausführen",
+		"key.command.complete": "vervollständigen",
+		"key.command.cursor":   "in der Zeile bewegen",
+		"key.command.ends":     "Anfang/Ende der Zeile",
+		"key.cancel":           "abbrechen",
+		"key.jump.next":        "nächster Treffer",
+		"key.close":            "schließen",
+		"key.scene.activate":   "aktivieren (smarte Szenen: an/aus)",
+		"key.scene.dynamic":    "dynamisch abspielen",
+		"key.enable":           "aktivieren/deaktivieren",
+		"key.sensitivity":      "Empfindlichkeit",
+		"key.back":             "zurück",
+		"keys.title":           "Tasten",
+		"keys.close":           "Beliebige Taste schließt",
+
+		"layout.too_small": "Terminal zu klein (mindestens %[1]dx%[2]d)",
+
+		"summary.lights.one":    "%[1]d Lampe",
+		"summary.lights.other":  "%[1]d Lampen",
+		"summary.on":            "%[1]d an",
+		"summary.unreachable":   "%[1]d nicht erreichbar",
+		"summary.average":       "Ø %.0[1]f%%",
+		"summary.updated":       "aktualisiert %[1]s",
+		"summary.dropped.one":   "%[1]d Ereignis verworfen",
+		"summary.dropped.other": "%[1]d Ereignisse verworfen",
+		"summary.latency":       "Bridge %[1]dms",
+		"summary.filter":        "Filter %[1]q: %[2]d angezeigt",
+		"summary.room":          "Raum %[1]s: %[2]d angezeigt",
+		"summary.room.filter":   "Raum %[1]s, Filter %[2]q: %[3]d angezeigt",
+
+		"box.hint":          "Drücke : für den Befehlsmodus",
+		"box.hint.scroll":   "Drücke : für den Befehlsmodus • Bild↑ zum Zurückblättern",
+		"box.more":          "%[1]d weitere unten • Bild↓ zum Blättern",
+		"box.command.help":  "Befehle: help, refresh, all_on, all_off • ESC bricht ab • ENTER führt aus",
+		"box.delete.prompt": "Zum Löschen %[1]q eingeben: ",
+		"box.jump.prompt":   "springen: ",
+		"box.jump.help":     "Anfang eines Namens tippen • TAB für den nächsten Treffer • ESC schließt",
+
+		"plain.heading": "Hue-Lampen. %[1]s.",
+		"plain.timers":  "Timer: %[1]s",
+		"plain.notice":  "Hinweis: %[1]s",
+		"plain.command": "Befehl: %[1]s",
+		"plain.jump":    "Springen zu: %[1]s",
+		"plain.status":  "Status: %[1]s",
+
+		"setup.existing":        "Eingerichtete Bridge: %[1]s",
+		"setup.error":           "Fehler: %[1]s",
+		"setup.existing.prompt": "ENTER koppelt erneut, d sucht sie neu, falls sich ihre Adresse geändert hat, n bricht ab: ",
+		"setup.none":            "Keine Hue-Bridge eingerichtet.",
+		"setup.discover.prompt": "Soll nach deiner Hue-Bridge gesucht werden? (y/n): ",
+		"setup.discovering":     "Suche nach der Hue-Bridge im Netzwerk...\nBitte warten...",
+		"setup.found":           "Hue-Bridge gefunden unter: %[1]s",
+		"setup.expired":         "Die Zeit für den Tastendruck ist abgelaufen. r startet den Countdown neu.",
+		"setup.press":           "Bitte drücke die Link-Taste auf deiner Hue-Bridge.",
+		"setup.waiting":         "%[1]s Warte auf die Taste… noch %[2]s (LEERTASTE versucht es sofort)",
+		"setup.paired":          "Mit %[1]s gekoppelt. Verbindung wird geprüft...",
+		"setup.several":         "Mehrere Hue-Bridges gefunden:",
+		"setup.unknown_id":      "unbekannte ID",
+		"setup.choice":          "  %[1]d. %[2]s (%[3]s, über %[4]s)",
+		"setup.choose.prompt":   "Nummer der Bridge zum Koppeln drücken, oder n zum Abbrechen: ",
+		"setup.connected.one":   "Mit '%[1]s' unter %[2]s verbunden, %[3]d Lampe gefunden.",
+		"setup.connected.other": "Mit '%[1]s' unter %[2]s verbunden, %[3]d Lampen gefunden.",
+		"setup.replaces":        "Das ersetzt die eingerichtete Bridge unter %[1]s. Andere Einstellungen bleiben erhalten.",
+		"setup.overwrite":       "Konfiguration überschreiben? (y/n): ",
+		"setup.complete":        "Einrichtung abgeschlossen!",
+		"setup.summary.one":     "Mit '%[1]s' verbunden, %[2]d Lampe gefunden",
+		"setup.summary.other":   "Mit '%[1]s' verbunden, %[2]d Lampen gefunden",
+		"setup.ip":              "Bridge-IP: %[1]s",
+		"setup.key.saved":       "API-Schlüssel: %[1]s, in der Konfiguration gespeichert",
+		"setup.saved":           "Konfiguration gespeichert unter %[1]s",
+		"setup.reveal":          "Mit :reveal-key zeigt die App den ganzen Schlüssel.",
+		"setup.key":             "API-Schlüssel: %[1]s",
+		"setup.save.failed":     "Die Konfiguration konnte nicht unter %[1]s gespeichert werden:\n%[2]s",
+		"setup.save.nowhere":    "Kein Ort zum Speichern der Konfiguration gefunden:\n%[1]s",
+		"setup.session.only":    "Die App funktioniert für diese Sitzung, aber die Einrichtung startet beim nächsten Mal erneut.",
+		"setup.manual":          "Zum selbst Speichern dies in ~/.openhue/config.yaml eintragen:\n\nbridge: %[1]s\nkey: %[2]s",
+		"setup.print.prompt":    "p zeigt Bridge-IP und Schlüssel zum manuellen Speichern.",
+		"setup.start":           "ENTER startet die Anwendung...",
+
+		"exit.left_on.one":   "%[1]d Lampe bleibt an: %[2]s",
+		"exit.left_on.other": "%[1]d Lampen bleiben an: %[2]s",
+		"exit.ask":           "Vor dem Beenden ausschalten? (y/N): ",
+		"exit.failed":        "Nicht alle ließen sich ausschalten: %[1]v",
+		"exit.done":          "Ausgeschaltet.",
+
+		"action.skipped":                   " · %[1]d übersprungen",
+		"action.failed":                    " · %[1]d fehlgeschlagen",
+		"action.toggled.one":               "%[1]d Lampe umgeschaltet",
+		"action.toggled.other":             "%[1]d Lampen umgeschaltet",
+		"action.already":                   " · %[1]d bereits %[2]s",
+		"action.turned_on.one":             "%[1]d Lampe eingeschaltet",
+		"action.turned_on.other":           "%[1]d Lampen eingeschaltet",
+		"action.turned_on.filtered.one":    "%[1]d gefilterte Lampe eingeschaltet",
+		"action.turned_on.filtered.other":  "%[1]d gefilterte Lampen eingeschaltet",
+		"action.turned_off.one":            "%[1]d Lampe ausgeschaltet",
+		"action.turned_off.other":          "%[1]d Lampen ausgeschaltet",
+		"action.turned_off.filtered.one":   "%[1]d gefilterte Lampe ausgeschaltet",
+		"action.turned_off.filtered.other": "%[1]d gefilterte Lampen ausgeschaltet",
+		"action.onoff_only.one":            " · %[1]s bei %[2]d Ein/Aus-Gerät übersprungen",
+		"action.onoff_only.other":          " · %[1]s bei %[2]d Ein/Aus-Geräten übersprungen",
+		"action.ct.one":                    "%[2]dK bei %[1]d Lampe",
+		"action.ct.other":                  "%[2]dK bei %[1]d Lampen",
+		"action.warmer.one":                "Wärmer bei %[1]d Lampe",
+		"action.warmer.other":              "Wärmer bei %[1]d Lampen",
+		"action.cooler.one":                "Kühler bei %[1]d Lampe",
+		"action.cooler.other":              "Kühler bei %[1]d Lampen",
+		"action.brightness.one":            "Helligkeit %[2]d %% bei %[1]d Lampe",
+		"action.brightness.other":          "Helligkeit %[2]d %% bei %[1]d Lampen",
+		"action.clamped":                   " · %[1]d auf ihr Minimum angehoben",
+		"action.brightness.step.one":       "Helligkeit %+[2]d %% bei %[1]d Lampe",
+		"action.brightness.step.other":     "Helligkeit %+[2]d %% bei %[1]d Lampen",
+		"action.color.one":                 "Farbe von %[1]d Lampe gesetzt",
+		"action.color.other":               "Farbe von %[1]d Lampen gesetzt",
+		"action.restored.one":              "%[1]d Lampe wiederhergestellt",
+		"action.restored.other":            "%[1]d Lampen wiederhergestellt",
+		"action.gradient.one":              "Verlauf bei %[1]d Lampe gesetzt",
+		"action.gradient.other":            "Verlauf bei %[1]d Lampen gesetzt",
+		"action.match.one":                 "%[2]s auf %[1]d Lampe übertragen",
+		"action.match.other":               "%[2]s auf %[1]d Lampen übertragen",
+		"action.match.approximated":        " · %[1]d als Farbtemperatur",
+		"action.match.incompatible":        " · können die Farbe nicht zeigen: %[1]s",
+		"action.signal.one":                "Signal für %[2]d s bei %[1]d Lampe",
+		"action.signal.other":              "Signal für %[2]d s bei %[1]d Lampen",
+		"action.signal.colorless":          " · %[1]d ohne Farbe",
+		"action.sync_locked.one":           " · %[1]s ist durch das Streaming des Entertainment-Bereichs %[2]q gesperrt; die Sync-App beenden, um sie zu steuern",
+		"action.sync_locked.other":         " · %[1]s sind durch Entertainment-Streaming gesperrt; die Sync-App beenden, um sie zu steuern",
+
+		"state.on":         "an",
+		"state.off":        "aus",
+		"state.at_ct":      "bei dieser Farbtemperatur",
+		"state.at_percent": "bei %[1]d %%",
+		"state.enabled":    "aktiv",
+		"state.disabled":   "inaktiv",
+		"state.errored":    "Fehler",
+
+		"what.brightness": "Helligkeit",
+		"what.ct":         "Farbtemperatur",
+
+		"status.brightness.failed.one":     "Helligkeit bei %[1]d Lampe fehlgeschlagen",
+		"status.brightness.failed.other":   "Helligkeit bei %[1]d Lampen fehlgeschlagen",
+		"status.color.unchanged":           "Farbe unverändert",
+		"status.none_selected":             "Keine Lampen ausgewählt",
+		"status.lights.fetch_failed":       "Fehler beim Abrufen der Lampen: %[1]v",
+		"status.color.none":                "Keine der ausgewählten Lampen kann die Farbe ändern",
+		"status.color.skipped.one":         "%[1]d ausgewählte Lampe kann die Farbe nicht ändern und bleibt unverändert",
+		"status.color.skipped.other":       "%[1]d ausgewählte Lampen können die Farbe nicht ändern und bleiben unverändert",
+		"status.signal.unrestored.one":     "Signal beendet · %[1]d Lampe konnte nicht wiederhergestellt werden",
+		"status.signal.unrestored.other":   "Signal beendet · %[1]d Lampen konnten nicht wiederhergestellt werden",
+		"status.signal.done":               "Signal beendet, Lampen wiederhergestellt",
+		"status.scene.recall_failed":       "Fehler beim Abrufen der Szene %[1]s: %[2]v",
+		"status.scene.activated_key":       "Szene %[1]s aktiviert (%[2]s)",
+		"status.change_failed":             "%[1]s konnte nicht geändert werden: %[2]v",
+		"status.enabled":                   "%[1]s aktiviert",
+		"status.disabled":                  "%[1]s deaktiviert",
+		"status.delete.cancelled":          "Löschen abgebrochen",
+		"status.command.too_long":          "Befehle sind auf %[1]d Zeichen begrenzt",
+		"status.error":                     "Fehler: %[1]v",
+		"status.internal_error":            "Interner Fehler: %[1]v. Details stehen im Log; bitte melden",
+		"status.commands":                  "Befehle: %[1]s",
+		"status.version":                   "%[1]s · Bridge-Software %[2]s",
+		"status.refreshed":                 "Lampen aktualisiert: %[1]s",
+		"status.scene.activated":           "Szene %[1]s aktiviert",
+		"status.selection.cleared":         "Auswahl aufgehoben",
+		"status.selected.name":             "%[1]s ausgewählt",
+		"status.selected.one":              "%[1]d Lampe ausgewählt",
+		"status.selected.other":            "%[1]d Lampen ausgewählt",
+		"status.macro.deleted":             "Makro %[1]s gelöscht",
+		"status.macro.saved":               "Makro %[1]s gespeichert",
+		"status.sse.none":                  "Noch keine SSE-Ereignisse",
+		"status.sse.dump_failed":           "Fehler beim Speichern der SSE-Daten: %[1]v",
+		"status.sse.dumped":                "Gespeichert in %[1]s",
+		"status.search.started":            "Suche nach neuen Lampen… jetzt einschalten",
+		"status.search.refresh_failed":     "Suche beendet, aber das Aktualisieren der Lampen schlug fehl: %[1]v",
+		"status.search.none":               "Suche beendet: keine neuen Lampen gefunden",
+		"status.search.found.one":          "%[1]d neue Lampe gefunden: %[2]s",
+		"status.search.found.other":        "%[1]d neue Lampen gefunden: %[2]s",
+		"status.filter.cleared":            "Filter aufgehoben",
+		"status.filter.showing.one":        "%[1]d Lampe passt zu %[2]q",
+		"status.filter.showing.other":      "%[1]d Lampen passen zu %[2]q",
+		"status.firmware.none":             "Keine Firmware-Updates ausstehend",
+		"status.firmware.pending.one":      "%[1]d Gerät mit Firmware-Update:",
+		"status.firmware.pending.other":    "%[1]d Geräte mit Firmware-Update:",
+		"status.connectivity.checking":     "Verbindung wird geprüft…",
+		"status.connectivity.paused":       "Regelmäßige Verbindungsprüfungen pausiert",
+		"status.connectivity.resumed":      "Regelmäßige Verbindungsprüfungen fortgesetzt, alle %[1]s",
+		"status.connectivity.failed":       "Verbindung konnte nicht geprüft werden: %[1]v",
+		"status.connectivity.checked":      "Verbindung geprüft · %[1]d nicht erreichbar",
+		"status.sensors.load_failed":       "Sensoren konnten nicht geladen werden: %[1]v",
+		"status.sensor.listed_only":        "%[1]s ist vom Typ %[2]s; diese werden hier nur aufgelistet",
+		"status.sensor.no_sensitivity":     "%[1]s hat keine einstellbare Empfindlichkeit",
+		"status.sensor.sensitivity":        "%[1]s Empfindlichkeit %[2]d/%[3]d",
+		"status.group.created.one":         "%[1]s %[2]s mit %[3]d Mitglied erstellt",
+		"status.group.created.other":       "%[1]s %[2]s mit %[3]d Mitgliedern erstellt",
+		"status.group.added.one":           "%[1]d Mitglied zu %[2]s hinzugefügt",
+		"status.group.added.other":         "%[1]d Mitglieder zu %[2]s hinzugefügt",
+		"status.group.removed.one":         "%[1]d Mitglied aus %[2]s entfernt",
+		"status.group.removed.other":       "%[1]d Mitglieder aus %[2]s entfernt",
+		"status.room.off":                  "%[1]s aus",
+		"status.room.brightness":           "%[1]s %[2]s",
+		"status.scenes.load_failed":        "Szenen konnten nicht geladen werden: %[1]v",
+		"status.scene.no_dynamics":         "%[1]s hat keine Dynamik; sie kann nur statisch abgerufen werden",
+		"status.scene.activate_failed":     "%[1]s konnte nicht aktiviert werden: %[2]v",
+		"status.scene.dynamic":             "Szene %[1]s läuft dynamisch",
+		"status.scene.smart_no_dynamics":   "%[1]s ist eine intelligente Szene; Dynamik ist nicht verfügbar",
+		"status.smart_scene.activated":     "Intelligente Szene %[1]s für %[2]s aktiviert",
+		"status.smart_scene.deactivated":   "Intelligente Szene %[1]s deaktiviert",
+		"status.scene.speed":               "Geschwindigkeit %[1]d %% bei %[2]s",
+		"status.timer.set":                 "%[1]s schaltet um %[2]s aus",
+		"status.timer.cancelled":           "Timer für %[1]s abgebrochen",
+		"status.timer.failed":              "%[1]s konnte nicht ausgeschaltet werden: %[2]v",
+		"status.timer.done":                "Timer abgelaufen, %[1]s ausgeschaltet",
+		"status.set":                       "%[1]s auf %[2]v gesetzt",
+		"status.ramp.up.one":               "%[1]d Lampe wird heller…",
+		"status.ramp.up.other":             "%[1]d Lampen werden heller…",
+		"status.ramp.down.one":             "%[1]d Lampe wird gedimmt…",
+		"status.ramp.down.other":           "%[1]d Lampen werden gedimmt…",
+		"status.ramp.stopped":              "Helligkeitsrampe gestoppt",
+		"status.delete.confirm":            "Löschen entfernt das Gerät von der Bridge. %[1]q eingeben und ENTER drücken zum Bestätigen, ESC zum Abbrechen",
+		"status.delete.mismatch":           "Name stimmt nicht überein; nichts wurde gelöscht",
+		"status.delete.failed":             "%[1]s konnte nicht gelöscht werden: %[2]v",
+		"status.delete.done":               "%[1]s von der Bridge gelöscht",
+		"status.renamed":                   "%[1]s in %[2]s umbenannt",
+		"status.away.started.one":          "Abwesenheitsmodus für %[1]d Lampe gestartet, %[2]s",
+		"status.away.started.other":        "Abwesenheitsmodus für %[1]d Lampen gestartet, %[2]s",
+		"status.away.stopped":              "Abwesenheitsmodus beendet, Lampen wiederhergestellt",
+		"status.mirror.stopped":            "Spiegeln beendet",
+		"status.mirror.started.one":        "%[1]s wird auf %[2]d Lampe gespiegelt",
+		"status.mirror.started.other":      "%[1]s wird auf %[2]d Lampen gespiegelt",
+		"status.columns.available":         "Spalten: %[1]s (verfügbar: %[2]s)",
+		"status.columns":                   "Spalten: %[1]s",
+		"status.sse.reconnecting":          "Ereignisstrom wird neu verbunden",
+		"status.order.reset":               "Lampenreihenfolge zurückgesetzt",
+		"status.unreachable.confirm.one":   "%[1]d von %[2]d ausgewählten Lampen ist nicht erreichbar und wird übersprungen (erneut drücken zum Fortfahren)",
+		"status.unreachable.confirm.other": "%[1]d von %[2]d ausgewählten Lampen sind nicht erreichbar und werden übersprungen (erneut drücken zum Fortfahren)",
+		"status.diagnostics.written":       "Diagnose geschrieben nach %[1]s",
+		"status.profile.exported":          "Profil exportiert nach %[1]s",
+		"status.ping":                      "Bridge antwortete in %[1]d ms",
+		"status.ping.average":              " · zuletzt im Schnitt %[1]d ms",
+		"status.profile.imported.one":      "%[1]d Einstellung aus %[2]s importiert",
+		"status.profile.imported.other":    "%[1]d Einstellungen aus %[2]s importiert",
+		"status.profile.rejected":          " · %[1]d abgelehnt:\n  %[2]s",
+		"status.aliases.none":              "Keine Aliase konfiguriert (einen Abschnitt aliases: in der Konfigurationsdatei anlegen)",
+		"status.macros.none":               "Keine Makros gespeichert (macro save <name> <befehle> verwenden)",
+		"status.turned":                    "%[1]s %[2]sgeschaltet",
+
+		"detail.name":         "Name",
+		"detail.device":       "Gerät",
+		"detail.room":         "Raum",
+		"detail.type":         "Typ",
+		"detail.capabilities": "Fähigkeiten",
+		"detail.product":      "Produkt",
+		"detail.model":        "Modell-ID",
+		"detail.manufacturer": "Hersteller",
+		"detail.software":     "Software",
+		"detail.hardware":     "Hardware",
+		"detail.firmware":     "Firmware-Update",
+		"detail.ct":           "Farbtemperatur",
+		"detail.gradient":     "Verlaufspunkte",
+		"detail.light_id":     "Lampen-ID",
+		"detail.device_id":    "Geräte-ID",
+		"detail.title":        "Lampendetails",
+		"detail.gone":         "die Lampe ist nicht mehr da",
+
+		"time.ago": "vor %[1]s",
+
+		"scenes.limit":           "%[1]d/%[2]d Szenen",
+		"scenes.title":           "Szenen",
+		"scenes.loading":         "lädt…",
+		"scenes.loading.first":   "Szenen werden geladen…",
+		"scenes.column.name":     "Name",
+		"scenes.column.room":     "Raum",
+		"scenes.column.lights":   "Lampen",
+		"scenes.column.recalled": "Abgerufen",
+		"scenes.smart.active":    "intelligent · aktiv",
+		"scenes.smart.inactive":  "intelligent · inaktiv",
+		"scenes.dynamic":         "dynamisch %.0[1]f %%",
+		"scenes.active":          "aktiv",
+
+		"sensors.button":        "Taste %[1]d %[2]s %[3]s",
+		"sensors.motion":        "Bewegung jetzt",
+		"sensors.closed":        "zu",
+		"sensors.open":          "offen",
+		"sensors.tampered":      "MANIPULIERT",
+		"sensors.title":         "Sensoren",
+		"sensors.none":          "Keine Sensoren oder Schalter an dieser Bridge",
+		"sensors.home":          "zu Hause",
+		"sensors.away":          "weg",
+		"sensors.buttons.one":   "%[1]d Taste",
+		"sensors.buttons.other": "%[1]d Tasten",
+		"sensors.sensitivity":   "Empfindl. %[1]d/%[2]d",
+
+		"automations.title": "Automationen",
+		"automations.none":  "Keine Automationen an dieser Bridge",
+
+		"refresh.unreachable":   "%[1]s jetzt nicht erreichbar",
+		"refresh.reachable":     "%[1]s wieder erreichbar",
+		"refresh.added.one":     "+%[1]d neue Lampe",
+		"refresh.added.other":   "+%[1]d neue Lampen",
+		"refresh.removed.one":   "-%[1]d entfernte Lampe",
+		"refresh.removed.other": "-%[1]d entfernte Lampen",
+		"refresh.none":          "keine Änderungen",
+	},
+}
+
+// tr looks up a message in the active language, falling back to English, and
+// formats it with args
+func tr(key string, args ...any) string {
+	format, ok := catalogs[language][key]
+	if !ok {
+		if format, ok = catalogs[defaultLanguage][key]; !ok {
+			logDebug("No message for %q", key)
+			return key
+		}
+	}
+	// Only the message's own glyphs are swapped, not the arguments
+	format = glyphText(format)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// trn is tr for messages that depend on a count: it picks key.one or
+// key.other by n. n isn't passed to the format on its own, so include it in args.
+func trn(key string, n int, args ...any) string {
+	if n == 1 {
+		return tr(key+".one", args...)
+	}
+	return tr(key+".other", args...)
+}
+
+// languages lists the languages with a catalog
+func languages() []string {
+	var langs []string
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// resolveLanguage picks the catalog for a --lang value, or the environment's
+// locale when it's empty. Tags such as "de_DE.UTF-8" or "de-AT" use the "de"
+// catalog; an unknown language is an error only when given with --lang.
+func resolveLanguage(flagValue string) (string, error) {
+	if flagValue != "" {
+		if lang := baseLanguage(flagValue); catalogs[lang] != nil {
+			return lang, nil
+		}
+		return "", fmt.Errorf("no translation for %q; available: %s", flagValue, strings.Join(languages(), ", "))
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if lang := baseLanguage(value); catalogs[lang] != nil {
+				return lang, nil
+			}
+			break
+		}
+	}
+	return defaultLanguage, nil
+}
+
+// baseLanguage reduces a locale tag to its lowercase language code
+func baseLanguage(tag string) string {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "_")
+	tag, _, _ = strings.Cut(tag, "-")
+	return strings.ToLower(tag)
+}
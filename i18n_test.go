@@ -0,0 +1,136 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// verbPattern matches a format verb and captures its argument index
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*(?:\[(\d+)\])?[a-zA-Z%]`)
+
+// argIndexes lists the argument indexes a format uses, sorted and unique
+func argIndexes(format string) []string {
+	var indexes []string
+	for _, match := range verbPattern.FindAllStringSubmatch(format, -1) {
+		if match[0] != "%%" && !slices.Contains(indexes, match[1]) {
+			indexes = append(indexes, match[1])
+		}
+	}
+	slices.Sort(indexes)
+	return indexes
+}
+
+func TestCatalogFormats(t *testing.T) {
+	// fmt wants the index right before the verb: %.0[1]f, not %[1].0f
+	misplaced := regexp.MustCompile(`%\[\d+\][-+# 0-9.]`)
+
+	for lang, catalog := range catalogs {
+		for key, format := range catalog {
+			if misplaced.MatchString(format) {
+				t.Errorf("%s %s: %q has an argument index before its flags or precision", lang, key, format)
+			}
+			for _, index := range argIndexes(format) {
+				if index == "" {
+					t.Errorf("%s %s: %q has a verb without an argument index", lang, key, format)
+				}
+			}
+			english, ok := catalogs[defaultLanguage][key]
+			if !ok {
+				t.Errorf("%s %s has no English string", lang, key)
+				continue
+			}
+			if got, want := argIndexes(format), argIndexes(english); !slices.Equal(got, want) {
+				t.Errorf("%s %s uses arguments %v, English uses %v", lang, key, got, want)
+			}
+		}
+	}
+}
+
+func TestStatusAndPanesFollowLanguage(t *testing.T) {
+	defer func(previous string) { language = previous }(language)
+	language = "de"
+
+	m := sizedModel(3, 120, 30)
+	if err := m.filterCommand(""); err != nil {
+		t.Fatal(err)
+	}
+	if m.status != "Filter aufgehoben" {
+		t.Errorf("status = %q, want the German string", m.status)
+	}
+	if got := summarizeAction("action.toggled", 2, 1, 0); !strings.Contains(got, "übersprungen") {
+		t.Errorf("summary = %q, want the German skipped note", got)
+	}
+
+	m.openDetail()
+	detail := ansi.Strip(m.renderDetail())
+	for _, label := range []string{"Gerät", "Raum", "Lampen-ID", "i: " + tr("key.close")} {
+		if !strings.Contains(detail, label) {
+			t.Errorf("detail pane is missing %q:\n%s", label, detail)
+		}
+	}
+	if strings.Contains(detail, "Device ID") {
+		t.Errorf("detail pane still has English labels:\n%s", detail)
+	}
+
+	m.light[0].Status, m.light[1].Status = "on", "on"
+	var out strings.Builder
+	showExitSummary(m, exitSummaryAsk, strings.NewReader("n\n"), &out)
+	if got, want := out.String(), "2 Lampen bleiben an: Light 00 (50%), Light 01 (50%)\n"+tr("exit.ask"); got != want {
+		t.Errorf("exit summary = %q, want %q", got, want)
+	}
+}
+
+// Every language's status and brightness labels fit their columns, the
+// longest durations and device counts included
+func TestCellLabelsFit(t *testing.T) {
+	defer func(previous string) { language = previous }(language)
+
+	updating := testLight()
+	updating.Update = updateInstalling
+	on := testLight()
+	on.Status = "on"
+	unreachable := testLight()
+	unreachable.Reachable = false
+	unreachable.UnreachableSince = time.Now().Add(-59 * time.Minute)
+	lights := make([]Light, 12)
+	for i := range lights {
+		lights[i] = testLight()
+	}
+	lights[0].Status = "on"
+	lights[1].Reachable = false
+	members := make([]int, len(lights))
+	for i := range members {
+		members[i] = i
+	}
+
+	for _, lang := range languages() {
+		language = lang
+		statuses := []string{
+			lightStatusCell(updating),
+			lightStatusCell(on),
+			lightStatusCell(testLight()),
+			lightStatusCell(unreachable),
+			tr("cell.unreachable.since", "99d"),
+			deviceStatusCell(lights, members),
+			deviceStatusCell(lights, members[1:]),
+		}
+		for _, cell := range statuses {
+			if w := ansi.StringWidth(cell); w > statusWidth {
+				t.Errorf("%s: %q is %d wide, the status column %d", lang, ansi.Strip(cell), w, statusWidth)
+			}
+		}
+		if cell := lightBrightnessCell(unreachable); ansi.StringWidth(cell) > brightnessWidth {
+			t.Errorf("%s: %q is wider than the brightness column", lang, ansi.Strip(cell))
+		}
+	}
+
+	language = "de"
+	if got := ansi.Strip(lightStatusCell(unreachable)); got != "UNERREICHBAR (59m)" {
+		t.Errorf("German unreachable cell = %q", got)
+	}
+}
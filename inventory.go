@@ -0,0 +1,516 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// inventoryVersion is the export format's version. Import refuses newer
+// versions rather than guessing at fields it doesn't know.
+const inventoryVersion = 1
+
+// inventory is the export format: everything needed to document a setup and
+// to put names and rooms back on a rebuilt bridge
+type inventory struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	BridgeID   string            `json:"bridge_id,omitempty"`
+	Devices    []inventoryDevice `json:"devices"`
+	Lights     []inventoryLight  `json:"lights"`
+	Rooms      []inventoryGroup  `json:"rooms"`
+	Zones      []inventoryGroup  `json:"zones"`
+	Scenes     []inventoryScene  `json:"scenes"`
+}
+
+// inventoryDevice is a physical device. MAC is its Zigbee address, which
+// stays the same when the device is paired to another bridge.
+type inventoryDevice struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Archetype    string   `json:"archetype,omitempty"`
+	MAC          string   `json:"mac,omitempty"`
+	ModelID      string   `json:"model_id,omitempty"`
+	ProductName  string   `json:"product_name,omitempty"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Software     string   `json:"software_version,omitempty"`
+	Hardware     string   `json:"hardware_platform,omitempty"`
+	Lights       []string `json:"lights,omitempty"` // light service IDs, in the device's order
+}
+
+type inventoryLight struct {
+	ID           string                `json:"id"`
+	DeviceID     string                `json:"device_id"`
+	Name         string                `json:"name"`
+	Room         string                `json:"room,omitempty"`
+	Archetype    string                `json:"archetype,omitempty"`
+	Capabilities inventoryCapabilities `json:"capabilities"`
+}
+
+type inventoryCapabilities struct {
+	Dimmable         bool `json:"dimmable"`
+	Color            bool `json:"color"`
+	ColorTemperature bool `json:"color_temperature"`
+	MirekMin         int  `json:"mirek_min,omitempty"`
+	MirekMax         int  `json:"mirek_max,omitempty"`
+	GradientPoints   int  `json:"gradient_points,omitempty"`
+}
+
+// inventoryGroup is a room, whose members are devices, or a zone, whose
+// members are lights
+type inventoryGroup struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Archetype string   `json:"archetype,omitempty"`
+	Members   []string `json:"members"`
+}
+
+type inventoryScene struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"` // room or zone name
+}
+
+// inventoryStep is one write import would make
+type inventoryStep struct {
+	method string
+	path   string
+	body   any
+	why    string
+}
+
+// runInventoryCommand runs the export or import subcommand
+func runInventoryCommand(command, outPath string, args []string, dryRun bool) error {
+	switch command {
+	case "export":
+		inv, err := buildInventory()
+		if err != nil {
+			return err
+		}
+		return writeInventory(inv, outPath)
+	case "import":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: import [--dry-run] <file>")
+		}
+		inv, err := readInventory(args[0])
+		if err != nil {
+			return err
+		}
+		steps, notes, err := planImport(inv)
+		if err != nil {
+			return err
+		}
+		return applyImport(steps, notes, dryRun, os.Stdout)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// zigbeeMACs maps device IDs to their Zigbee MAC addresses
+func zigbeeMACs() (map[string]string, error) {
+	var resp struct {
+		Data []struct {
+			Owner      resourceRef `json:"owner"`
+			MACAddress string      `json:"mac_address"`
+		} `json:"data"`
+	}
+	if err := clipGet("resource/zigbee_connectivity", &resp); err != nil {
+		return nil, err
+	}
+	macs := make(map[string]string, len(resp.Data))
+	for _, conn := range resp.Data {
+		if conn.MACAddress != "" {
+			macs[conn.Owner.Rid] = strings.ToLower(conn.MACAddress)
+		}
+	}
+	return macs, nil
+}
+
+// getDevices lists every device on the bridge
+func getDevices() ([]DeviceResource, error) {
+	var devices []DeviceResource
+	if err := bridgeCache.list("device", &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// buildInventory reads the bridge's devices, lights, rooms, zones and scenes
+func buildInventory() (inventory, error) {
+	inv := inventory{Version: inventoryVersion, ExportedAt: time.Now().UTC()}
+	if bridge, err := getBridge(); err == nil {
+		inv.BridgeID = bridge.BridgeID
+	}
+
+	devices, err := getDevices()
+	if err != nil {
+		return inv, fmt.Errorf("error fetching devices: %v", err)
+	}
+	macs, err := zigbeeMACs()
+	if err != nil {
+		// Import can still match by ID on the same bridge
+		logError("Failed to fetch Zigbee addresses: %v", err)
+	}
+	groups, err := getGroups()
+	if err != nil {
+		return inv, fmt.Errorf("error fetching rooms and zones: %v", err)
+	}
+	lights, err := cachedLights()
+	if err != nil {
+		return inv, fmt.Errorf("error fetching lights: %v", err)
+	}
+
+	roomOfDevice := make(map[string]string)
+	for _, group := range groups {
+		entry := inventoryGroup{ID: group.ID, Name: group.Metadata.Name, Archetype: group.Metadata.Archetype}
+		for _, child := range group.Children {
+			entry.Members = append(entry.Members, child.Rid)
+			if group.Type == "room" {
+				roomOfDevice[child.Rid] = group.Metadata.Name
+			}
+		}
+		if group.Type == "room" {
+			inv.Rooms = append(inv.Rooms, entry)
+		} else {
+			inv.Zones = append(inv.Zones, entry)
+		}
+	}
+
+	for _, device := range devices {
+		entry := inventoryDevice{
+			ID:           device.ID,
+			Name:         device.Metadata.Name,
+			Archetype:    device.Metadata.Archetype,
+			MAC:          macs[device.ID],
+			ModelID:      device.ProductData.ModelID,
+			ProductName:  device.ProductData.ProductName,
+			Manufacturer: device.ProductData.ManufacturerName,
+			Software:     device.ProductData.SoftwareVersion,
+			Hardware:     device.ProductData.HardwarePlatformType,
+		}
+		for _, service := range device.Services {
+			if service.Rtype == "light" {
+				entry.Lights = append(entry.Lights, service.Rid)
+			}
+		}
+		inv.Devices = append(inv.Devices, entry)
+	}
+
+	for id, get := range lights {
+		light := lightFromResource(id, get)
+		caps := readCaps(get)
+		entry := inventoryLight{
+			ID:        id,
+			DeviceID:  light.DeviceOwner,
+			Name:      light.Name,
+			Room:      roomOfDevice[light.DeviceOwner],
+			Archetype: light.Type,
+			Capabilities: inventoryCapabilities{
+				Dimmable:         caps.dimmable,
+				Color:            caps.color,
+				ColorTemperature: caps.ct,
+			},
+		}
+		if caps.ct {
+			entry.Capabilities.MirekMin, entry.Capabilities.MirekMax = caps.mirekRange()
+		}
+		if get.Gradient != nil && get.Gradient.PointsCapable != nil {
+			entry.Capabilities.GradientPoints = *get.Gradient.PointsCapable
+		}
+		inv.Lights = append(inv.Lights, entry)
+	}
+
+	scenes, err := getScenes()
+	if err != nil {
+		return inv, err
+	}
+	for _, scene := range scenes {
+		inv.Scenes = append(inv.Scenes, inventoryScene{ID: scene.ID, Name: scene.Name, Group: scene.Room})
+	}
+
+	// Stable output, so exports can be diffed
+	sort.Slice(inv.Devices, func(i, j int) bool { return inv.Devices[i].Name < inv.Devices[j].Name })
+	sort.Slice(inv.Lights, func(i, j int) bool { return inv.Lights[i].Name < inv.Lights[j].Name })
+	sort.Slice(inv.Rooms, func(i, j int) bool { return inv.Rooms[i].Name < inv.Rooms[j].Name })
+	sort.Slice(inv.Zones, func(i, j int) bool { return inv.Zones[i].Name < inv.Zones[j].Name })
+	return inv, nil
+}
+
+// writeInventory writes inv as indented JSON to path, or to stdout when path is empty
+func writeInventory(inv inventory, path string) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d devices, %d lights, %d rooms, %d zones and %d scenes to %s\n",
+		len(inv.Devices), len(inv.Lights), len(inv.Rooms), len(inv.Zones), len(inv.Scenes), path)
+	return nil
+}
+
+// readInventory loads an export, refusing formats newer than this build knows
+func readInventory(path string) (inventory, error) {
+	var inv inventory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return inv, err
+	}
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return inv, fmt.Errorf("%s: %v", path, err)
+	}
+	if inv.Version < 1 || inv.Version > inventoryVersion {
+		return inv, fmt.Errorf("%s: export version %d isn't supported (this build reads up to %d)", path, inv.Version, inventoryVersion)
+	}
+	return inv, nil
+}
+
+// matchDevices maps exported device IDs to devices on this bridge, by Zigbee
+// MAC where both sides have one and by ID otherwise
+func matchDevices(exported []inventoryDevice, current []DeviceResource, macs map[string]string) map[string]DeviceResource {
+	byMAC := make(map[string]DeviceResource)
+	byID := make(map[string]DeviceResource)
+	for _, device := range current {
+		byID[device.ID] = device
+		if mac := macs[device.ID]; mac != "" {
+			byMAC[mac] = device
+		}
+	}
+
+	matched := make(map[string]DeviceResource)
+	for _, device := range exported {
+		if device.MAC != "" {
+			if found, ok := byMAC[strings.ToLower(device.MAC)]; ok {
+				matched[device.ID] = found
+				continue
+			}
+		}
+		if found, ok := byID[device.ID]; ok {
+			matched[device.ID] = found
+		}
+	}
+	return matched
+}
+
+// planImport works out the writes that give the bridge the exported device
+// and light names and room memberships, and creates missing rooms and zones.
+// notes lists what couldn't be matched.
+func planImport(inv inventory) (steps []inventoryStep, notes []string, err error) {
+	current, err := getDevices()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching devices: %v", err)
+	}
+	macs, err := zigbeeMACs()
+	if err != nil {
+		logError("Failed to fetch Zigbee addresses, matching by ID only: %v", err)
+	}
+	groups, err := getGroups()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching rooms and zones: %v", err)
+	}
+	lights, err := cachedLights()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching lights: %v", err)
+	}
+
+	matched := matchDevices(inv.Devices, current, macs)
+
+	// Names: devices first, then their light services by position
+	lightMap := make(map[string]string) // exported light ID -> current light ID
+	for _, device := range inv.Devices {
+		found, ok := matched[device.ID]
+		if !ok {
+			notes = append(notes, fmt.Sprintf("no device on this bridge matches %q", device.Name))
+			continue
+		}
+		if found.Metadata.Name != device.Name {
+			steps = append(steps, inventoryStep{"PUT", "resource/device/" + found.ID,
+				map[string]any{"metadata": map[string]string{"name": device.Name}},
+				fmt.Sprintf("rename device %q to %q", found.Metadata.Name, device.Name)})
+		}
+		var currentLights []string
+		for _, service := range found.Services {
+			if service.Rtype == "light" {
+				currentLights = append(currentLights, service.Rid)
+			}
+		}
+		for i, id := range device.Lights {
+			if i < len(currentLights) {
+				lightMap[id] = currentLights[i]
+			}
+		}
+	}
+	for _, light := range inv.Lights {
+		id, ok := lightMap[light.ID]
+		if !ok {
+			continue
+		}
+		if get, ok := lights[id]; ok && lightFromResource(id, get).Name != light.Name {
+			steps = append(steps, inventoryStep{"PUT", "resource/light/" + id,
+				map[string]any{"metadata": map[string]string{"name": light.Name}},
+				fmt.Sprintf("rename light %q to %q", lightFromResource(id, get).Name, light.Name)})
+		}
+	}
+
+	roomSteps, roomNotes := planGroups("room", inv.Rooms, groups, func(id string) (string, bool) {
+		device, ok := matched[id]
+		return device.ID, ok
+	})
+	zoneSteps, zoneNotes := planGroups("zone", inv.Zones, groups, func(id string) (string, bool) {
+		light, ok := lightMap[id]
+		return light, ok
+	})
+	steps = append(steps, roomSteps...)
+	steps = append(steps, zoneSteps...)
+	notes = append(notes, roomNotes...)
+	notes = append(notes, zoneNotes...)
+	return steps, notes, nil
+}
+
+// planGroups plans the rooms or zones of an export. A device can be in one
+// room only, so devices leave their current room before joining another:
+// shrinking rooms come first, then new and growing ones.
+func planGroups(rtype string, exported []inventoryGroup, current []GroupResource, mapMember func(id string) (string, bool)) (steps []inventoryStep, notes []string) {
+	memberType := "device"
+	if rtype == "zone" {
+		memberType = "light"
+	}
+
+	existing := make(map[string]GroupResource)
+	for _, group := range current {
+		if group.Type == rtype {
+			existing[strings.ToLower(group.Metadata.Name)] = group
+		}
+	}
+
+	// Where each member should end up, for taking devices out of other rooms
+	wanted := make(map[string][]resourceRef)
+	placed := make(map[string]string)
+	for _, group := range exported {
+		var refs []resourceRef
+		for _, member := range group.Members {
+			id, ok := mapMember(member)
+			if !ok {
+				continue
+			}
+			refs = append(refs, resourceRef{Rid: id, Rtype: memberType})
+			placed[id] = strings.ToLower(group.Name)
+		}
+		wanted[strings.ToLower(group.Name)] = refs
+	}
+
+	var shrinking, growing []inventoryStep
+	for name, group := range existing {
+		if _, ok := wanted[name]; ok || rtype != "room" {
+			continue
+		}
+		// A room the export doesn't have keeps its devices, except those
+		// that belong somewhere else now
+		var keep []resourceRef
+		for _, child := range group.Children {
+			if target, ok := placed[child.Rid]; !ok || target == name {
+				keep = append(keep, child)
+			}
+		}
+		if len(keep) != len(group.Children) {
+			shrinking = append(shrinking, inventoryStep{"PUT", "resource/room/" + group.ID,
+				map[string]any{"children": keep},
+				fmt.Sprintf("move %d devices out of room %q", len(group.Children)-len(keep), group.Metadata.Name)})
+		}
+	}
+
+	for _, group := range exported {
+		name := strings.ToLower(group.Name)
+		refs := wanted[name]
+		if len(refs) < len(group.Members) {
+			notes = append(notes, fmt.Sprintf("%d of %d members of %s %q have no match", len(group.Members)-len(refs), len(group.Members), rtype, group.Name))
+		}
+		found, ok := existing[name]
+		if !ok {
+			metadata := map[string]string{"name": group.Name}
+			if group.Archetype != "" {
+				metadata["archetype"] = group.Archetype
+			}
+			growing = append(growing, inventoryStep{"POST", "resource/" + rtype,
+				map[string]any{"metadata": metadata, "children": refs},
+				fmt.Sprintf("create %s %q with %d members", rtype, group.Name, len(refs))})
+			continue
+		}
+		if sameRefs(found.Children, refs) {
+			continue
+		}
+		step := inventoryStep{"PUT", "resource/" + rtype + "/" + found.ID,
+			map[string]any{"children": refs},
+			fmt.Sprintf("set the members of %s %q", rtype, group.Name)}
+		if len(refs) < len(found.Children) {
+			shrinking = append(shrinking, step)
+		} else {
+			growing = append(growing, step)
+		}
+	}
+	return append(shrinking, growing...), notes
+}
+
+// sameRefs reports whether two member lists hold the same resources in any order
+func sameRefs(a, b []resourceRef) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := make(map[string]bool, len(a))
+	for _, ref := range a {
+		ids[ref.Rid] = true
+	}
+	for _, ref := range b {
+		if !ids[ref.Rid] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyImport prints the plan and, unless dryRun, carries it out. Failed
+// steps are reported and the rest still run.
+func applyImport(steps []inventoryStep, notes []string, dryRun bool, out io.Writer) error {
+	for _, note := range notes {
+		fmt.Fprintln(out, "note:", note)
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(out, "Nothing to change")
+		return nil
+	}
+
+	failed := 0
+	for _, step := range steps {
+		body, _ := json.Marshal(step.body)
+		if dryRun {
+			fmt.Fprintf(out, "%s %s %s  # %s\n", step.method, step.path, body, step.why)
+			continue
+		}
+		if _, err := clipWrite(step.method, step.path, step.body); err != nil {
+			if step.method == "POST" {
+				err = limitError(err, limitNoun(strings.TrimPrefix(step.path, "resource/")))
+			}
+			fmt.Fprintf(out, "failed: %s: %v\n", step.why, err)
+			failed++
+			continue
+		}
+		fmt.Fprintln(out, "done:", step.why)
+	}
+	if dryRun {
+		fmt.Fprintf(out, "%d %s planned; run without --dry-run to apply\n", len(steps), pluralize(len(steps), "change", "changes"))
+		return nil
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d changes failed", failed, len(steps))
+	}
+	return nil
+}
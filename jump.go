@@ -0,0 +1,71 @@
+package main
+
+import "strings"
+
+// jumpState is the quick-jump prompt opened with f: typing a prefix moves the
+// cursor to the first row whose name starts with it, without hiding any rows
+type jumpState struct {
+	active bool
+	text   string
+}
+
+// jumpMatches returns the rows whose names start with prefix, ignoring case
+func jumpMatches(rows []tableRow, prefix string) []int {
+	prefix = strings.ToLower(prefix)
+	var matches []int
+	for i, row := range rows {
+		if strings.HasPrefix(strings.ToLower(row.name), prefix) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// nextMatch picks the first match after the cursor, wrapping around
+func nextMatch(matches []int, cursor int) int {
+	for _, row := range matches {
+		if row > cursor {
+			return row
+		}
+	}
+	return matches[0]
+}
+
+// handleJumpKey processes a key while the quick-jump prompt is open
+func (m *lightModel) handleJumpKey(key string) {
+	switch key {
+	case "esc", "enter":
+		m.jump = jumpState{}
+		return
+	case "tab":
+		m.cycleJump()
+		return
+	case "backspace":
+		if len(m.jump.text) > 0 {
+			m.jump.text = m.jump.text[:len(m.jump.text)-1]
+		}
+		return
+	}
+
+	if len(key) != 1 {
+		return
+	}
+
+	if matches := jumpMatches(m.rows, m.jump.text+key); len(matches) > 0 {
+		m.jump.text += key
+		m.cursor = matches[0]
+		return
+	}
+
+	// Pressing the last letter again cycles when it doesn't extend the prefix
+	if strings.HasSuffix(m.jump.text, key) {
+		m.cycleJump()
+	}
+}
+
+// cycleJump moves the cursor to the next row matching the typed prefix
+func (m *lightModel) cycleJump() {
+	if matches := jumpMatches(m.rows, m.jump.text); len(matches) > 0 {
+		m.cursor = nextMatch(matches, m.cursor)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxFooterKeys caps how many bindings the footer shows; ? lists the rest
+const maxFooterKeys = 8
+
+// keyBinding documents keys the handlers respond to. The footer and the ?
+// list are built from these, so a key added to a handler belongs in its
+// keymap too.
+type keyBinding struct {
+	keys   string // as shown, e.g. "←/→ h/l"
+	help   string // i18n key of what the keys do
+	footer bool   // shown in the footer, not only in the ? list
+
+	// when limits the binding to states where it does something, e.g. a
+	// selection to act on; nil means always. The ? list ignores it.
+	when func(m lightModel) bool
+
+	// args are passed to the description, e.g. the brightness step
+	args func() []any
+}
+
+// keyContext is the part of the UI whose keys are active
+type keyContext string
+
+const (
+	keysLights      keyContext = "lights"
+	keysCommand     keyContext = "command"
+	keysJump        keyContext = "jump"
+	keysScenes      keyContext = "scenes"
+	keysSensors     keyContext = "sensors"
+	keysAutomations keyContext = "automations"
+)
+
+func hasSelection(m lightModel) bool { return len(m.selected) > 0 }
+func hasRows(m lightModel) bool      { return len(m.rows) > 0 }
+
+// keymaps lists each context's bindings, most useful first, since the
+// footer shows the first ones that apply
+var keymaps = map[keyContext][]keyBinding{
+	keysLights: {
+		{keys: ":filter", help: "key.filter.clear", footer: true, when: func(m lightModel) bool { return m.filter != "" || m.roomScope != "" }},
+		{keys: "↑/↓ j/k", help: "key.move", footer: true, when: hasRows},
+		{keys: "Space", help: "key.select", footer: true, when: hasRows},
+		{keys: "←/→ h/l", help: "key.brightness", footer: true,
+			when: func(m lightModel) bool { return hasSelection(m) || m.roomsView },
+			args: func() []any { return []any{appConfig.brightnessStep()} }},
+		{keys: "Enter", help: "key.toggle.selected", footer: true, when: hasSelection},
+		{keys: "t", help: "key.toggle.cursor", footer: true, when: hasRows},
+		{keys: ":", help: "key.command", footer: true},
+		{keys: "q", help: "key.quit", footer: true},
+		{keys: "s", help: "key.scenes", footer: true},
+		{keys: "r", help: "key.rooms", footer: true},
+		{keys: "H/L shift+←/→", help: "key.brightness.fine", when: hasSelection,
+			args: func() []any { return []any{fineBrightnessStep} }},
+		{keys: "[/]", help: "key.ct", when: hasSelection},
+		{keys: "c", help: "key.color", when: hasSelection},
+		{keys: "i", help: "key.detail"},
+		{keys: "f", help: "key.jump"},
+		{keys: "S", help: "key.sensors"},
+		{keys: "J/K", help: "key.reorder"},
+		{keys: "gg/G", help: "key.ends"},
+		{keys: "ctrl+d/u", help: "key.halfpage"},
+		{keys: "PgUp/PgDn", help: "key.output"},
+		{keys: "ctrl+e", help: "key.events"},
+		{keys: "ctrl+c", help: "key.quit.now"},
+	},
+	keysCommand: {
+		{keys: "Enter", help: "key.command.run", footer: true},
+		{keys: "Tab", help: "key.command.complete", footer: true},
+		{keys: "←/→", help: "key.command.cursor", footer: true},
+		{keys: "ctrl+a/e", help: "key.command.ends", footer: true},
+		{keys: "Esc", help: "key.cancel", footer: true},
+	},
+	keysJump: {
+		{keys: "Tab", help: "key.jump.next", footer: true},
+		{keys: "Enter/Esc", help: "key.close", footer: true},
+	},
+	keysScenes: {
+		{keys: "j/k", help: "key.move", footer: true},
+		{keys: "Enter", help: "key.scene.activate", footer: true},
+		{keys: "d", help: "key.scene.dynamic", footer: true},
+		{keys: "s/Esc", help: "key.back", footer: true},
+	},
+	keysSensors: {
+		{keys: "j/k", help: "key.move", footer: true},
+		{keys: "Enter", help: "key.enable", footer: true},
+		{keys: "←/→", help: "key.sensitivity", footer: true},
+		{keys: "S/Esc", help: "key.back", footer: true},
+	},
+	keysAutomations: {
+		{keys: "j/k", help: "key.move", footer: true},
+		{keys: "Enter", help: "key.enable", footer: true},
+		{keys: "Esc", help: "key.back", footer: true},
+	},
+}
+
+// keyContext is the context whose keys apply now
+func (m lightModel) keyContext() keyContext {
+	switch {
+	case m.scenePane.open:
+		return keysScenes
+	case m.sensorPane.open:
+		return keysSensors
+	case m.automationPane.open:
+		return keysAutomations
+	case m.commandMode:
+		return keysCommand
+	case m.jump.active:
+		return keysJump
+	}
+	return keysLights
+}
+
+// hasKeyList reports whether ? opens the key list in a context; where keys
+// are typed as text, ? is just a character
+func (c keyContext) hasKeyList() bool {
+	return c != keysCommand && c != keysJump
+}
+
+// helpText is what the keys do, in the UI language
+func (b keyBinding) helpText() string {
+	var args []any
+	if b.args != nil {
+		args = b.args()
+	}
+	return tr(b.help, args...)
+}
+
+// describe renders "keys: what they do"
+func (b keyBinding) describe() string {
+	return glyphText(b.keys) + ": " + b.helpText()
+}
+
+// keyFooter lists the bindings that matter in the current state, as many as
+// fit in width (0 for no limit) up to maxFooterKeys, then how to see them all
+func (m lightModel) keyFooter(context keyContext, width int) string {
+	var suffix string
+	if context.hasKeyList() {
+		suffix = "?: " + tr("key.all")
+	}
+	var parts []string
+	used := ansi.StringWidth(suffix)
+	for _, b := range keymaps[context] {
+		if len(parts) == maxFooterKeys {
+			break
+		}
+		if !b.footer || (b.when != nil && !b.when(m)) {
+			continue
+		}
+		part := b.describe()
+		if width > 0 && used+ansi.StringWidth(part)+3 > width {
+			break
+		}
+		parts = append(parts, part)
+		used += ansi.StringWidth(part) + 3
+	}
+	if suffix != "" {
+		parts = append(parts, suffix)
+	}
+	return strings.Join(parts, glyphText(" • "))
+}
+
+// keyList is the ? overlay listing every key of a context
+type keyList struct {
+	open    bool
+	context keyContext
+}
+
+// renderKeyList draws the ? overlay
+func (m lightModel) renderKeyList() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	keyStyle := lipgloss.NewStyle().Bold(true)
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(tr("keys.title")) + "\n\n")
+	for _, binding := range keymaps[m.keyList.context] {
+		b.WriteString("  " + keyStyle.Render(fitCell(glyphText(binding.keys), 14)) + " " + binding.helpText() + "\n")
+	}
+	b.WriteString("\n" + faint.Render(tr("keys.close")))
+	return viewText(b.String()) + "\n"
+}
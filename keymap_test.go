@@ -0,0 +1,105 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// keyLabels maps the labels used in keymaps to the names bubbletea reports
+var keyLabels = map[string]string{
+	"↑": "up", "↓": "down", "←": "left", "→": "right",
+	"Space": " ", "Enter": "enter", "Esc": "esc", "Tab": "tab",
+	"PgUp": "pgup", "PgDn": "pgdown",
+}
+
+// documentedKeys lists the key names a label such as "←/→ h/l" or
+// "ctrl+d/u" stands for
+func documentedKeys(label string) []string {
+	var keys []string
+	for _, word := range strings.Fields(label) {
+		var modifier string
+		for _, part := range strings.Split(word, "/") {
+			if i := strings.LastIndex(part, "+"); i > 0 {
+				modifier, part = part[:i+1], part[i+1:]
+			}
+			if name, ok := keyLabels[part]; ok {
+				part = name
+			}
+			keys = append(keys, modifier+part)
+		}
+	}
+	return keys
+}
+
+// lightsSwitchKeys reads the keys the table's key switch in update handles,
+// found as the switch with a case for "q"
+func lightsSwitchKeys(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "lights.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		sw, ok := n.(*ast.SwitchStmt)
+		if !ok || keys != nil {
+			return keys == nil
+		}
+		var cases []string
+		for _, stmt := range sw.Body.List {
+			for _, expr := range stmt.(*ast.CaseClause).List {
+				if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					key, _ := strconv.Unquote(lit.Value)
+					cases = append(cases, key)
+				}
+			}
+		}
+		for _, key := range cases {
+			if key == "q" {
+				keys = cases
+			}
+		}
+		return true
+	})
+	if keys == nil {
+		t.Fatal("no key switch found in lights.go")
+	}
+	return keys
+}
+
+func TestLightKeysAreDocumented(t *testing.T) {
+	documented := map[string]bool{}
+	for _, b := range keymaps[keysLights] {
+		for _, key := range documentedKeys(b.keys) {
+			documented[key] = true
+		}
+	}
+	for _, key := range lightsSwitchKeys(t) {
+		if !documented[key] {
+			t.Errorf("key %q is handled but missing from keymaps[keysLights]", key)
+		}
+	}
+}
+
+func TestDocumentedKeys(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"←/→ h/l", "left,right,h,l"},
+		{"H/L shift+←/→", "H,L,shift+left,shift+right"},
+		{"ctrl+d/u", "ctrl+d,ctrl+u"},
+		{"Space", " "},
+		{"[/]", "[,]"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(documentedKeys(tt.label), ","); got != tt.want {
+			t.Errorf("documentedKeys(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// latencySamples is how many recent bridge requests the average covers
+const latencySamples = 20
+
+// Latencies above these are shown in warning colors
+const (
+	latencySlow     = 200 * time.Millisecond
+	latencyVerySlow = time.Second
+)
+
+var (
+	latencySlowStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C"))
+	latencyVerySlowStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF5555"))
+)
+
+// latencyRing keeps the most recent bridge request durations. Requests run on
+// several goroutines, so it's locked.
+type latencyRing struct {
+	mu      sync.Mutex
+	samples [latencySamples]time.Duration
+	count   int // samples filled, up to latencySamples
+	next    int // where the next sample goes
+}
+
+// bridgeLatency measures every request sent through bridgeClient
+var bridgeLatency latencyRing
+
+func (r *latencyRing) record(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % latencySamples
+	r.count = min(r.count+1, latencySamples)
+}
+
+// average is the mean of the recent samples, and false before the first request
+func (r *latencyRing) average() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, d := range r.samples[:r.count] {
+		total += d
+	}
+	return total / time.Duration(r.count), true
+}
+
+// latencyTransport times requests to the bridge. The event stream stays open
+// for the whole session, so it isn't counted.
+type latencyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *latencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && !strings.HasPrefix(req.URL.Path, "/eventstream") {
+		bridgeLatency.record(time.Since(start))
+	}
+	return resp, err
+}
+
+// renderLatency shows the average latency for the summary line, e.g.
+// "bridge 34ms", colored when it's slow; "" before any request
+func renderLatency() string {
+	avg, ok := bridgeLatency.average()
+	if !ok {
+		return ""
+	}
+	text := tr("summary.latency", avg.Milliseconds())
+	switch {
+	case avg > latencyVerySlow:
+		return latencyVerySlowStyle.Render(text)
+	case avg > latencySlow:
+		return latencySlowStyle.Render(text)
+	}
+	return summaryStyle.UnsetMarginLeft().Render(text)
+}
+
+// pingCommand handles ":ping", timing one small request to the bridge
+func (m *lightModel) pingCommand() error {
+	if bridgeIP == "" || apiKey == "" {
+		return fmt.Errorf("bridge configuration not initialized")
+	}
+	ctx, cancel := context.WithTimeout(appCtx, 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := bridgeClient().Do(ctx, "GET", "clip/v2/resource/bridge", nil, nil); err != nil {
+		return fmt.Errorf("bridge didn't answer: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	summary := tr("status.ping", elapsed.Milliseconds())
+	if avg, ok := bridgeLatency.average(); ok {
+		summary += tr("status.ping.average", avg.Milliseconds())
+	}
+	m.setStatus("%s", summary)
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The smallest terminal the TUI draws in; below it only a notice is shown
+const (
+	minTerminalWidth  = 60
+	minTerminalHeight = 16
+)
+
+// compactNameWidth is the name column's width in the compact layout
+const compactNameWidth = 20
+
+// compactColumns are the columns the compact layout keeps, when configured
+var compactColumns = []string{"name", "status", "brightness"}
+
+// tooSmall reports whether the terminal is below the minimum size. Until the
+// first WindowSizeMsg the size is unknown and assumed to be big enough.
+func (m lightModel) tooSmall() bool {
+	return m.width > 0 && (m.width < minTerminalWidth || m.height < minTerminalHeight)
+}
+
+// renderTooSmall is shown instead of the UI in a terminal below the minimum size
+func (m lightModel) renderTooSmall() string {
+	return viewText(lipgloss.NewStyle().Faint(true).Render(tr("layout.too_small", minTerminalWidth, minTerminalHeight))) + "\n"
+}
+
+// tableWidth is how wide the boxed table is with columns: the cursor and
+// checkmark, the cells and the gaps between them, and the box's padding and
+// border
+func tableWidth(columns []column) int {
+	width := 4 + 2*(len(columns)-1) + 2*2 + 2
+	for _, col := range columns {
+		width += col.width
+	}
+	return width
+}
+
+// nameColumnWidth is the name column's width in the current layout
+func (m lightModel) nameColumnWidth() int {
+	if m.compact {
+		return compactNameWidth
+	}
+	return nameWidth
+}
+
+// layoutColumns returns the columns to render: the configured ones, or in
+// the compact layout those of them that matter most, with a narrower name
+func (m lightModel) layoutColumns() []column {
+	columns := appConfig.visibleColumns()
+	if !m.compact {
+		return columns
+	}
+	var kept []column
+	for _, col := range columns {
+		if !slices.Contains(compactColumns, col.key) {
+			continue
+		}
+		if col.key == "name" {
+			col.width = compactNameWidth
+		}
+		kept = append(kept, col)
+	}
+	return kept
+}
+
+// renderCompactStatus replaces the command box in the compact layout: the
+// box only opens for : and jumps, and otherwise the status's first line is
+// shown on its own
+func (m lightModel) renderCompactStatus() string {
+	if m.commandMode || m.jump.active {
+		return m.renderCommandBox()
+	}
+	status, _, _ := strings.Cut(m.status, "\n")
+	if status == "" {
+		status = tr("box.hint")
+	}
+	return lipgloss.NewStyle().MarginLeft(2).Render(ansi.Truncate(status, max(0, m.width-3), glyphText("…")))
+}
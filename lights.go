@@ -1,55 +1,71 @@
 package main
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/openhue/openhue-go"
+	"github.com/sethchev/hue-control-tui/pkg/hueclient"
 )
 
 type Light struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Type        string  `json:"type"`
-	Status      string  `json:"status"`
-	Brightness  float32 `json:"brightness"`
-	Reachable   bool    `json:"reachable"`
-	DeviceOwner string  `json:"device_owner"` // Device ID for connectivity lookup
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	Brightness  float32    `json:"brightness"`
+	Reachable   bool       `json:"reachable"`
+	Caps        capability `json:"-"`             // What the light supports; on/off devices such as smart plugs lack capDimming
+	MinDimLevel float32    `json:"min_dim_level"` // Lowest brightness the light can show, in percent
+	DeviceOwner string     `json:"device_owner"`  // Device ID for connectivity lookup
+	DeviceName  string     `json:"device_name"`   // Owning device's name, which can differ per light service
+
+	Gradient       []xyColor `json:"-"` // Gradient points, for lightstrips that show several colors at once
+	GradientPoints int       `json:"-"` // Most gradient points the light can show; 0 for non-gradient lights
+	Color          *xyColor  `json:"-"` // Current color, when the light is in color mode
+	Mirek          int       `json:"-"` // Current color temperature, when the light is in white mode
+	MirekMin       int       `json:"-"` // Color temperature range from the light's mirek_schema; 0 without color temperature
+	MirekMax       int       `json:"-"`
+	Dynamics       string    `json:"dynamics,omitempty"` // The bridge's dynamics status while one is playing, "" when none
+
+	Product productInfo `json:"product"`          // The owning device's product data; third-party bulbs may leave parts empty
+	Update  string      `json:"update,omitempty"` // The owning device's firmware update state, "" when there is none
+
+	LastSeen         time.Time `json:"last_seen"`         // Last SSE event or successful state read
+	UnreachableSince time.Time `json:"unreachable_since"` // Zero when reachable or unknown
+
+	ChangedBy string    `json:"changed_by"` // Who last changed on/off or brightness this session: changedByMe, changedExternally or ""
+	ChangedAt time.Time `json:"changed_at"` // When ChangedBy was set
 }
 
 type Scene struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Type string `json:"type"`
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Group   string  `json:"group"`   // Room or zone ID the scene belongs to
+	Room    string  `json:"room"`    // Room or zone name
+	Status  string  `json:"status"`  // "inactive", "static" or "dynamic_palette"
+	Dynamic bool    `json:"dynamic"` // Has a palette to play dynamically
+	Speed   float32 `json:"speed"`   // Dynamic palette speed, 0-1
+	Smart   bool    `json:"smart"`   // A smart scene; Status is then "active" or "inactive"
+	Lights  int     `json:"lights"`  // Lights the scene sets, from its actions
 }
 
-// ZigbeeConnectivity represents the connectivity status of a Zigbee device
-type ZigbeeConnectivity struct {
-	ID    string `json:"id"`
-	IDV1  string `json:"id_v1"`
-	Owner struct {
-		Rid   string `json:"rid"`
-		Rtype string `json:"rtype"`
-	} `json:"owner"`
-	Status string `json:"status"` // "connected", "disconnected", etc.
-	Type   string `json:"type"`
-}
+// clockTickMsg re-renders time-dependent parts of the view
+type clockTickMsg time.Time
 
-// ZigbeeConnectivityResponse wraps the API response
-type ZigbeeConnectivityResponse struct {
-	Errors []interface{}        `json:"errors"`
-	Data   []ZigbeeConnectivity `json:"data"`
-}
+// clockTickInterval is how often relative times like "UNREACHABLE (2h)" refresh
+const clockTickInterval = 30 * time.Second
 
-type SSEMsg struct {
-	Data []byte
+func clockTick() tea.Cmd {
+	return tea.Tick(clockTickInterval, func(t time.Time) tea.Msg {
+		return clockTickMsg(t)
+	})
 }
 
 // Minimal SSE parsing types for filtering "light" and "zigbee_connectivity" events
@@ -64,293 +80,696 @@ type SSEDataItem struct {
 	Dimming *struct {
 		Brightness float64 `json:"brightness"`
 	} `json:"dimming,omitempty"`
+	Color *struct {
+		XY struct {
+			X float64 `json:"x"`
+			Y float64 `json:"y"`
+		} `json:"xy"`
+	} `json:"color,omitempty"`
+	ColorTemperature *struct {
+		Mirek      *int `json:"mirek"`
+		MirekValid bool `json:"mirek_valid"`
+	} `json:"color_temperature,omitempty"`
+	Gradient *GradientState `json:"gradient,omitempty"`
+	Dynamics *struct {
+		Status string `json:"status"` // "none", "dynamic_palette" or an effect
+	} `json:"dynamics,omitempty"`
 	Owner *struct {
 		Rid   string `json:"rid"`
 		Rtype string `json:"rtype"`
 	} `json:"owner,omitempty"`
-	Status string `json:"status,omitempty"` // For zigbee_connectivity: "connected" or "disconnected"
+	Status  sseStatus          `json:"status,omitempty"`
+	Speed   *float64           `json:"speed,omitempty"`   // For scenes
+	Actions *[]json.RawMessage `json:"actions,omitempty"` // For scenes; only counted
+	State   string             `json:"state,omitempty"`   // For smart scenes: "active" or "inactive"
+
+	// For motion sensors
+	Enabled *bool `json:"enabled,omitempty"`
+	Motion  *struct {
+		Motion       bool          `json:"motion"`
+		MotionReport *motionReport `json:"motion_report"`
+	} `json:"motion,omitempty"`
+	Sensitivity *struct {
+		Sensitivity    int `json:"sensitivity"`
+		SensitivityMax int `json:"sensitivity_max"`
+	} `json:"sensitivity,omitempty"`
+
+	// For contact sensors; tamper reports are a pointer so an empty list is
+	// told apart from an absent one
+	ContactReport *contactReport  `json:"contact_report,omitempty"`
+	TamperReports *[]tamperReport `json:"tamper_reports,omitempty"`
+
+	// For phones used by home and away automations
+	IsAtHome *bool `json:"is_at_home,omitempty"`
+
+	// For switches
+	Button *struct {
+		ButtonReport *buttonReport `json:"button_report"`
+	} `json:"button,omitempty"`
+	PowerState *struct {
+		BatteryLevel *int `json:"battery_level"`
+	} `json:"power_state,omitempty"`
 }
 
-type SSEUpdate struct {
-	CreationTime string        `json:"creationtime"`
-	Data         []SSEDataItem `json:"data"`
-	ID           string        `json:"id"`
-	Type         string        `json:"type"`
+// sseStatus is an item's "status": a string for zigbee_connectivity
+// ("connected" or "disconnected") and an object for scenes ({"active": "static"})
+type sseStatus struct {
+	State  string
+	Active string
+}
+
+func (s *sseStatus) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &s.State)
+	}
+	var status struct {
+		Active string `json:"active"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return err
+	}
+	s.Active = status.Active
+	return nil
 }
 
 type lightModel struct {
 	light       []Light
+	rows        []tableRow // table layout over light; the cursor indexes into this
 	cursor      int
 	selected    map[int]struct{}
-	sseChannel  chan []byte
+	sseChannel  chan sseMessage
 	commandMode bool
 	commandText string
+	commandPos  int // cursor position in commandText, in runes
+
+	status     string    // last command or action result, shown in the command box
+	statusAt   time.Time // when setStatus last changed status
+	macroDepth int       // nesting of macro run, to stop macros that call themselves
+
+	ramp      dimmingRamp                // hold-to-dim state
+	sceneKeys map[string]sceneKeyBinding // scene hotkeys by key name
+	pending   keyPrefix                  // count prefix and half-typed gg
+	jump      jumpState                  // quick-jump prompt
+	height    int                        // terminal height, for half-page jumps
+	width     int                        // terminal width, for the compact layout
+	compact   bool                       // set on the copy being drawn in the compact layout
+	updatedAt time.Time                  // last refresh or SSE update of the light list
+
+	groups        map[string]lightGroup    // rooms and zones by grouped_light ID
+	pendingGroups map[string][]SSEDataItem // events for groups still being fetched
+
+	sseLog          []sseLogEntry // recent raw SSE payloads, newest last
+	ssePane         ssePane       // ctrl+e debug pane
+	sseReconnecting bool          // the event stream was dropped and hasn't sent anything since
+	keyList         keyList       // ? overlay listing the active keys
+
+	snapshotRequests chan chan lightSnapshot // status server requests, nil without --listen
+
+	search                 *lightSearch         // running :search, nil otherwise
+	pendingDelete          *deleteRequest       // :delete awaiting confirmation
+	mirror                 mirrorState          // :mirror leader and followers
+	away                   *awayMode            // running :away, nil otherwise
+	awayGeneration         int                  // counts :away sessions so stale ticks are dropped
+	scenePane              scenePane            // scenes view
+	entertainment          []entertainmentArea  // entertainment areas, which lock their lights while streaming
+	roomTimers             map[string]roomTimer // :room off-in countdowns by grouped_light ID
+	roomTimerTicking       bool                 // a room timer tick is pending
+	connectivityPaused     bool                 // periodic connectivity checks are paused
+	connectivityGeneration int                  // drops periodic ticks scheduled before a resume
+
+	output       []string  // command results shown in the command box, oldest first
+	outputScroll int       // lines the output view is scrolled back from the newest
+	outputAt     time.Time // when output last grew
+
+	picker colorPicker // c color picker
+	detail detailPane  // i light details
+
+	sensorPane sensorPane // S sensors view
+
+	automationPane automationPane // :automations view
+
+	roomsView bool   // r groups the table by room
+	filter    string // :filter text; only matching lights are shown
+	roomScope string // the room --room limits the table to, if any
+
+	flashes      map[string]rowFlash // rows tinted after a change, by light ID
+	flashPending bool                // a flashExpiredMsg is on its way
+
+	unreachablePrompt unreachablePrompt // warning about unreachable lights awaiting a second press
+
+	brightnessIntents    map[string]brightnessIntent // brightness keypresses not yet confirmed by the bridge, by light ID
+	brightnessGeneration int                         // drops flushes scheduled before the latest keypress
+
+	signal           *signalRun // running :signal, nil otherwise
+	signalGeneration int        // counts :signal runs so stale timers are dropped
+	queued           []tea.Cmd  // background work started by commands, run after they finish
+	inCommand        bool       // a command line is running, so write results go to the output too
+	writesFailed     bool       // a background write failed, for --exec-quit's exit code
+	startup          tea.Cmd    // background work started by --exec or the restored view, run by Init
+
+	lastClickRow int       // row of the previous left click, for double-click detection
+	lastClickAt  time.Time // time of the previous left click
+
+	quitting     bool // waiting for in-flight writes before exiting
+	shutdownSlow bool // quitting has taken long enough to tell the user
+
+	notifications        []notification
+	notificationOverflow int // notifications dropped by the cap since the stack last emptied
 }
 
-func initialModel(lights []Light, sseChannel chan []byte) lightModel {
+func initialModel(lights []Light, sseChannel chan sseMessage) lightModel {
 	var listLights []Light
 
 	listLights = append(listLights, lights...)
 
 	return lightModel{
 		light:       listLights,
+		rows:        buildRows(listLights),
 		selected:    make(map[int]struct{}),
 		sseChannel:  sseChannel,
 		commandMode: false,
 		commandText: "",
+		updatedAt:   time.Now(),
 	}
 }
 
 func (m lightModel) Init() tea.Cmd {
+	return tea.Batch(m.listenForSSE(), clockTick(), m.listenForSnapshotRequests(), listenForRetries(), remotePoll(), connectivityTick(0), m.startup)
+}
+
+// listenForSSE waits for the next SSE payload from the subscription goroutine
+func (m lightModel) listenForSSE() tea.Cmd {
 	return func() tea.Msg {
-		data := <-m.sseChannel
-		return SSEMsg{Data: data}
+		return <-m.sseChannel
 	}
 }
 
-func (m lightModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case SSEMsg:
-		// Parse SSE JSON and handle only inner items of type "light"
-		var updates []SSEUpdate
-		if err := json.Unmarshal(msg.Data, &updates); err != nil {
-			log.Printf("SSE: failed to parse JSON: %v", err)
-			log.Printf("raw: %s", string(msg.Data))
-			return m, m.Init()
-		}
-
-		for _, upd := range updates {
-			// top-level update.Type may be "update" etc.; iterate inner data
-			for _, item := range upd.Data {
-				// Handle light events
-				if item.Type == "light" {
-					m = m.handleLightUpdate(item)
-				} else if item.Type == "zigbee_connectivity" {
-					// Handle connectivity events
-					m = m.handleConnectivityUpdate(item)
-				}
-			}
+// Update handles a message, recovering from a panic in any handler so one bad
+// message can't take down the TUI
+func (m lightModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			model, cmd = m.recoverFromPanic(msg, r)
 		}
+	}()
+	return m.update(msg)
+}
 
-		return m, m.Init()
+func (m lightModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sseEventsMsg:
+		m.logSSE(msg.raw, nil)
+		return m.handleSSEEvents(msg)
+	case sseStreamMsg:
+		m.sseReconnecting = msg.reconnecting
+		if msg.resumed {
+			return m, tea.Batch(m.listenForSSE(), reloadGroups(m.light))
+		}
+		return m, m.listenForSSE()
+	case sseErrorMsg:
+		m.logSSE(msg.raw, msg.err)
+		logError("SSE: failed to parse JSON: %v", msg.err)
+		logDebug("raw: %s", string(msg.raw))
+		return m, m.listenForSSE()
+	case groupLoadedMsg:
+		return m.handleGroupLoaded(msg)
+	case groupsLoadedMsg:
+		m.handleGroupsLoaded(msg)
+	case searchTickMsg:
+		return m, m.handleSearchTick()
+	case awayTickMsg:
+		return m, m.handleAwayTick(msg)
+	case roomTimerTickMsg:
+		return m, m.handleRoomTimerTick(time.Now())
+	case connectivityTickMsg:
+		return m, m.handleConnectivityTick(msg)
+	case connectivityCheckedMsg:
+		return m, m.handleConnectivityChecked(msg)
+	case retryNotice:
+		m.handleRetryNotice(msg)
+		return m, listenForRetries()
+	case signalDoneMsg:
+		m.handleSignalDone(msg)
+	case remotePollMsg:
+		return m, pollLights()
+	case writesDoneMsg:
+		m.handleWritesDone(msg)
+	case lightsRefreshedMsg:
+		if msg.err != nil {
+			logError("Failed to refresh lights: %v", msg.err)
+		} else {
+			m.replaceLights(msg.lights)
+		}
+	case lightsPolledMsg:
+		if msg.err != nil {
+			logError("Failed to poll lights: %v", msg.err)
+		} else {
+			m.replaceLights(msg.lights)
+		}
+		return m, remotePoll()
+	case snapshotRequestMsg:
+		m.answerSnapshot(msg)
+		return m, m.listenForSnapshotRequests()
+	case shutdownCompleteMsg:
+		if msg.timedOut {
+			logError("Timed out waiting for pending writes during shutdown")
+		}
+		return m, tea.Quit
+	case rampTickMsg:
+		return m, m.handleRampTick()
+	case brightnessFlushMsg:
+		m.handleBrightnessFlush(msg)
+	case scenesLoadedMsg:
+		m.handleScenesLoaded(msg)
+	case pickerTickMsg:
+		m.handlePickerTick()
+	case shutdownSlowMsg:
+		m.shutdownSlow = true
+	case notificationExpiredMsg:
+		m.pruneNotifications(time.Now())
+	case flashExpiredMsg:
+		return m, m.handleFlashExpired(time.Now())
+	case clockTickMsg:
+		return m, clockTick()
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+	case tea.MouseMsg:
+		if m.quitting || m.commandMode || m.keyList.open || m.scenePane.open || m.sensorPane.open || m.automationPane.open || m.picker.open || m.detail.open {
+			return m, nil
+		}
+		m.handleMouse(msg)
 	case tea.KeyMsg:
+		if m.quitting {
+			return m, nil
+		}
+		if msg.String() == "ctrl+c" {
+			return m.beginShutdown()
+		}
+		if m.keyList.open {
+			// Any key closes the list
+			m.keyList = keyList{}
+			return m, nil
+		}
+		if context := m.keyContext(); msg.String() == "?" && context.hasKeyList() && !m.ssePane.open && !m.picker.open && !m.detail.open {
+			m.keyList = keyList{open: true, context: context}
+			return m, nil
+		}
+		if m.ssePane.open {
+			m.handleSSEPaneKey(msg.String())
+			return m, nil
+		}
+		if m.scenePane.open {
+			m.handleScenePaneKey(msg.String())
+			return m, nil
+		}
+		if m.sensorPane.open {
+			m.handleSensorPaneKey(msg.String())
+			return m, nil
+		}
+		if m.automationPane.open {
+			m.handleAutomationPaneKey(msg.String())
+			return m, nil
+		}
+		if m.picker.open {
+			cmd := m.handlePickerKey(msg.String())
+			return m, tea.Batch(cmd, m.takeQueued())
+		}
+		if m.detail.open {
+			m.handleDetailKey(msg.String())
+			return m, nil
+		}
+		if m.jump.active {
+			m.handleJumpKey(msg.String())
+			return m, nil
+		}
 		if m.commandMode {
 			switch msg.String() {
-			case "escape":
+			case "esc", "escape":
+				if m.pendingDelete != nil {
+					m.pendingDelete = nil
+					m.setStatusTr("status.delete.cancelled")
+				}
 				m.commandMode = false
-				m.commandText = ""
+				m.commandText, m.commandPos = "", 0
 			case "enter":
+				if m.pendingDelete != nil {
+					m.confirmDelete(m.commandText)
+					m.commandMode = false
+					m.commandText, m.commandPos = "", 0
+					return m, nil
+				}
 				m.executeCommand(m.commandText)
-				m.commandMode = false
-				m.commandText = ""
-			case "backspace":
-				if len(m.commandText) > 0 {
-					m.commandText = m.commandText[:len(m.commandText)-1]
+				// :delete keeps the box open for the confirmation
+				m.commandMode = m.pendingDelete != nil
+				m.commandText, m.commandPos = "", 0
+				return m, m.takeQueued()
+			case "tab":
+				completed, candidates := completeCommand(m.commandText, appConfig.Aliases)
+				m.commandText = completed
+				m.commandPos = len([]rune(completed))
+				if len(candidates) > 1 {
+					m.setStatus("%s", strings.Join(candidates, "  "))
 				}
 			default:
-				// Add character to command text
-				if len(msg.String()) == 1 {
-					m.commandText += msg.String()
+				// Cursor movement, deletion, and typed or pasted text
+				m.commandText, m.commandPos, _ = editLine(m.commandText, m.commandPos, msg)
+				if msg.Type == tea.KeyRunes && len([]rune(m.commandText)) >= maxCommandLength {
+					m.setStatusTr("status.command.too_long", maxCommandLength)
 				}
 			}
 		} else {
+			// Counts and gg span several keypresses
+			if m.consumePrefixKey(msg.String()) {
+				return m, nil
+			}
+			count, counted := m.takeCount()
+
 			switch msg.String() {
 			// These keys should exit the program.
-			case "ctrl+c", "q":
-				return m, tea.Quit
+			case "q":
+				return m.beginShutdown()
 
 			// Open command mode
 			case ":":
 				m.commandMode = true
-				m.commandText = ""
+				m.commandText, m.commandPos = "", 0
+
+			// Scroll command output without leaving the table
+			case "pgup":
+				m.scrollOutput(outputViewLines)
+			case "pgdown":
+				m.scrollOutput(-outputViewLines)
+
+			// Raw SSE traffic for debugging
+			case "ctrl+e":
+				m.ssePane = ssePane{open: true}
+
+			// Browse and recall scenes
+			case "s":
+				return m, m.openScenes()
+
+			// Motion sensors
+			case "S":
+				m.openSensors()
+
+			// Quick-jump to a light by typing the start of its name
+			case "f":
+				m.jump = jumpState{active: true}
+
+			// Group the table by room
+			case "r":
+				m.toggleRoomsView()
+
+			// Pick a color for the selected lights
+			case "c":
+				m.openPicker()
+
+			// Show what's known about the cursor light
+			case "i":
+				m.openDetail()
 
 			// The "up" and "k" keys move the cursor up
 			case "up", "k":
-				if m.cursor > 0 {
-					m.cursor--
-				}
+				m.moveCursorTo(m.cursor - count)
 
 			// The "down" and "j" keys move the cursor down
 			case "down", "j":
-				if m.cursor < len(m.light)-1 {
-					m.cursor++
+				m.moveCursorTo(m.cursor + count)
+
+			// Shift+j/k move the cursor row and save the order
+			case "K":
+				if err := m.moveCursorRow(-1); err != nil {
+					m.setStatusTr("status.error", err)
+				}
+
+			case "J":
+				if err := m.moveCursorRow(1); err != nil {
+					m.setStatusTr("status.error", err)
+				}
+
+			// G jumps to the last row, or to row N with a count
+			case "G":
+				if counted {
+					m.moveCursorTo(count - 1)
+				} else {
+					m.moveCursorTo(len(m.rows) - 1)
 				}
 
+			// Half-page jumps
+			case "ctrl+d":
+				m.moveCursorTo(m.cursor + count*m.halfPage())
+
+			case "ctrl+u":
+				m.moveCursorTo(m.cursor - count*m.halfPage())
+
+			// Holding these keys ramps brightness smoothly
 			case "right", "l":
+				if room, ok := m.cursorRoom(); ok {
+					m.adjustRoomBrightness(room, count*appConfig.brightnessStep())
+				} else if len(m.selected) > 0 && m.confirmUnreachable("brighter") {
+					return m, m.brightnessKey(1, count*appConfig.brightnessStep())
+				}
+
+			case "left", "h":
+				if room, ok := m.cursorRoom(); ok {
+					m.adjustRoomBrightness(room, -count*appConfig.brightnessStep())
+				} else if len(m.selected) > 0 && m.confirmUnreachable("dimmer") {
+					return m, m.brightnessKey(-1, count*appConfig.brightnessStep())
+				}
+
+			// Fine adjustment for the bottom of the range
+			case "shift+right", "L":
 				if len(m.selected) > 0 {
-					for index := range m.selected {
-						if !m.light[index].Reachable {
-							log.Printf("Skipping unreachable light %s", m.light[index].Name)
-							continue
-						}
-						lightID := m.light[index].ID
-						lightBright, err := setLightBrightness(lightID, 10)
-						if err != nil {
-							log.Printf("Error setting light brightness for %s: %v", lightID, err)
-							continue
-						}
-						log.Printf("Increased brightness of light %s to %d", lightID, lightBright)
+					return m, m.adjustSelectedBrightness(count * fineBrightnessStep)
+				}
+
+			case "shift+left", "H":
+				if len(m.selected) > 0 {
+					return m, m.adjustSelectedBrightness(-count * fineBrightnessStep)
+				}
+
+			// Step the color temperature of the selected lights
+			case "[":
+				if len(m.selected) > 0 {
+					if err := m.stepSelectedCT(1); err != nil {
+						m.setStatusTr("status.error", err)
 					}
 				}
 
-			case "left", "h":
+			case "]":
 				if len(m.selected) > 0 {
-					for index := range m.selected {
-						if !m.light[index].Reachable {
-							log.Printf("Skipping unreachable light %s", m.light[index].Name)
-							continue
-						}
-						lightID := m.light[index].ID
-						lightBright, err := setLightBrightness(lightID, -10)
-						if err != nil {
-							log.Printf("Error setting light brightness for %s: %v", lightID, err)
-							continue
-						}
-						log.Printf("Decreasing brightness of light %s to %d", lightID, lightBright)
+					if err := m.stepSelectedCT(-1); err != nil {
+						m.setStatusTr("status.error", err)
 					}
 				}
 
 			// The spacebar toggles item for selection
 			case " ":
-				_, ok := m.selected[m.cursor]
-				if ok {
-					delete(m.selected, m.cursor)
-				} else {
-					m.selected[m.cursor] = struct{}{}
-				}
+				m.toggleRowSelection(m.cursor)
 
 			case "enter":
 				// If something is selected
-				if len(m.selected) > 0 {
-					for index := range m.selected {
-						if !m.light[index].Reachable {
-							log.Printf("Skipping unreachable light %s", m.light[index].Name)
-							continue
-						}
-						lightID := m.light[index].ID
-						lightStatus, err := getLightStatus(lightID)
-						if err != nil {
-							log.Printf("Error getting light status for %s: %v", lightID, err)
-							continue
-						}
-						err = toggleLight(lightID, lightStatus)
-						if err != nil {
-							log.Printf("Error toggling light for %s: %v", lightID, err)
-							continue
-						}
-						m.selected = make(map[int]struct{})
-					}
-					// Refresh the entire list
-					freshLights, err := returnLights()
-					if err != nil {
-						log.Printf("Warning: Failed to refresh lights after toggle: %v", err)
-					} else {
-						m.light = freshLights
-					}
-
-					m.selected = make(map[int]struct{})
+				if len(m.selected) > 0 && m.confirmUnreachable("toggle") {
+					m.toggleSelected()
 				}
+
+			// t toggles just the cursor row, leaving the selection alone
+			case "t":
+				m.toggleCursor()
+
+			// Anything else may be a scene hotkey from the config
+			default:
+				m.recallSceneKey(msg.String())
 			}
 		}
 	}
 
-	return m, nil
+	return m, m.takeQueued()
 }
 
-func (m lightModel) View() string {
-	const (
-		nameWidth       = 30
-		statusWidth     = 12
-		brightnessWidth = 15
-		totalWidth      = nameWidth + statusWidth + brightnessWidth + 10 // includes spacing and padding
-	)
-
-	// Styles
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#BD93F9"))
-	dividerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#44475A"))
-
-	var rows []string
-
-	// Header row — built exactly like data rows → perfect alignment
-	header := lipgloss.NewStyle().Width(nameWidth).Render(headerStyle.Render("NAME")) + "  " +
-		lipgloss.NewStyle().Width(statusWidth).Render(headerStyle.Render("STATUS")) + "  " +
-		lipgloss.NewStyle().Width(brightnessWidth).MarginLeft(3).Render(headerStyle.Render("BRIGHTNESS"))
-
-	rows = append(rows, "  "+header)
+// toggleSelected flips every selected light, then reloads the list
+func (m *lightModel) toggleSelected() {
+	indexes := make([]int, 0, len(m.selected))
+	for index := range m.selected {
+		indexes = append(indexes, index)
+	}
+	m.toggleLights(indexes)
+	m.selected = make(map[int]struct{})
+}
 
-	// Horizontal divider
-	divider := lipgloss.NewStyle().Width(nameWidth).Render(dividerStyle.Render(strings.Repeat("─", nameWidth))) + "  " +
-		lipgloss.NewStyle().Width(statusWidth).Render(dividerStyle.Render(strings.Repeat("─", statusWidth))) + "  " +
-		lipgloss.NewStyle().Width(brightnessWidth).MarginLeft(3).Render(dividerStyle.Render(strings.Repeat("─", brightnessWidth)))
+// toggleLights flips the lights at the given indexes in the background,
+// then reloads the list
+func (m *lightModel) toggleLights(indexes []int) {
+	skipped := 0
+	var writes []lightWrite
+	for _, index := range indexes {
+		light := m.light[index]
+		if !light.Reachable {
+			logInfo("Skipping unreachable light %s", light.Name)
+			skipped++
+			continue
+		}
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			lightStatus, err := getLightStatus(light.ID)
+			if err != nil {
+				return err
+			}
+			return toggleLight(light.ID, lightStatus)
+		}})
+	}
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		m.setStatus("%s", summarizeAction("action.toggled", len(written), skipped, len(failed))+m.syncHint(writeLights(failed)))
+		// Refresh the entire list
+		m.queue(refreshLights())
+	})
+}
 
-	rows = append(rows, "  "+divider)
+// toggleCursor flips the light(s) under the cursor without touching the
+// selection. It trusts the cached status and updates it once the write is
+// done instead of re-reading from the bridge; SSE corrects it if the write
+// didn't take.
+func (m *lightModel) toggleCursor() {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return
+	}
+	row := m.rows[m.cursor]
 
-	// Data rows
-	for i, light := range m.light {
-		cursor := "  "
-		if m.cursor == i {
-			cursor = cursorStyle.Render("▶ ")
+	// A device row switches all its channels the same way: off if any is on
+	anyOn := false
+	for _, index := range row.lights {
+		if m.light[index].Status == "on" {
+			anyOn = true
 		}
+	}
 
-		checkmark := "  "
-		if _, ok := m.selected[i]; ok {
-			checkmark = selectedStyle.Render("✓ ")
+	skipped := 0
+	var writes []lightWrite
+	for _, index := range row.lights {
+		light := m.light[index]
+		if !light.Reachable {
+			logInfo("Skipping unreachable light %s", light.Name)
+			skipped++
+			continue
 		}
-
-		// Truncate long names/types
-		name := light.Name
-		if len(name) > nameWidth {
-			name = name[:nameWidth-3] + "..."
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			return toggleLight(light.ID, anyOn)
+		}})
+	}
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		for _, w := range written {
+			m.setLightStatus(w.light.ID, onOffStatus(!anyOn))
 		}
+		m.setStatus("%s", summarizeAction("action.toggled", len(written), skipped, len(failed))+m.syncHint(writeLights(failed)))
+	})
+}
 
-		status := "OFF"
-		if !light.Reachable {
-			status = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF8C00")).Render("UNREACHABLE")
-		} else if light.Status == "on" {
-			status = statusOnStyle.Render("ON")
-		} else {
-			status = statusOffStyle.Render("OFF")
+// setLightBrightness shows a light's new brightness ahead of its SSE echo
+func (m *lightModel) setLightBrightness(lightID string, brightness float32) {
+	for i := range m.light {
+		if m.light[i].ID == lightID {
+			m.light[i].Brightness = brightness
 		}
+	}
+}
 
-		bright := ""
-		if !light.Reachable {
-			bright = lipgloss.NewStyle().Faint(true).Render("N/A")
-		} else {
-			bright = fmt.Sprintf("%.0f%%", light.Brightness)
+// setLightStatus shows a light switched on or off ahead of its SSE echo. The
+// light is found by ID, since the list may have changed while the write ran.
+func (m *lightModel) setLightStatus(lightID, status string) {
+	for i := range m.light {
+		if m.light[i].ID == lightID {
+			m.light[i].Status = status
 		}
-		bright = lipgloss.NewStyle().Width(brightnessWidth).MarginLeft(3).Render(bright)
+	}
+}
 
-		row := cursor + checkmark +
-			lipgloss.NewStyle().Width(nameWidth).Render(name) + "  " +
-			lipgloss.NewStyle().Width(statusWidth).Render(status) + "  " +
-			lipgloss.NewStyle().Width(brightnessWidth).Render(bright)
-		rows = append(rows, "  "+row)
+// summarizeAction builds a status line like "Toggled 3 lights · 1 skipped".
+// done is the catalog key of the first part, with .one and .other forms; it
+// is given the number of lights changed, then args.
+func summarizeAction(done string, changed, skipped, failed int, args ...any) string {
+	s := trn(done, changed, append([]any{changed}, args...)...)
+	if skipped > 0 {
+		s += tr("action.skipped", skipped)
+	}
+	if failed > 0 {
+		s += tr("action.failed", failed)
 	}
+	return s
+}
 
-	// Join everything
-	tableContent := lipgloss.JoinVertical(lipgloss.Left, rows...)
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
 
-	// Box with padding
-	boxed := tableStyle.Render(tableContent)
+// setStatus shows a one-line message in the command box and mirrors it to the log
+func (m *lightModel) setStatus(format string, args ...any) {
+	m.status = fmt.Sprintf(format, args...)
+	m.statusAt = time.Now()
+	logInfo("%s", m.status)
+}
 
-	// Title & footer
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6")).MarginLeft(2).Render("Your Hue Lights")
-	footer := lipgloss.NewStyle().Faint(true).MarginTop(1).MarginLeft(2).Render(
-		"• Space: select  • < >: brightness  • Enter: toggle  • :: commands  • q: quit\n" +
-			"• Unreachable lights will be skipped  • :refresh to update connectivity status")
+// setStatusTr is setStatus with a message from the catalogs
+func (m *lightModel) setStatusTr(key string, args ...any) {
+	m.setStatus("%s", tr(key, args...))
+}
 
-	// Always render command box area (static space)
-	commandBox := m.renderCommandBox()
+// Table column widths, shared by the table and the command box
+const (
+	nameWidth       = 30
+	statusWidth     = 18
+	brightnessWidth = 15
+	totalWidth      = nameWidth + statusWidth + brightnessWidth + 10 // includes spacing and padding
+)
 
-	result := title + "\n" + boxed + footer + "\n" + commandBox
+func (m lightModel) View() (view string) {
+	defer func() {
+		if r := recover(); r != nil {
+			view = m.renderPanic(r)
+		}
+	}()
 
-	return result
+	if m.shutdownSlow {
+		return viewText(lipgloss.NewStyle().Faint(true).MarginLeft(2).Render(glyphText("Shutting down…"))) + "\n"
+	}
+	if m.tooSmall() {
+		return m.renderTooSmall()
+	}
+	if m.keyList.open {
+		return m.renderKeyList()
+	}
+	if m.ssePane.open {
+		return m.renderSSEPane()
+	}
+	if m.scenePane.open {
+		return m.renderScenePane()
+	}
+	if m.sensorPane.open {
+		return m.renderSensorPane()
+	}
+	if m.automationPane.open {
+		return m.renderAutomationPane()
+	}
+	if m.picker.open {
+		return m.renderPicker()
+	}
+	if m.detail.open {
+		return m.renderDetail()
+	}
+	return activeRenderer.render(m)
 }
 
 func returnLights() ([]Light, error) {
-	lights, err := home.GetLights()
+	var lights map[string]openhue.LightGet
+	err := withRetry("loading lights", func() error {
+		var err error
+		lights, err = cachedLights()
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error fetching lights: %v", err)
+		return nil, fmt.Errorf("error fetching lights: %w", err)
 	}
 
 	// Extract IDs and sort them to maintain consistent order
@@ -362,45 +781,138 @@ func returnLights() ([]Light, error) {
 
 	var result []Light
 	for _, id := range ids {
-		light := lights[id]
-		status := "off"
-		if light.IsOn() {
-			status = "on"
-		}
+		result = append(result, lightFromResource(id, lights[id]))
+	}
+	result = applyOrder(result, appConfig.Order)
+
+	// Device names let multi-service fixtures be grouped under one row; the
+	// product data comes with them
+	devices, err := getDevices()
+	if err != nil {
+		logError("Failed to fetch devices: %v", err)
+	}
+	byDevice := make(map[string]DeviceResource, len(devices))
+	for _, device := range devices {
+		byDevice[device.ID] = device
+	}
+	for i := range result {
+		device := byDevice[result[i].DeviceOwner]
+		result[i].DeviceName = device.Metadata.Name
+		result[i].Product = productFromDevice(device)
+	}
 
-		// Get device owner for connectivity check
-		deviceOwner := ""
-		if light.Owner != nil && light.Owner.Rid != nil {
-			deviceOwner = *light.Owner.Rid
+	// openhue-go decodes gradient points in the wrong shape, so read them directly
+	gradients, err := getGradients()
+	if err != nil {
+		logError("Failed to fetch gradients: %v", err)
+	}
+	for i := range result {
+		if gradient, ok := gradients[result[i].ID]; ok {
+			result[i].Gradient = gradient.colors()
+			result[i].GradientPoints = gradient.PointsCapable
 		}
+	}
 
-		result = append(result, Light{
-			ID:          id,
-			Name:        *light.Metadata.Name,
-			Type:        string(*light.Metadata.Archetype),
-			Status:      status,
-			Brightness:  *light.Dimming.Brightness,
-			Reachable:   true, // Will be updated by checkConnectivity
-			DeviceOwner: deviceOwner,
-		})
+	updates, err := getSoftwareUpdates()
+	if err != nil {
+		logError("Failed to fetch firmware update states: %v", err)
 	}
+	applySoftwareUpdates(result, updates)
 
 	// Check connectivity status for all lights
 	checkConnectivity(result)
+	markSeen(result, time.Now())
 
 	return result, nil
 }
 
+// lightFromResource converts a bridge light resource into a Light, filling in
+// defaults for any fields the bridge left out (plugs, third-party bulbs)
+func lightFromResource(id string, light openhue.LightGet) Light {
+	var missing []string
+
+	name := id
+	archetype := "unknown"
+	if light.Metadata != nil && light.Metadata.Name != nil {
+		name = *light.Metadata.Name
+	} else {
+		missing = append(missing, "metadata.name")
+	}
+	if light.Metadata != nil && light.Metadata.Archetype != nil {
+		archetype = string(*light.Metadata.Archetype)
+	} else {
+		missing = append(missing, "metadata.archetype")
+	}
+
+	status := "off"
+	if light.On != nil && light.On.On != nil {
+		if *light.On.On {
+			status = "on"
+		}
+	} else {
+		missing = append(missing, "on")
+	}
+
+	// Smart plugs and other on/off devices have no dimming capability
+	caps := readCaps(light)
+	var brightness float32
+	if caps.dimmable {
+		brightness = *light.Dimming.Brightness
+	}
+
+	// Get device owner for connectivity check
+	deviceOwner := ""
+	if light.Owner != nil && light.Owner.Rid != nil {
+		deviceOwner = *light.Owner.Rid
+	}
+
+	if len(missing) > 0 {
+		logInfo("Light %s is missing fields: %s", id, strings.Join(missing, ", "))
+	}
+
+	result := Light{
+		ID:          id,
+		Name:        name,
+		Type:        archetype,
+		Status:      status,
+		Brightness:  brightness,
+		Reachable:   true, // Will be updated by checkConnectivity
+		Caps:        caps.capabilities(),
+		MinDimLevel: caps.minDimLevel,
+		DeviceOwner: deviceOwner,
+	}
+	if light.Dynamics != nil && light.Dynamics.Status != nil {
+		result.Dynamics = dynamicsState(string(*light.Dynamics.Status))
+	}
+	if caps.ct {
+		result.MirekMin, result.MirekMax = caps.mirekRange()
+	}
+	if light.Gradient != nil {
+		result.Caps |= capGradient
+	}
+	state := readMatchState(light)
+	result.Color = state.xy
+	if state.mirek != nil {
+		result.Mirek = *state.mirek
+	}
+	return result
+}
+
+// connectivityError is why the last connectivity check failed, or nil. Until a
+// check succeeds, Reachable can't be trusted, so it's shown in the status line.
+var connectivityError error
+
 // checkConnectivity queries the zigbee_connectivity endpoint and updates Light.Reachable
 func checkConnectivity(lights []Light) {
-	if home == nil {
+	if bridgeIP == "" || apiKey == "" {
 		return
 	}
 
 	// Make direct API call to get zigbee_connectivity data
 	connectivityMap, err := getZigbeeConnectivity()
+	connectivityError = err
 	if err != nil {
-		log.Printf("Warning: Failed to check connectivity: %v", err)
+		logError("Failed to check connectivity: %v", err)
 		return
 	}
 
@@ -417,106 +929,241 @@ func checkConnectivity(lights []Light) {
 	}
 }
 
-// getZigbeeConnectivity makes a direct API call to get connectivity status
-func getZigbeeConnectivity() (map[string]string, error) {
-	// Use global bridgeIP and apiKey
-	if bridgeIP == "" || apiKey == "" {
-		return nil, fmt.Errorf("bridge configuration not initialized")
+// markSeen records a successful state read for every reachable light
+func markSeen(lights []Light, now time.Time) {
+	for i := range lights {
+		if lights[i].Reachable {
+			lights[i].LastSeen = now
+		}
 	}
+}
 
-	// Build the API URL
-	url := fmt.Sprintf("https://%s/clip/v2/resource/zigbee_connectivity", bridgeIP)
+// replaceLights swaps in a freshly fetched light list, carrying over the
+// timestamps that only this session knows about
+func (m *lightModel) replaceLights(fresh []Light) {
+	previous := make(map[string]Light, len(m.light))
+	for _, light := range m.light {
+		previous[light.ID] = light
+	}
 
-	// Create HTTP client with TLS skip verification (same as SSE client)
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
+	for i := range fresh {
+		old, ok := previous[fresh[i].ID]
+		if !ok {
+			continue
+		}
+		if fresh[i].LastSeen.IsZero() {
+			fresh[i].LastSeen = old.LastSeen
+		}
+		if !fresh[i].Reachable {
+			if !old.Reachable {
+				fresh[i].UnreachableSince = old.UnreachableSince
+			} else {
+				fresh[i].UnreachableSince = time.Now()
+			}
+		}
 	}
 
-	// Create request
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+	m.setLights(fresh)
+	m.updatedAt = time.Now()
+}
+
+// setLights swaps in a new light list, rebuilding the rows and carrying the
+// selection across by light ID, since it is keyed by index. The cursor is
+// kept on a row. Every change to the list's length or order goes through
+// here, so no index outlives the list it pointed into.
+func (m *lightModel) setLights(lights []Light) {
+	selectedIDs := make(map[string]bool, len(m.selected))
+	for index := range m.selected {
+		if index < len(m.light) {
+			selectedIDs[m.light[index].ID] = true
+		}
 	}
 
-	// Add authentication header
-	req.Header.Set("hue-application-key", apiKey)
+	m.light = lights
+	m.rows = m.layoutRows()
+	m.moveCursorTo(m.cursor)
 
-	// Make request
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %v", err)
+	m.selected = make(map[int]struct{})
+	for i, light := range m.light {
+		if selectedIDs[light.ID] {
+			m.selected[i] = struct{}{}
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	// Parse response
-	var connectivityResp ZigbeeConnectivityResponse
-	if err := json.NewDecoder(resp.Body).Decode(&connectivityResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+// offlineSince returns the best known time the light went offline, or zero
+// when it is reachable or nothing is known about it
+func (l Light) offlineSince() time.Time {
+	if l.Reachable {
+		return time.Time{}
+	}
+	if !l.UnreachableSince.IsZero() {
+		return l.UnreachableSince
 	}
+	return l.LastSeen
+}
 
-	// Build map of device ID -> connectivity status
-	connectivityMap := make(map[string]string)
-	for _, conn := range connectivityResp.Data {
-		if conn.Owner.Rid != "" {
-			connectivityMap[conn.Owner.Rid] = conn.Status
-		}
+// humanizeDuration renders a duration in its largest sensible unit, e.g. "5m" or "2h"
+func humanizeDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// getZigbeeConnectivity makes a direct API call to get connectivity status
+func getZigbeeConnectivity() (map[string]string, error) {
+	if bridgeIP == "" || apiKey == "" {
+		return nil, fmt.Errorf("bridge configuration not initialized")
 	}
 
+	var connectivityMap map[string]string
+	err := withRetry("bridge request", func() error {
+		var err error
+		connectivityMap, err = bridgeClient().Connectivity(appCtx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking connectivity: %v", err)
+	}
 	return connectivityMap, nil
 }
 
-func getScenes() {
-	scenes, err := home.GetScenes()
+// getScenes lists the bridge's scenes with the name of the room or zone each belongs to
+func getScenes() ([]Scene, error) {
+	scenes, err := cachedScenes()
 	if err != nil {
-		log.Printf("error fetching scenes: %v", err)
+		return nil, fmt.Errorf("error fetching scenes: %v", err)
+	}
+
+	groupNames, err := getGroupNames()
+	if err != nil {
+		logError("Failed to fetch room and zone names: %v", err)
 	}
 
 	var result []Scene
-	for _, scene := range scenes {
+	for id, scene := range scenes {
+		name := id
+		if scene.Metadata != nil && scene.Metadata.Name != nil {
+			name = *scene.Metadata.Name
+		}
+		group := ""
+		if scene.Group != nil && scene.Group.Rid != nil {
+			group = *scene.Group.Rid
+		}
+		status := ""
+		if scene.Status != nil && scene.Status.Active != nil {
+			status = string(*scene.Status.Active)
+		}
+		var speed float32
+		if scene.Speed != nil {
+			speed = *scene.Speed
+		}
+		actions := 0
+		if scene.Actions != nil {
+			actions = len(*scene.Actions)
+		}
 		result = append(result, Scene{
-			ID:   *scene.Id,
-			Name: *scene.Metadata.Name,
+			ID:      id,
+			Name:    name,
+			Group:   group,
+			Room:    groupNames[group],
+			Status:  status,
+			Dynamic: hasPalette(scene.Palette),
+			Speed:   speed,
+			Lights:  actions,
 		})
 	}
-	log.Printf("Scenes: %v", result)
 
+	// Map iteration order is random; keep lookups deterministic
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Room != result[j].Room {
+			return result[i].Room < result[j].Room
+		}
+		return result[i].Name < result[j].Name
+	})
+	logDebug("Scenes: %v", result)
+
+	return result, nil
+}
+
+// findScene looks up a scene by name, optionally scoped to a room or zone as
+// "Living Room/Movie"
+func findScene(scenes []Scene, ref string) (Scene, error) {
+	room, name, scoped := strings.Cut(ref, "/")
+	if !scoped {
+		name = ref
+	}
+
+	for _, scene := range scenes {
+		if scene.Name != name {
+			continue
+		}
+		if scoped && !strings.EqualFold(scene.Room, room) {
+			continue
+		}
+		return scene, nil
+	}
+	return Scene{}, fmt.Errorf("scene not found: %s", ref)
 }
 
 func setScene(sceneName string) error {
-	log.Printf("Setting scene %s", sceneName)
-	// get sceneID from sceneName
-	scenes, err := home.GetScenes()
+	logInfo("Setting scene %s", sceneName)
+	scenes, err := getScenes()
 	if err != nil {
-		log.Printf("error fetching scenes: %v", err)
+		return err
 	}
 
-	var sceneID string
-	for _, scene := range scenes {
-		log.Printf("Scene Name: %s", *scene.Metadata.Name)
-		log.Printf("Scene being passed in: %v", sceneName)
-		if *scene.Metadata.Name == sceneName {
-			sceneID = *scene.Id
-			break
-		}
-	}
-	if sceneID == "" {
-		return fmt.Errorf("scene not found: %s", sceneName)
-	}
-	log.Printf("Scene ID: %s", sceneID)
-	action := openhue.SceneRecallActionActive
-	return home.UpdateScene(sceneID, openhue.ScenePut{
-		Recall: &openhue.SceneRecall{
-			Action: &action,
-		},
+	scene, err := findScene(scenes, sceneName)
+	if err != nil {
+		return err
+	}
+	return recallScene(scene.ID)
+}
+
+// hasPalette reports whether a scene has colors to play dynamically
+func hasPalette(palette *openhue.ScenePalette) bool {
+	if palette == nil {
+		return false
+	}
+	return (palette.Color != nil && len(*palette.Color) > 0) ||
+		(palette.ColorTemperature != nil && len(*palette.ColorTemperature) > 0)
+}
+
+// recallScene activates a scene by ID
+func recallScene(sceneID string) error {
+	return recallSceneAction(sceneID, openhue.SceneRecallActionActive)
+}
+
+// recallSceneAction recalls a scene statically, or playing its palette with dynamic_palette
+func recallSceneAction(sceneID string, action openhue.SceneRecallAction) error {
+	logDebug("Scene ID: %s", sceneID)
+	outgoing.recordBulk()
+	defer trackWrite()()
+	err := withRetry("scene recall", func() error {
+		if action == openhue.SceneRecallActionActive {
+			return bridgeClient().RecallScene(appCtx, sceneID)
+		}
+		return updateResource("scene", sceneID, openhue.ScenePut{
+			Recall: &openhue.SceneRecall{
+				Action: &action,
+			},
+		})
 	})
+	if err == nil {
+		sceneRecalls.record(sceneID, time.Now())
+	}
+	return err
 }
 
 func getLightStatus(lightID string) (bool, error) {
-	lights, err := home.GetLights()
+	lights, err := cachedLights()
 	if err != nil {
 		return false, fmt.Errorf("error fetching lights: %v", err)
 	}
@@ -524,214 +1171,174 @@ func getLightStatus(lightID string) (bool, error) {
 	if !ok {
 		return false, fmt.Errorf("light not found: %s", lightID)
 	}
+	if light.On == nil || light.On.On == nil {
+		return false, fmt.Errorf("light has no on state: %s", lightID)
+	}
 	return light.IsOn(), nil
 }
 
 func toggleLight(lightID string, currentStatus bool) error {
 	newStatus := !currentStatus
-	log.Printf("Toggling light %s from %t to %t", lightID, currentStatus, newStatus)
-	return home.UpdateLight(lightID, openhue.LightPut{
-		On: &openhue.On{On: &newStatus},
+	logInfo("Toggling light %s from %t to %t", lightID, currentStatus, newStatus)
+	outgoing.recordOn(lightID, newStatus)
+	put := openhue.LightPut{On: &openhue.On{On: &newStatus}}
+	return writeLight(lightID, put, func(client *hueclient.Client) error {
+		return client.SetOn(appCtx, lightID, newStatus)
 	})
 }
 
-func setLightBrightness(lightID string, change int) (int, error) {
-	lights, err := home.GetLights()
-	if err != nil {
-		return 0, fmt.Errorf("error fetching lights: %v", err)
+// clampBrightness limits a dimming target to [minDimLevel, 100]. The bridge
+// silently rounds anything lower up to the light's minimum, so the UI uses the
+// same value the light will actually report.
+func clampBrightness(brightness, minDimLevel float32) float32 {
+	if brightness < minDimLevel {
+		brightness = minDimLevel
 	}
-	light, ok := lights[lightID]
-	if !ok {
-		return 0, fmt.Errorf("light not found: %s", lightID)
-	}
-	currentBrightness := int(*light.Dimming.Brightness)
-	newBrightness := currentBrightness + change
-	if newBrightness < 0 {
-		newBrightness = 0
-	} else if newBrightness > 100 {
-		newBrightness = 100
-	}
-	log.Printf("Setting brightness of light %s from %d to %d", lightID, currentBrightness, newBrightness)
-	brightnessFinal := openhue.Brightness(newBrightness)
-	err = home.UpdateLight(lightID, openhue.LightPut{
+	if brightness < 0 {
+		brightness = 0
+	} else if brightness > 100 {
+		brightness = 100
+	}
+	return brightness
+}
+
+// writeBrightness sets a light's absolute brightness, clamped to the light's
+// dimming range, and returns the effective value
+func writeBrightness(lightID string, brightness, minDimLevel float32) (float32, error) {
+	brightness = clampBrightness(brightness, minDimLevel)
+	logInfo("Setting brightness of light %s to %.1f", lightID, brightness)
+	brightnessFinal := openhue.Brightness(brightness)
+	outgoing.recordBrightness(lightID, brightnessFinal)
+	err := updateLight(lightID, openhue.LightPut{
 		Dimming: &openhue.Dimming{Brightness: &brightnessFinal},
 	})
 	if err != nil {
-		return currentBrightness, fmt.Errorf("error updating brightness: %v", err)
+		return 0, fmt.Errorf("error updating brightness: %v", err)
 	}
-	return newBrightness, nil
+	return brightness, nil
 }
 
-func (m *lightModel) executeCommand(command string) {
-	log.Printf("Executing command: %s", command)
-
-	switch command {
-	case "help":
-		log.Println("Available commands: help, refresh, all_on, all_off, scene <name>")
-		log.Println("refresh - Updates light status and checks connectivity")
-	case "refresh":
-		freshLights, err := returnLights()
-		if err != nil {
-			log.Printf("Error refreshing lights: %v", err)
-		} else {
-			m.light = freshLights
-			log.Println("Lights refreshed with connectivity status")
-		}
-	case "all_on":
-		for _, light := range m.light {
-			if light.Reachable && light.Status == "off" {
-				err := toggleLight(light.ID, false)
-				if err != nil {
-					log.Printf("Error turning on light %s: %v", light.Name, err)
-				}
-			}
-		}
-		// Refresh after toggling
-		freshLights, err := returnLights()
-		if err == nil {
-			m.light = freshLights
-		}
-		log.Println("All lights turned on")
-	case "all_off":
-		for _, light := range m.light {
-			if light.Reachable && light.Status == "on" {
-				err := toggleLight(light.ID, true)
-				if err != nil {
-					log.Printf("Error turning off light %s: %v", light.Name, err)
-				}
-			}
-		}
-		// Refresh after toggling
-		freshLights, err := returnLights()
-		if err == nil {
-			m.light = freshLights
-		}
-		log.Println("All lights turned off")
-	default:
-		log.Printf("Unknown command: %s", command)
+// writeBrightnessOn switches a light on at a brightness in one write, the way
+// a dimmer switch turns a light on when it's dimmed up
+func writeBrightnessOn(lightID string, brightness, minDimLevel float32) (float32, error) {
+	brightness = clampBrightness(brightness, minDimLevel)
+	logInfo("Switching on light %s at brightness %.1f", lightID, brightness)
+	on := true
+	brightnessFinal := openhue.Brightness(brightness)
+	outgoing.recordOn(lightID, on)
+	outgoing.recordBrightness(lightID, brightnessFinal)
+	put := openhue.LightPut{
+		On:      &openhue.On{On: &on},
+		Dimming: &openhue.Dimming{Brightness: &brightnessFinal},
 	}
-	parts := strings.SplitN(command, " ", 2)
-	switch parts[0] {
-	case "scene":
-		if len(parts) < 2 {
-			log.Println("Usage: scene <scene name>")
-			return
-		}
-		sceneName := parts[1]
-		setScene(sceneName)
+	err := writeLight(lightID, put, func(client *hueclient.Client) error {
+		return client.SetBrightness(appCtx, lightID, float64(brightness))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error updating brightness: %v", err)
 	}
+	return brightness, nil
 }
 
 func (m lightModel) renderCommandBox() string {
-	const totalWidth = 30 + 12 + 15 + 10 // matches table width
 	commandBoxStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(boxBorder()).
 		BorderForeground(lipgloss.Color("#FF79C6")).
 		Padding(0, 1).
 		Margin(1, 0).
 		Width(totalWidth).
-		Height(3)
+		Height(outputViewLines + 1)
 
 	if m.commandMode {
+		promptText := ":"
+		if m.pendingDelete != nil {
+			promptText = tr("box.delete.prompt", m.pendingDelete.name)
+		}
 		prompt := lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("#FF79C6")).
-			Render(":")
+			Render(promptText)
 
-		text := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#F8F8F2")).
-			Render(m.commandText)
+		textStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F8F8F2"))
 
-		cursor := lipgloss.NewStyle().
+		blockStyle := lipgloss.NewStyle().
 			Background(lipgloss.Color("#F8F8F2")).
-			Foreground(lipgloss.Color("#282A36")).
-			Render(" ")
+			Foreground(lipgloss.Color("#282A36"))
 
-		commandLine := prompt + text + cursor
+		before, at, after := renderWithCursor(m.commandText, m.commandPos)
+		commandLine := prompt + textStyle.Render(before) + blockStyle.Render(at) + textStyle.Render(after)
 
 		help := lipgloss.NewStyle().
 			Faint(true).
-			Render("Commands: help, refresh, all_on, all_off • ESC to cancel • ENTER to execute")
+			Render(tr("box.command.help"))
 
 		content := commandLine + "\n" + help
 		return commandBoxStyle.Render(content)
-	} else {
-		// Show empty box with hint when not in command mode
-		hint := lipgloss.NewStyle().
-			Faint(true).
-			Render("Press : to open command mode")
-
-		content := "\n" + hint
-		return commandBoxStyle.Render(content)
-	}
-}
-
-// handleLightUpdate processes SSE updates for light events
-func (m lightModel) handleLightUpdate(item SSEDataItem) lightModel {
-	log.Printf("Entire light item: %+v", item)
+	} else if m.jump.active {
+		prompt := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF79C6")).
+			Render(tr("box.jump.prompt"))
 
-	// Find the light in our list
-	lightIndex := -1
-	for i := range m.light {
-		if m.light[i].ID == item.ID {
-			lightIndex = i
-			break
-		}
-	}
+		text := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F8F8F2")).
+			Render(m.jump.text)
 
-	// Skip if light not found
-	if lightIndex == -1 {
-		return m
-	}
+		help := lipgloss.NewStyle().
+			Faint(true).
+			Render(tr("box.jump.help"))
 
-	// Log event for debugging
-	brightnessVal := float64(-1)
-	if item.Dimming != nil {
-		brightnessVal = item.Dimming.Brightness
-	}
-	log.Printf("SSE light event: id=%s id_v1=%s on=%v brightness=%v",
-		item.ID, item.IDV1, item.On, brightnessVal)
+		return commandBoxStyle.Render(prompt + text + "\n" + help)
+	} else {
+		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#F8F8F2"))
+		hintStyle := lipgloss.NewStyle().Faint(true)
 
-	// Update status if the On field was present in the JSON
-	if item.On != nil {
-		if item.On.On {
-			m.light[lightIndex].Status = "on"
-		} else {
-			m.light[lightIndex].Status = "off"
+		// Until a command runs, the box shows the last status message
+		if len(m.output) == 0 {
+			return commandBoxStyle.Render(statusStyle.Render(m.status) + "\n" + hintStyle.Render(tr("box.hint")))
 		}
-	}
 
-	// Update brightness if present (including 0 for off lights)
-	if item.Dimming != nil {
-		m.light[lightIndex].Brightness = float32(item.Dimming.Brightness)
+		// Command output, with the latest status below it when something
+		// other than a command set it since
+		var lines []string
+		for _, line := range m.visibleOutput() {
+			lines = append(lines, statusStyle.Render(line))
+		}
+		switch {
+		case m.status != "" && m.statusAt.After(m.outputAt):
+			lines = append(lines, statusStyle.Render(m.status))
+		case m.outputScroll > 0:
+			lines = append(lines, hintStyle.Render(tr("box.more", m.outputScroll)))
+		case len(m.output) > outputViewLines:
+			lines = append(lines, hintStyle.Render(tr("box.hint.scroll")))
+		default:
+			lines = append(lines, hintStyle.Render(tr("box.hint")))
+		}
+		return commandBoxStyle.Render(strings.Join(lines, "\n"))
 	}
+}
 
-	// If we received any update, the light is reachable
-	m.light[lightIndex].Reachable = true
-
+// handleLightUpdate processes SSE updates for light events
+func (m lightModel) handleLightUpdate(item SSEDataItem) lightModel {
+	state := m.newLightState()
+	state.applyLight(item)
+	state.flush(&m)
 	return m
 }
 
 // handleConnectivityUpdate processes SSE updates for zigbee_connectivity events
 func (m lightModel) handleConnectivityUpdate(item SSEDataItem) lightModel {
-	log.Printf("SSE connectivity event: id=%s owner=%v status=%s",
-		item.ID, item.Owner, item.Status)
-
-	// Skip if no owner information
-	if item.Owner == nil || item.Owner.Rid == "" {
-		return m
-	}
-
-	// Find all lights that belong to this device
-	deviceID := item.Owner.Rid
-	isConnected := (item.Status == "connected")
-
-	for i := range m.light {
-		if m.light[i].DeviceOwner == deviceID {
-			m.light[i].Reachable = isConnected
-			log.Printf("Updated light %s reachability to %v", m.light[i].Name, isConnected)
-		}
-	}
-
+	state := m.newLightState()
+	state.applyConnectivity(item)
+	state.flush(&m)
 	return m
 }
+
+// setDeviceReachable updates every light of a device, notifying when its
+// reachability changes
+func (m *lightModel) setDeviceReachable(deviceID string, isConnected bool) {
+	state := m.newLightState()
+	state.setDeviceReachable(deviceID, isConnected)
+	state.flush(m)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/openhue/openhue-go"
+)
+
+// sparseLights are lights as third-party bulbs and plugs report them: no
+// dimming, color or color temperature, and sometimes no metadata or on state
+const sparseLights = `{"errors":[],"data":[
+	{"id":"plug","type":"light","owner":{"rid":"plug-device","rtype":"device"},
+	 "metadata":{"name":"Plug","archetype":"plug"},"on":{"on":true}},
+	{"id":"bare","type":"light"},
+	{"id":"half","type":"light","metadata":{},"on":{},"dimming":{},"color":{},
+	 "color_temperature":{"mirek_schema":{}},"dynamics":{}}
+]}`
+
+func TestReturnLightsSparse(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/light", sparseLights)
+	bridge.reply("/clip/v2/resource/zigbee_connectivity", `{"errors":[],"data":[
+		{"owner":{"rid":"plug-device"},"status":"connected"}]}`)
+
+	lights, err := returnLights()
+	if err != nil {
+		t.Fatalf("returnLights: %v", err)
+	}
+	if len(lights) != 3 {
+		t.Fatalf("got %d lights, want 3", len(lights))
+	}
+	byID := make(map[string]Light)
+	for _, light := range lights {
+		byID[light.ID] = light
+	}
+
+	plug := byID["plug"]
+	if plug.Name != "Plug" || plug.Status != "on" || plug.DeviceOwner != "plug-device" {
+		t.Errorf("plug = %+v", plug)
+	}
+	if plug.can(capDimming) || plug.can(capColor) || plug.can(capColorTemperature) || plug.Brightness != 0 {
+		t.Errorf("plug caps = %v, brightness %v; want on/off only", plug.Caps, plug.Brightness)
+	}
+
+	// Missing names fall back to the ID, a missing on state to off
+	if bare := byID["bare"]; bare.Name != "bare" || bare.Status != "off" || bare.Type != "unknown" {
+		t.Errorf("bare = %+v", bare)
+	}
+	if half := byID["half"]; half.Name != "half" || half.can(capDimming) || half.can(capColorTemperature) {
+		t.Errorf("half = %+v", half)
+	}
+}
+
+func TestLightFromResourceEmpty(t *testing.T) {
+	light := lightFromResource("empty", openhue.LightGet{})
+	if light.Name != "empty" || light.Status != "off" || light.Caps != capOnOff || light.Color != nil {
+		t.Errorf("lightFromResource of an empty resource = %+v", light)
+	}
+}
+
+func TestClampBrightness(t *testing.T) {
+	tests := []struct {
+		brightness, minDimLevel, want float32
+	}{
+		// A light whose minimum is 10%
+		{5, 10, 10},
+		{9.9, 10, 10},
+		{10, 10, 10},
+		{10.5, 10, 10.5},
+		{50, 10, 50},
+		{100, 10, 100},
+		{150, 10, 100},
+		{0, 10, 10},
+		{-5, 10, 10},
+		// A minimum above the usual floor
+		{20, 40, 40},
+		{40, 40, 40},
+		{75, 40, 75},
+		// No minimum reported
+		{0, 0, 0},
+		{0.2, 0, 0.2},
+		{-5, 0, 0},
+		{101, 0, 100},
+	}
+	for _, tt := range tests {
+		if got := clampBrightness(tt.brightness, tt.minDimLevel); got != tt.want {
+			t.Errorf("clampBrightness(%v, min %v) = %v, want %v", tt.brightness, tt.minDimLevel, got, tt.want)
+		}
+	}
+}
+
+// A refresh that returns fewer lights keeps the cursor on a row and the
+// selection on the same lights, dropping the ones that went away
+func TestReplaceLightsShrinks(t *testing.T) {
+	lights := testLights(10)
+	m := initialModel(lights, nil)
+	m.width, m.height = 120, 30
+	m.moveCursorTo(9)
+	m.selected = map[int]struct{}{2: {}, 7: {}, 9: {}}
+
+	// Lights 5, 6, 8 and 9 are gone; 7 moved up to index 5
+	m.replaceLights([]Light{lights[0], lights[1], lights[2], lights[3], lights[4], lights[7]})
+
+	if m.cursor != len(m.rows)-1 {
+		t.Errorf("cursor = %d, want the last row %d", m.cursor, len(m.rows)-1)
+	}
+	want := map[int]struct{}{2: {}, 5: {}}
+	if !maps.Equal(m.selected, want) {
+		t.Errorf("selected = %v, want %v", m.selected, want)
+	}
+	for index := range m.selected {
+		if index >= len(m.light) {
+			t.Errorf("selected index %d is past the %d lights", index, len(m.light))
+		}
+	}
+	if view := ansi.Strip(tableRenderer{}.render(m)); !strings.Contains(view, "▶ ") {
+		t.Errorf("no cursor in the table:\n%s", view)
+	}
+
+	// Every light gone
+	m.replaceLights(nil)
+	if m.cursor != 0 || len(m.selected) != 0 {
+		t.Errorf("cursor %d, selected %v after every light went away", m.cursor, m.selected)
+	}
+	if view := ansi.Strip(tableRenderer{}.render(m)); !strings.Contains(view, tr("empty.none")) {
+		t.Errorf("no empty message:\n%s", view)
+	}
+}
+
+// The filter and room left in place are saved on quit and put back next time,
+// the cursor landing on the same light among the rows they show; a saved one
+// that matches nothing any more opens the table unscoped
+func TestUIStateKeepsFilter(t *testing.T) {
+	lights := testLights(12)
+	kitchen := lightGroup{groupedLightID: "kitchen-group", ownerType: "room", name: "Kitchen",
+		lightIDs: []string{"light-08", "light-10", "light-11"}}
+	open := func() lightModel {
+		m := initialModel(lights, nil)
+		m.groups = map[string]lightGroup{kitchen.groupedLightID: kitchen}
+		return m
+	}
+
+	m := open()
+	if err := m.scopeToRoom("kitchen"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.filterCommand("Light 1"); err != nil {
+		t.Fatal(err)
+	}
+	m.moveCursorTo(len(m.rows) - 1)
+	state := m.uiState()
+	if state.Filter != "Light 1" || state.Room != "Kitchen" || state.CursorLight != "light-11" {
+		t.Fatalf("saved %+v", state)
+	}
+
+	m = open()
+	if warnings := m.applyStartup(StartupConfig{}.withSaved(state)); len(warnings) != 0 {
+		t.Errorf("warnings %v", warnings)
+	}
+	m.restoreUIState(state)
+	if m.filter != "Light 1" || m.roomScope != "Kitchen" || len(m.rows) != 2 {
+		t.Errorf("filter %q, room %q, %d rows; want the 2 saved ones", m.filter, m.roomScope, len(m.rows))
+	}
+	if got := m.light[m.rows[m.cursor].lights[0]].ID; got != "light-11" {
+		t.Errorf("cursor on %s, want light-11", got)
+	}
+
+	// The config or flags scope the table instead of the saved filter
+	if got := (StartupConfig{Filter: "Light 0"}).withSaved(state); got.Filter != "Light 0" || got.Room != "" {
+		t.Errorf("withSaved = %+v, want the configured filter alone", got)
+	}
+
+	state.Filter = "gone"
+	m = open()
+	if warnings := m.applyStartup(StartupConfig{}.withSaved(state)); len(warnings) != 1 {
+		t.Errorf("warnings %v, want one for the filter", warnings)
+	}
+	m.restoreUIState(state)
+	if m.filter != "" || len(m.rows) != 3 {
+		t.Errorf("filter %q with %d rows, want the kitchen's 3", m.filter, len(m.rows))
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bridgeLimit is one entry of the CLIP v1 capabilities resource: how many
+// resources of a kind the bridge can hold, and how many more it has room for
+type bridgeLimit struct {
+	Available int `json:"available"`
+	Total     int `json:"total"`
+}
+
+// used is how many resources of the kind the bridge holds
+func (l bridgeLimit) used() int {
+	return l.Total - l.Available
+}
+
+// bridgeLimits are the limits the app checks. CLIP v2 doesn't expose them,
+// so they come from v1, where rooms, zones and entertainment areas all count
+// as groups.
+type bridgeLimits struct {
+	Lights bridgeLimit `json:"lights"`
+	Groups bridgeLimit `json:"groups"`
+	Scenes bridgeLimit `json:"scenes"`
+	Rules  bridgeLimit `json:"rules"`
+}
+
+// getBridgeLimits fetches the bridge's resource limits
+func getBridgeLimits() (*bridgeLimits, error) {
+	var limits bridgeLimits
+	if err := bridgeGet("api/"+apiKey+"/capabilities", &limits); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// checkGroupLimit is run before creating a room or zone, failing early with
+// a clear message when the bridge is full. If the limits can't be read, the
+// bridge gets to decide.
+func checkGroupLimit(rtype string) error {
+	limits, err := getBridgeLimits()
+	if err != nil {
+		logDebug("Couldn't read the bridge's limits: %v", err)
+		return nil
+	}
+	if limits.Groups.Total > 0 && limits.Groups.Available <= 0 {
+		return fmt.Errorf("the bridge already holds %d rooms, zones and entertainment areas, its limit; delete ones you no longer use in the Hue app to create another %s", limits.Groups.Total, rtype)
+	}
+	return nil
+}
+
+// limitError rewords a bridge error about a full resource table, which the
+// bridge words differently by API version and firmware, into one that says
+// what to do. Other errors are returned unchanged.
+func limitError(err error, what string) error {
+	if err == nil {
+		return nil
+	}
+	text := strings.ToLower(err.Error())
+	for _, phrase := range []string{"limit reached", "resource limit", "table full", "no space", "too many"} {
+		if strings.Contains(text, phrase) {
+			return fmt.Errorf("the bridge has no room for more %s; delete ones you no longer use and try again (bridge said: %v)", what, err)
+		}
+	}
+	return err
+}
+
+// limitNoun names what a resource type's limit counts, for limitError
+func limitNoun(rtype string) string {
+	switch rtype {
+	case "room", "zone", "entertainment_configuration":
+		return "rooms and zones"
+	case "scene":
+		return "scenes"
+	case "behavior_instance":
+		return "automations"
+	}
+	return rtype + " resources"
+}
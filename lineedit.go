@@ -0,0 +1,126 @@
+package main
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCommandLength caps the command box, in runes. Real commands are far
+// shorter; the cap keeps a stray paste from filling the screen.
+const maxCommandLength = 500
+
+// editLine applies an editing key to text with the cursor at pos, counted in
+// runes, and returns the new text and cursor. handled is false for keys that
+// aren't editing keys, such as enter. Typed and pasted text is inserted at the
+// cursor, cleaned by sanitizeInput and cut off at maxCommandLength; a
+// bracketed paste arrives as one message, so it is inserted in one go.
+func editLine(text string, pos int, msg tea.KeyMsg) (string, int, bool) {
+	runes := []rune(text)
+	pos = min(max(pos, 0), len(runes))
+
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		if msg.Alt {
+			break
+		}
+		inserted := msg.Runes
+		if msg.Type == tea.KeySpace {
+			inserted = []rune{' '}
+		}
+		inserted = sanitizeInput(inserted)
+		inserted = inserted[:min(len(inserted), max(0, maxCommandLength-len(runes)))]
+		runes = append(runes[:pos], append(inserted, runes[pos:]...)...)
+		return string(runes), pos + len(inserted), true
+	}
+
+	switch msg.String() {
+	case "left", "ctrl+b":
+		return text, max(pos-1, 0), true
+	case "right", "ctrl+f":
+		return text, min(pos+1, len(runes)), true
+	case "home", "ctrl+a":
+		return text, 0, true
+	case "end", "ctrl+e":
+		return text, len(runes), true
+	case "alt+left", "ctrl+left", "alt+b":
+		return text, wordStart(runes, pos), true
+	case "alt+right", "ctrl+right", "alt+f":
+		return text, wordEnd(runes, pos), true
+	case "backspace", "ctrl+h":
+		if pos == 0 {
+			return text, pos, true
+		}
+		return string(append(runes[:pos-1], runes[pos:]...)), pos - 1, true
+	case "delete", "ctrl+d":
+		if pos == len(runes) {
+			return text, pos, true
+		}
+		return string(append(runes[:pos], runes[pos+1:]...)), pos, true
+	case "ctrl+w", "alt+backspace":
+		start := wordStart(runes, pos)
+		return string(append(runes[:start], runes[pos:]...)), start, true
+	case "alt+d":
+		end := wordEnd(runes, pos)
+		return string(append(runes[:pos], runes[end:]...)), pos, true
+	case "ctrl+u":
+		return string(runes[pos:]), 0, true
+	case "ctrl+k":
+		return string(runes[:pos]), pos, true
+	}
+	return text, pos, false
+}
+
+// sanitizeInput cleans typed or pasted runes for the command box. Newlines,
+// tabs and other whitespace become spaces, since a pasted newline would otherwise end up
+// inside the command. Other control characters are dropped: they are
+// usually the remains of an escape sequence the terminal split up, and
+// drawing them would corrupt the screen.
+func sanitizeInput(runes []rune) []rune {
+	clean := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		switch {
+		case unicode.IsSpace(r):
+			clean = append(clean, ' ')
+		case r == utf8.RuneError || !unicode.IsPrint(r):
+			continue
+		default:
+			clean = append(clean, r)
+		}
+	}
+	return clean
+}
+
+// wordStart returns the start of the word before pos, skipping spaces first
+func wordStart(runes []rune, pos int) int {
+	for pos > 0 && unicode.IsSpace(runes[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(runes[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd returns the end of the word after pos, skipping spaces first
+func wordEnd(runes []rune, pos int) int {
+	for pos < len(runes) && unicode.IsSpace(runes[pos]) {
+		pos++
+	}
+	for pos < len(runes) && !unicode.IsSpace(runes[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// renderWithCursor splits text at pos for drawing the cursor block over the
+// character it sits on, or past the end
+func renderWithCursor(text string, pos int) (before, at, after string) {
+	runes := []rune(text)
+	pos = min(max(pos, 0), len(runes))
+	if pos == len(runes) {
+		return text, " ", ""
+	}
+	return string(runes[:pos]), string(runes[pos]), string(runes[pos+1:])
+}
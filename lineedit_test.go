@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys opens the command box and feeds msgs through Update
+func typeKeys(t *testing.T, msgs ...tea.KeyMsg) lightModel {
+	t.Helper()
+	m := initialModel([]Light{testLight()}, nil)
+	m.width, m.height = 100, 30
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{':'}})
+	m = model.(lightModel)
+	if !m.commandMode {
+		t.Fatal(": didn't open the command box")
+	}
+	for _, msg := range msgs {
+		model, _ = m.Update(msg)
+		m = model.(lightModel)
+	}
+	return m
+}
+
+// checkCommandText fails unless the command box holds sane text: valid
+// UTF-8, nothing unprintable, within the length cap and with the cursor in it
+func checkCommandText(t *testing.T, m lightModel) {
+	t.Helper()
+	if !utf8.ValidString(m.commandText) {
+		t.Errorf("command text %q isn't valid UTF-8", m.commandText)
+	}
+	for _, r := range m.commandText {
+		if !unicode.IsPrint(r) {
+			t.Errorf("command text %q holds unprintable %U", m.commandText, r)
+		}
+	}
+	if n := utf8.RuneCountInString(m.commandText); n > maxCommandLength {
+		t.Errorf("command text is %d runes, over the %d cap", n, maxCommandLength)
+	}
+	if m.commandPos < 0 || m.commandPos > utf8.RuneCountInString(m.commandText) {
+		t.Errorf("cursor %d is outside %q", m.commandPos, m.commandText)
+	}
+	m.renderCommandBox()
+}
+
+func runes(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+func paste(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s), Paste: true}
+}
+
+func TestCommandBoxPathologicalInput(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []tea.KeyMsg
+		want string
+	}{
+		{"alt combos insert nothing", []tea.KeyMsg{
+			runes("on"), {Type: tea.KeyRunes, Runes: []rune{'x'}, Alt: true}, {Type: tea.KeyRunes, Runes: []rune{'['}, Alt: true},
+		}, "on"},
+		{"escape sequence fragments", []tea.KeyMsg{runes("dim\x1b[A"), runes("\x1b"), runes("[1;5C")}, "dim[A[1;5C"},
+		{"control characters", []tea.KeyMsg{runes("a\x00b\x07c\x7fd\u200be")}, "abcde"},
+		{"unicode", []tea.KeyMsg{runes("filter Küche 💡 日本")}, "filter Küche 💡 日本"},
+		{"combining marks", []tea.KeyMsg{runes("Café")}, "Café"},
+		{"invalid UTF-8", []tea.KeyMsg{runes("ok\xff\xfe")}, "ok"},
+		{"pasted newlines and tabs", []tea.KeyMsg{paste("filter\nDesk\tLamp\r\n")}, "filter Desk Lamp  "},
+		{"keys without text", []tea.KeyMsg{
+			runes("on"), {Type: tea.KeyF1}, {Type: tea.KeyF20}, {Type: tea.KeyCtrlT}, {Type: tea.KeyInsert}, {Type: tea.KeyShiftTab},
+		}, "on"},
+		{"editing past the ends", []tea.KeyMsg{
+			{Type: tea.KeyLeft}, {Type: tea.KeyBackspace}, runes("ab"), {Type: tea.KeyRight}, {Type: tea.KeyRight},
+			{Type: tea.KeyDelete}, {Type: tea.KeyHome}, {Type: tea.KeyBackspace}, {Type: tea.KeyCtrlW},
+		}, "ab"},
+		{"wide runes deleted whole", []tea.KeyMsg{runes("日本語"), {Type: tea.KeyBackspace}, {Type: tea.KeyLeft}, runes("x")}, "日x本"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := typeKeys(t, tt.keys...)
+			if m.commandText != tt.want {
+				t.Errorf("command text = %q, want %q", m.commandText, tt.want)
+			}
+			checkCommandText(t, m)
+		})
+	}
+}
+
+func TestCommandBoxLongPaste(t *testing.T) {
+	long := strings.Repeat("日本 lamp ", 2000)
+
+	m := typeKeys(t, paste(long))
+	if n := utf8.RuneCountInString(m.commandText); n != maxCommandLength {
+		t.Errorf("a long paste left %d runes, want the %d cap", n, maxCommandLength)
+	}
+	if !strings.Contains(m.status, "limited") {
+		t.Errorf("status = %q, want it to mention the limit", m.status)
+	}
+	checkCommandText(t, m)
+
+	// Typing in the middle of a full box doesn't grow it
+	m = typeKeys(t, paste(long), tea.KeyMsg{Type: tea.KeyHome}, runes("more"), paste(long))
+	if n := utf8.RuneCountInString(m.commandText); n != maxCommandLength {
+		t.Errorf("typing into a full box left %d runes", n)
+	}
+	if m.commandPos != 0 {
+		t.Errorf("cursor = %d, want it still at the start", m.commandPos)
+	}
+	checkCommandText(t, m)
+
+	// Many small pastes add up to the same cap
+	var keys []tea.KeyMsg
+	for range 300 {
+		keys = append(keys, paste("ab\x1bc "))
+	}
+	m = typeKeys(t, keys...)
+	if n := utf8.RuneCountInString(m.commandText); n != maxCommandLength {
+		t.Errorf("many pastes left %d runes", n)
+	}
+	checkCommandText(t, m)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logLevel controls how much detail is written to the log file
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelInfo
+	levelDebug
+)
+
+// Active log level, set from the --log-level flag
+var currentLogLevel = levelInfo
+
+// maxRecentErrors caps how many errors are kept for :diagnostics
+const maxRecentErrors = 20
+
+// recentErrors keeps the last errors logged, whether or not a log file is
+// open, so a diagnostics bundle can include them
+var recentErrors = &errorRing{}
+
+// errorRing is a capped list of timestamped error lines
+type errorRing struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *errorRing) add(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, time.Now().Format(time.RFC3339)+" "+line)
+	if len(r.lines) > maxRecentErrors {
+		r.lines = r.lines[len(r.lines)-maxRecentErrors:]
+	}
+}
+
+func (r *errorRing) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+func (l logLevel) String() string {
+	switch l {
+	case levelError:
+		return "error"
+	case levelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+func parseLogLevel(s string) (logLevel, error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return levelError, nil
+	case "info":
+		return levelInfo, nil
+	case "debug":
+		return levelDebug, nil
+	}
+	return levelInfo, fmt.Errorf("unknown log level %q (want error, info or debug)", s)
+}
+
+// setupLogging points the standard logger at path, or at fallback when path
+// is empty. The returned closer is nil when nothing was opened.
+func setupLogging(path string, fallback io.Writer, level logLevel) (io.Closer, error) {
+	currentLogLevel = level
+	if path == "" {
+		log.SetOutput(fallback)
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(f)
+	return f, nil
+}
+
+func logAt(level logLevel, format string, args ...any) {
+	if level > currentLogLevel {
+		return
+	}
+	// The key can turn up in URLs and wrapped errors; it never belongs in a log
+	line := fmt.Sprintf(format, args...)
+	if apiKey != "" {
+		line = strings.ReplaceAll(line, apiKey, maskKey(apiKey))
+	}
+	if level == levelError {
+		recentErrors.add(line)
+	}
+	log.Print(strings.ToUpper(level.String()) + " " + line)
+}
+
+// maskKey shortens a credential to its first and last few characters, e.g. "2b6…a91"
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "…"
+	}
+	return key[:3] + "…" + key[len(key)-3:]
+}
+
+func logError(format string, args ...any) { logAt(levelError, format, args...) }
+func logInfo(format string, args ...any)  { logAt(levelInfo, format, args...) }
+func logDebug(format string, args ...any) { logAt(levelDebug, format, args...) }
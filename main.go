@@ -1,18 +1,16 @@
 package main
 
 import (
-	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/openhue/openhue-go"
-	"github.com/r3labs/sse/v2"
 )
 
 var (
@@ -28,108 +26,336 @@ var (
 			Padding(0, 2).
 			Margin(1, 0)
 
-	// Openhue home instance
-	home *openhue.Home
-
 	// Global bridge configuration
 	bridgeIP string
 	apiKey   string
 )
 
 func main() {
+	// Paths that finish early set exitCode and return, so deferred cleanup
+	// such as closing the log runs before the process exits
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// export and import are subcommands, followed by the usual flags
+	subcommand := ""
+	if len(os.Args) > 1 && (os.Args[1] == "export" || os.Args[1] == "import") {
+		subcommand = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	bridge_ip := flag.String("bridge_ip", "", "IP address of the Hue Bridge")
 	hue_application_key := flag.String("key", "", "Hue application key")
-	debug := flag.Bool("debug", false, "Enable debug mode")
+	debug := flag.Bool("debug", false, "Shorthand for --log ~/.openhue/debug.log --log-level debug")
+	logPath := flag.String("log", "", "Write logs to this file (default: logging disabled, or stderr with --rules)")
+	logLevelName := flag.String("log-level", "info", "Log level: error, info or debug")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	execScript := flag.String("exec", "", "Commands to run at startup, separated by ;")
+	execQuit := flag.Bool("exec-quit", false, "Exit after running --exec instead of opening the TUI")
+	discovery := flag.String("discovery", discoveryBoth, "Bridge discovery method: mdns, cloud or both")
+	remote := flag.Bool("remote", false, "Connect through the Hue Remote API instead of the local bridge")
+	remoteLoginFlag := flag.Bool("remote-login", false, "Authorize remote access with the Hue Remote API and exit")
+	forceSetup := flag.Bool("setup", false, "Run the bridge setup wizard even if a config file exists")
+	ascii := flag.Bool("ascii", false, "Draw the UI with ASCII characters only")
+	listenAddr := flag.String("listen", "", "Serve the light list read-only over HTTP on this address, e.g. 127.0.0.1:9111")
+	replayPath := flag.String("replay", "", "Play SSE events from a capture file instead of the bridge's event stream")
+	recordPath := flag.String("record", "", "Append every SSE event to a capture file for --replay")
+	plain := flag.Bool("plain", false, "Render a plain list without borders or color, for screen readers")
+	rulesPath := flag.String("rules", "", "Run without the TUI, applying the rules in this file until interrupted")
+	lang := flag.String("lang", "", "Language of the interface, e.g. en or de (default: from LANG)")
+	outPath := flag.String("out", "", "export: write the inventory to this file instead of stdout")
+	dryRun := flag.Bool("dry-run", false, "import: print the changes without making them")
+	viewFlag := flag.String("view", "", "View to open with: lights, rooms, scenes or sensors (default: the last one)")
+	filterFlag := flag.String("filter", "", "Start with only the lights matching this filter, like :filter")
+	roomFlag := flag.String("room", "", "Start with only the lights in this room")
+	flag.BoolVar(&redactNames, "redact-names", false, "Leave light, device, room and scene names out of :diagnostics")
 	flag.Parse()
 
-	// Set up logging to file
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+	if *execQuit && *execScript == "" {
+		fmt.Println("--exec-quit requires --exec")
+		os.Exit(1)
+	}
+
+	level, err := parseLogLevel(*logLevelName)
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+	language, err = resolveLanguage(*lang)
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+	discoveryMethod, err = parseDiscoveryMethod(*discovery)
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+	if *viewFlag, err = parseStartupView(*viewFlag); err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
 	if *debug {
 		userHomeDir, err := os.UserHomeDir()
 		if err != nil {
 			fmt.Println("fatal:", err)
 			os.Exit(1)
 		}
-		configDir := userHomeDir + "/.openhue"
-		f, err := tea.LogToFile(configDir+"/debug.log", "debug")
-		if err != nil {
-			fmt.Println("fatal:", err)
+		if *logPath == "" {
+			*logPath = userHomeDir + "/.openhue/debug.log"
+		}
+		level = levelDebug
+	}
+
+	// Set up logging to file. The TUI owns the terminal, but rules mode has
+	// no other way to report what it did, so it logs to stderr by default.
+	var fallback io.Writer = io.Discard
+	if *rulesPath != "" {
+		fallback = os.Stderr
+	}
+	logFile, err := setupLogging(*logPath, fallback, level)
+	if err != nil {
+		fmt.Println("fatal:", err)
+		os.Exit(1)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	// TUI settings come from the config file even when the bridge is given by flags
+	config, warnings, err := loadConfig()
+	if err != nil {
+		logError("Error loading config: %v", err)
+	}
+	appConfig = config
+	for _, warning := range warnings {
+		logError("Config: %s", warning)
+	}
+	if *remoteLoginFlag {
+		if err := remoteLogin(appConfig.Remote); err != nil {
+			fmt.Println("Remote login failed:", err)
 			os.Exit(1)
 		}
-		defer f.Close()
-	} else {
-		log.SetOutput(io.Discard)
+		return
+	}
+	if *remote && !appConfig.Remote.configured() {
+		fmt.Println("Remote access isn't set up; run with --remote-login first")
+		os.Exit(1)
 	}
 
+	setASCIIMode(*ascii || appConfig.ASCII || terminalLacksUnicode())
+
 	// Try flags first
-	if *bridge_ip != "" && *hue_application_key != "" {
+	usingFlags := *bridge_ip != "" && *hue_application_key != ""
+	if usingFlags {
 		bridgeIP = *bridge_ip
 		apiKey = *hue_application_key
-		log.Println("Using flags for bridge connection")
+		logInfo("Using flags for bridge connection")
 	} else {
 		// Try config file
-		log.Printf("Startup flags %s and %s not found: ", *bridge_ip, *hue_application_key)
-		log.Println("Checking config file instead...")
+		logInfo("Startup flags not set, checking config file instead...")
 		_, err := openhue.LoadConf()
-		if err != nil {
-			// No config file, start bridge setup TUI
-			log.Println("No config file found, starting bridge setup...")
+		if err != nil || *forceSetup {
+			// No config file (or --setup), start bridge setup TUI
+			logInfo("Starting bridge setup...")
 			setupModel := bridgeSetupModel{step: 0}
+			if err == nil {
+				setupModel.existingIP, _ = openhue.LoadConfNoError()
+			}
 			p := tea.NewProgram(setupModel)
 
-			if _, err := p.Run(); err != nil {
+			finalModel, err := p.Run()
+			if err != nil {
 				fmt.Printf("Error during setup: %v", err)
 				os.Exit(1)
 			}
 
-			// After setup, try loading config again
-			_, err = openhue.LoadConf()
-			if err != nil {
+			// Use the new credentials directly, since saving them may have failed
+			result := finalModel.(bridgeSetupModel)
+			switch {
+			case result.complete():
+				bridgeIP, apiKey = result.bridgeIP, result.apiKey
+			case result.existingIP != "":
+				logInfo("Setup cancelled, keeping the existing configuration")
+				bridgeIP, apiKey = openhue.LoadConfNoError()
+			default:
 				fmt.Println("Setup was cancelled or failed")
 				os.Exit(1)
 			}
+		} else {
+			// Load from config
+			bridgeIP, apiKey = openhue.LoadConfNoError()
 		}
+	}
 
-		// Load from config
-		bridgeIP, apiKey = openhue.LoadConfNoError()
+	if subcommand != "" {
+		if err := runInventoryCommand(subcommand, *outPath, flag.Args(), *dryRun); err != nil {
+			logError("%s: %v", subcommand, err)
+			fmt.Printf("%s: %v\n", subcommand, err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Initialize openhue home instance
-	var err error
-	home, err = openhue.NewHome(bridgeIP, apiKey)
-	if err != nil {
-		log.Fatalf("Failed to create openhue home: %v", err)
+	if *rulesPath != "" {
+		if err := runRules(*rulesPath); err != nil {
+			logError("Rules: %v", err)
+			fmt.Println("Rules:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Create channel for SSE events
-	sseChannel := make(chan []byte)
-
-	// Start SSE client in a goroutine so it doesn't block the TUI
-	go func() {
-		sse_client := sse.NewClient("https://" + bridgeIP + "/eventstream/clip/v2")
-		sse_client.Connection.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
+	sseChannel := make(chan sseMessage)
+
+	if *remote {
+		if err := startRemote(appConfig.Remote); err != nil {
+			fmt.Printf("Unable to connect through the Hue Remote API: %v\n", err)
+			os.Exit(1)
 		}
-		sse_client.Headers["hue-application-key"] = apiKey
-		err := sse_client.SubscribeRaw(func(msg *sse.Event) {
-			sseChannel <- msg.Data
-		})
-		if err != nil {
-			log.Printf("Error subscribing to SSE: %v", err)
+	}
+
+	lights, err := returnLights()
+	if err != nil && isNetworkError(err) && !usingFlags && recoverMovedBridge() {
+		lights, err = returnLights()
+	}
+	if err != nil && isNetworkError(err) && !remoteMode && appConfig.Remote.configured() {
+		fmt.Println("The bridge is unreachable, switching to the Hue Remote API...")
+		if remoteErr := startRemote(appConfig.Remote); remoteErr != nil {
+			logError("Remote fallback failed: %v", remoteErr)
+		} else {
+			lights, err = returnLights()
 		}
-	}()
+	}
+	if err != nil {
+		logError("Error returning lights: %v", err)
+		fmt.Printf("Error returning lights: %v\n", err)
+		os.Exit(1)
+	}
 
-	p := tea.NewProgram(initialModel(func() []Light {
-		lights, err := returnLights()
+	model := initialModel(lights, sseChannel)
+	model.groups, err = loadGroups(lights)
+	if err != nil {
+		logError("Failed to load rooms and zones: %v", err)
+	}
+	startup := appConfig.Startup.withFlags(*viewFlag, *filterFlag, *roomFlag)
+	state, err := loadUIState()
+	if err != nil {
+		logError("Failed to load UI state: %v", err)
+	}
+	if startup.View != "" {
+		state.View = startup.View
+	}
+	// Scope the table first, so the cursor is restored among the rows shown
+	startup = startup.withSaved(state)
+	startupWarnings := model.applyStartup(startup)
+	model.restoreUIState(state)
+	model.entertainment, err = loadEntertainmentAreas()
+	if err != nil {
+		logError("Failed to load entertainment areas: %v", err)
+	}
+	sceneKeys, sceneKeyWarnings := resolveSceneKeys(appConfig.SceneKeys)
+	model.sceneKeys = sceneKeys
+	for _, warning := range sceneKeyWarnings {
+		logError("Config: %s", warning)
+	}
+	warnings = append(warnings, sceneKeyWarnings...)
+	if len(warnings) > 0 {
+		model.status = "Config: " + strings.Join(warnings, "; ")
+	}
+	if len(startupWarnings) > 0 {
+		model.status = strings.TrimPrefix(model.status+"\nStartup: "+strings.Join(startupWarnings, "; "), "\n")
+	}
+	if connectivityError != nil {
+		// Already logged by checkConnectivity
+		model.status = strings.TrimPrefix(model.status+"\nReachability is unknown: "+connectivityError.Error(), "\n")
+	}
+
+	// Run the startup script once the light list is loaded
+	if *execScript != "" {
+		err := model.executeCommand(*execScript)
+		if *execQuit {
+			model = finishExec(model, model.takeQueued(), shutdownTimeout)
+			waitForWrites(shutdownTimeout)()
+			cancelApp()
+			fmt.Println(model.status)
+			if err != nil || model.writesFailed {
+				exitCode = 1
+			}
+			return
+		}
+	}
+	// Work queued by the script or by restoring the UI state
+	model.startup = model.takeQueued()
+
+	var recorder *sseRecorder
+	if *recordPath != "" {
+		recorder, err = newSSERecorder(*recordPath)
 		if err != nil {
-			log.Fatalf("Error returning lights: %v", err)
+			fmt.Printf("Unable to open %s: %v\n", *recordPath, err)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+	}
+
+	// Start SSE client in a goroutine so it doesn't block the TUI. The Remote
+	// API has no event stream, so remote mode polls instead.
+	if remoteMode {
+		model.status = "Connected through the Hue Remote API; lights refresh every 15s"
+	} else if *replayPath != "" {
+		go func() {
+			if err := replaySSE(*replayPath, sseChannel); err != nil {
+				logError("Error replaying %s: %v", *replayPath, err)
+			}
+		}()
+	} else {
+		go subscribeSSE(sseChannel, recorder)
+	}
+
+	// The status server reads the model through Update, so it starts with the program
+	if *listenAddr != "" {
+		server := newStatusServer(*listenAddr)
+		model.snapshotRequests = server.requests
+		if err := server.start(); err != nil {
+			logError("Unable to start the status server: %v", err)
+			fmt.Printf("Unable to start the status server on %s: %v\n", *listenAddr, err)
+			exitCode = 1
+			return
 		}
-		return lights
-	}(), sseChannel))
+		defer server.shutdown()
+	}
 
-	if _, err := p.Run(); err != nil {
+	// Mouse positions only make sense against the table layout
+	options := []tea.ProgramOption{tea.WithMouseCellMotion()}
+	if *plain {
+		activeRenderer = plainRenderer{}
+		options = nil
+	}
+	p := tea.NewProgram(model, options...)
+
+	final, err := p.Run()
+	if m, ok := final.(lightModel); ok && err == nil {
+		mode, _ := parseExitSummary(appConfig.ExitSummary)
+		showExitSummary(m, mode, os.Stdin, os.Stdout)
+	}
+	cancelApp()
+	if errors.Is(err, tea.ErrProgramPanic) {
+		// bubbletea has restored the terminal and printed the panic
+		fmt.Println(crashHint(*logPath))
+		exitCode = 1
+		return
+	}
+	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
-		os.Exit(1)
+		exitCode = 1
 	}
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMain keeps the app's logging out of test output
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// readTestdata returns a file under testdata
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// testBridge is a fake bridge the app's globals point at for one test. GETs
+// are answered from replies; writes are recorded and succeed.
+type testBridge struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	replies map[string]string // path such as "/clip/v2/resource/light" -> body
+	writes  []testWrite
+	gets    map[string]int // GETs served, by path
+}
+
+// testWrite is a write the fake bridge received
+type testWrite struct {
+	method string
+	path   string
+	body   map[string]any
+}
+
+// newTestBridge starts a fake bridge and points bridgeIP, apiKey and an empty
+// bridgeCache at it until the test ends
+func newTestBridge(t *testing.T) *testBridge {
+	t.Helper()
+	b := &testBridge{t: t, replies: make(map[string]string), gets: make(map[string]int)}
+	useTestServer(t, http.HandlerFunc(b.serve))
+	return b
+}
+
+// settle runs the background work m queued, such as writes, and passes what
+// it returns through Update as the TUI would
+func settle(m lightModel) lightModel {
+	return finishExec(m, m.takeQueued(), 5*time.Second)
+}
+
+// useTestServer points bridgeIP, apiKey and an empty bridgeCache at a TLS
+// server running handler until the test ends
+func useTestServer(t *testing.T, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldIP, oldKey, oldCache := bridgeIP, apiKey, bridgeCache
+	bridgeIP, apiKey = u.Host, "test-key"
+	bridgeCache = &resourceCache{types: make(map[string]map[string]map[string]any)}
+	t.Cleanup(func() {
+		bridgeIP, apiKey, bridgeCache = oldIP, oldKey, oldCache
+	})
+}
+
+func (b *testBridge) serve(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r.Method != http.MethodGet {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			b.t.Errorf("%s %s: body isn't JSON: %q", r.Method, r.URL.Path, data)
+		}
+		b.writes = append(b.writes, testWrite{method: r.Method, path: r.URL.Path, body: body})
+		fmt.Fprint(w, `{"errors":[],"data":[]}`)
+		return
+	}
+	b.gets[r.URL.Path]++
+	reply, ok := b.replies[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors":[{"description":"resource not found"}],"data":[]}`)
+		return
+	}
+	fmt.Fprint(w, reply)
+}
+
+// reply sets the body served for GETs of path
+func (b *testBridge) reply(path, body string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.replies[path] = body
+}
+
+// recorded returns the writes received so far
+func (b *testBridge) recorded() []testWrite {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]testWrite(nil), b.writes...)
+}
+
+// served returns how many GETs of path were answered
+func (b *testBridge) served(path string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.gets[path]
+}
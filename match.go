@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openhue/openhue-go"
+)
+
+// matchState is the part of a light's state that :match copies
+type matchState struct {
+	on         bool
+	brightness *float32 // nil for lights without dimming
+	mirek      *int     // set when the light is in color temperature mode
+	xy         *xyColor // set when the light is in color mode
+}
+
+// lightCaps is what a light can be asked to show
+type lightCaps struct {
+	dimmable    bool
+	minDimLevel float32
+	color       bool
+	ct          bool
+	mirekMin    int
+	mirekMax    int
+	gamut       *gamut // nil when the light doesn't report one
+}
+
+// readMatchState extracts the copyable state from a bridge light
+func readMatchState(light openhue.LightGet) matchState {
+	// IsOn dereferences On, which sparse resources leave out
+	state := matchState{on: light.On != nil && light.On.On != nil && *light.On.On}
+	if light.Dimming != nil && light.Dimming.Brightness != nil {
+		state.brightness = light.Dimming.Brightness
+	}
+
+	// The bridge only reports a valid mirek while the light is in CT mode
+	ct := light.ColorTemperature
+	if ct != nil && ct.Mirek != nil && ct.MirekValid != nil && *ct.MirekValid {
+		state.mirek = ct.Mirek
+	} else if light.Color != nil && light.Color.Xy != nil && light.Color.Xy.X != nil && light.Color.Xy.Y != nil {
+		state.xy = &xyColor{x: float64(*light.Color.Xy.X), y: float64(*light.Color.Xy.Y)}
+	}
+	return state
+}
+
+// readCaps extracts a bridge light's dimming and color capabilities
+func readCaps(light openhue.LightGet) lightCaps {
+	caps := lightCaps{
+		dimmable: light.Dimming != nil && light.Dimming.Brightness != nil,
+		color:    light.Color != nil,
+	}
+	if caps.dimmable && light.Dimming.MinDimLevel != nil {
+		caps.minDimLevel = *light.Dimming.MinDimLevel
+	}
+	if g := light.Color; g != nil && g.Gamut != nil {
+		corners := []*openhue.GamutPosition{g.Gamut.Red, g.Gamut.Green, g.Gamut.Blue}
+		var triangle gamut
+		complete := true
+		for i, corner := range corners {
+			if corner == nil || corner.X == nil || corner.Y == nil {
+				complete = false
+				break
+			}
+			triangle[i] = xyColor{x: float64(*corner.X), y: float64(*corner.Y)}
+		}
+		if complete {
+			caps.gamut = &triangle
+		}
+	}
+	if ct := light.ColorTemperature; ct != nil && ct.MirekSchema != nil &&
+		ct.MirekSchema.MirekMinimum != nil && ct.MirekSchema.MirekMaximum != nil {
+		caps.ct = true
+		caps.mirekMin = *ct.MirekSchema.MirekMinimum
+		caps.mirekMax = *ct.MirekSchema.MirekMaximum
+	}
+	return caps
+}
+
+// matchUpdate builds the write that makes a light with caps look like source.
+// approximated is true when a color was turned into a color temperature; an
+// error means the light can't show the source's color at all.
+func matchUpdate(source matchState, caps lightCaps) (put openhue.LightPut, approximated bool, err error) {
+	on := source.on
+	put.On = &openhue.On{On: &on}
+	if !source.on {
+		return put, false, nil
+	}
+
+	if caps.dimmable && source.brightness != nil {
+		brightness := clampBrightness(*source.brightness, caps.minDimLevel)
+		put.Dimming = &openhue.Dimming{Brightness: &brightness}
+	}
+
+	approximated, err = setColor(&put, source.mirek, source.xy, caps)
+	return put, approximated, err
+}
+
+// setColor adds a color temperature or color to put, converting between the
+// two when the light only supports one. At most one of mirek and xy is set.
+func setColor(put *openhue.LightPut, mirek *int, xy *xyColor, caps lightCaps) (approximated bool, err error) {
+	switch {
+	case mirek != nil && caps.ct:
+		clamped := caps.clampMirek(*mirek)
+		put.ColorTemperature = &openhue.ColorTemperature{Mirek: &clamped}
+	case mirek != nil && caps.color:
+		put.Color = xyPut(mirekToXY(*mirek))
+	case xy != nil && caps.color:
+		color := *xy
+		if caps.gamut != nil {
+			color = caps.gamut.clamp(color)
+		}
+		put.Color = xyPut(color)
+	case xy != nil:
+		approx, ok := xyToMirek(*xy)
+		if !ok {
+			return false, fmt.Errorf("can't show that color")
+		}
+		if caps.ct {
+			approx = caps.clampMirek(approx)
+			put.ColorTemperature = &openhue.ColorTemperature{Mirek: &approx}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// xyPut wraps a chromaticity for a light write
+func xyPut(c xyColor) *openhue.Color {
+	x, y := float32(c.x), float32(c.y)
+	return &openhue.Color{Xy: &openhue.GamutPosition{X: &x, Y: &y}}
+}
+
+// matchCommand handles ":match", copying the cursor light's state onto the
+// selected lights
+func (m *lightModel) matchCommand() error {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return fmt.Errorf("no light under the cursor")
+	}
+	if len(m.selected) == 0 {
+		return fmt.Errorf("no lights selected")
+	}
+
+	lights, err := cachedLights()
+	if err != nil {
+		return fmt.Errorf("error fetching lights: %v", err)
+	}
+	sourceLight := m.light[m.rows[m.cursor].lights[0]]
+	sourceGet, ok := lights[sourceLight.ID]
+	if !ok {
+		return fmt.Errorf("light not found: %s", sourceLight.ID)
+	}
+	source := readMatchState(sourceGet)
+
+	skipped, approximated := 0, 0
+	var incompatible []string
+	var writes []lightWrite
+	puts := make(map[string]openhue.LightPut)
+	for index := range m.selected {
+		light := m.light[index]
+		if light.ID == sourceLight.ID {
+			continue
+		}
+		target, ok := lights[light.ID]
+		if !ok || !light.Reachable {
+			skipped++
+			continue
+		}
+
+		put, approx, err := matchUpdate(source, readCaps(target))
+		if err != nil {
+			incompatible = append(incompatible, light.Name)
+			skipped++
+			continue
+		}
+
+		outgoing.recordOn(light.ID, source.on)
+		if put.Dimming != nil {
+			outgoing.recordBrightness(light.ID, *put.Dimming.Brightness)
+		}
+		puts[light.ID] = put
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			return updateLight(light.ID, put)
+		}})
+		if approx {
+			approximated++
+		}
+	}
+
+	sort.Strings(incompatible)
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		for _, w := range written {
+			m.setLightStatus(w.light.ID, onOffStatus(source.on))
+			if put := puts[w.light.ID]; put.Dimming != nil {
+				m.setLightBrightness(w.light.ID, *put.Dimming.Brightness)
+			}
+		}
+		summary := summarizeAction("action.match", len(written), skipped, len(failed), sourceLight.Name)
+		if approximated > 0 {
+			summary += tr("action.match.approximated", approximated)
+		}
+		if len(incompatible) > 0 {
+			summary += tr("action.match.incompatible", strings.Join(incompatible, ", "))
+		}
+		m.setStatus("%s", summary)
+	})
+	return nil
+}
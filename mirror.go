@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/openhue/openhue-go"
+)
+
+// Table markers for :mirror, appended to the row name
+const (
+	mirrorLeaderMark   = " ◆"
+	mirrorFollowerMark = " ◇"
+)
+
+// mirrorState is a :mirror session: changes to the leader are replayed to the
+// followers. A light is never both, so the followers' own events can't feed
+// back into the mirror.
+type mirrorState struct {
+	leader    string               // light ID, empty when mirroring is off
+	followers map[string]lightCaps // light IDs, with what each can show
+}
+
+// marker returns the mark for a table row, or "" if it takes no part
+func (s mirrorState) marker(tr tableRow, lights []Light) string {
+	if s.leader == "" {
+		return ""
+	}
+	for _, index := range tr.lights {
+		if lights[index].ID == s.leader {
+			return mirrorLeaderMark
+		}
+	}
+	for _, index := range tr.lights {
+		if _, ok := s.followers[lights[index].ID]; ok {
+			return mirrorFollowerMark
+		}
+	}
+	return ""
+}
+
+// mirrorCommand handles ":mirror on" and ":mirror off"
+func (m *lightModel) mirrorCommand(args string) error {
+	switch args {
+	case "on":
+		return m.startMirror()
+	case "off":
+		if m.mirror.leader == "" {
+			return fmt.Errorf("mirroring is not on")
+		}
+		m.mirror = mirrorState{}
+		m.setStatusTr("status.mirror.stopped")
+		return nil
+	default:
+		return fmt.Errorf("usage: mirror on|off")
+	}
+}
+
+// startMirror makes the cursor light the leader and the selected lights its followers
+func (m *lightModel) startMirror() error {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return fmt.Errorf("no light under the cursor")
+	}
+	leader := m.light[m.rows[m.cursor].lights[0]]
+
+	lights, err := cachedLights()
+	if err != nil {
+		return fmt.Errorf("error fetching lights: %v", err)
+	}
+	followers := make(map[string]lightCaps)
+	for index := range m.selected {
+		light := m.light[index]
+		if target, ok := lights[light.ID]; ok && light.ID != leader.ID {
+			followers[light.ID] = readCaps(target)
+		}
+	}
+	if len(followers) == 0 {
+		return fmt.Errorf("select the lights that should follow %s", leader.Name)
+	}
+
+	m.mirror = mirrorState{leader: leader.ID, followers: followers}
+	m.setStatus("%s", trn("status.mirror.started", len(followers), leader.Name, len(followers)))
+	return nil
+}
+
+// mirrorLeader replays a leader's SSE change to the followers in the
+// background. Events for any other light return nil.
+func (m lightModel) mirrorLeader(item SSEDataItem) tea.Cmd {
+	if m.mirror.leader == "" || item.ID != m.mirror.leader {
+		return nil
+	}
+
+	var mirek *int
+	var xy *xyColor
+	if ct := item.ColorTemperature; ct != nil && ct.MirekValid && ct.Mirek != nil {
+		mirek = ct.Mirek
+	} else if item.Color != nil {
+		xy = &xyColor{x: item.Color.XY.X, y: item.Color.XY.Y}
+	}
+
+	followers := m.mirror.followers
+	return func() tea.Msg {
+		for id, caps := range followers {
+			var put openhue.LightPut
+			if item.On != nil {
+				on := item.On.On
+				put.On = &openhue.On{On: &on}
+				outgoing.recordOn(id, on)
+			}
+			if item.Dimming != nil && caps.dimmable {
+				brightness := clampBrightness(float32(item.Dimming.Brightness), caps.minDimLevel)
+				put.Dimming = &openhue.Dimming{Brightness: &brightness}
+				outgoing.recordBrightness(id, brightness)
+			}
+			// A color the follower can't show is left out; the rest still applies
+			if _, err := setColor(&put, mirek, xy, caps); err != nil {
+				logDebug("Mirror: %s %v", id, err)
+			}
+			if put.On == nil && put.Dimming == nil && put.Color == nil && put.ColorTemperature == nil {
+				continue
+			}
+
+			if err := updateLight(id, put); err != nil {
+				logError("Mirror: error updating light %s: %v", id, err)
+			}
+		}
+		return nil
+	}
+}
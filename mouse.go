@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// doubleClickWindow is the longest gap between two clicks on a row that counts as a double-click
+const doubleClickWindow = 400 * time.Millisecond
+
+// tableColumn identifies which cell of a table row was clicked: a column
+// key such as "status", or one of these
+type tableColumn string
+
+const (
+	columnNone  tableColumn = ""
+	columnCheck tableColumn = "check"
+)
+
+// Horizontal layout of a data row, mirroring View: table border, table
+// padding, the row indent, then cursor and checkmark cells before the columns
+const (
+	rowLeft     = 1 + 2 + 2
+	checkLeft   = rowLeft + 2
+	columnsLeft = checkLeft + 2
+)
+
+// firstRowY returns the screen line of the first data row: the title, the
+// summary, the away banner, room timers and notifications if shown, the
+// table's top margin and border, then the header and divider
+func (m lightModel) firstRowY() int {
+	bannerLines := strings.Count(m.renderAwayBanner()+m.renderRoomTimers(), "\n")
+	notificationLines := strings.Count(m.renderNotifications(), "\n")
+	return 1 + 1 + bannerLines + notificationLines + 1 + 1 + 2
+}
+
+// hitTest maps a screen position to a row index and column of the light
+// table, in the layout and scroll position View draws
+func (m lightModel) hitTest(x, y int) (int, tableColumn, bool) {
+	table := tableRenderer{}
+	m = table.fit(m)
+	start, end := table.window(m)
+	line := y - m.firstRowY()
+	row := start + line
+	if line < 0 || row >= end {
+		return 0, columnNone, false
+	}
+
+	switch {
+	case x < rowLeft:
+		return row, columnNone, true
+	case x < columnsLeft:
+		return row, columnCheck, true
+	}
+
+	// Each column is followed by a two-space gap, which counts as part of it
+	left := columnsLeft
+	for _, col := range m.layoutColumns() {
+		left += col.width + 2
+		if x < left {
+			return row, tableColumn(col.key), true
+		}
+	}
+	return row, columnNone, true
+}
+
+// handleMouse moves the cursor, toggles selection or toggles lights from mouse input
+func (m *lightModel) handleMouse(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.moveCursorTo(m.cursor - 1)
+		return
+	case tea.MouseButtonWheelDown:
+		m.moveCursorTo(m.cursor + 1)
+		return
+	case tea.MouseButtonLeft:
+	default:
+		return
+	}
+
+	if msg.Action != tea.MouseActionPress {
+		return
+	}
+
+	row, column, ok := m.hitTest(msg.X, msg.Y)
+	if !ok {
+		return
+	}
+	m.cursor = row
+
+	now := time.Now()
+	doubleClick := row == m.lastClickRow && now.Sub(m.lastClickAt) < doubleClickWindow
+	m.lastClickRow, m.lastClickAt = row, now
+
+	switch {
+	case column == "status":
+		// Clicking ON/OFF toggles just that light, or every service of a device
+		m.toggleLights(m.rows[row].lights)
+	case column == columnCheck || doubleClick:
+		m.toggleRowSelection(row)
+		// Don't let a third click count as another double-click
+		m.lastClickAt = time.Time{}
+	}
+}
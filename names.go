@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxNameSuggestions caps the "did you mean" list of a name that isn't found
+const maxNameSuggestions = 3
+
+// resolveLightName finds the one light a command names, returning its index.
+// Names match ignoring case and surrounding space. Hue allows duplicate
+// names, so when several lights share one the error lists them with their
+// rooms, numbered, and "name#2" picks the second. A name that matches nothing
+// gets up to three similar names as suggestions.
+func resolveLightName(lights []Light, name string, roomOf func(string) string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return -1, fmt.Errorf("no light named")
+	}
+
+	matches := lightsNamed(lights, name)
+	pick := 0
+	if len(matches) == 0 {
+		// "name#n" only counts when the name alone doesn't match
+		if base, n, ok := cutOrdinal(name); ok {
+			matches, name, pick = lightsNamed(lights, base), base, n
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return -1, lightNotFound(lights, name)
+	case pick > len(matches):
+		return -1, fmt.Errorf("only %d %s named %q", len(matches), pluralize(len(matches), "light is", "lights are"), name)
+	case pick > 0:
+		return matches[pick-1], nil
+	case len(matches) == 1:
+		return matches[0], nil
+	}
+
+	choices := make([]string, len(matches))
+	for i, index := range matches {
+		room := roomOf(lights[index].ID)
+		if room == "" {
+			room = "no room"
+		}
+		choices[i] = fmt.Sprintf("%s#%d (%s)", lights[index].Name, i+1, room)
+	}
+	return -1, fmt.Errorf("%d lights are named %q: %s; add #n to pick one", len(matches), name, strings.Join(choices, ", "))
+}
+
+// lightsNamed lists the indices of the lights named name, in list order
+func lightsNamed(lights []Light, name string) []int {
+	var matches []int
+	for i, light := range lights {
+		if strings.EqualFold(strings.TrimSpace(light.Name), name) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// cutOrdinal splits "Lamp#2" into "Lamp" and 2
+func cutOrdinal(name string) (string, int, bool) {
+	i := strings.LastIndex(name, "#")
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(name[i+1:])
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+	return strings.TrimSpace(name[:i]), n, true
+}
+
+// lightNotFound is the error for a name no light has, suggesting the closest
+// names: those containing it, then those a couple of typos away
+func lightNotFound(lights []Light, name string) error {
+	want := []rune(strings.ToLower(name))
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, light := range lights {
+		lower := strings.ToLower(light.Name)
+		if seen[lower] {
+			continue
+		}
+		seen[lower] = true
+		distance := editDistance(want, []rune(lower))
+		if strings.Contains(lower, string(want)) {
+			distance = 0
+		}
+		if distance <= max(2, len(want)/4) {
+			candidates = append(candidates, candidate{light.Name, distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	if len(candidates) == 0 {
+		return fmt.Errorf("no light named %q", name)
+	}
+	suggestions := make([]string, 0, maxNameSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxNameSuggestions)] {
+		suggestions = append(suggestions, strconv.Quote(c.name))
+	}
+	return fmt.Errorf("no light named %q; did you mean %s?", name, strings.Join(suggestions, ", "))
+}
+
+// editDistance is the Levenshtein distance between two names, counted in
+// runes so accented and other non-ASCII letters are one edit each
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if unicode.ToLower(a[i-1]) == unicode.ToLower(b[j-1]) {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// hasGlob reports whether a :select argument is a pattern rather than a name
+func hasGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// toggleCommand handles ":toggle <light>", switching one light by name
+func (m *lightModel) toggleCommand(args string) error {
+	if args == "" {
+		return fmt.Errorf("usage: toggle <light>")
+	}
+	index, err := resolveLightName(m.light, unquote(args), m.roomOf)
+	if err != nil {
+		return err
+	}
+	light := m.light[index]
+	if !light.Reachable {
+		return fmt.Errorf("%s is unreachable", light.Name)
+	}
+	if err := toggleLight(light.ID, light.Status == "on"); err != nil {
+		return fmt.Errorf("toggling %s: %v%s", light.Name, err, m.syncHint([]Light{light}))
+	}
+	m.light[index].Status = onOffStatus(light.Status != "on")
+	m.setStatusTr("status.turned", light.Name, tr("state."+m.light[index].Status))
+	return nil
+}
+
+// renameCommand handles ":rename <light> = <new name>"
+func (m *lightModel) renameCommand(args string) error {
+	old, name, ok := strings.Cut(args, "=")
+	name = unquote(name)
+	if !ok || strings.TrimSpace(old) == "" || name == "" {
+		return fmt.Errorf("usage: rename <light> = <new name>")
+	}
+	index, err := resolveLightName(m.light, unquote(old), m.roomOf)
+	if err != nil {
+		return err
+	}
+	if len([]rune(name)) > 32 {
+		return fmt.Errorf("light names can be at most 32 characters")
+	}
+
+	light := m.light[index]
+	body := map[string]any{"metadata": map[string]string{"name": name}}
+	if _, err := clipWrite("PUT", "resource/light/"+light.ID, body); err != nil {
+		return fmt.Errorf("renaming %s: %v", light.Name, err)
+	}
+	bridgeCache.mergeWrite("light", light.ID, body)
+	m.light[index].Name = name
+	m.setStatusTr("status.renamed", light.Name, name)
+	return nil
+}
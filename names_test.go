@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveLightName(t *testing.T) {
+	names := []string{
+		"Desk",     // 0
+		"desk ",    // 1, the same name in another room
+		"Küche",    // 2
+		"日本の照明",    // 3
+		"💡 Lamp",   // 4
+		"Lamp#1",   // 5, a # in the name itself
+		"Ceiling",  // 6
+		"ΟΔΟΣ",     // 7
+		"Ceiling",  // 8
+		"Ceiling",  // 9
+		"Hallway",  // 10
+		"Hall way", // 11
+	}
+	lights := make([]Light, len(names))
+	for i, name := range names {
+		lights[i] = Light{ID: name + string(rune('a'+i)), Name: name}
+	}
+	rooms := map[string]string{lights[0].ID: "Office", lights[1].ID: "Bedroom", lights[6].ID: "Kitchen"}
+	roomOf := func(id string) string { return rooms[id] }
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr string // part of the error; "" for a match
+	}{
+		{"Küche", 2, ""},
+		{"küche", 2, ""},
+		{"KÜCHE", 2, ""},
+		{"  Küche  ", 2, ""},
+		{"日本の照明", 3, ""},
+		{"💡 lamp", 4, ""},
+		{"οδος", 7, ""},
+		{"hallway", 10, ""},
+		{"HALL WAY", 11, ""},
+
+		// Duplicates, matched ignoring case and surrounding space
+		{"desk", -1, `2 lights are named "desk": Desk#1 (Office), desk #2 (Bedroom); add #n to pick one`},
+		{"DESK#2", 1, ""},
+		{"desk #1", 0, ""},
+		{"Desk#3", -1, `only 2 lights are named "Desk"`},
+		{"Desk#0", -1, `no light named "Desk#0"`},
+		{"ceiling", -1, "Ceiling#1 (Kitchen), Ceiling#2 (no room), Ceiling#3 (no room)"},
+		{"ceiling#3", 9, ""},
+
+		// A name with a # is matched whole before it's read as name#n
+		{"lamp#1", 5, ""},
+		{"Lamp#2", -1, `no light named "Lamp"`},
+
+		// Not found, with suggestions
+		{"Kuche", -1, `did you mean "Küche"?`},
+		{"日本", -1, `did you mean "日本の照明"?`},
+		{"Porch", -1, `no light named "Porch"`},
+		{"", -1, "no light named"},
+		{"   ", -1, "no light named"},
+	}
+	for _, tt := range tests {
+		got, err := resolveLightName(lights, tt.name, roomOf)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%q: %v, want light %d", tt.name, err, tt.want)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%q: got %d, %v; want an error containing %q", tt.name, got, err, tt.wantErr)
+		case got != tt.want:
+			t.Errorf("%q = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+// maxCount caps numeric prefixes so a stray run of digits can't overflow
+const maxCount = 999
+
+// tableChromeLines is roughly how many lines the table's surroundings take
+// below the first row: border, footer, and the command box
+const tableChromeLines = 8
+
+// keyPrefix is the pending state for vim-style multi-key input. Bubbletea
+// delivers keys one at a time, so counts and gg are accumulated here until
+// the key they apply to arrives.
+type keyPrefix struct {
+	count int  // numeric prefix typed so far, 0 when none
+	g     bool // a g is waiting for its second g
+}
+
+// consumePrefixKey records a count digit or the first g of gg, and handles
+// the second g. It reports whether the key was used up.
+func (m *lightModel) consumePrefixKey(key string) bool {
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || m.pending.count > 0) {
+		m.pending.count = min(m.pending.count*10+int(key[0]-'0'), maxCount)
+		m.pending.g = false
+		return true
+	}
+
+	if key == "g" {
+		if m.pending.g {
+			m.moveCursorTo(0)
+			m.pending = keyPrefix{}
+		} else {
+			m.pending.g = true
+		}
+		return true
+	}
+	m.pending.g = false
+	return false
+}
+
+// takeCount returns the pending count, or 1 when none was typed, and clears it
+func (m *lightModel) takeCount() (count int, given bool) {
+	count = m.pending.count
+	m.pending.count = 0
+	if count == 0 {
+		return 1, false
+	}
+	return count, true
+}
+
+// moveCursorTo puts the cursor on row, clamped to the table
+func (m *lightModel) moveCursorTo(row int) {
+	m.cursor = max(0, min(row, len(m.rows)-1))
+}
+
+// halfPage is how many rows ctrl+d and ctrl+u move: half the rows that fit on screen
+func (m lightModel) halfPage() int {
+	if m.height == 0 {
+		return 10
+	}
+	return max(1, (m.height-m.firstRowY()-tableChromeLines)/2)
+}
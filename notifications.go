@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// ownChangeWindow is how long after a write its SSE echo is treated as ours
+	ownChangeWindow = 3 * time.Second
+
+	// notificationTTL is how long a notification stays on screen
+	notificationTTL = 4 * time.Second
+
+	// maxNotifications caps the stack so a scene recall can't cover the table
+	maxNotifications = 3
+)
+
+var notificationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C")).MarginLeft(2)
+
+// outgoingChange is a write this client sent to the bridge
+type outgoingChange struct {
+	on         *bool
+	brightness *float32
+	at         time.Time
+}
+
+// changeTracker remembers recent writes so their SSE echoes aren't reported
+// as external changes
+type changeTracker struct {
+	mu      sync.Mutex
+	changes map[string]outgoingChange // light ID -> last write
+	bulkAt  time.Time                 // last scene recall or other multi-light write
+}
+
+// Global tracker shared by the light write helpers
+var outgoing = &changeTracker{changes: make(map[string]outgoingChange)}
+
+func (t *changeTracker) recordOn(lightID string, on bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change := t.changes[lightID]
+	change.on = &on
+	change.at = time.Now()
+	t.changes[lightID] = change
+}
+
+func (t *changeTracker) recordBrightness(lightID string, brightness float32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change := t.changes[lightID]
+	change.brightness = &brightness
+	change.at = time.Now()
+	t.changes[lightID] = change
+}
+
+// recordBulk marks a write whose per-light effects we can't predict, such as a scene recall
+func (t *changeTracker) recordBulk() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bulkAt = time.Now()
+}
+
+// isOwn reports whether an SSE light event matches a recent write from this client
+func (t *changeTracker) isOwn(lightID string, on *bool, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.bulkAt) < ownChangeWindow {
+		return true
+	}
+
+	change, ok := t.changes[lightID]
+	if !ok || now.Sub(change.at) >= ownChangeWindow {
+		return false
+	}
+	if on != nil && change.on != nil && *on != *change.on {
+		return false
+	}
+	return true
+}
+
+// notification is a transient message about something that happened outside this client
+type notification struct {
+	text    string
+	expires time.Time
+}
+
+// notificationExpiredMsg prompts the model to drop expired notifications
+type notificationExpiredMsg struct{}
+
+func expireNotificationsAfter(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return notificationExpiredMsg{}
+	})
+}
+
+// notify queues a notification, keeping only the newest few and counting the rest
+func (m *lightModel) notify(format string, args ...any) {
+	m.notifications = append(m.notifications, notification{
+		text:    fmt.Sprintf(format, args...),
+		expires: time.Now().Add(notificationTTL),
+	})
+	if len(m.notifications) > maxNotifications {
+		m.notificationOverflow += len(m.notifications) - maxNotifications
+		m.notifications = m.notifications[len(m.notifications)-maxNotifications:]
+	}
+}
+
+// pruneNotifications drops expired notifications
+func (m *lightModel) pruneNotifications(now time.Time) {
+	var kept []notification
+	for _, n := range m.notifications {
+		if now.Before(n.expires) {
+			kept = append(kept, n)
+		}
+	}
+	m.notifications = kept
+	if len(kept) == 0 {
+		m.notificationOverflow = 0
+	}
+}
+
+func (m lightModel) renderNotifications() string {
+	if len(m.notifications) == 0 {
+		return ""
+	}
+
+	s := ""
+	for _, n := range m.notifications {
+		s += notificationStyle.Render(glyphText("• ")+n.text) + "\n"
+	}
+	if m.notificationOverflow > 0 {
+		s += notificationStyle.Faint(true).Render(fmt.Sprintf(glyphText("  …and %d more"), m.notificationOverflow)) + "\n"
+	}
+	return s
+}
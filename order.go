@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// applyOrder sorts lights by the saved order of light IDs. Lights missing from
+// the saved order (new, or never moved) keep their default order at the end.
+func applyOrder(lights []Light, order []string) []Light {
+	if len(order) == 0 {
+		return lights
+	}
+
+	position := make(map[string]int, len(order))
+	for i, id := range order {
+		position[id] = i
+	}
+
+	ordered := slices.Clone(lights)
+	slices.SortStableFunc(ordered, func(a, b Light) int {
+		pa, aKnown := position[a.ID]
+		pb, bKnown := position[b.ID]
+		switch {
+		case aKnown && bKnown:
+			return pa - pb
+		case aKnown:
+			return -1
+		case bKnown:
+			return 1
+		}
+		return 0
+	})
+	return ordered
+}
+
+// topLevelRows returns the rows that can be moved: plain lights and device
+// headers. Members move with their device.
+func topLevelRows(rows []tableRow) []int {
+	var top []int
+	for i, row := range rows {
+		if !row.member {
+			top = append(top, i)
+		}
+	}
+	return top
+}
+
+// moveCursorRow swaps the cursor row (or the device it belongs to) with its
+// neighbour and saves the resulting order to the config file
+func (m *lightModel) moveCursorRow(direction int) error {
+	if m.roomsView {
+		return fmt.Errorf("rows can only be moved in the lights view")
+	}
+	if m.filtered() {
+		return fmt.Errorf("rows can't be moved while a filter is active")
+	}
+	top := topLevelRows(m.rows)
+	unit := -1
+	for i, row := range top {
+		if row <= m.cursor {
+			unit = i
+		}
+	}
+	if unit < 0 {
+		return nil
+	}
+
+	target := unit + direction
+	if target < 0 || target >= len(top) {
+		return nil
+	}
+	top[unit], top[target] = top[target], top[unit]
+
+	var ids []string
+	for _, row := range top {
+		for _, index := range m.rows[row].lights {
+			ids = append(ids, m.light[index].ID)
+		}
+	}
+	// Names can repeat, so the moved row is found again by its first light
+	moved := m.light[m.rows[top[target]].lights[0]].ID
+
+	m.reorderLights(ids)
+	if err := setConfigValue("order", ids); err != nil {
+		return fmt.Errorf("saving order: %v", err)
+	}
+	appConfig.Order = ids
+
+	// Follow the moved row
+	for i, row := range m.rows {
+		if !row.member && m.light[row.lights[0]].ID == moved {
+			m.cursor = i
+			break
+		}
+	}
+	return nil
+}
+
+// reorderLights rearranges the light slice, carrying the selection across
+func (m *lightModel) reorderLights(order []string) {
+	m.setLights(applyOrder(m.light, order))
+}
+
+// orderCommand handles ":order reset"
+func (m *lightModel) orderCommand(args string) error {
+	if args != "reset" {
+		return fmt.Errorf("usage: order reset")
+	}
+	if err := setConfigValue("order", []string{}); err != nil {
+		return fmt.Errorf("saving order: %v", err)
+	}
+	appConfig.Order = nil
+
+	freshLights, err := returnLights()
+	if err != nil {
+		return err
+	}
+	m.replaceLights(freshLights)
+	m.setStatusTr("status.order.reset")
+	return nil
+}
+
+// moveCommand handles ":move up" and ":move down"
+func (m *lightModel) moveCommand(args string) error {
+	switch args {
+	case "up":
+		return m.moveCursorRow(-1)
+	case "down":
+		return m.moveCursorRow(1)
+	}
+	return fmt.Errorf("usage: move up|down")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveCursorRowDuplicateNames(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.Mkdir(filepath.Join(home, ".openhue"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	oldConfig := appConfig
+	t.Cleanup(func() { appConfig = oldConfig })
+	appConfig = &Config{}
+
+	lights := testLights(3)
+	for i := range lights {
+		lights[i].Name = "Lamp"
+	}
+	m := initialModel(lights, nil)
+	m.cursor = 0
+
+	if err := m.moveCursorRow(1); err != nil {
+		t.Fatal(err)
+	}
+	if m.cursor != 1 {
+		t.Fatalf("cursor = %d, want 1", m.cursor)
+	}
+	if got := m.light[m.rows[m.cursor].lights[0]].ID; got != "light-00" {
+		t.Errorf("cursor on %s, want light-00", got)
+	}
+}
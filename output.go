@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// maxOutputLines is how much command output the command box keeps
+	maxOutputLines = 20
+
+	// outputViewLines is how many lines of output the command box shows at once
+	outputViewLines = 3
+)
+
+// appendOutput adds text to the command box's output, keeping the newest
+// maxOutputLines lines and scrolling back to the bottom. It isn't logged, so
+// results such as :reveal-key stay off disk.
+func (m *lightModel) appendOutput(text string) {
+	if text == "" {
+		return
+	}
+	m.output = append(m.output, strings.Split(text, "\n")...)
+	if len(m.output) > maxOutputLines {
+		m.output = m.output[len(m.output)-maxOutputLines:]
+	}
+	m.outputScroll = 0
+	m.outputAt = time.Now()
+}
+
+// scrollOutput moves the output view by delta lines; positive scrolls back
+func (m *lightModel) scrollOutput(delta int) {
+	m.outputScroll = min(max(m.outputScroll+delta, 0), max(len(m.output)-outputViewLines, 0))
+}
+
+// visibleOutput returns the output lines in view
+func (m lightModel) visibleOutput() []string {
+	end := len(m.output) - m.outputScroll
+	return m.output[max(end-outputViewLines, 0):end]
+}
+
+// clearCommand handles :clear
+func (m *lightModel) clearCommand() {
+	m.output = nil
+	m.outputScroll = 0
+	m.status = ""
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/openhue/openhue-go"
+)
+
+const (
+	pickerHues        = 24 // hue row cells, 15° apart
+	pickerSaturations = 8  // grid columns, from white to full color
+	pickerLevels      = 5  // grid rows, from 20% to 100% brightness
+	pickerCTSteps     = 16 // color temperature slider cells, warm to cool
+
+	// pickerWriteInterval is the least time between preview writes, so
+	// holding an arrow doesn't flood the bridge
+	pickerWriteInterval = 150 * time.Millisecond
+)
+
+// pickerTarget is a light the picker previews on, with what it showed
+// before the picker opened
+type pickerTarget struct {
+	id     string
+	name   string
+	caps   lightCaps
+	before matchState
+}
+
+// colorPicker is the color picker overlay's state
+type colorPicker struct {
+	open    bool
+	targets []pickerTarget
+	ctOnly  bool // no target shows color, so only the color temperature slider is offered
+
+	onHueRow   bool // arrows move along the hue row rather than the grid
+	hue        int  // index into the hue row
+	saturation int  // grid column
+	level      int  // grid row, 0 being the dimmest
+	ctStep     int  // slider cell, 0 being the warmest
+	mirekMin   int  // slider range, across every target
+	mirekMax   int
+
+	dirty       bool      // moved since the last preview write
+	previewed   bool      // a preview has been written, so esc has something to undo
+	lastWrite   time.Time // when the last preview write went out
+	tickPending bool      // a pickerTickMsg is on its way
+}
+
+// pickerTickMsg writes a preview held back by pickerWriteInterval
+type pickerTickMsg struct{}
+
+// openPicker snapshots the selected lights and shows the color picker
+func (m *lightModel) openPicker() {
+	if len(m.selected) == 0 {
+		m.setStatusTr("status.none_selected")
+		return
+	}
+	lights, err := cachedLights()
+	if err != nil {
+		m.setStatusTr("status.lights.fetch_failed", err)
+		return
+	}
+
+	var indices []int
+	for index := range m.selected {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	picker := colorPicker{open: true, ctOnly: true, mirekMin: math.MaxInt}
+	skipped := 0
+	for _, index := range indices {
+		light := m.light[index]
+		get, ok := lights[light.ID]
+		if !ok || !light.Reachable {
+			skipped++
+			continue
+		}
+		caps := readCaps(get)
+		if !caps.color && !caps.ct {
+			skipped++
+			continue
+		}
+		picker.targets = append(picker.targets, pickerTarget{id: light.ID, name: light.Name, caps: caps, before: readMatchState(get)})
+		if caps.color {
+			picker.ctOnly = false
+		}
+		if caps.ct {
+			low, high := caps.mirekRange()
+			picker.mirekMin = min(picker.mirekMin, low)
+			picker.mirekMax = max(picker.mirekMax, high)
+		}
+	}
+	if len(picker.targets) == 0 {
+		m.setStatusTr("status.color.none")
+		return
+	}
+
+	// Start where the first light is, so the first move is a small one
+	first := picker.targets[0].before
+	picker.level = pickerLevels - 1
+	if first.brightness != nil {
+		picker.level = min(max(int(math.Round(float64(*first.brightness)/100*pickerLevels))-1, 0), pickerLevels-1)
+	}
+	picker.saturation = pickerSaturations - 1
+	picker.ctStep = pickerCTSteps / 2
+	if first.mirek != nil && picker.mirekMax > picker.mirekMin {
+		span := float64(picker.mirekMax - picker.mirekMin)
+		picker.ctStep = min(max(int(math.Round(float64(picker.mirekMax-*first.mirek)/span*(pickerCTSteps-1))), 0), pickerCTSteps-1)
+	}
+
+	m.picker = picker
+	if skipped > 0 {
+		m.setStatus("%s", trn("status.color.skipped", skipped, skipped))
+	}
+}
+
+// handlePickerKey moves around the picker, previewing each move on the lights
+func (m *lightModel) handlePickerKey(key string) tea.Cmd {
+	p := &m.picker
+	switch key {
+	case "esc", "q":
+		m.revertPicker()
+		return nil
+	case "enter":
+		m.commitPicker()
+		return nil
+	case "tab":
+		p.onHueRow = !p.onHueRow && !p.ctOnly
+		return nil
+	case "left", "h":
+		switch {
+		case p.ctOnly:
+			p.ctStep = max(p.ctStep-1, 0)
+		case p.onHueRow:
+			p.hue = (p.hue + pickerHues - 1) % pickerHues
+		default:
+			p.saturation = max(p.saturation-1, 0)
+		}
+	case "right", "l":
+		switch {
+		case p.ctOnly:
+			p.ctStep = min(p.ctStep+1, pickerCTSteps-1)
+		case p.onHueRow:
+			p.hue = (p.hue + 1) % pickerHues
+		default:
+			p.saturation = min(p.saturation+1, pickerSaturations-1)
+		}
+	case "up", "k":
+		// Up from the grid's top row reaches the hue row above it
+		if p.level == pickerLevels-1 && !p.ctOnly {
+			p.onHueRow = true
+			return nil
+		}
+		p.level = min(p.level+1, pickerLevels-1)
+	case "down", "j":
+		if p.onHueRow {
+			p.onHueRow = false
+			return nil
+		}
+		p.level = max(p.level-1, 0)
+	default:
+		return nil
+	}
+	p.dirty = true
+	return m.previewPicker()
+}
+
+// previewPicker writes the picked color now, or schedules it once
+// pickerWriteInterval has passed since the last write
+func (m *lightModel) previewPicker() tea.Cmd {
+	p := &m.picker
+	wait := pickerWriteInterval - time.Since(p.lastWrite)
+	if wait <= 0 {
+		m.writePicker(false)
+		return nil
+	}
+	if p.tickPending {
+		return nil
+	}
+	p.tickPending = true
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return pickerTickMsg{}
+	})
+}
+
+// handlePickerTick writes a preview that was held back
+func (m *lightModel) handlePickerTick() {
+	m.picker.tickPending = false
+	if m.picker.open && m.picker.dirty {
+		m.writePicker(false)
+	}
+}
+
+// pickerBrightness is the brightness of the picker's grid row
+func (p colorPicker) pickerBrightness() float32 {
+	return float32(100 * (p.level + 1) / pickerLevels)
+}
+
+// pickerHSV is the hue, saturation and value of a hue and grid cell
+func pickerHSV(hue, saturation, level int) (float64, float64, float64) {
+	return float64(hue) * 360 / pickerHues,
+		float64(saturation) / (pickerSaturations - 1),
+		float64(level+1) / pickerLevels
+}
+
+// pickedColor is the color under the grid cursor, at full brightness
+func (p colorPicker) pickedColor() xyColor {
+	h, s, _ := pickerHSV(p.hue, p.saturation, p.level)
+	return rgbToXY(hsvToRGB(h, s, 1))
+}
+
+// stepMirek is the color temperature of a slider cell
+func (p colorPicker) stepMirek(step int) int {
+	return p.mirekMax - step*(p.mirekMax-p.mirekMin)/(pickerCTSteps-1)
+}
+
+// writePicker sends the picked color and brightness to every target in the
+// background. Previews go out once, since a retry would only be overtaken by
+// the next move; the final write on enter is retried like any other and
+// reports its result.
+func (m *lightModel) writePicker(final bool) {
+	p := &m.picker
+	p.dirty = false
+	p.lastWrite = time.Now()
+
+	brightness := p.pickerBrightness()
+	var xy *xyColor
+	var mirek *int
+	if p.ctOnly {
+		picked := p.stepMirek(p.ctStep)
+		mirek = &picked
+	} else {
+		picked := p.pickedColor()
+		xy = &picked
+	}
+
+	skipped := 0
+	var writes []lightWrite
+	puts := make(map[string]openhue.LightPut)
+	for _, target := range p.targets {
+		on := true
+		put := openhue.LightPut{On: &openhue.On{On: &on}}
+		if target.caps.dimmable {
+			level := clampBrightness(brightness, target.caps.minDimLevel)
+			put.Dimming = &openhue.Dimming{Brightness: &level}
+		}
+		if _, err := setColor(&put, mirek, xy, target.caps); err != nil {
+			// A white-only light can't follow a saturated color
+			skipped++
+			continue
+		}
+
+		outgoing.recordOn(target.id, true)
+		if put.Dimming != nil {
+			outgoing.recordBrightness(target.id, *put.Dimming.Brightness)
+		}
+		puts[target.id] = put
+		send := func() error { return updateResource("light", target.id, put) }
+		if final {
+			send = func() error { return updateLight(target.id, put) }
+		}
+		writes = append(writes, lightWrite{light: Light{ID: target.id, Name: target.name}, send: send})
+	}
+	// Revert puts back what the lights showed even if a preview is still on its way
+	if len(writes) > 0 {
+		p.previewed = true
+	}
+
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		for _, w := range written {
+			m.showWritten(w.light.ID, puts[w.light.ID])
+		}
+		if final {
+			m.setStatus("%s", summarizeAction("action.color", len(written), skipped, len(failed)))
+		}
+	})
+}
+
+// commitPicker keeps the picked color and closes the picker
+func (m *lightModel) commitPicker() {
+	m.picker.open = false
+	switch {
+	case m.picker.dirty:
+		m.writePicker(true)
+	case m.picker.previewed:
+		m.setStatus("%s", summarizeAction("action.color", len(m.picker.targets), 0, 0))
+	default:
+		m.setStatusTr("status.color.unchanged")
+	}
+}
+
+// revertPicker closes the picker, putting back what the lights showed before it opened
+func (m *lightModel) revertPicker() {
+	m.picker.open = false
+	if !m.picker.previewed {
+		m.setStatusTr("status.color.unchanged")
+		return
+	}
+
+	unmatched := 0
+	var writes []lightWrite
+	puts := make(map[string]openhue.LightPut)
+	for _, target := range m.picker.targets {
+		put, _, err := matchUpdate(target.before, target.caps)
+		if err != nil {
+			unmatched++
+			continue
+		}
+		outgoing.recordOn(target.id, target.before.on)
+		if put.Dimming != nil {
+			outgoing.recordBrightness(target.id, *put.Dimming.Brightness)
+		}
+		puts[target.id] = put
+		writes = append(writes, lightWrite{light: Light{ID: target.id, Name: target.name}, send: func() error {
+			return updateLight(target.id, put)
+		}})
+	}
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		for _, w := range written {
+			m.showWritten(w.light.ID, puts[w.light.ID])
+		}
+		m.setStatus("%s", summarizeAction("action.restored", len(written), 0, len(failed)+unmatched))
+	})
+}
+
+// showWritten applies a successful write to the light list ahead of its SSE echo
+func (m *lightModel) showWritten(lightID string, put openhue.LightPut) {
+	for i := range m.light {
+		light := &m.light[i]
+		if light.ID != lightID {
+			continue
+		}
+		if put.On != nil && put.On.On != nil {
+			light.Status = "off"
+			if *put.On.On {
+				light.Status = "on"
+			}
+		}
+		if put.Dimming != nil {
+			light.Brightness = *put.Dimming.Brightness
+		}
+		if put.ColorTemperature != nil && put.ColorTemperature.Mirek != nil {
+			light.Mirek, light.Color = *put.ColorTemperature.Mirek, nil
+		}
+		if put.Color != nil && put.Color.Xy != nil {
+			light.Color = &xyColor{x: float64(*put.Color.Xy.X), y: float64(*put.Color.Xy.Y)}
+			light.Mirek = 0
+		}
+		return
+	}
+}
+
+// pickerCell draws one swatch of the picker, marked when it's the cursor
+func pickerCell(hex string, cursor bool) string {
+	style := lipgloss.NewStyle().Background(lipgloss.Color(hex))
+	if cursor {
+		return style.Foreground(lipgloss.Color("#000000")).Bold(true).Render("[]")
+	}
+	return style.Render("  ")
+}
+
+// hsvHex is a hue, saturation and value as a hex code for drawing
+func hsvHex(h, s, v float64) string {
+	r, g, b := hsvToRGB(h, s, v)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// renderPicker draws the color picker
+func (m lightModel) renderPicker() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	faint := lipgloss.NewStyle().Faint(true)
+	p := m.picker
+
+	var names []string
+	for _, target := range p.targets {
+		names = append(names, target.name)
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Color") + " " + faint.Render(strings.Join(names, ", ")) + "\n\n")
+
+	if p.ctOnly {
+		for step := range pickerCTSteps {
+			b.WriteString(pickerCell(xyToHex(mirekToXY(p.stepMirek(step))), step == p.ctStep))
+		}
+		b.WriteString(fmt.Sprintf(glyphText("\n\n%dK · %.0f%%\n"), mirekToKelvin(p.stepMirek(p.ctStep)), p.pickerBrightness()))
+		b.WriteString("\n" + faint.Render(glyphText("←/→: warmer/cooler • ↑/↓: brightness • enter: keep • esc: revert")))
+	} else {
+		marker := "  "
+		if p.onHueRow {
+			marker = cursorStyle.Render(glyphText("▶ "))
+		}
+		b.WriteString(marker)
+		for hue := range pickerHues {
+			h, _, _ := pickerHSV(hue, 0, 0)
+			b.WriteString(pickerCell(hsvHex(h, 1, 1), hue == p.hue))
+		}
+		b.WriteString("\n\n")
+
+		for level := pickerLevels - 1; level >= 0; level-- {
+			marker = "  "
+			if !p.onHueRow && level == p.level {
+				marker = cursorStyle.Render(glyphText("▶ "))
+			}
+			b.WriteString(marker)
+			for saturation := range pickerSaturations {
+				h, s, v := pickerHSV(p.hue, saturation, level)
+				b.WriteString(pickerCell(hsvHex(h, s, v), saturation == p.saturation && level == p.level))
+			}
+			b.WriteString("\n")
+		}
+
+		picked := p.pickedColor()
+		b.WriteString(fmt.Sprintf(glyphText("\n%s · %.0f%%"), xyToHex(picked), p.pickerBrightness()))
+		var outside []string
+		for _, target := range p.targets {
+			if target.caps.gamut != nil && !target.caps.gamut.contains(picked) {
+				outside = append(outside, target.name)
+			}
+		}
+		if len(outside) > 0 {
+			b.WriteString(" " + faint.Render(glyphText("· nearest color on ")+strings.Join(outside, ", ")))
+		}
+		b.WriteString("\n\n" + faint.Render(glyphText("arrows: move • tab: hue/grid • enter: keep • esc: revert")))
+	}
+
+	if m.status != "" {
+		b.WriteString("\n" + m.status)
+	}
+	return viewText(b.String()) + "\n"
+}
@@ -0,0 +1,163 @@
+// Package hueclient talks to a Philips Hue bridge over the CLIP v2 API. It is
+// the bridge-facing half of hue-control-tui, usable from other programs: a
+// Client holds everything it needs, so several can run side by side.
+package hueclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Bridges answer on the LAN within milliseconds, so a request that takes
+// longer than these has hit a dead or unreachable bridge
+const (
+	// RequestTimeout bounds one request whose context has no deadline of its
+	// own. The event stream stays open and isn't bound by it.
+	RequestTimeout = 5 * time.Second
+
+	// dialTimeout and tlsHandshakeTimeout bound connecting, for requests and
+	// the event stream alike
+	dialTimeout         = 3 * time.Second
+	tlsHandshakeTimeout = 3 * time.Second
+)
+
+// Client sends authenticated requests to one bridge
+type Client struct {
+	host    string // bridge IP, or a host and path prefix such as a Remote API route
+	key     string // hue-application-key
+	http    *http.Client
+	timeout time.Duration // bounds a request whose context has no deadline
+}
+
+// New returns a client for the bridge at host using the application key. A nil
+// httpClient gets one that accepts the bridge's self-signed certificate.
+func New(host, key string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = InsecureHTTPClient()
+	}
+	return &Client{host: host, key: key, http: httpClient, timeout: RequestTimeout}
+}
+
+// InsecureHTTPClient returns an HTTP client that accepts the bridge's
+// self-signed certificate. Connecting times out, but there is no overall
+// timeout, which would cut the event stream short; Do bounds requests.
+func InsecureHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout: tlsHandshakeTimeout,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+}
+
+// Host returns the bridge address the client was created with
+func (c *Client) Host() string {
+	return c.host
+}
+
+// Key returns the client's application key
+func (c *Client) Key() string {
+	return c.key
+}
+
+// clipErrors is the "errors" array every CLIP v2 response carries
+type clipErrors struct {
+	Errors []struct {
+		Description string `json:"description"`
+	} `json:"errors"`
+}
+
+// Do sends a request to a bridge path such as "clip/v2/resource/light" with
+// body as JSON, if given, and decodes the JSON reply into out, if given.
+// Errors the bridge reports, in the CLIP v2 errors array or as an HTTP error
+// status, are returned with the bridge's own wording. Transport failures wrap
+// the underlying error so callers can tell them apart. A ctx without a
+// deadline gets RequestTimeout, so a dead bridge fails fast.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	url := fmt.Sprintf("https://%s/%s", c.host, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("hue-application-key", c.key)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	return decodeResponse(resp.StatusCode, resp.Status, data, out)
+}
+
+// decodeResponse turns a bridge reply into out or an error. v1 replies are
+// arrays and never match the CLIP v2 errors envelope, so only the status code
+// applies to them.
+func decodeResponse(statusCode int, status string, data []byte, out any) error {
+	var envelope clipErrors
+	if json.Unmarshal(data, &envelope) == nil && len(envelope.Errors) > 0 {
+		descriptions := make([]string, len(envelope.Errors))
+		for i, e := range envelope.Errors {
+			descriptions[i] = e.Description
+		}
+		return errors.New(strings.Join(descriptions, "; "))
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return fmt.Errorf("bridge returned %s", status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// get fetches a CLIP v2 resource path such as "light" into out
+func (c *Client) get(ctx context.Context, resource string, out any) error {
+	return c.Do(ctx, "GET", "clip/v2/resource/"+resource, nil, out)
+}
+
+// Update sends body as a CLIP v2 update to one resource, such as a light's
+// on and dimming fields. body is anything that marshals to the resource's
+// PUT schema.
+func (c *Client) Update(ctx context.Context, rtype, id string, body any) error {
+	return c.Do(ctx, "PUT", "clip/v2/resource/"+rtype+"/"+id, body, nil)
+}
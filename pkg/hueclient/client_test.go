@@ -0,0 +1,496 @@
+package hueclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testKey = "test-application-key"
+
+// fakeBridge serves canned CLIP v2 replies and records the writes it gets
+type fakeBridge struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu      sync.Mutex
+	replies map[string]string // "GET /clip/v2/resource/light" -> body
+	writes  []recordedWrite
+}
+
+type recordedWrite struct {
+	method string
+	path   string
+	body   map[string]any
+}
+
+func newFakeBridge(t *testing.T) *fakeBridge {
+	b := &fakeBridge{t: t, replies: make(map[string]string)}
+	b.server = httptest.NewTLSServer(http.HandlerFunc(b.serve))
+	t.Cleanup(b.server.Close)
+	return b
+}
+
+func (b *fakeBridge) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("hue-application-key") != testKey {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":[{"description":"unauthorized user"}],"data":[]}`)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r.Method != http.MethodGet {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			b.t.Errorf("%s %s: body isn't JSON: %q", r.Method, r.URL.Path, data)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			b.t.Errorf("%s %s: Content-Type = %q", r.Method, r.URL.Path, got)
+		}
+		b.writes = append(b.writes, recordedWrite{method: r.Method, path: r.URL.Path, body: body})
+		fmt.Fprint(w, `{"errors":[],"data":[]}`)
+		return
+	}
+	reply, ok := b.replies[r.Method+" "+r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors":[{"description":"resource not found"}],"data":[]}`)
+		return
+	}
+	fmt.Fprint(w, reply)
+}
+
+func (b *fakeBridge) reply(method, path, body string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.replies[method+" "+path] = body
+}
+
+func (b *fakeBridge) recorded() []recordedWrite {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]recordedWrite(nil), b.writes...)
+}
+
+// client returns a Client for the fake bridge with the given key
+func (b *fakeBridge) client(key string) *Client {
+	u, err := url.Parse(b.server.URL)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return New(u.Host, key, b.server.Client())
+}
+
+const lightsReply = `{"errors":[],"data":[
+	{"id":"light-1","metadata":{"name":"Desk"},"owner":{"rid":"device-1"},"on":{"on":true},
+	 "dimming":{"brightness":42.5,"min_dim_level":2}},
+	{"id":"light-2","metadata":{"name":"Plug"},"owner":{"rid":"device-2"},"on":{"on":false}}
+]}`
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		status  string
+		body    string
+		wantErr string // "" for success
+	}{
+		{"errors array", 400, "400 Bad Request", `{"errors":[{"description":"invalid body"},{"description":"unknown field"}]}`, "invalid body; unknown field"},
+		{"errors array wins over 200", 200, "200 OK", `{"errors":[{"description":"not writable"}],"data":[]}`, "not writable"},
+		{"empty errors array", 200, "200 OK", `{"errors":[],"data":[{"id":"a"}]}`, ""},
+		{"non-JSON 5xx", 503, "503 Service Unavailable", "<html>busy</html>", "bridge returned 503 Service Unavailable"},
+		{"empty 5xx", 502, "502 Bad Gateway", "", "bridge returned 502 Bad Gateway"},
+		{"empty 200", 200, "200 OK", "", "failed to decode response"},
+		{"v1 array", 200, "200 OK", `[{"success":{"/lights/1/state/on":true}}]`, "failed to decode response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out struct {
+				Data []struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			}
+			err := decodeResponse(tt.code, tt.status, []byte(tt.body), &out)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("err = %v, want success", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Without out, an empty 2xx body is fine
+	if err := decodeResponse(200, "200 OK", nil, nil); err != nil {
+		t.Errorf("empty body, no out: %v", err)
+	}
+}
+
+func TestListLights(t *testing.T) {
+	bridge := newFakeBridge(t)
+	bridge.reply("GET", "/clip/v2/resource/light", lightsReply)
+
+	lights, err := bridge.client(testKey).ListLights(context.Background())
+	if err != nil {
+		t.Fatalf("ListLights: %v", err)
+	}
+	want := []Light{
+		{ID: "light-1", Name: "Desk", DeviceID: "device-1", On: true, Dimmable: true, Brightness: 42.5, MinDimming: 2},
+		{ID: "light-2", Name: "Plug", DeviceID: "device-2"},
+	}
+	if len(lights) != len(want) {
+		t.Fatalf("got %d lights, want %d", len(lights), len(want))
+	}
+	for i := range want {
+		if lights[i] != want[i] {
+			t.Errorf("light %d = %+v, want %+v", i, lights[i], want[i])
+		}
+	}
+}
+
+func TestGetLight(t *testing.T) {
+	bridge := newFakeBridge(t)
+	bridge.reply("GET", "/clip/v2/resource/light/light-2", `{"errors":[],"data":[
+		{"id":"light-2","metadata":{"name":"Plug"},"owner":{"rid":"device-2"},"on":{"on":false}}]}`)
+	bridge.reply("GET", "/clip/v2/resource/light/gone", `{"errors":[],"data":[]}`)
+	client := bridge.client(testKey)
+
+	light, err := client.GetLight(context.Background(), "light-2")
+	if err != nil {
+		t.Fatalf("GetLight: %v", err)
+	}
+	if light.Name != "Plug" || light.Dimmable {
+		t.Errorf("GetLight = %+v", light)
+	}
+
+	if _, err := client.GetLight(context.Background(), "gone"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetLight of an empty reply: err = %v, want not found", err)
+	}
+	if _, err := client.GetLight(context.Background(), "unknown"); err == nil || err.Error() != "resource not found" {
+		t.Errorf("GetLight of a 404: err = %v, want the bridge's description", err)
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	bridge := newFakeBridge(t)
+	bridge.reply("GET", "/clip/v2/resource/light", lightsReply)
+
+	_, err := bridge.client("wrong").ListLights(context.Background())
+	if err == nil || err.Error() != "unauthorized user" {
+		t.Errorf("err = %v, want unauthorized user", err)
+	}
+}
+
+func TestToggle(t *testing.T) {
+	bridge := newFakeBridge(t)
+	bridge.reply("GET", "/clip/v2/resource/light/light-1", lightsReply)
+
+	on, err := bridge.client(testKey).Toggle(context.Background(), "light-1")
+	if err != nil {
+		t.Fatalf("Toggle: %v", err)
+	}
+	if on {
+		t.Errorf("Toggle of a light that was on returned on")
+	}
+	writes := bridge.recorded()
+	if len(writes) != 1 {
+		t.Fatalf("got %d writes, want 1", len(writes))
+	}
+	w := writes[0]
+	if w.method != "PUT" || w.path != "/clip/v2/resource/light/light-1" {
+		t.Errorf("write = %s %s", w.method, w.path)
+	}
+	if got := w.body["on"].(map[string]any)["on"]; got != false {
+		t.Errorf("on = %v, want false", got)
+	}
+}
+
+func TestSetBrightness(t *testing.T) {
+	tests := []struct {
+		percent float64
+		wantErr bool
+	}{
+		{0, false},
+		{55.5, false},
+		{100, false},
+		{-1, true},
+		{100.1, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.percent), func(t *testing.T) {
+			bridge := newFakeBridge(t)
+			err := bridge.client(testKey).SetBrightness(context.Background(), "light-1", tt.percent)
+			writes := bridge.recorded()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SetBrightness(%v) succeeded", tt.percent)
+				}
+				if len(writes) != 0 {
+					t.Errorf("an out of range value was sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetBrightness(%v): %v", tt.percent, err)
+			}
+			if len(writes) != 1 {
+				t.Fatalf("got %d writes, want 1", len(writes))
+			}
+			if got := writes[0].body["dimming"].(map[string]any)["brightness"]; got != tt.percent {
+				t.Errorf("brightness = %v, want %v", got, tt.percent)
+			}
+			if got := writes[0].body["on"].(map[string]any)["on"]; got != true {
+				t.Errorf("on = %v, want true", got)
+			}
+		})
+	}
+}
+
+func TestScenes(t *testing.T) {
+	bridge := newFakeBridge(t)
+	bridge.reply("GET", "/clip/v2/resource/scene", `{"errors":[],"data":[
+		{"id":"scene-1","metadata":{"name":"Relax"},"group":{"rid":"room-1"},"status":{"active":"static"}},
+		{"id":"scene-2","metadata":{"name":"Read"},"group":{"rid":"room-1"},"status":{"active":"inactive"}},
+		{"id":"scene-3","metadata":{"name":"Fire"},"group":{"rid":"room-2"}}]}`)
+	client := bridge.client(testKey)
+
+	scenes, err := client.ListScenes(context.Background())
+	if err != nil {
+		t.Fatalf("ListScenes: %v", err)
+	}
+	want := []Scene{
+		{ID: "scene-1", Name: "Relax", GroupID: "room-1", Active: true},
+		{ID: "scene-2", Name: "Read", GroupID: "room-1"},
+		{ID: "scene-3", Name: "Fire", GroupID: "room-2"},
+	}
+	for i := range want {
+		if scenes[i] != want[i] {
+			t.Errorf("scene %d = %+v, want %+v", i, scenes[i], want[i])
+		}
+	}
+
+	if err := client.RecallScene(context.Background(), "scene-2"); err != nil {
+		t.Fatalf("RecallScene: %v", err)
+	}
+	writes := bridge.recorded()
+	if len(writes) != 1 || writes[0].path != "/clip/v2/resource/scene/scene-2" {
+		t.Fatalf("writes = %+v", writes)
+	}
+	if got := writes[0].body["recall"].(map[string]any)["action"]; got != "active" {
+		t.Errorf("recall action = %v, want active", got)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	bridge := newFakeBridge(t)
+	body := map[string]any{"on": map[string]bool{"on": false}}
+	if err := bridge.client(testKey).Update(context.Background(), "grouped_light", "group-1", body); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	writes := bridge.recorded()
+	if len(writes) != 1 || writes[0].method != "PUT" || writes[0].path != "/clip/v2/resource/grouped_light/group-1" {
+		t.Errorf("writes = %+v", writes)
+	}
+}
+
+func TestConnectivity(t *testing.T) {
+	bridge := newFakeBridge(t)
+	bridge.reply("GET", "/clip/v2/resource/zigbee_connectivity", `{"errors":[],"data":[
+		{"owner":{"rid":"device-1"},"status":"connected"},
+		{"owner":{"rid":"device-2"},"status":"connectivity_issue"},
+		{"owner":{"rid":""},"status":"connected"}]}`)
+
+	statuses, err := bridge.client(testKey).Connectivity(context.Background())
+	if err != nil {
+		t.Fatalf("Connectivity: %v", err)
+	}
+	if len(statuses) != 2 || statuses["device-1"] != "connected" || statuses["device-2"] != "connectivity_issue" {
+		t.Errorf("Connectivity = %v", statuses)
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	payload := `[
+		{"type":"update","data":[
+			{"id":"light-1","type":"light","owner":{"rid":"device-1"},"on":{"on":true},"dimming":{"brightness":20}},
+			{"id":"conn-1","type":"zigbee_connectivity","owner":{"rid":"device-2"},"status":"connectivity_issue"},
+			{"id":"scene-1","type":"scene","status":{"active":"static"}}]},
+		{"type":"delete","data":[{"id":"light-2","type":"light"}]}
+	]`
+	events, err := ParseEvents([]byte(payload))
+	if err != nil {
+		t.Fatalf("ParseEvents: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+
+	light := events[0]
+	if light.Kind != "update" || light.ResourceID != "light-1" || light.OwnerID != "device-1" {
+		t.Errorf("light event = %+v", light)
+	}
+	if light.On == nil || !*light.On || light.Brightness == nil || *light.Brightness != 20 {
+		t.Errorf("light state = on %v, brightness %v", light.On, light.Brightness)
+	}
+	var raw struct {
+		Dimming struct {
+			Brightness float64 `json:"brightness"`
+		} `json:"dimming"`
+	}
+	if err := json.Unmarshal(light.Raw, &raw); err != nil || raw.Dimming.Brightness != 20 {
+		t.Errorf("Raw = %s", light.Raw)
+	}
+
+	if conn := events[1]; conn.Connected == nil || *conn.Connected {
+		t.Errorf("connectivity event = %+v", conn)
+	}
+	if scene := events[2]; scene.Connected != nil || scene.On != nil {
+		t.Errorf("scene event = %+v, want no light fields", scene)
+	}
+	if deleted := events[3]; deleted.Kind != "delete" || deleted.ResourceID != "light-2" {
+		t.Errorf("delete event = %+v", deleted)
+	}
+
+	if _, err := ParseEvents([]byte(`{"not":"an array"}`)); err == nil {
+		t.Errorf("ParseEvents accepted an object")
+	}
+}
+
+func TestStreamRaw(t *testing.T) {
+	const payload = `[{"type":"update","data":[{"id":"light-1","type":"light","on":{"on":false}}]}]`
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eventstream/clip/v2" || r.Header.Get("hue-application-key") != testKey {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "id: 1:0\ndata: %s\n\n", payload)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	client := New(u.Host, testKey, server.Client())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	received := make(chan []byte, 1)
+	var activity sync.Once
+	active := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.StreamRawWatched(ctx, func(data []byte) {
+			select {
+			case received <- data:
+			default:
+			}
+		}, nil, func() { activity.Do(func() { close(active) }) })
+	}()
+
+	select {
+	case data := <-received:
+		if string(data) != payload {
+			t.Errorf("payload = %s", data)
+		}
+	case <-ctx.Done():
+		t.Fatal("no payload before the timeout")
+	}
+	select {
+	case <-active:
+	default:
+		t.Errorf("activity wasn't reported")
+	}
+
+	cancel()
+	if err := <-errs; err != nil {
+		t.Errorf("StreamRawWatched after cancel = %v, want nil", err)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("hue-application-key") != testKey {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":[{"description":"unauthorized user"}],"data":[]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 1:0\ndata: [{\"type\":\"update\",\"data\":[{\"id\":\"light-1\",\"type\":\"light\",\"on\":{\"on\":false}}]}]\n\n")
+		fmt.Fprint(w, "id: 2:0\ndata: not json\n\n")
+		fmt.Fprint(w, "id: 3:0\ndata: [{\"type\":\"update\",\"data\":[{\"id\":\"light-2\",\"type\":\"light\",\"dimming\":{\"brightness\":30}}]},{\"type\":\"delete\",\"data\":[{\"id\":\"light-3\",\"type\":\"light\"}]}]\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A refused stream is reported up front, in the bridge's words
+	if _, err := New(u.Host, "wrong", server.Client()).StreamEvents(ctx); err == nil || err.Error() != "unauthorized user" {
+		t.Errorf("StreamEvents with the wrong key: err = %v, want unauthorized user", err)
+	}
+
+	events, err := New(u.Host, testKey, server.Client()).StreamEvents(ctx)
+	if err != nil {
+		t.Fatalf("StreamEvents: %v", err)
+	}
+	var got []Event
+	for len(got) < 3 {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		case <-ctx.Done():
+			t.Fatalf("got %d events before the timeout, want 3", len(got))
+		}
+	}
+	if got[0].ResourceID != "light-1" || got[0].On == nil || *got[0].On {
+		t.Errorf("first event = %+v", got[0])
+	}
+	if got[1].ResourceID != "light-2" || got[1].Brightness == nil || *got[1].Brightness != 30 {
+		t.Errorf("second event = %+v, want the payload after the bad one", got[1])
+	}
+	if got[2].Kind != "delete" || got[2].ResourceID != "light-3" {
+		t.Errorf("third event = %+v", got[2])
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestDoTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	u, _ := url.Parse(server.URL)
+	c := New(u.Host, testKey, nil)
+	c.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	err := c.Do(context.Background(), "GET", "clip/v2/resource/light", nil, nil)
+	if err == nil {
+		t.Fatal("a bridge that never answers didn't fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the request took %s to fail", elapsed)
+	}
+	// Callers retry timeouts, so they must still look like one
+	if !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+}
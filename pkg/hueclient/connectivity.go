@@ -0,0 +1,27 @@
+package hueclient
+
+import "context"
+
+// Connectivity maps device IDs to their zigbee status, such as "connected" or
+// "connectivity_issue"
+func (c *Client) Connectivity(ctx context.Context) (map[string]string, error) {
+	var resp struct {
+		Data []struct {
+			Owner struct {
+				Rid string `json:"rid"`
+			} `json:"owner"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	if err := c.get(ctx, "zigbee_connectivity", &resp); err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]string)
+	for _, conn := range resp.Data {
+		if conn.Owner.Rid != "" {
+			statuses[conn.Owner.Rid] = conn.Status
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,252 @@
+package hueclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/r3labs/sse/v2"
+)
+
+// Event is one resource change from the bridge's event stream. Fields that
+// weren't part of the change are nil.
+type Event struct {
+	Kind         string // "update", "add" or "delete"
+	ResourceID   string
+	ResourceType string // "light", "zigbee_connectivity", "scene", ...
+	OwnerID      string // owning device or group, when the resource has one
+
+	On         *bool
+	Brightness *float64
+	Connected  *bool // zigbee_connectivity only
+
+	// Raw is the resource as the bridge sent it, for fields Event doesn't cover
+	Raw json.RawMessage
+}
+
+// ParseEvents decodes one event stream payload into its changes, in order
+func ParseEvents(data []byte) ([]Event, error) {
+	var updates []struct {
+		Type string            `json:"type"`
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &updates); err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, upd := range updates {
+		for _, raw := range upd.Data {
+			var item struct {
+				ID    string `json:"id"`
+				Type  string `json:"type"`
+				Owner *struct {
+					Rid string `json:"rid"`
+				} `json:"owner"`
+				On *struct {
+					On bool `json:"on"`
+				} `json:"on"`
+				Dimming *struct {
+					Brightness float64 `json:"brightness"`
+				} `json:"dimming"`
+				Status json.RawMessage `json:"status"`
+			}
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return nil, err
+			}
+			event := Event{Kind: upd.Type, ResourceID: item.ID, ResourceType: item.Type, Raw: raw}
+			if item.Owner != nil {
+				event.OwnerID = item.Owner.Rid
+			}
+			if item.On != nil {
+				event.On = &item.On.On
+			}
+			if item.Dimming != nil {
+				event.Brightness = &item.Dimming.Brightness
+			}
+			// status is a string only for connectivity; scenes send an object
+			var status string
+			if item.Type == "zigbee_connectivity" && json.Unmarshal(item.Status, &status) == nil {
+				connected := status == "connected"
+				event.Connected = &connected
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// StreamEvents subscribes to the bridge's event stream and sends each change
+// on the returned channel, in order, until ctx is done. It returns once the
+// bridge has accepted the stream, or with the error that kept it from doing
+// so. After that a dropped stream is reconnected, and changes made while it
+// was down are never sent. The channel is closed when the stream ends; a
+// payload that doesn't parse is skipped.
+func (c *Client) StreamEvents(ctx context.Context) (<-chan Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	connected := make(chan error, 1)
+	var once sync.Once
+	report := func(err error) {
+		once.Do(func() { connected <- err })
+	}
+
+	client := c.eventClient(nil)
+	client.ReconnectStrategy = &streamBackOff{ctx: ctx}
+	client.ResponseValidator = func(_ *sse.Client, resp *http.Response) error {
+		if resp.StatusCode == http.StatusOK {
+			report(nil)
+			return nil
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		err := decodeResponse(resp.StatusCode, resp.Status, data, nil)
+		report(err)
+		return err
+	}
+	// Called for each failed attempt, including ones that never got a response
+	client.ReconnectNotify = func(err error, _ time.Duration) {
+		report(err)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer cancel()
+		defer close(events)
+		client.SubscribeRawWithContext(ctx, func(msg *sse.Event) {
+			parsed, err := ParseEvents(msg.Data)
+			if err != nil {
+				return
+			}
+			for _, event := range parsed {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+		report(ctx.Err())
+	}()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return events, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// streamBackOff spaces out reconnection attempts, doubling from
+// minStreamBackOff up to maxStreamBackOff, and stops once ctx is done
+type streamBackOff struct {
+	ctx  context.Context
+	next time.Duration
+}
+
+const (
+	minStreamBackOff = 500 * time.Millisecond
+	maxStreamBackOff = 30 * time.Second
+)
+
+func (b *streamBackOff) Reset() { b.next = minStreamBackOff }
+
+func (b *streamBackOff) NextBackOff() time.Duration {
+	if b.ctx.Err() != nil {
+		return -1 // backoff.Stop
+	}
+	next := b.next
+	b.next = min(b.next*2, maxStreamBackOff)
+	return next
+}
+
+// StreamRaw subscribes to the bridge's event stream and calls handle with each
+// payload until ctx is done or the stream fails. It returns nil after ctx ends.
+func (c *Client) StreamRaw(ctx context.Context, handle func(data []byte)) error {
+	return c.StreamRawReconnecting(ctx, handle, nil)
+}
+
+// StreamRawReconnecting is StreamRaw, calling reconnected each time the stream
+// comes back after dropping. Changes made while it was down are never sent,
+// so anything built from earlier payloads may be out of date by then.
+func (c *Client) StreamRawReconnecting(ctx context.Context, handle func(data []byte), reconnected func()) error {
+	return c.StreamRawWatched(ctx, handle, reconnected, nil)
+}
+
+// StreamRawWatched is StreamRawReconnecting, also calling activity whenever
+// bytes arrive, the bridge's keepalives included. A connection that stays
+// open but goes silent never fails on its own, so this lets the caller notice
+// and cancel ctx to drop it.
+func (c *Client) StreamRawWatched(ctx context.Context, handle func(data []byte), reconnected, activity func()) error {
+	client := c.eventClient(activity)
+	if reconnected != nil {
+		connects := 0
+		client.OnConnect(func(*sse.Client) {
+			connects++
+			if connects > 1 {
+				reconnected()
+			}
+		})
+	}
+	err := client.SubscribeRawWithContext(ctx, func(msg *sse.Event) {
+		handle(msg.Data)
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// eventClient returns an SSE client for the bridge's event stream, calling
+// activity, if given, whenever bytes arrive
+func (c *Client) eventClient(activity func()) *sse.Client {
+	client := sse.NewClient(fmt.Sprintf("https://%s/eventstream/clip/v2", c.host))
+	client.Connection = c.http
+	if activity != nil {
+		watched := *c.http
+		watched.Transport = activityTransport{base: c.http.Transport, activity: activity}
+		client.Connection = &watched
+	}
+	client.Headers["hue-application-key"] = c.key
+	return client
+}
+
+// activityTransport wraps response bodies so reading from them calls activity
+type activityTransport struct {
+	base     http.RoundTripper
+	activity func()
+}
+
+func (t activityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = activityBody{ReadCloser: resp.Body, activity: t.activity}
+	return resp, nil
+}
+
+// activityBody calls activity each time a read returns data
+type activityBody struct {
+	io.ReadCloser
+	activity func()
+}
+
+func (b activityBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.activity()
+	}
+	return n, err
+}
@@ -0,0 +1,108 @@
+package hueclient
+
+import (
+	"context"
+	"fmt"
+)
+
+// Light is a light service with its current state
+type Light struct {
+	ID         string
+	Name       string
+	DeviceID   string // owning device, which connectivity is reported for
+	On         bool
+	Dimmable   bool
+	Brightness float64 // percent; 0 for lights that can't dim
+	MinDimming float64 // lowest brightness the light accepts, in percent
+}
+
+// lightResource is the part of a CLIP v2 light the package reads
+type lightResource struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Owner struct {
+		Rid string `json:"rid"`
+	} `json:"owner"`
+	On struct {
+		On bool `json:"on"`
+	} `json:"on"`
+	Dimming *struct {
+		Brightness  float64 `json:"brightness"`
+		MinDimLevel float64 `json:"min_dim_level"`
+	} `json:"dimming"`
+}
+
+func (r lightResource) light() Light {
+	light := Light{
+		ID:       r.ID,
+		Name:     r.Metadata.Name,
+		DeviceID: r.Owner.Rid,
+		On:       r.On.On,
+	}
+	if r.Dimming != nil {
+		light.Dimmable = true
+		light.Brightness = r.Dimming.Brightness
+		light.MinDimming = r.Dimming.MinDimLevel
+	}
+	return light
+}
+
+// ListLights returns every light the bridge knows
+func (c *Client) ListLights(ctx context.Context) ([]Light, error) {
+	var resp struct {
+		Data []lightResource `json:"data"`
+	}
+	if err := c.get(ctx, "light", &resp); err != nil {
+		return nil, err
+	}
+	lights := make([]Light, len(resp.Data))
+	for i, r := range resp.Data {
+		lights[i] = r.light()
+	}
+	return lights, nil
+}
+
+// GetLight returns one light by ID
+func (c *Client) GetLight(ctx context.Context, id string) (Light, error) {
+	var resp struct {
+		Data []lightResource `json:"data"`
+	}
+	if err := c.get(ctx, "light/"+id, &resp); err != nil {
+		return Light{}, err
+	}
+	if len(resp.Data) == 0 {
+		return Light{}, fmt.Errorf("light not found: %s", id)
+	}
+	return resp.Data[0].light(), nil
+}
+
+// SetOn turns a light on or off
+func (c *Client) SetOn(ctx context.Context, id string, on bool) error {
+	return c.Update(ctx, "light", id, map[string]any{
+		"on": map[string]bool{"on": on},
+	})
+}
+
+// Toggle flips a light on or off and returns whether it is now on
+func (c *Client) Toggle(ctx context.Context, id string) (bool, error) {
+	light, err := c.GetLight(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	on := !light.On
+	return on, c.SetOn(ctx, id, on)
+}
+
+// SetBrightness sets a light's brightness in percent, turning it on. Values
+// outside 0-100 are an error rather than clamped.
+func (c *Client) SetBrightness(ctx context.Context, id string, percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("brightness %.0f%% is outside 0-100", percent)
+	}
+	return c.Update(ctx, "light", id, map[string]any{
+		"on":      map[string]bool{"on": true},
+		"dimming": map[string]float64{"brightness": percent},
+	})
+}
@@ -0,0 +1,49 @@
+package hueclient
+
+import "context"
+
+// Scene is a stored scene and the room or zone it belongs to
+type Scene struct {
+	ID      string
+	Name    string
+	GroupID string
+	Active  bool // recalled and not changed since, statically or playing its palette
+}
+
+// ListScenes returns every scene
+func (c *Client) ListScenes(ctx context.Context) ([]Scene, error) {
+	var resp struct {
+		Data []struct {
+			ID       string `json:"id"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Group struct {
+				Rid string `json:"rid"`
+			} `json:"group"`
+			Status struct {
+				Active string `json:"active"`
+			} `json:"status"`
+		} `json:"data"`
+	}
+	if err := c.get(ctx, "scene", &resp); err != nil {
+		return nil, err
+	}
+	scenes := make([]Scene, len(resp.Data))
+	for i, r := range resp.Data {
+		scenes[i] = Scene{
+			ID:      r.ID,
+			Name:    r.Metadata.Name,
+			GroupID: r.Group.Rid,
+			Active:  r.Status.Active != "" && r.Status.Active != "inactive",
+		}
+	}
+	return scenes, nil
+}
+
+// RecallScene activates a scene by ID
+func (c *Client) RecallScene(ctx context.Context, id string) error {
+	return c.Update(ctx, "scene", id, map[string]any{
+		"recall": map[string]string{"action": "active"},
+	})
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// uiProfile is the part of the config that shapes the UI rather than which
+// bridge it talks to, so it can be shared without the bridge key
+type uiProfile struct {
+	Columns        []string          `yaml:"columns,omitempty"`
+	Aliases        map[string]string `yaml:"aliases,omitempty"`
+	Macros         map[string]string `yaml:"macros,omitempty"`
+	SceneKeys      map[string]string `yaml:"scene_keys,omitempty"`
+	Units          UnitsConfig       `yaml:"units,omitempty"`
+	BrightnessStep int               `yaml:"brightness_step,omitempty"`
+}
+
+// profileCommand handles ":profile export <file>" and ":profile import <file>"
+func (m *lightModel) profileCommand(args string) error {
+	sub, path, _ := strings.Cut(args, " ")
+	path = unquote(strings.TrimSpace(path))
+	if path == "" {
+		return fmt.Errorf("usage: profile export <file> | profile import <file>")
+	}
+	switch sub {
+	case "export":
+		return m.exportProfile(path)
+	case "import":
+		return m.importProfile(path)
+	}
+	return fmt.Errorf("usage: profile export <file> | profile import <file>")
+}
+
+// exportProfile writes the UI settings from the running config to path
+func (m *lightModel) exportProfile(path string) error {
+	profile := uiProfile{
+		Columns:        appConfig.Columns,
+		Aliases:        appConfig.Aliases,
+		Macros:         appConfig.Macros,
+		SceneKeys:      appConfig.SceneKeys,
+		Units:          appConfig.Units,
+		BrightnessStep: appConfig.BrightnessStep,
+	}
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing profile: %v", err)
+	}
+	m.setStatusTr("status.profile.exported", path)
+	return nil
+}
+
+// importProfile applies the profile at path to the running config and the
+// config file. Every entry is checked, and each one rejected is reported;
+// the rest are applied.
+func (m *lightModel) importProfile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading profile: %v", err)
+	}
+	var doc map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing profile: %v", err)
+	}
+
+	profile, rejected := validateProfile(doc)
+	applied := m.applyProfile(profile)
+	if applied == 0 && len(rejected) == 0 {
+		return fmt.Errorf("%s has no settings to import", path)
+	}
+
+	summary := trn("status.profile.imported", applied, applied, path)
+	if len(rejected) > 0 {
+		summary += tr("status.profile.rejected", len(rejected), strings.Join(rejected, "\n  "))
+	}
+	m.setStatus("%s", summary)
+	return nil
+}
+
+// validateProfile reads a profile's entries one by one, keeping the valid
+// ones and describing each one it rejects, rather than stopping at the first
+func validateProfile(doc map[string]yaml.Node) (uiProfile, []string) {
+	var profile uiProfile
+	var rejected []string
+	reject := func(format string, args ...any) {
+		rejected = append(rejected, fmt.Sprintf(format, args...))
+	}
+
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		node := doc[key]
+		switch key {
+		case "columns":
+			var columns []string
+			if err := node.Decode(&columns); err != nil {
+				reject("columns: not a list of column names")
+				continue
+			}
+			for i := range columns {
+				columns[i] = strings.ToLower(columns[i])
+			}
+			if err := validateColumns(columns); err != nil {
+				reject("columns: %v", err)
+				continue
+			}
+			profile.Columns = columns
+		case "aliases", "macros":
+			entries, problems := validateCommandEntries(key, node)
+			rejected = append(rejected, problems...)
+			if key == "aliases" {
+				profile.Aliases = entries
+			} else {
+				profile.Macros = entries
+			}
+		case "scene_keys":
+			profile.SceneKeys, rejected = validateSceneKeyEntries(node, rejected)
+		case "units":
+			var units map[string]string
+			if err := node.Decode(&units); err != nil {
+				reject("units: not a mapping")
+				continue
+			}
+			for unit, value := range units {
+				setting, ok := settableKeys["units."+unit]
+				if !ok {
+					reject("units.%s: unknown unit", unit)
+					continue
+				}
+				scratch := &Config{}
+				if _, err := setting.apply(scratch, value); err != nil {
+					reject("%v", err)
+					continue
+				}
+				if scratch.Units.Temperature != "" {
+					profile.Units.Temperature = scratch.Units.Temperature
+				}
+				if scratch.Units.Time != "" {
+					profile.Units.Time = scratch.Units.Time
+				}
+			}
+		case "brightness_step":
+			scratch := &Config{}
+			if _, err := settableKeys["brightness_step"].apply(scratch, node.Value); err != nil {
+				reject("%v", err)
+				continue
+			}
+			profile.BrightnessStep = scratch.BrightnessStep
+		case "bridge", "key", "bridge_id", "remote":
+			reject("%s: bridge settings aren't part of a profile", key)
+		default:
+			reject("%s: not a UI setting", key)
+		}
+	}
+	return profile, rejected
+}
+
+// validateCommandEntries reads a profile's aliases or macros, rejecting
+// names that can't be typed as commands, aliases shadowing built-in
+// commands and empty expansions
+func validateCommandEntries(kind string, node yaml.Node) (map[string]string, []string) {
+	var raw map[string]string
+	if err := node.Decode(&raw); err != nil {
+		return nil, []string{fmt.Sprintf("%s: not a mapping of names to commands", kind)}
+	}
+	entries := make(map[string]string)
+	var rejected []string
+	for name, body := range raw {
+		switch {
+		case name == "" || strings.ContainsAny(name, " .;\"'"):
+			rejected = append(rejected, fmt.Sprintf("%s.%s: names can't contain spaces, . ; or quotes", kind, name))
+		case strings.TrimSpace(body) == "":
+			rejected = append(rejected, fmt.Sprintf("%s.%s: no command given", kind, name))
+		case kind == "aliases" && isBuiltinCommand(name):
+			rejected = append(rejected, fmt.Sprintf("aliases.%s: shadows a built-in command", name))
+		default:
+			entries[name] = strings.TrimSpace(body)
+		}
+	}
+	sort.Strings(rejected)
+	return entries, rejected
+}
+
+// validateSceneKeyEntries reads a profile's scene hotkeys, rejecting those
+// whose scene isn't on this bridge
+func validateSceneKeyEntries(node yaml.Node, rejected []string) (map[string]string, []string) {
+	var raw map[string]string
+	if err := node.Decode(&raw); err != nil {
+		return nil, append(rejected, "scene_keys: not a mapping of keys to scenes")
+	}
+	if len(raw) == 0 {
+		return nil, rejected
+	}
+	scenes, err := getScenes()
+	if err != nil {
+		return nil, append(rejected, fmt.Sprintf("scene_keys: couldn't check the scenes: %v", err))
+	}
+	entries := make(map[string]string)
+	var problems []string
+	for name, ref := range raw {
+		key, err := normalizeKeyName(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("scene_keys.%s: %v", name, err))
+			continue
+		}
+		if _, err := findScene(scenes, ref); err != nil {
+			problems = append(problems, fmt.Sprintf("scene_keys.%s: %v", key, err))
+			continue
+		}
+		entries[key] = ref
+	}
+	sort.Strings(problems)
+	return entries, append(rejected, problems...)
+}
+
+// applyProfile merges a validated profile into the running config and saves
+// it, returning how many settings changed. Imported aliases, macros and
+// scene keys are added to the existing ones, replacing any of the same name.
+func (m *lightModel) applyProfile(profile uiProfile) int {
+	applied := 0
+	save := func(key string, value any) {
+		if err := setConfigValue(key, value); err != nil {
+			logError("Failed to save %s: %v", key, err)
+		}
+	}
+	merge := func(dst *map[string]string, src map[string]string, key string) {
+		if len(src) == 0 {
+			return
+		}
+		if *dst == nil {
+			*dst = make(map[string]string)
+		}
+		for name, value := range src {
+			(*dst)[name] = value
+		}
+		applied += len(src)
+		save(key, *dst)
+	}
+
+	if profile.Columns != nil {
+		appConfig.Columns = profile.Columns
+		save("columns", profile.Columns)
+		applied++
+	}
+	merge(&appConfig.Aliases, profile.Aliases, "aliases")
+	merge(&appConfig.Macros, profile.Macros, "macros")
+	merge(&appConfig.SceneKeys, profile.SceneKeys, "scene_keys")
+	if len(profile.SceneKeys) > 0 {
+		m.sceneKeys, _ = resolveSceneKeys(appConfig.SceneKeys)
+	}
+	if profile.Units.Temperature != "" {
+		appConfig.Units.Temperature = profile.Units.Temperature
+		save("units.temperature", profile.Units.Temperature)
+		applied++
+	}
+	if profile.Units.Time != "" {
+		appConfig.Units.Time = profile.Units.Time
+		save("units.time", profile.Units.Time)
+		applied++
+	}
+	if profile.BrightnessStep != 0 {
+		appConfig.BrightnessStep = profile.BrightnessStep
+		save("brightness_step", profile.BrightnessStep)
+		applied++
+	}
+	return applied
+}
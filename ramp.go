@@ -0,0 +1,152 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/openhue/openhue-go"
+)
+
+const (
+	// holdDetectWindow is the longest gap between presses that can only be
+	// terminal auto-repeat, i.e. the key is being held down
+	holdDetectWindow = 120 * time.Millisecond
+
+	// rampReleaseGap is how long without a repeat before a held key counts as released
+	rampReleaseGap = 300 * time.Millisecond
+
+	// rampDuration is how long a ramp takes to cover the full brightness range
+	rampDuration = 4 * time.Second
+
+	// rampTickInterval is how often the ramp checks for key release
+	rampTickInterval = 100 * time.Millisecond
+)
+
+// dimmingRamp tracks a continuous brightness change driven by a held key
+type dimmingRamp struct {
+	direction int       // +1 up, -1 down, 0 when idle
+	lightIDs  []string  // lights the bridge is ramping
+	lastKey   time.Time // last repeat of the held key
+
+	lastStepDirection int       // direction of the previous single step
+	lastStepAt        time.Time // time of the previous single step
+}
+
+// rampTickMsg checks whether the held key has been released
+type rampTickMsg struct{}
+
+func rampTick() tea.Cmd {
+	return tea.Tick(rampTickInterval, func(time.Time) tea.Msg {
+		return rampTickMsg{}
+	})
+}
+
+// brightnessKey handles a brightness keypress: a single press steps, while
+// auto-repeat from a held key starts a smooth ramp on the bridge
+func (m *lightModel) brightnessKey(direction, step int) tea.Cmd {
+	now := time.Now()
+
+	// Repeats of the held key just keep the ramp alive
+	if m.ramp.direction == direction {
+		m.ramp.lastKey = now
+		return nil
+	}
+	if m.ramp.direction != 0 {
+		m.stopRamp()
+	}
+
+	held := m.ramp.lastStepDirection == direction && now.Sub(m.ramp.lastStepAt) < holdDetectWindow
+	m.ramp.lastStepDirection, m.ramp.lastStepAt = direction, now
+	if held {
+		return m.startRamp(direction)
+	}
+
+	return m.adjustSelectedBrightness(direction * step)
+}
+
+// startRamp asks the bridge to dim every selected light continuously. The
+// press that came before the hold left a brightness target waiting to be
+// written; the ramp takes over from it, so it is dropped rather than written
+// afterwards, which would snap the lights back.
+func (m *lightModel) startRamp(direction int) tea.Cmd {
+	m.brightnessGeneration++
+	var lightIDs []string
+	for index := range m.selected {
+		light := m.light[index]
+		if !light.Reachable || !light.can(capDimming) {
+			continue
+		}
+		delete(m.brightnessIntents, light.ID)
+		if err := startDimmingDelta(light.ID, direction > 0); err != nil {
+			logError("Error starting brightness ramp for %s: %v", light.ID, err)
+			continue
+		}
+		lightIDs = append(lightIDs, light.ID)
+	}
+	flush := m.rescheduleBrightnessFlush()
+	if len(lightIDs) == 0 {
+		return flush
+	}
+
+	m.ramp.direction = direction
+	m.ramp.lightIDs = lightIDs
+	m.ramp.lastKey = time.Now()
+	if direction > 0 {
+		m.setStatus("%s", trn("status.ramp.up", len(lightIDs), len(lightIDs)))
+	} else {
+		m.setStatus("%s", trn("status.ramp.down", len(lightIDs), len(lightIDs)))
+	}
+	return tea.Batch(rampTick(), flush)
+}
+
+// stopRamp halts the bridge-side ramp wherever it has got to
+func (m *lightModel) stopRamp() {
+	for _, lightID := range m.ramp.lightIDs {
+		if err := stopDimmingDelta(lightID); err != nil {
+			logError("Error stopping brightness ramp for %s: %v", lightID, err)
+		}
+	}
+	m.ramp.direction = 0
+	m.ramp.lightIDs = nil
+	m.setStatusTr("status.ramp.stopped")
+}
+
+// handleRampTick stops the ramp once the key has been released
+func (m *lightModel) handleRampTick() tea.Cmd {
+	if m.ramp.direction == 0 {
+		return nil
+	}
+	if time.Since(m.ramp.lastKey) > rampReleaseGap {
+		m.stopRamp()
+		return nil
+	}
+	return rampTick()
+}
+
+// startDimmingDelta starts a transition across the full range, which the
+// bridge performs smoothly until it is stopped
+func startDimmingDelta(lightID string, up bool) error {
+	action := openhue.DimmingDeltaActionDown
+	if up {
+		action = openhue.DimmingDeltaActionUp
+	}
+	delta := float32(100)
+	duration := int(rampDuration.Milliseconds())
+
+	outgoing.recordBulk()
+	defer trackWrite()()
+	return updateResource("light", lightID, openhue.LightPut{
+		DimmingDelta: &openhue.DimmingDelta{Action: &action, BrightnessDelta: &delta},
+		Dynamics:     &openhue.LightDynamics{Duration: &duration},
+	})
+}
+
+// stopDimmingDelta halts a transition started by startDimmingDelta
+func stopDimmingDelta(lightID string) error {
+	action := openhue.DimmingDeltaActionStop
+
+	defer trackWrite()()
+	return updateResource("light", lightID, openhue.LightPut{
+		DimmingDelta: &openhue.DimmingDelta{Action: &action},
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A single press leaves a target waiting to be written; holding the key then
+// starts a ramp. The waiting target must not be written once the ramp is
+// running, or the light would snap back to it.
+func TestPressThenHoldDropsPendingTarget(t *testing.T) {
+	bridge := newTestBridge(t)
+	m := initialModel([]Light{testLight()}, nil)
+	m.selected[0] = struct{}{}
+
+	if cmd := m.brightnessKey(1, 10); cmd == nil {
+		t.Fatal("a single press scheduled no write")
+	}
+	pressGeneration := m.brightnessGeneration
+	if _, ok := m.brightnessIntents[testLightID]; !ok {
+		t.Fatal("a single press left no target")
+	}
+
+	// The repeat arrives within holdDetectWindow
+	m.ramp.lastStepAt = time.Now()
+	m.brightnessKey(1, 10)
+	if m.ramp.direction != 1 {
+		t.Fatalf("ramp direction = %d, want 1", m.ramp.direction)
+	}
+	if _, ok := m.brightnessIntents[testLightID]; ok {
+		t.Error("the pressed target survived the ramp starting")
+	}
+	if m.brightnessGeneration == pressGeneration {
+		t.Error("the press's flush is still current")
+	}
+
+	// The flush the press scheduled fires during the ramp
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: pressGeneration})
+	m = settle(m)
+
+	writes := bridge.recorded()
+	if len(writes) != 1 {
+		t.Fatalf("got %d writes, want only the ramp start: %+v", len(writes), writes)
+	}
+	if writes[0].path != "/clip/v2/resource/light/"+testLightID {
+		t.Errorf("write went to %s", writes[0].path)
+	}
+	if _, ok := writes[0].body["dimming_delta"]; !ok {
+		t.Errorf("write = %v, want a dimming_delta", writes[0].body)
+	}
+	if _, ok := writes[0].body["dimming"]; ok {
+		t.Errorf("an absolute brightness was written: %v", writes[0].body)
+	}
+}
+
+// Targets for lights the ramp doesn't cover are still written
+func TestRampKeepsOtherTargets(t *testing.T) {
+	bridge := newTestBridge(t)
+	other := testLight()
+	other.ID, other.Name = "other-light", "Shelf"
+	m := initialModel([]Light{testLight(), other}, nil)
+	m.selected[1] = struct{}{}
+	m.adjustSelectedBrightness(10)
+	delete(m.selected, 1)
+	m.selected[0] = struct{}{}
+
+	cmd := m.startRamp(1)
+	if cmd == nil {
+		t.Fatal("startRamp returned no command")
+	}
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: m.brightnessGeneration})
+	m = settle(m)
+
+	var shelf bool
+	for _, w := range bridge.recorded() {
+		if w.path == "/clip/v2/resource/light/other-light" {
+			shelf = true
+		}
+	}
+	if !shelf {
+		t.Error("the other light's target was never written")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recoverFromPanic logs a panic raised while handling msg and returns the
+// model as it was before the message, with its indexes made safe again. The
+// listeners that msg would have restarted are restarted, so SSE events,
+// retries and status server requests keep arriving.
+func (m lightModel) recoverFromPanic(msg tea.Msg, r any) (tea.Model, tea.Cmd) {
+	logError("Recovered from a panic handling %T: %v\n%s", msg, r, debug.Stack())
+	m.clampIndices()
+	m.setStatusTr("status.internal_error", r)
+
+	switch msg.(type) {
+	case sseEventsMsg, sseErrorMsg:
+		return m, m.listenForSSE()
+	case retryNotice:
+		return m, listenForRetries()
+	case snapshotRequestMsg:
+		return m, m.listenForSnapshotRequests()
+	}
+	return m, nil
+}
+
+// clampIndices drops selections past the end of the light list and keeps the
+// cursors on a row
+func (m *lightModel) clampIndices() {
+	for index := range m.selected {
+		if index < 0 || index >= len(m.light) {
+			delete(m.selected, index)
+		}
+	}
+	if !m.rowsMatchLights() {
+		m.rows = m.layoutRows()
+	}
+	m.moveCursorTo(m.cursor)
+	m.scenePane.cursor = max(0, min(m.scenePane.cursor, len(m.scenePane.scenes)-1))
+	m.sensorPane.cursor = max(0, min(m.sensorPane.cursor, len(m.sensorPane.sensors)-1))
+	m.automationPane.cursor = max(0, min(m.automationPane.cursor, len(m.automationPane.automations)-1))
+}
+
+// rowsMatchLights reports whether every row points into the light list
+func (m lightModel) rowsMatchLights() bool {
+	for _, row := range m.rows {
+		for _, index := range row.lights {
+			if index < 0 || index >= len(m.light) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// renderPanic stands in for a view that panicked
+func (m lightModel) renderPanic(r any) string {
+	logError("Recovered from a panic rendering the view: %v\n%s", r, debug.Stack())
+	return fmt.Sprintf("Internal error drawing the screen: %v\nDetails are in the log. Press q to quit.", r)
+}
+
+// crashHint tells the user where to find details after the TUI panicked
+func crashHint(logPath string) string {
+	if logPath == "" {
+		return "hue-control-tui crashed. Run it again with --debug to record the details in ~/.openhue/debug.log, and include that log in a bug report."
+	}
+	return "hue-control-tui crashed. Please include " + logPath + " in a bug report."
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// refreshBrightnessThreshold is the smallest brightness change, in percentage
+// points, a refresh diff reports; smaller ones are rounding and drift
+const refreshBrightnessThreshold = 2
+
+// diffLights describes what changed between the light lists from before and
+// after a refresh, e.g. "Kitchen off→on, Desk 80→45%, Porch now unreachable,
+// +1 new light", in the order of the new list. It returns "no changes" when
+// nothing did.
+func diffLights(before, after []Light) string {
+	previous := make(map[string]Light, len(before))
+	for _, light := range before {
+		previous[light.ID] = light
+	}
+
+	var changes []string
+	added := 0
+	for _, light := range after {
+		old, ok := previous[light.ID]
+		if !ok {
+			added++
+			continue
+		}
+		delete(previous, light.ID)
+		if old.Status != light.Status && old.Reachable && light.Reachable {
+			changes = append(changes, fmt.Sprintf(glyphText("%s %s→%s"), light.Name, old.Status, light.Status))
+		}
+		if math.Abs(float64(old.Brightness-light.Brightness)) >= refreshBrightnessThreshold {
+			changes = append(changes, fmt.Sprintf(glyphText("%s %.0f→%.0f%%"), light.Name, old.Brightness, light.Brightness))
+		}
+		switch {
+		case old.Reachable && !light.Reachable:
+			changes = append(changes, tr("refresh.unreachable", light.Name))
+		case !old.Reachable && light.Reachable:
+			changes = append(changes, tr("refresh.reachable", light.Name))
+		}
+	}
+	if added > 0 {
+		changes = append(changes, trn("refresh.added", added, added))
+	}
+	if removed := len(previous); removed > 0 {
+		changes = append(changes, trn("refresh.removed", removed, removed))
+	}
+
+	if len(changes) == 0 {
+		return tr("refresh.none")
+	}
+	return strings.Join(changes, ", ")
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The Hue Remote API mirrors the bridge's CLIP API under /route, authorized
+// with an OAuth2 bearer token on top of the usual application key
+const (
+	remoteHost         = "api.meethue.com"
+	remoteRoute        = remoteHost + "/route"
+	remoteAuthorizeURL = "https://" + remoteHost + "/v2/oauth2/authorize"
+
+	// remotePollInterval replaces SSE, which the Remote API doesn't offer
+	remotePollInterval = 15 * time.Second
+
+	// remoteTokenMargin is how long before it expires the access token is
+	// refreshed, so a request never goes out with a token about to lapse
+	remoteTokenMargin = time.Minute
+)
+
+// remoteTokenURL is the OAuth2 token endpoint; tests point it elsewhere
+var remoteTokenURL = "https://" + remoteHost + "/v2/oauth2/token"
+
+// RemoteConfig holds the Hue Remote API credentials. The client ID and secret
+// come from registering an app at developers.meethue.com.
+type RemoteConfig struct {
+	ClientID     string `yaml:"client_id,omitempty"`
+	ClientSecret string `yaml:"client_secret,omitempty"`
+	RefreshToken string `yaml:"refresh_token,omitempty"`
+}
+
+// configured reports whether remote mode can be used without logging in again
+func (r RemoteConfig) configured() bool {
+	return r.ClientID != "" && r.ClientSecret != "" && r.RefreshToken != ""
+}
+
+// remoteMode is set once requests go through the Remote API
+var remoteMode bool
+
+// remoteSession holds the Remote API access token, refreshed from the stored
+// refresh token before it expires or when the API rejects it
+type remoteSession struct {
+	mu      sync.Mutex
+	config  RemoteConfig
+	access  string
+	expires time.Time // zero when the token endpoint didn't say
+}
+
+// remoteToken is this session's token, set up by startRemote
+var remoteToken = &remoteSession{}
+
+// token returns an access token that isn't about to expire. stale is a token
+// the API just rejected; it is replaced unless another request already did.
+func (s *remoteSession) token(stale string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fresh := s.expires.IsZero() || time.Until(s.expires) > remoteTokenMargin
+	if s.access != "" && s.access != stale && fresh {
+		return s.access, nil
+	}
+	if err := s.refresh(); err != nil {
+		return "", err
+	}
+	return s.access, nil
+}
+
+// refresh exchanges the refresh token for a new access token and saves the
+// rotated refresh token. The caller holds mu.
+func (s *remoteSession) refresh() error {
+	token, err := requestToken(s.config, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {s.config.RefreshToken},
+	})
+	if err != nil {
+		return err
+	}
+	if token.RefreshToken != "" && token.RefreshToken != s.config.RefreshToken {
+		s.config.RefreshToken = token.RefreshToken
+		if err := setConfigValue("remote.refresh_token", token.RefreshToken); err != nil {
+			logError("Failed to save rotated refresh token: %v", err)
+		}
+	}
+	s.access = token.AccessToken
+	s.expires = time.Time{}
+	if token.ExpiresIn > 0 {
+		s.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	logDebug("Remote access token refreshed, expires %v", s.expires)
+	return nil
+}
+
+// tokenResponse is the OAuth2 token endpoint's reply
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// requestToken posts an OAuth2 grant to the token endpoint
+func requestToken(remote RemoteConfig, form url.Values) (*tokenResponse, error) {
+	req, err := http.NewRequestWithContext(appCtx, "POST", remoteTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(remote.ClientID, remote.ClientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %v", err)
+	}
+	return &token, nil
+}
+
+// remoteLogin walks the user through the authorization code flow. Hue doesn't
+// support the device flow, so the code is pasted back from the browser.
+func remoteLogin(remote RemoteConfig) error {
+	if remote.ClientID == "" || remote.ClientSecret == "" {
+		return fmt.Errorf("set remote.client_id and remote.client_secret in the config file first")
+	}
+
+	state := make([]byte, 8)
+	if _, err := rand.Read(state); err != nil {
+		return err
+	}
+	authorize := remoteAuthorizeURL + "?" + url.Values{
+		"client_id":     {remote.ClientID},
+		"response_type": {"code"},
+		"state":         {hex.EncodeToString(state)},
+	}.Encode()
+
+	fmt.Printf("Open this URL, sign in and allow access:\n\n  %s\n\n", authorize)
+	fmt.Print("Then paste the code parameter from the address you're sent back to: ")
+	var code string
+	if _, err := fmt.Scanln(&code); err != nil {
+		return fmt.Errorf("reading code: %v", err)
+	}
+
+	token, err := requestToken(remote, url.Values{
+		"grant_type": {"authorization_code"},
+		"code":       {strings.TrimSpace(code)},
+	})
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue("remote.refresh_token", token.RefreshToken); err != nil {
+		return fmt.Errorf("saving refresh token: %v", err)
+	}
+	fmt.Println("Remote access is set up; start with --remote to use it.")
+	return nil
+}
+
+// startRemote swaps the bridge connection for the Remote API. The refresh
+// token is exchanged for this session's access token, which is refreshed
+// again as it runs out.
+func startRemote(remote RemoteConfig) error {
+	remoteToken.mu.Lock()
+	remoteToken.config = remote
+	err := remoteToken.refresh()
+	remoteToken.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Requests go to https://<bridgeIP>/clip/v2/..., so the route prefix
+	// stands in for the IP, and bearerTransport adds the token
+	remoteMode = true
+	bridgeIP = remoteRoute
+	logInfo("Using the Hue Remote API")
+	return nil
+}
+
+// bearerTransport adds the Remote API access token to requests for the remote
+// host. A request the API turns away with 401 is sent once more with a
+// refreshed token, in case the token was revoked or lapsed early.
+type bearerTransport struct {
+	base    http.RoundTripper
+	session *remoteSession
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != remoteHost {
+		return t.base.RoundTrip(req)
+	}
+	token, err := t.session.token("")
+	if err != nil {
+		return nil, fmt.Errorf("refreshing the remote access token: %w", err)
+	}
+	resp, err := t.send(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if token, err = t.session.token(token); err != nil {
+		return nil, fmt.Errorf("refreshing the remote access token: %w", err)
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.send(retry, token)
+}
+
+// send sends req with token as its bearer token
+func (t *bearerTransport) send(req *http.Request, token string) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// remotePollMsg triggers a refresh in remote mode
+type remotePollMsg struct{}
+
+// lightsPolledMsg carries the result of a background refresh
+type lightsPolledMsg struct {
+	lights []Light
+	err    error
+}
+
+func remotePoll() tea.Cmd {
+	if !remoteMode {
+		return nil
+	}
+	return tea.Tick(remotePollInterval, func(time.Time) tea.Msg {
+		return remotePollMsg{}
+	})
+}
+
+// pollLights refreshes the light list off the UI goroutine
+func pollLights() tea.Cmd {
+	return func() tea.Msg {
+		lights, err := returnLights()
+		return lightsPolledMsg{lights: lights, err: err}
+	}
+}
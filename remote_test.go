@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// roundTripFunc lets a function stand in for the Remote API behind bearerTransport
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// useTokenServer points remoteTokenURL at a server handing out access tokens
+// "token-1", "token-2" and so on, each valid for expiresIn seconds
+func useTokenServer(t *testing.T, expiresIn int) *int {
+	t.Helper()
+	var mu sync.Mutex
+	grants := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "refresh" {
+			t.Errorf("unexpected token request: %v", r.Form)
+		}
+		grants++
+		fmt.Fprintf(w, `{"access_token":"token-%d","refresh_token":"refresh","expires_in":%d}`, grants, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	old := remoteTokenURL
+	remoteTokenURL = server.URL
+	t.Cleanup(func() { remoteTokenURL = old })
+	return &grants
+}
+
+func TestRemoteTokenRefreshedBeforeExpiry(t *testing.T) {
+	grants := useTokenServer(t, 3600)
+	session := &remoteSession{config: RemoteConfig{RefreshToken: "refresh"}, access: "old", expires: time.Now().Add(10 * time.Second)}
+
+	token, err := session.token("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-1" || *grants != 1 {
+		t.Errorf("token = %q after %d grants, want a refreshed token", token, *grants)
+	}
+	// Good for an hour now, so it's reused
+	if token, _ = session.token(""); token != "token-1" || *grants != 1 {
+		t.Errorf("token = %q after %d grants, want the same token reused", token, *grants)
+	}
+}
+
+func TestRemoteRetryAfterUnauthorized(t *testing.T) {
+	grants := useTokenServer(t, 3600)
+	session := &remoteSession{config: RemoteConfig{RefreshToken: "refresh"}, access: "revoked", expires: time.Now().Add(time.Hour)}
+
+	var sent []string
+	transport := &bearerTransport{session: session, base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		sent = append(sent, req.Header.Get("Authorization")+" "+string(body))
+		status := http.StatusOK
+		if req.Header.Get("Authorization") == "Bearer revoked" {
+			status = http.StatusUnauthorized
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+
+	req, err := http.NewRequest("PUT", "https://"+remoteRoute+"/clip/v2/resource/light/1", strings.NewReader(`{"on":{"on":true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want the retry's 200", resp.StatusCode)
+	}
+	want := []string{`Bearer revoked {"on":{"on":true}}`, `Bearer token-1 {"on":{"on":true}}`}
+	if strings.Join(sent, "\n") != strings.Join(want, "\n") || *grants != 1 {
+		t.Errorf("sent %q with %d grants, want %q after one refresh", sent, *grants, want)
+	}
+}
+
+func TestRemoteClientVerifiesCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	// The test server's certificate is self-signed, like a bridge's
+	if resp, err := remoteHTTPClient().Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("the remote client accepted a self-signed certificate")
+	}
+	oldMode := remoteMode
+	remoteMode = false
+	defer func() { remoteMode = oldMode }()
+	resp, err := bridgeHTTPClient().Get(server.URL)
+	if err != nil {
+		t.Fatalf("the LAN client rejected the bridge's certificate: %v", err)
+	}
+	resp.Body.Close()
+}
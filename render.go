@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// renderer draws the model. Only the rendering differs between modes; keys,
+// selection and commands behave the same.
+type renderer interface {
+	render(m lightModel) string
+}
+
+// rowWindow is the part of the table on screen when not every row fits, from
+// start up to end, scrolled just far enough to keep the cursor in view, as the
+// scenes pane does. chrome is how many lines the rest of the view takes.
+// While the terminal size is unknown every row is shown.
+func (m lightModel) rowWindow(chrome int) (start, end int) {
+	if m.height == 0 {
+		return 0, len(m.rows)
+	}
+	visible := max(1, m.height-chrome)
+	start = max(0, m.cursor-visible+1)
+	return start, min(start+visible, len(m.rows))
+}
+
+// activeRenderer is the table unless --plain was given
+var activeRenderer renderer = tableRenderer{}
+
+// plainMode reports whether --plain was given, for the panes drawn outside
+// the renderer
+func plainMode() bool {
+	_, ok := activeRenderer.(plainRenderer)
+	return ok
+}
+
+// tableRenderer draws the styled, boxed light table
+type tableRenderer struct{}
+
+// render draws the full layout, or the compact one when the full layout
+// doesn't fit the terminal
+func (t tableRenderer) render(m lightModel) string {
+	m = t.fit(m)
+	if len(m.rows) == 0 {
+		return t.layout(m, []string{"", "    " + lipgloss.NewStyle().Faint(true).Render(m.emptyMessage()), ""})
+	}
+	start, end := m.rowWindow(t.chrome(m))
+	return t.layout(m, t.dataRows(m, start, end))
+}
+
+// fit picks the layout to draw: the full one when it fits the terminal with
+// every row, otherwise the compact one, which scrolls if the rows still
+// don't fit
+func (t tableRenderer) fit(m lightModel) lightModel {
+	rows := len(m.rows)
+	if rows == 0 {
+		rows = 3 // the empty message
+	}
+	if m.width == 0 || (tableWidth(m.layoutColumns()) <= m.width && t.chrome(m)+rows <= m.height) {
+		return m
+	}
+	m.compact = true
+	return m
+}
+
+// chrome is how many lines the layout takes besides the data rows
+func (t tableRenderer) chrome(m lightModel) int {
+	return lipgloss.Height(t.layout(m, nil))
+}
+
+// window is the part of the table the layout m.fit picks shows
+func (t tableRenderer) window(m lightModel) (start, end int) {
+	return m.rowWindow(t.chrome(t.fit(m)))
+}
+
+// dataRows draws the table rows from start up to end
+func (tableRenderer) dataRows(m lightModel, start, end int) []string {
+	var rows []string
+	columns := m.layoutColumns()
+	now := time.Now()
+	for i := start; i < end; i++ {
+		tr := m.rows[i]
+		cursor := "  "
+		if m.cursor == i {
+			cursor = cursorStyle.Render(glyphText("▶ "))
+		}
+
+		checkmark := "  "
+		if m.rowSelected(tr) {
+			checkmark = selectedStyle.Render(glyphText("✓ "))
+		}
+
+		if tr.room {
+			rows = append(rows, "  "+cursor+checkmark+roomHeaderStyle.Render(m.roomHeaderText(tr)))
+			continue
+		}
+
+		cells := make([]string, len(columns))
+		for j, col := range columns {
+			cells[j] = fitCell(col.cell(m, tr, now), col.width)
+		}
+		line := strings.Join(cells, "  ")
+		if style, ok := m.rowFlashStyle(tr); ok {
+			// The cells' own colors would cut the tint short, so it replaces them
+			line = style.Render(ansi.Strip(line))
+		}
+		rows = append(rows, "  "+cursor+checkmark+line)
+	}
+	return rows
+}
+
+// layout draws the view around body, the lines below the table's header
+func (tableRenderer) layout(m lightModel, body []string) string {
+	// Styles
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#BD93F9"))
+	dividerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#44475A"))
+
+	columns := m.layoutColumns()
+
+	// Header and divider are built exactly like data rows, for alignment
+	var header, divider []string
+	for _, col := range columns {
+		header = append(header, fitCell(headerStyle.Render(tr(col.title)), col.width))
+		divider = append(divider, dividerStyle.Render(strings.Repeat(glyphText("─"), col.width)))
+	}
+	rows := []string{"    " + strings.Join(header, "  "), "    " + strings.Join(divider, "  ")}
+	rows = append(rows, body...)
+
+	// Join everything
+	tableContent := lipgloss.JoinVertical(lipgloss.Left, rows...)
+
+	// Box with padding
+	boxed := tableStyle.Render(tableContent)
+
+	// Title & footer
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6")).MarginLeft(2).Render(tr("title")) +
+		lipgloss.NewStyle().Faint(true).MarginLeft(1).Render(shortVersion())
+	if m.sseReconnecting {
+		title += droppedStyle.MarginLeft(2).Render(tr("title.reconnecting"))
+	}
+	footerWidth := max(0, m.width-2)
+	footer := lipgloss.NewStyle().Faint(true).MarginTop(1).MarginLeft(2).Render(
+		m.keyFooter(m.keyContext(), footerWidth) + "\n" + tr("footer.notes"))
+
+	// Always render command box area (static space)
+	commandBox := m.renderCommandBox()
+	if m.compact {
+		footer = lipgloss.NewStyle().Faint(true).MarginLeft(2).Render(m.keyFooter(m.keyContext(), footerWidth))
+		commandBox = m.renderCompactStatus()
+	}
+
+	result := title + "\n" + m.renderSummary() + "\n" + m.renderAwayBanner() + m.renderRoomTimers() + m.renderNotifications() + boxed + m.renderEntertainment() + footer + "\n" + commandBox
+
+	return result
+}
+
+// plainRenderer writes one labeled line per light with no borders or color,
+// for screen readers
+type plainRenderer struct{}
+
+func (plainRenderer) render(m lightModel) string {
+	head, tail := plainHead(m), plainTail(m)
+	var b strings.Builder
+	b.WriteString(head)
+	if len(m.rows) == 0 {
+		fmt.Fprintln(&b, m.emptyMessage())
+	}
+	start, end := m.rowWindow(strings.Count(head+tail, "\n"))
+	for i := start; i < end; i++ {
+		prefix := "   "
+		if m.cursor == i {
+			prefix = "-> "
+		}
+		fmt.Fprintf(&b, "%s%s\n", prefix, plainRowText(m, m.rows[i]))
+	}
+	b.WriteString(tail)
+	return viewText(b.String())
+}
+
+// plainHead is the plain view above the rows: the summary and notices
+func plainHead(m lightModel) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, tr("plain.heading", summarizeLights(m.light)))
+	if m.away != nil {
+		fmt.Fprintf(&b, "%s\n", m.away.describe(time.Now()))
+	}
+	if len(m.roomTimers) > 0 {
+		fmt.Fprintln(&b, tr("plain.timers", m.describeRoomTimers()))
+	}
+	for _, n := range m.notifications {
+		fmt.Fprintln(&b, tr("plain.notice", n.text))
+	}
+	return b.String()
+}
+
+// plainTail is the plain view below the rows: the command line or status
+func plainTail(m lightModel) string {
+	var b strings.Builder
+	if m.commandMode {
+		fmt.Fprintln(&b, tr("plain.command", m.commandText))
+	} else if m.jump.active {
+		fmt.Fprintln(&b, tr("plain.jump", m.jump.text))
+	} else if m.status != "" {
+		fmt.Fprintln(&b, tr("plain.status", m.status))
+	}
+	return b.String()
+}
+
+// emptyMessage explains an empty table and how to get lights back into it
+func (m lightModel) emptyMessage() string {
+	if len(m.light) > 0 {
+		return tr("empty.filtered")
+	}
+	return tr("empty.none")
+}
+
+// plainRowText describes one table row, e.g. "Kitchen: ON, 80%, reachable, selected"
+func plainRowText(m lightModel, tr tableRow) string {
+	if tr.room {
+		return m.roomHeaderText(tr)
+	}
+	var fields []string
+	if tr.device {
+		fields = append(fields, fmt.Sprintf("device with %d lights", len(tr.lights)))
+		on := 0
+		for _, index := range tr.lights {
+			if m.light[index].Reachable && m.light[index].Status == "on" {
+				on++
+			}
+		}
+		fields = append(fields, fmt.Sprintf("%d on", on))
+	} else {
+		light := m.light[tr.lights[0]]
+		if !light.Reachable {
+			fields = append(fields, "unreachable")
+		} else {
+			fields = append(fields, strings.ToUpper(light.Status))
+			if light.can(capDimming) {
+				fields = append(fields, formatPercent(light.Brightness))
+			} else {
+				fields = append(fields, "not dimmable")
+			}
+			fields = append(fields, "reachable")
+		}
+	}
+	if m.rowSelected(tr) {
+		fields = append(fields, "selected")
+	}
+	if !tr.device && len(m.light[tr.lights[0]].Gradient) > 0 {
+		fields = append(fields, fmt.Sprintf("gradient of %d colors", len(m.light[tr.lights[0]].Gradient)))
+	}
+	if m.rowSyncing(tr) {
+		fields = append(fields, "locked by entertainment streaming")
+	}
+	if appConfig.showsColumn("changed") && !tr.device {
+		if light := m.light[tr.lights[0]]; light.ChangedBy != "" {
+			fields = append(fields, fmt.Sprintf("last changed by %s at %s", light.ChangedBy, formatClock(light.ChangedAt, time.Now(), appConfig.Units.Time)))
+		}
+	}
+	switch m.mirror.marker(tr, m.light) {
+	case mirrorLeaderMark:
+		fields = append(fields, "mirror leader")
+	case mirrorFollowerMark:
+		fields = append(fields, "mirror follower")
+	}
+	return tr.name + ": " + strings.Join(fields, ", ")
+}
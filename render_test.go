@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// testLights returns n lights on their own devices, named "Light 00" on
+func testLights(n int) []Light {
+	lights := make([]Light, n)
+	for i := range lights {
+		light := testLight()
+		light.ID = fmt.Sprintf("light-%02d", i)
+		light.Name = fmt.Sprintf("Light %02d", i)
+		light.DeviceOwner = fmt.Sprintf("device-%02d", i)
+		lights[i] = light
+	}
+	return lights
+}
+
+// sizedModel is a model of n lights in a width x height terminal
+func sizedModel(n, width, height int) lightModel {
+	m := initialModel(testLights(n), nil)
+	m.width, m.height = width, height
+	return m
+}
+
+func TestTableScrollsToCursor(t *testing.T) {
+	m := sizedModel(40, 120, 30)
+	m.moveCursorTo(35)
+
+	view := tableRenderer{}.render(m)
+	if h := lipgloss.Height(view); h > m.height {
+		t.Errorf("view is %d lines in a %d line terminal", h, m.height)
+	}
+	plain := ansi.Strip(view)
+	if !strings.Contains(plain, "▶ ") || !strings.Contains(plain, "Light 35") {
+		t.Errorf("the cursor's row isn't shown:\n%s", plain)
+	}
+	if strings.Contains(plain, "Light 00") {
+		t.Errorf("rows above the window are shown:\n%s", plain)
+	}
+	if strings.Contains(plain, "Light 36") {
+		t.Errorf("the cursor isn't on the last row shown:\n%s", plain)
+	}
+
+	// Back at the top, the window follows
+	m.moveCursorTo(0)
+	plain = ansi.Strip(tableRenderer{}.render(m))
+	if !strings.Contains(plain, "Light 00") || strings.Contains(plain, "Light 35") {
+		t.Errorf("the window didn't follow the cursor up:\n%s", plain)
+	}
+}
+
+func TestTableShowsEveryRowThatFits(t *testing.T) {
+	m := sizedModel(5, 120, 40)
+	table := tableRenderer{}
+	if start, end := table.window(m); start != 0 || end != 5 {
+		t.Errorf("window = %d-%d, want 0-5", start, end)
+	}
+	if table.fit(m).compact {
+		t.Error("a table that fits was drawn compact")
+	}
+}
+
+func TestPlainScrollsToCursor(t *testing.T) {
+	m := sizedModel(40, 80, 20)
+	m.moveCursorTo(30)
+
+	view := plainRenderer{}.render(m)
+	if lines := strings.Count(view, "\n"); lines > m.height {
+		t.Errorf("view is %d lines in a %d line terminal", lines, m.height)
+	}
+	if !strings.Contains(view, "-> Light 30") {
+		t.Errorf("the cursor's row isn't shown:\n%s", view)
+	}
+	if strings.Contains(view, "Light 00") {
+		t.Errorf("rows above the window are shown:\n%s", view)
+	}
+}
+
+func TestHitTestScrolledTable(t *testing.T) {
+	m := sizedModel(40, 120, 30)
+	m.moveCursorTo(35)
+	start, end := tableRenderer{}.window(m)
+	if start == 0 || end != 36 {
+		t.Fatalf("window = %d-%d, want it to end at the cursor", start, end)
+	}
+
+	// The first line of the table is the window's first row
+	row, _, ok := m.hitTest(columnsLeft, m.firstRowY())
+	if !ok || row != start {
+		t.Errorf("top line hit row %d (ok %t), want %d", row, ok, start)
+	}
+	row, _, ok = m.hitTest(columnsLeft, m.firstRowY()+end-start-1)
+	if !ok || row != 35 {
+		t.Errorf("bottom line hit row %d (ok %t), want 35", row, ok)
+	}
+	if _, _, ok := m.hitTest(columnsLeft, m.firstRowY()+end-start); ok {
+		t.Error("a click below the window hit a row")
+	}
+	if _, _, ok := m.hitTest(columnsLeft, m.firstRowY()-1); ok {
+		t.Error("a click on the header hit a row")
+	}
+}
+
+func TestHitTestCompactColumns(t *testing.T) {
+	m := sizedModel(3, 70, 30)
+	compact := tableRenderer{}.fit(m)
+	if !compact.compact {
+		t.Fatal("a 70 column terminal didn't get the compact layout")
+	}
+	columns := compact.layoutColumns()
+	if len(columns) < 2 {
+		t.Fatalf("compact columns = %v", columns)
+	}
+
+	// Just past the first column is the second one, at its compact width
+	x := columnsLeft + columns[0].width + 2
+	_, column, ok := m.hitTest(x, m.firstRowY())
+	if !ok || column != tableColumn(columns[1].key) {
+		t.Errorf("hit column %q, want %q", column, columns[1].key)
+	}
+}
+
+// emptyTableInput is every key and click that acts on the table's rows
+var emptyTableInput = []tea.Msg{
+	tea.KeyMsg{Type: tea.KeyUp},
+	tea.KeyMsg{Type: tea.KeyDown},
+	tea.KeyMsg{Type: tea.KeyLeft},
+	tea.KeyMsg{Type: tea.KeyRight},
+	tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}},
+	tea.KeyMsg{Type: tea.KeyEnter},
+	tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}},
+	tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'-'}},
+	tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}},
+	tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}},
+	tea.KeyMsg{Type: tea.KeyCtrlD},
+	tea.KeyMsg{Type: tea.KeyCtrlU},
+	tea.KeyMsg{Type: tea.KeyPgDown},
+	tea.KeyMsg{Type: tea.KeyPgUp},
+	tea.KeyMsg{Type: tea.KeyHome},
+	tea.KeyMsg{Type: tea.KeyEnd},
+	tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress},
+	tea.MouseMsg{Button: tea.MouseButtonWheelUp, Action: tea.MouseActionPress},
+	tea.MouseMsg{X: columnsLeft, Y: 0, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress},
+	tea.MouseMsg{X: columnsLeft + 4, Y: 6, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress},
+	tea.MouseMsg{X: columnsLeft + 30, Y: 8, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress},
+}
+
+func TestEmptyTableInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       func() lightModel
+		wantMessage string
+	}{
+		{"no lights", func() lightModel {
+			return initialModel(nil, nil)
+		}, tr("empty.none")},
+		{"everything filtered out", func() lightModel {
+			m := initialModel(testLights(3), nil)
+			m.filter = "no such light"
+			m.rows = m.layoutRows()
+			m.moveCursorTo(m.cursor)
+			return m
+		}, tr("empty.filtered")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestBridge(t)
+			for _, msg := range emptyTableInput {
+				m := tt.model()
+				m.width, m.height = 120, 30
+				if len(m.rows) != 0 {
+					t.Fatalf("the table has %d rows", len(m.rows))
+				}
+
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							t.Errorf("%v panicked: %v", msg, r)
+						}
+					}()
+					model, _ := m.Update(msg)
+					m = model.(lightModel)
+					tableView := ansi.Strip(tableRenderer{}.render(m))
+					plainView := plainRenderer{}.render(m)
+					if !strings.Contains(tableView, tt.wantMessage) || !strings.Contains(plainView, tt.wantMessage) {
+						t.Errorf("after %v the empty message isn't shown:\n%s\n%s", msg, tableView, plainView)
+					}
+				}()
+			}
+		})
+	}
+}
+
+func TestPlainPanes(t *testing.T) {
+	defer func(r renderer) { activeRenderer = r }(activeRenderer)
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	// Styles only emit colors when the profile has them
+	lipgloss.SetColorProfile(termenv.TrueColor)
+
+	m := sizedModel(3, 120, 30)
+	m.scenePane.scenes = []Scene{{ID: testSceneID, Name: "Sunset", Room: "Kitchen", Status: "static", Lights: 3}}
+	m.scenePane.loaded = true
+	m.sensorPane.sensors = []sensor{{name: "Hallway motion", kind: sensorMotion, enabled: true, battery: 80}}
+	m.openDetail()
+	panes := map[string]func() string{
+		"scenes":      m.renderScenePane,
+		"detail":      m.renderDetail,
+		"sensors":     m.renderSensorPane,
+		"automations": m.renderAutomationPane,
+	}
+
+	for name, render := range panes {
+		if view := render(); !strings.Contains(view, "\x1b[") {
+			t.Errorf("%s pane has no colors outside plain mode; the test can't tell them apart", name)
+		}
+	}
+
+	// Glyphs are swapped where messages are made, so the status is made in plain mode
+	activeRenderer = plainRenderer{}
+	m.setStatus("%s", "Scene Sunset activated"+tr("action.skipped", 1))
+	for name, render := range panes {
+		view := render()
+		if strings.Contains(view, "\x1b[") {
+			t.Errorf("%s pane has escape sequences in plain mode:\n%q", name, view)
+		}
+		if strings.ContainsAny(view, "▶•·─╭│") {
+			t.Errorf("%s pane has glyphs in plain mode:\n%s", name, view)
+		}
+	}
+	if view := m.renderScenePane(); !strings.Contains(view, "-> Sunset") {
+		t.Errorf("the scene under the cursor isn't marked the way plainRenderer marks rows:\n%s", view)
+	}
+}
+
+// ASCII mode swaps the TUI's own glyphs but leaves names as they are, and the
+// table stays aligned
+func TestASCIIModeKeepsNames(t *testing.T) {
+	defer setASCIIMode(false)
+	setASCIIMode(true)
+
+	m := sizedModel(3, 120, 30)
+	names := []string{"Desk — left", "Hall · 2", "Porch → garden…"}
+	for i, name := range names {
+		m.light[i].Name = name
+	}
+	m.rows = m.layoutRows()
+	m.selected = map[int]struct{}{1: {}}
+
+	view := ansi.Strip(tableRenderer{}.render(m))
+	for _, name := range names {
+		if !strings.Contains(view, name) {
+			t.Errorf("%q was changed:\n%s", name, view)
+		}
+	}
+	if strings.ContainsAny(view, "▶✓─╭│") {
+		t.Errorf("the table has non-ASCII glyphs:\n%s", view)
+	}
+
+	// Every line of the table's box is as wide as its top border
+	var box []string
+	for _, line := range strings.Split(view, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "+") && !strings.HasPrefix(trimmed, "|") {
+			continue
+		}
+		box = append(box, line)
+		if len(box) > 1 && strings.HasPrefix(trimmed, "+") {
+			break
+		}
+	}
+	if len(box) < 3 {
+		t.Fatalf("no ASCII box in the table:\n%s", view)
+	}
+	for _, line := range box {
+		if ansi.StringWidth(line) != ansi.StringWidth(box[0]) {
+			t.Errorf("%q is %d wide, the border %d", line, ansi.StringWidth(line), ansi.StringWidth(box[0]))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSE captures are text files with one event payload per line. A line may
+// start with the delay since the previous event, e.g.
+//
+//	250ms [{"type":"update","data":[...]}]
+//
+// Lines without a delay are replayed immediately; blank lines and lines
+// starting with # are ignored.
+
+// parseReplayLine splits a capture line into its delay and payload
+func parseReplayLine(line string) (time.Duration, []byte, error) {
+	line = strings.TrimSpace(line)
+	if line[0] == '[' || line[0] == '{' {
+		return 0, []byte(line), nil
+	}
+
+	delay, payload, ok := strings.Cut(line, " ")
+	if !ok {
+		return 0, nil, fmt.Errorf("expected a delay and a JSON payload")
+	}
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return 0, nil, fmt.Errorf("bad delay %q: %v", delay, err)
+	}
+	return d, []byte(strings.TrimSpace(payload)), nil
+}
+
+// replaySSE feeds a capture into the SSE channel on its recorded schedule,
+// standing in for the bridge's event stream
+func replaySSE(path string, sseChannel chan<- sseMessage) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pump := startSSEPump(sseChannel)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		delay, payload, err := parseReplayLine(line)
+		if err != nil {
+			logError("Replay %s:%d: %v", path, lineNo, err)
+			continue
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-appCtx.Done():
+			return nil
+		}
+		pump.push(payload)
+	}
+	logInfo("Replay of %s finished after %d lines", path, lineNo)
+	return scanner.Err()
+}
+
+// sseRecorder appends every SSE payload to a capture file that replaySSE can play back
+type sseRecorder struct {
+	mu   sync.Mutex
+	f    *os.File
+	last time.Time
+}
+
+func newSSERecorder(path string) (*sseRecorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &sseRecorder{f: f}, nil
+}
+
+// record writes one payload with the delay since the previous one
+func (r *sseRecorder) record(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var delay time.Duration
+	if !r.last.IsZero() {
+		delay = now.Sub(r.last).Round(time.Millisecond)
+	}
+	r.last = now
+
+	// Payloads are single-line JSON, but be safe so the capture stays line-based
+	data = bytes.ReplaceAll(data, []byte("\n"), []byte(" "))
+	if _, err := fmt.Fprintf(r.f, "%s %s\n", delay, data); err != nil {
+		logError("Recording SSE payload: %v", err)
+	}
+}
+
+func (r *sseRecorder) Close() error {
+	return r.f.Close()
+}
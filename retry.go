@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/openhue/openhue-go"
+	"github.com/sethchev/hue-control-tui/pkg/hueclient"
+)
+
+// Transient failures are retried retryAttempts times in all, waiting
+// retryBaseDelay before the second attempt and twice as long before each next
+const (
+	retryAttempts  = 3
+	retryBaseDelay = 300 * time.Millisecond
+)
+
+// retryNotice tells the model a bridge call is being retried
+type retryNotice struct {
+	text string
+	at   time.Time
+}
+
+// retryNotices carries retry progress to the status line. Sends never block,
+// so a full buffer only drops notices.
+var retryNotices = make(chan retryNotice, 16)
+
+// withRetry runs fn, retrying while it fails with a network error such as a
+// timeout. Errors the bridge answers with, like 4xx responses, aren't retried.
+func withRetry(op string, fn func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == retryAttempts || !isNetworkError(err) || appCtx.Err() != nil {
+			return err
+		}
+
+		logInfo("%s failed (%v), retrying in %s", op, err, delay)
+		select {
+		case retryNotices <- retryNotice{text: fmt.Sprintf(glyphText("Retrying %s… (attempt %d of %d)"), op, attempt+1, retryAttempts), at: time.Now()}:
+		default:
+		}
+		select {
+		case <-time.After(delay):
+		case <-appCtx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// updateLight writes a light's state, retrying transient failures
+func updateLight(lightID string, put openhue.LightPut) error {
+	return writeLight(lightID, put, func(client *hueclient.Client) error {
+		return client.Update(appCtx, "light", lightID, put)
+	})
+}
+
+// writeLight sends a light write with one of the client's helpers, retrying
+// transient failures, and merges put, the state it sets, into the cache
+func writeLight(lightID string, put openhue.LightPut, send func(client *hueclient.Client) error) error {
+	defer trackWrite()()
+	err := withRetry("light update", func() error {
+		return send(bridgeClient())
+	})
+	if err == nil {
+		bridgeCache.mergeWrite("light", lightID, put)
+	}
+	return err
+}
+
+// listenForRetries waits for the next retry notice
+func listenForRetries() tea.Cmd {
+	return func() tea.Msg {
+		return <-retryNotices
+	}
+}
+
+// handleRetryNotice shows a retry in the status line. Writes run in the
+// background, so their retries show while they are under way; a notice older
+// than the status, such as one from an action whose result is already shown,
+// is dropped so it doesn't replace it.
+func (m *lightModel) handleRetryNotice(notice retryNotice) {
+	if notice.at.After(m.statusAt) {
+		m.status = notice.text
+	}
+}
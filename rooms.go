@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// groupCommand handles ":room ..." and ":zone ...". Rooms hold devices and
+// zones hold light services, so the selection is mapped accordingly.
+func (m *lightModel) groupCommand(rtype, args string) error {
+	action, name, _ := strings.Cut(args, " ")
+	name = strings.TrimSpace(name)
+	if rtype == "room" && action == "off-in" {
+		return m.roomOffIn(name)
+	}
+	name = unquote(name)
+	if rtype == "room" && action == "cancel" {
+		return m.roomCancel(name)
+	}
+	if name == "" || (action != "create" && action != "add" && action != "remove") {
+		return fmt.Errorf("usage: %s create|add|remove <name>", rtype)
+	}
+
+	members := m.selectedGroupMembers(rtype)
+	if len(members) == 0 && action != "create" {
+		return fmt.Errorf("select the lights to %s first", action)
+	}
+
+	groups, err := getGroups()
+	if err != nil {
+		return fmt.Errorf("fetching rooms and zones: %v", err)
+	}
+
+	switch action {
+	case "create":
+		if findGroup(groups, rtype, name) != nil {
+			return fmt.Errorf("%s %q already exists", rtype, name)
+		}
+		if err := checkGroupLimit(rtype); err != nil {
+			return err
+		}
+		// A device can only be in one room, so take it out of its old one first
+		if rtype == "room" {
+			if err := releaseFromRooms(groups, members, ""); err != nil {
+				return err
+			}
+		}
+		body := map[string]any{
+			"metadata": map[string]string{"name": name, "archetype": "other"},
+			"children": members,
+		}
+		if _, err := clipWrite("POST", "resource/"+rtype, body); err != nil {
+			return fmt.Errorf("creating %s %s: %v", rtype, name, limitError(err, limitNoun(rtype)))
+		}
+		m.setStatus("%s", trn("status.group.created", len(members), rtype, name, len(members)))
+
+	case "add":
+		group := findGroup(groups, rtype, name)
+		if group == nil {
+			return fmt.Errorf("no %s named %q", rtype, name)
+		}
+		if rtype == "room" {
+			if err := releaseFromRooms(groups, members, group.ID); err != nil {
+				return err
+			}
+		}
+		children := mergeRefs(group.Children, members)
+		if err := putChildren(*group, children); err != nil {
+			return err
+		}
+		m.setStatus("%s", trn("status.group.added", len(members), len(members), group.Metadata.Name))
+
+	case "remove":
+		group := findGroup(groups, rtype, name)
+		if group == nil {
+			return fmt.Errorf("no %s named %q", rtype, name)
+		}
+		children := subtractRefs(group.Children, members)
+		if len(children) == 0 {
+			return fmt.Errorf("that would leave %s %s empty", rtype, group.Metadata.Name)
+		}
+		if err := putChildren(*group, children); err != nil {
+			return err
+		}
+		removed := len(group.Children) - len(children)
+		m.setStatus("%s", trn("status.group.removed", removed, removed, group.Metadata.Name))
+	}
+
+	// Keep grouped_light tracking in step with the new membership
+	if groups, err := loadGroups(m.light); err != nil {
+		logError("Failed to reload rooms and zones: %v", err)
+	} else {
+		m.groups = groups
+	}
+	return nil
+}
+
+// selectedGroupMembers maps the selection to room children (devices) or zone children (lights)
+func (m lightModel) selectedGroupMembers(rtype string) []resourceRef {
+	var refs []resourceRef
+	for index := range m.selected {
+		light := m.light[index]
+		ref := resourceRef{Rid: light.ID, Rtype: "light"}
+		if rtype == "room" {
+			if light.DeviceOwner == "" {
+				continue
+			}
+			ref = resourceRef{Rid: light.DeviceOwner, Rtype: "device"}
+		}
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// findGroup finds a room or zone by name, ignoring case
+func findGroup(groups []GroupResource, rtype, name string) *GroupResource {
+	for i := range groups {
+		if groups[i].Type == rtype && strings.EqualFold(groups[i].Metadata.Name, name) {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+// releaseFromRooms takes devices out of the rooms they're in, other than
+// target, refusing if that would leave a room empty
+func releaseFromRooms(groups []GroupResource, devices []resourceRef, target string) error {
+	var updates []GroupResource
+	for _, group := range groups {
+		if group.Type != "room" || group.ID == target {
+			continue
+		}
+		remaining := subtractRefs(group.Children, devices)
+		if len(remaining) == len(group.Children) {
+			continue
+		}
+		if len(remaining) == 0 {
+			return fmt.Errorf("moving would leave room %s empty", group.Metadata.Name)
+		}
+		group.Children = remaining
+		updates = append(updates, group)
+	}
+
+	for _, group := range updates {
+		if err := putChildren(group, group.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putChildren(group GroupResource, children []resourceRef) error {
+	_, err := clipWrite("PUT", "resource/"+group.Type+"/"+group.ID, map[string]any{"children": children})
+	if err != nil {
+		return fmt.Errorf("updating %s %s: %v", group.Type, group.Metadata.Name, err)
+	}
+	return nil
+}
+
+// mergeRefs returns refs plus any of extra not already present
+func mergeRefs(refs, extra []resourceRef) []resourceRef {
+	merged := slices.Clone(refs)
+	for _, ref := range extra {
+		if !slices.Contains(merged, ref) {
+			merged = append(merged, ref)
+		}
+	}
+	return merged
+}
+
+// subtractRefs returns refs without any in remove
+func subtractRefs(refs, remove []resourceRef) []resourceRef {
+	var kept []resourceRef
+	for _, ref := range refs {
+		if !slices.Contains(remove, ref) {
+			kept = append(kept, ref)
+		}
+	}
+	return kept
+}
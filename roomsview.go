@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/openhue/openhue-go"
+)
+
+var roomHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#8BE9FD"))
+
+// buildRoomRows lays out the rooms view: a header per room, sorted by name,
+// followed by the room's rows as buildRows lays them out. Lights in no room
+// come last under their own header. Devices belong to one room, so a device
+// and its members always land together.
+func buildRoomRows(lights []Light, groups map[string]lightGroup) []tableRow {
+	rows := buildRows(lights)
+
+	var rooms []lightGroup
+	for _, group := range groups {
+		if group.ownerType == "room" {
+			rooms = append(rooms, group)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].name < rooms[j].name })
+
+	indexByID := make(map[string]int, len(lights))
+	for i, light := range lights {
+		indexByID[light.ID] = i
+	}
+
+	placed := make([]bool, len(rows))
+	var result []tableRow
+	addSection := func(header tableRow, member func(index int) bool) {
+		var section []tableRow
+		for i, row := range rows {
+			if !placed[i] && member(row.lights[0]) {
+				placed[i] = true
+				section = append(section, row)
+			}
+		}
+		if len(section) == 0 {
+			return
+		}
+		for _, row := range section {
+			if !row.member {
+				header.lights = append(header.lights, row.lights...)
+			}
+		}
+		result = append(result, header)
+		result = append(result, section...)
+	}
+
+	for _, room := range rooms {
+		members := make(map[int]bool, len(room.lightIDs))
+		for _, id := range room.lightIDs {
+			if index, ok := indexByID[id]; ok {
+				members[index] = true
+			}
+		}
+		header := tableRow{device: true, room: true, group: room.groupedLightID, name: room.name}
+		addSection(header, func(index int) bool { return members[index] })
+	}
+	addSection(tableRow{device: true, room: true, name: "No room"}, func(int) bool { return true })
+	return result
+}
+
+// layoutRows lays out the table for the current view
+func (m lightModel) layoutRows() []tableRow {
+	rows := buildRows(m.light)
+	if m.roomsView {
+		rows = buildRoomRows(m.light, m.groups)
+	}
+	if !m.filtered() {
+		return rows
+	}
+	visible := make(map[int]bool)
+	for _, index := range m.visibleLights() {
+		visible[index] = true
+	}
+	return filterRows(rows, visible)
+}
+
+// toggleRoomsView switches between the lights and rooms views, keeping the
+// cursor on the same light
+func (m *lightModel) toggleRoomsView() {
+	cursorLight := ""
+	if m.cursor >= 0 && m.cursor < len(m.rows) {
+		cursorLight = m.light[m.rows[m.cursor].lights[0]].ID
+	}
+
+	m.roomsView = !m.roomsView
+	m.rows = m.layoutRows()
+	m.cursor = 0
+	for row, tr := range m.rows {
+		if !tr.room && m.light[tr.lights[0]].ID == cursorLight {
+			m.cursor = row
+			break
+		}
+	}
+}
+
+// roomHeaderText describes a room header, e.g. "Living Room · 3/5 on · 62%".
+// The brightness is the room's grouped_light, like other Hue apps show.
+func (m lightModel) roomHeaderText(row tableRow) string {
+	on := 0
+	for _, index := range row.lights {
+		if m.light[index].Reachable && m.light[index].Status == "on" {
+			on++
+		}
+	}
+	text := fmt.Sprintf(glyphText("%s · %d/%d on"), row.name, on, len(row.lights))
+	if group, ok := m.groups[row.group]; ok && group.on {
+		text += glyphText(" · ") + formatPercent(group.brightness)
+	}
+	return text
+}
+
+// cursorRoom returns the room whose header is under the cursor
+func (m lightModel) cursorRoom() (lightGroup, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) || !m.rows[m.cursor].room {
+		return lightGroup{}, false
+	}
+	group, ok := m.groups[m.rows[m.cursor].group]
+	return group, ok
+}
+
+// adjustRoomBrightness dims or brightens a whole room with one write to its
+// grouped_light. Like dimStep for a light, brightening a room that's off
+// switches it on and dimming it past the bottom switches it off.
+func (m *lightModel) adjustRoomBrightness(room lightGroup, delta int) {
+	brightness := room.brightness
+	if !room.on {
+		brightness = 0
+	}
+	switchOff := delta < 0 && room.on && brightness+float32(delta) <= 0
+	brightness = min(max(brightness+float32(delta), 1), 100)
+
+	put := openhue.GroupedLightPut{Dimming: &openhue.Dimming{Brightness: &brightness}}
+	switch {
+	case switchOff:
+		off := false
+		put = openhue.GroupedLightPut{On: &openhue.On{On: &off}}
+	case delta > 0:
+		on := true
+		put.On = &openhue.On{On: &on}
+	case !room.on:
+		return
+	}
+
+	outgoing.recordBulk()
+	done := trackWrite()
+	err := withRetry("room brightness", func() error {
+		return updateResource("grouped_light", room.groupedLightID, put)
+	})
+	done()
+	if err != nil {
+		m.setStatusTr("status.change_failed", room.name, err)
+		return
+	}
+
+	// The grouped_light event confirms this shortly
+	if switchOff {
+		room.on = false
+		m.groups[room.groupedLightID] = room
+		m.setStatusTr("status.room.off", room.name)
+		return
+	}
+	room.brightness = brightness
+	room.on = true
+	m.groups[room.groupedLightID] = room
+	m.setStatusTr("status.room.brightness", room.name, formatPercent(brightness))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAdjustRoomBrightness(t *testing.T) {
+	tests := []struct {
+		name       string
+		on         bool
+		brightness float32
+		delta      int
+		wantWrite  map[string]any // keys of the body and their values; nil for no write
+		wantOn     bool
+		wantStatus string
+	}{
+		{"brighten", true, 50, 10, map[string]any{"on": true, "dimming": 60.0}, true, "Kitchen 60%"},
+		{"dim", true, 50, -10, map[string]any{"dimming": 40.0}, true, "Kitchen 40%"},
+		{"dim to the floor", true, 15, -10, map[string]any{"dimming": 5.0}, true, "Kitchen 5%"},
+		{"dim past the bottom", true, 5, -10, map[string]any{"on": false}, false, "Kitchen off"},
+		{"dim exactly to zero", true, 10, -10, map[string]any{"on": false}, false, "Kitchen off"},
+		{"brighten while off", false, 50, 10, map[string]any{"on": true, "dimming": 10.0}, true, "Kitchen 10%"},
+		{"dim while off", false, 50, -10, nil, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bridge := newTestBridge(t)
+			m := initialModel([]Light{testLight()}, nil)
+			room := lightGroup{groupedLightID: "kitchen-group", ownerType: "room", name: "Kitchen", on: tt.on, brightness: tt.brightness}
+			m.groups = map[string]lightGroup{room.groupedLightID: room}
+			m.status = ""
+
+			m.adjustRoomBrightness(room, tt.delta)
+
+			writes := bridge.recorded()
+			if tt.wantWrite == nil {
+				if len(writes) != 0 {
+					t.Fatalf("writes = %+v, want none", writes)
+				}
+				return
+			}
+			if len(writes) != 1 || writes[0].path != "/clip/v2/resource/grouped_light/kitchen-group" {
+				t.Fatalf("writes = %+v, want one to the grouped_light", writes)
+			}
+			body := writes[0].body
+			if len(body) != len(tt.wantWrite) {
+				t.Errorf("body = %v, want keys %v", body, tt.wantWrite)
+			}
+			for key, want := range tt.wantWrite {
+				var got any
+				switch key {
+				case "on":
+					got, _ = body["on"].(map[string]any)["on"]
+				case "dimming":
+					got, _ = body["dimming"].(map[string]any)["brightness"]
+				}
+				if got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			if m.groups[room.groupedLightID].on != tt.wantOn {
+				t.Errorf("room on = %t, want %t", m.groups[room.groupedLightID].on, tt.wantOn)
+			}
+			if m.status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", m.status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// Rooms resolve their members when loaded, so a room's update event reloads
+// them, and a deleted room's group goes with it
+func TestRoomEventsReloadGroups(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/room", `{"errors":[],"data":[{"id":"room-1","type":"room","metadata":{"name":"Kitchen"},
+		"children":[{"rid":"device-00","rtype":"device"},{"rid":"device-01","rtype":"device"}],
+		"services":[{"rid":"kitchen-group","rtype":"grouped_light"}]}]}`)
+	bridge.reply("/clip/v2/resource/zone", `{"errors":[],"data":[]}`)
+	bridge.reply("/clip/v2/resource/grouped_light", `{"errors":[],"data":[]}`)
+
+	m := initialModel(testLights(3), nil)
+	m.groups = map[string]lightGroup{"kitchen-group": {groupedLightID: "kitchen-group", ownerID: "room-1",
+		ownerType: "room", name: "Kitchen", lightIDs: []string{"light-00"}}}
+
+	apply := func(payload string) {
+		t.Helper()
+		events, err := parseSSEEvents([]byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range events {
+			var cmd tea.Cmd
+			m, cmd = m.handleSSEEvent(event)
+			if cmd != nil {
+				model, _ := m.Update(cmd())
+				m = model.(lightModel)
+			}
+		}
+	}
+
+	apply(`[{"type":"update","data":[{"id":"room-1","type":"room",
+		"children":[{"rid":"device-00","rtype":"device"},{"rid":"device-01","rtype":"device"}]}]}]`)
+	if room := m.roomOf("light-01"); room != "Kitchen" {
+		t.Errorf("light-01 is in %q after joining the kitchen", room)
+	}
+
+	apply(`[{"type":"delete","data":[{"id":"room-1","type":"room"}]}]`)
+	if len(m.groups) != 0 {
+		t.Errorf("groups %v remain after the room was deleted", m.groups)
+	}
+}
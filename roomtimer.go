@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/openhue/openhue-go"
+)
+
+var roomTimerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#8BE9FD")).MarginLeft(2)
+
+// roomTimer switches a room off with one grouped_light write when it expires
+type roomTimer struct {
+	groupedLightID string
+	room           string
+	deadline       time.Time
+}
+
+// roomTimerTickMsg counts the room timers down
+type roomTimerTickMsg struct{}
+
+func roomTimerTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return roomTimerTickMsg{}
+	})
+}
+
+// findRoom returns the room named name, ignoring case
+func (m lightModel) findRoom(name string) (lightGroup, bool) {
+	for _, group := range m.groups {
+		if group.ownerType == "room" && strings.EqualFold(group.name, name) {
+			return group, true
+		}
+	}
+	return lightGroup{}, false
+}
+
+// roomOffIn handles ":room off-in <room> <minutes>"
+func (m *lightModel) roomOffIn(args string) error {
+	cut := strings.LastIndex(args, " ")
+	if cut < 0 {
+		return fmt.Errorf("usage: room off-in <room> <minutes>")
+	}
+	minutes, err := strconv.Atoi(args[cut+1:])
+	if err != nil || minutes < 1 {
+		return fmt.Errorf("minutes must be a whole number of at least 1")
+	}
+	name := unquote(strings.TrimSpace(args[:cut]))
+	room, ok := m.findRoom(name)
+	if !ok {
+		return fmt.Errorf("no room named %q", name)
+	}
+
+	if m.roomTimers == nil {
+		m.roomTimers = make(map[string]roomTimer)
+	}
+	deadline := time.Now().Add(time.Duration(minutes) * time.Minute)
+	m.roomTimers[room.groupedLightID] = roomTimer{groupedLightID: room.groupedLightID, room: room.name, deadline: deadline}
+	if !m.roomTimerTicking {
+		m.roomTimerTicking = true
+		m.queue(roomTimerTick())
+	}
+	m.setStatusTr("status.timer.set", room.name, formatClock(deadline, time.Now(), appConfig.Units.Time))
+	return nil
+}
+
+// roomCancel handles ":room cancel <room>"
+func (m *lightModel) roomCancel(name string) error {
+	room, ok := m.findRoom(name)
+	if !ok {
+		return fmt.Errorf("no room named %q", name)
+	}
+	if _, ok := m.roomTimers[room.groupedLightID]; !ok {
+		return fmt.Errorf("%s has no timer running", room.name)
+	}
+	delete(m.roomTimers, room.groupedLightID)
+	m.setStatusTr("status.timer.cancelled", room.name)
+	return nil
+}
+
+// handleRoomTimerTick switches off rooms whose timers have run out
+func (m *lightModel) handleRoomTimerTick(now time.Time) tea.Cmd {
+	for id, timer := range m.roomTimers {
+		if now.Before(timer.deadline) {
+			continue
+		}
+		delete(m.roomTimers, id)
+
+		off := false
+		outgoing.recordBulk()
+		done := trackWrite()
+		err := withRetry("room timer", func() error {
+			return updateResource("grouped_light", id, openhue.GroupedLightPut{On: &openhue.On{On: &off}})
+		})
+		done()
+		if err != nil {
+			m.setStatusTr("status.timer.failed", timer.room, err)
+		} else {
+			m.setStatusTr("status.timer.done", timer.room)
+		}
+	}
+
+	if len(m.roomTimers) == 0 {
+		m.roomTimerTicking = false
+		return nil
+	}
+	return roomTimerTick()
+}
+
+// renderRoomTimers shows the running room countdowns, or "" when there are none
+func (m lightModel) renderRoomTimers() string {
+	if len(m.roomTimers) == 0 {
+		return ""
+	}
+	return roomTimerStyle.Render(m.describeRoomTimers()) + "\n"
+}
+
+// describeRoomTimers lists the countdowns, soonest first, e.g. "Kitchen off in 4:05"
+func (m lightModel) describeRoomTimers() string {
+	timers := make([]roomTimer, 0, len(m.roomTimers))
+	for _, timer := range m.roomTimers {
+		timers = append(timers, timer)
+	}
+	sort.Slice(timers, func(i, j int) bool { return timers[i].deadline.Before(timers[j].deadline) })
+
+	parts := make([]string, len(timers))
+	for i, timer := range timers {
+		parts[i] = fmt.Sprintf("%s off in %s", timer.room, formatCountdown(time.Until(timer.deadline)))
+	}
+	return strings.Join(parts, glyphText(" · "))
+}
+
+// formatCountdown renders a remaining duration as "4:05" or "1:02:00"
+func formatCountdown(d time.Duration) string {
+	seconds := max(0, int(d.Round(time.Second).Seconds()))
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
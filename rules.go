@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleCheckInterval is how often rules with time conditions are re-evaluated
+// between events
+const ruleCheckInterval = time.Minute
+
+// ruleFile is the --rules file
+type ruleFile struct {
+	Rules []rule `yaml:"rules"`
+}
+
+// rule fires its action on each light it matches when the condition becomes true
+type rule struct {
+	Name  string        `yaml:"name"`
+	Match ruleMatch     `yaml:"match"`
+	When  ruleCondition `yaml:"when"`
+	Then  ruleAction    `yaml:"then"`
+
+	window timeWindow // parsed When.Between
+}
+
+// ruleMatch picks lights by name glob and/or room name
+type ruleMatch struct {
+	Name string `yaml:"name"`
+	Room string `yaml:"room"`
+}
+
+// ruleCondition is true when every condition that is set holds
+type ruleCondition struct {
+	On              *bool    `yaml:"on"`
+	Reachable       *bool    `yaml:"reachable"`
+	BrightnessAbove *float32 `yaml:"brightness_above"`
+	BrightnessBelow *float32 `yaml:"brightness_below"`
+	Between         string   `yaml:"between"` // e.g. "01:00-06:00"
+}
+
+// ruleAction is what a rule does: on, off, brightness or scene
+type ruleAction struct {
+	Action     string `yaml:"action"`
+	Brightness int    `yaml:"brightness"`
+	Scene      string `yaml:"scene"`
+}
+
+// loadRules reads and validates a rules file. Errors name the offending rule.
+func loadRules(filename string) ([]rule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var file ruleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", filename, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("%s has no rules", filename)
+	}
+
+	for i := range file.Rules {
+		if err := file.Rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %v", i+1, file.Rules[i].Name, err)
+		}
+	}
+	return file.Rules, nil
+}
+
+// validate checks a rule and parses its time window
+func (r *rule) validate() error {
+	if r.Match.Name == "" && r.Match.Room == "" {
+		return fmt.Errorf("match needs a name or a room")
+	}
+	if r.Match.Name != "" {
+		if _, err := path.Match(strings.ToLower(r.Match.Name), ""); err != nil {
+			return fmt.Errorf("bad name pattern %q: %v", r.Match.Name, err)
+		}
+	}
+	if r.When.Between != "" {
+		window, err := parseTimeWindow(r.When.Between)
+		if err != nil {
+			return fmt.Errorf("between: %v", err)
+		}
+		r.window = window
+	}
+
+	switch r.Then.Action {
+	case "on", "off":
+	case "brightness":
+		if r.Then.Brightness < 1 || r.Then.Brightness > 100 {
+			return fmt.Errorf("brightness must be 1 to 100")
+		}
+	case "scene":
+		if r.Then.Scene == "" {
+			return fmt.Errorf("scene action needs a scene name")
+		}
+	case "":
+		return fmt.Errorf("then needs an action")
+	default:
+		return fmt.Errorf("unknown action %q; use on, off, brightness or scene", r.Then.Action)
+	}
+	return nil
+}
+
+// matches reports whether a light is one the rule applies to
+func (r rule) matches(light Light, rooms map[string]string) bool {
+	if r.Match.Name != "" {
+		matched, _ := path.Match(strings.ToLower(r.Match.Name), strings.ToLower(light.Name))
+		if !matched {
+			return false
+		}
+	}
+	return r.Match.Room == "" || strings.EqualFold(rooms[light.ID], r.Match.Room)
+}
+
+// holds reports whether the rule's condition is true for a light at now
+func (r rule) holds(light Light, now time.Time) bool {
+	c := r.When
+	switch {
+	case c.On != nil && *c.On != (light.Status == "on"):
+		return false
+	case c.Reachable != nil && *c.Reachable != light.Reachable:
+		return false
+	case c.BrightnessAbove != nil && light.Brightness <= *c.BrightnessAbove:
+		return false
+	case c.BrightnessBelow != nil && light.Brightness >= *c.BrightnessBelow:
+		return false
+	case c.Between != "" && !r.window.contains(now):
+		return false
+	}
+	return true
+}
+
+// ruleEngine evaluates rules against the live light list
+type ruleEngine struct {
+	rules []rule
+	rooms map[string]string // light ID -> room name
+	held  map[string]bool   // "rule index/light ID" -> condition held last time
+}
+
+// evaluate fires each rule for lights whose condition has just become true.
+// Firing on the transition keeps a rule from rewriting a light on every event.
+func (e *ruleEngine) evaluate(lights []Light, now time.Time) {
+	for i, r := range e.rules {
+		var fired []Light
+		for _, light := range lights {
+			if !r.matches(light, e.rooms) {
+				continue
+			}
+			key := fmt.Sprintf("%d/%s", i, light.ID)
+			holds := r.holds(light, now)
+			if holds && !e.held[key] {
+				fired = append(fired, light)
+			}
+			e.held[key] = holds
+		}
+		if len(fired) > 0 {
+			e.fire(r, fired)
+		}
+	}
+}
+
+// fire performs a rule's action, once for scene actions and otherwise per light
+func (e *ruleEngine) fire(r rule, lights []Light) {
+	if r.Then.Action == "scene" {
+		if err := setScene(r.Then.Scene); err != nil {
+			logError("Rule %q: scene %s failed: %v", r.Name, r.Then.Scene, err)
+			return
+		}
+		logInfo("Rule %q: recalled scene %s", r.Name, r.Then.Scene)
+		return
+	}
+
+	for _, light := range lights {
+		var err error
+		switch r.Then.Action {
+		case "on", "off":
+			on := r.Then.Action == "on"
+			if (light.Status == "on") == on {
+				continue
+			}
+			err = toggleLight(light.ID, !on)
+		case "brightness":
+			_, err = writeBrightness(light.ID, float32(r.Then.Brightness), light.MinDimLevel)
+		}
+		if err != nil {
+			logError("Rule %q: %s on %s failed: %v", r.Name, r.Then.Action, light.Name, err)
+			continue
+		}
+		logInfo("Rule %q: %s %s", r.Name, describeRuleAction(r.Then), light.Name)
+	}
+}
+
+func describeRuleAction(a ruleAction) string {
+	switch a.Action {
+	case "brightness":
+		return fmt.Sprintf("set brightness %d%% on", a.Brightness)
+	default:
+		return "turned " + a.Action
+	}
+}
+
+// runRules evaluates rules without a TUI until interrupted, on every SSE event
+// and every minute for time conditions
+func runRules(filename string) error {
+	rules, err := loadRules(filename)
+	if err != nil {
+		return err
+	}
+	lights, err := returnLights()
+	if err != nil {
+		return err
+	}
+
+	rooms := make(map[string]string)
+	groups, err := loadGroups(lights)
+	if err != nil {
+		logError("Failed to load rooms, room matches won't work: %v", err)
+	}
+	for _, group := range groups {
+		if group.ownerType == "room" {
+			for _, id := range group.lightIDs {
+				rooms[id] = group.name
+			}
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancelApp()
+	}()
+
+	sseChannel := make(chan sseMessage)
+	go subscribeSSE(sseChannel, nil)
+
+	engine := &ruleEngine{rules: rules, rooms: rooms, held: make(map[string]bool)}
+	logInfo("Evaluating %d %s from %s", len(rules), pluralize(len(rules), "rule", "rules"), filename)
+	engine.evaluate(lights, time.Now())
+
+	ticker := time.NewTicker(ruleCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-sseChannel:
+			events, ok := msg.(sseEventsMsg)
+			if !ok {
+				logError("SSE: failed to parse JSON: %v", msg.(sseErrorMsg).err)
+				continue
+			}
+			// Rules act on state, so notifications and own-write tracking don't matter
+			state := &lightState{lights: lights, now: time.Now()}
+			for _, event := range events.events {
+				switch e := event.(type) {
+				case lightChanged:
+					state.applyLight(e.item)
+				case connectivityChanged:
+					state.applyConnectivity(e.item)
+				}
+			}
+			engine.evaluate(lights, state.now)
+		case now := <-ticker.C:
+			engine.evaluate(lights, now)
+		case <-appCtx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sceneKeyBinding is a scene hotkey resolved to a scene ID at startup
+type sceneKeyBinding struct {
+	key     string
+	ref     string // scene name as written in the config
+	sceneID string
+}
+
+// resolveSceneKeys looks up the configured scene hotkeys, returning a warning
+// for each binding whose scene doesn't exist
+func resolveSceneKeys(keys map[string]string) (map[string]sceneKeyBinding, []string) {
+	bindings := make(map[string]sceneKeyBinding)
+	if len(keys) == 0 {
+		return bindings, nil
+	}
+
+	scenes, err := getScenes()
+	if err != nil {
+		return bindings, []string{fmt.Sprintf("scene keys disabled: %v", err)}
+	}
+
+	var warnings []string
+	for name, ref := range keys {
+		key, err := normalizeKeyName(name)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("scene key %q: %v", name, err))
+			continue
+		}
+		scene, err := findScene(scenes, ref)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("scene key %s: %v", key, err))
+			continue
+		}
+		bindings[key] = sceneKeyBinding{key: key, ref: ref, sceneID: scene.ID}
+	}
+	sort.Strings(warnings)
+	return bindings, warnings
+}
+
+// namedKeys are the names bubbletea gives keys other than typed characters,
+// e.g. "f1", "ctrl+a" and "pgdown"
+var namedKeys = func() map[string]bool {
+	names := make(map[string]bool)
+	for k := tea.KeyType(-128); k < 128; k++ {
+		if name := k.String(); utf8.RuneCountInString(name) > 1 {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// normalizeKeyName turns a configured key name into the form KeyMsg.String()
+// reports it in. A single character is kept as written, since "a" and "A" are
+// different keys; named keys are matched ignoring case. Keys bubbletea has no
+// name for, such as "f25" or "ctrl+shift+a", could never be pressed.
+func normalizeKeyName(name string) (string, error) {
+	alt := ""
+	if len(name) > len("alt+") && strings.EqualFold(name[:len("alt+")], "alt+") {
+		alt, name = "alt+", name[len("alt+"):]
+	}
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) && r != utf8.RuneError {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("%q isn't a key that can be typed", name)
+		}
+		return alt + name, nil
+	}
+	if lower := strings.ToLower(name); namedKeys[lower] {
+		return alt + lower, nil
+	}
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// describeSceneKeys lists the active scene hotkeys for :help
+func describeSceneKeys(bindings map[string]sceneKeyBinding) string {
+	keys := make([]string, 0, len(bindings))
+	for key := range bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf(glyphText("%s → %s"), key, bindings[key].ref))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recallSceneKey recalls the scene bound to key, if any
+func (m *lightModel) recallSceneKey(key string) bool {
+	binding, ok := m.sceneKeys[key]
+	if !ok {
+		return false
+	}
+	if err := recallScene(binding.sceneID); err != nil {
+		m.setStatusTr("status.scene.recall_failed", binding.ref, err)
+	} else {
+		m.setStatusTr("status.scene.activated_key", binding.ref, key)
+	}
+	return true
+}
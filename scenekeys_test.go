@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNormalizeKeyName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"f1", "f1", false},
+		{"F12", "f12", false},
+		{"f20", "f20", false},
+		{"f21", "", true},
+		{"f25", "", true},
+		{"ctrl+b", "ctrl+b", false},
+		{"CTRL+B", "ctrl+b", false},
+		{"ctrl+shift+b", "", true},
+		{"ctrl+shift+up", "ctrl+shift+up", false},
+		{"alt+x", "alt+x", false},
+		{"ALT+X", "alt+X", false},
+		{"alt+f1", "alt+f1", false},
+		{"alt+", "", true},
+		{"PgDown", "pgdown", false},
+		{"a", "a", false},
+		{"A", "A", false},
+		{"ä", "ä", false},
+		{"Ä", "Ä", false},
+		{"💡", "💡", false},
+		{"€", "€", false},
+		{"", "", true},
+		{"ab", "", true},
+		{"\x1b", "", true},
+		{"\u200b", "", true},
+		{"\xff", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeKeyName(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeKeyName(%q) = %q, %v; want %q, error %t", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestResolveSceneKeys(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/scene", `{"errors":[],"data":[`+testScene("relax", "Relax", "inactive")+`]}`)
+
+	bindings, warnings := resolveSceneKeys(map[string]string{
+		"F1":           "Relax",
+		"ctrl+b":       "Relax",
+		"alt+x":        "Relax",
+		"ä":            "Relax",
+		"A":            "Relax",
+		"f25":          "Relax",
+		"ctrl+shift+z": "Relax",
+		"f2":           "No Such Scene",
+	})
+
+	if len(warnings) != 3 {
+		t.Errorf("warnings = %q, want three", warnings)
+	}
+	for _, want := range []string{`"f25"`, `"ctrl+shift+z"`, "f2:"} {
+		if !strings.Contains(strings.Join(warnings, "\n"), want) {
+			t.Errorf("no warning for %s in %q", want, warnings)
+		}
+	}
+
+	// Each binding fires on the key as bubbletea reports it, and only on it
+	presses := []struct {
+		msg  tea.KeyMsg
+		want bool
+	}{
+		{tea.KeyMsg{Type: tea.KeyF1}, true},
+		{tea.KeyMsg{Type: tea.KeyCtrlB}, true},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}, Alt: true}, true},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'ä'}}, true},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'A'}}, true},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}, false},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Ä'}}, false},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}, false},
+		{tea.KeyMsg{Type: tea.KeyF2}, false},
+	}
+	for _, p := range presses {
+		if _, ok := bindings[p.msg.String()]; ok != p.want {
+			t.Errorf("%q bound = %t, want %t", p.msg.String(), ok, p.want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/openhue/openhue-go"
+)
+
+var (
+	sceneRoomStyle    = lipgloss.NewStyle().Faint(true)
+	sceneActiveStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#50FA7B"))
+	sceneDynamicStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#BD93F9"))
+)
+
+// scenePane is the scenes view's state. The scenes stay loaded after the
+// view is closed, so reopening it shows them at once while they reload.
+type scenePane struct {
+	open    bool
+	loading bool // a reload is running
+	loaded  bool // scenes holds a complete list
+	cursor  int
+	scenes  []Scene
+	limit   *bridgeLimit // the bridge's scene limit, nil when it couldn't be read
+}
+
+// scenesLoadedMsg carries the scenes loaded in the background
+type scenesLoadedMsg struct {
+	scenes []Scene
+	limit  *bridgeLimit
+	err    error
+}
+
+// sceneRecalls remembers when this app last recalled each scene, by scene
+// ID. The bridge doesn't record it, so it is saved with the UI state.
+var sceneRecalls = &recallLog{at: make(map[string]time.Time)}
+
+// recallLog is safe for concurrent use, since scenes are recalled from
+// commands, hotkeys and the scenes view alike
+type recallLog struct {
+	mu sync.Mutex
+	at map[string]time.Time
+}
+
+func (r *recallLog) record(id string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.at[id] = at
+}
+
+// last returns when a scene was last recalled, or the zero time
+func (r *recallLog) last(id string) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.at[id]
+}
+
+// snapshot copies the log for saving
+func (r *recallLog) snapshot() map[string]time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.at)
+}
+
+// restore loads a saved log, keeping anything recorded since
+func (r *recallLog) restore(saved map[string]time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, at := range saved {
+		if at.After(r.at[id]) {
+			r.at[id] = at
+		}
+	}
+}
+
+// openScenes shows the scenes view and loads the scenes in the background,
+// so a bridge with many scenes doesn't hold up the keyboard
+func (m *lightModel) openScenes() tea.Cmd {
+	m.scenePane.open = true
+	return m.reloadScenes()
+}
+
+// reloadScenes fetches the scenes again, unless a fetch is already running
+// or the scenes view has never been opened
+func (m *lightModel) reloadScenes() tea.Cmd {
+	if m.scenePane.loading || (!m.scenePane.open && !m.scenePane.loaded) {
+		return nil
+	}
+	m.scenePane.loading = true
+	return func() tea.Msg {
+		scenes, err := getScenes()
+		if err != nil {
+			return scenesLoadedMsg{err: err}
+		}
+		smart, err := loadSmartScenes()
+		if err != nil {
+			// Older bridges have no smart scenes; the regular ones are still useful
+			logError("Failed to fetch smart scenes: %v", err)
+		}
+		msg := scenesLoadedMsg{scenes: append(smart, scenes...)}
+		if limits, err := getBridgeLimits(); err != nil {
+			logDebug("Couldn't read the bridge's limits: %v", err)
+		} else if limits.Scenes.Total > 0 {
+			msg.limit = &limits.Scenes
+		}
+		return msg
+	}
+}
+
+// handleScenesLoaded shows freshly loaded scenes, smart scenes first,
+// keeping the cursor on the same scene
+func (m *lightModel) handleScenesLoaded(msg scenesLoadedMsg) {
+	pane := &m.scenePane
+	pane.loading = false
+	if msg.err != nil {
+		m.setStatusTr("status.scenes.load_failed", msg.err)
+		return
+	}
+
+	cursorID := ""
+	if pane.cursor < len(pane.scenes) {
+		cursorID = pane.scenes[pane.cursor].ID
+	}
+	pane.scenes = msg.scenes
+	pane.limit = msg.limit
+	pane.loaded = true
+	pane.cursor = 0
+	for i, scene := range pane.scenes {
+		if scene.ID == cursorID {
+			pane.cursor = i
+		}
+	}
+}
+
+// removeScene drops a deleted scene from the scenes view
+func (m *lightModel) removeScene(id string) {
+	pane := &m.scenePane
+	for i, scene := range pane.scenes {
+		if scene.ID == id {
+			pane.scenes = slices.Delete(pane.scenes, i, i+1)
+			pane.cursor = max(0, min(pane.cursor, len(pane.scenes)-1))
+			return
+		}
+	}
+}
+
+// loadSmartScenes lists the smart scenes as scenes view rows
+func loadSmartScenes() ([]Scene, error) {
+	resources, err := getSmartScenes()
+	if err != nil {
+		return nil, err
+	}
+	groupNames, err := getGroupNames()
+	if err != nil {
+		logError("Failed to fetch room and zone names: %v", err)
+	}
+
+	scenes := make([]Scene, len(resources))
+	for i, resource := range resources {
+		scenes[i] = Scene{
+			ID:     resource.ID,
+			Name:   resource.Metadata.Name,
+			Type:   resource.Type,
+			Group:  resource.Group.Rid,
+			Room:   groupNames[resource.Group.Rid],
+			Status: resource.State,
+			Smart:  true,
+		}
+	}
+	sort.Slice(scenes, func(i, j int) bool {
+		if scenes[i].Room != scenes[j].Room {
+			return scenes[i].Room < scenes[j].Room
+		}
+		return scenes[i].Name < scenes[j].Name
+	})
+	return scenes, nil
+}
+
+// handleScenePaneKey processes a key while the scenes view is open
+func (m *lightModel) handleScenePaneKey(key string) {
+	pane := &m.scenePane
+	switch key {
+	case "s", "esc", "q":
+		pane.open = false
+	case "up", "k":
+		pane.cursor = max(0, pane.cursor-1)
+	case "down", "j":
+		pane.cursor = min(pane.cursor+1, max(0, len(pane.scenes)-1))
+	case "enter":
+		m.recallPaneScene(openhue.SceneRecallActionActive)
+	case "d":
+		m.recallPaneScene(openhue.SceneRecallActionDynamicPalette)
+	}
+}
+
+// recallPaneScene recalls the scene under the scenes view cursor
+func (m *lightModel) recallPaneScene(action openhue.SceneRecallAction) {
+	if len(m.scenePane.scenes) == 0 {
+		return
+	}
+	scene := &m.scenePane.scenes[m.scenePane.cursor]
+	if scene.Smart {
+		m.toggleSmartScene(scene, action)
+		return
+	}
+	if action == openhue.SceneRecallActionDynamicPalette && !scene.Dynamic {
+		m.setStatusTr("status.scene.no_dynamics", scene.Name)
+		return
+	}
+	if err := recallSceneAction(scene.ID, action); err != nil {
+		m.setStatusTr("status.scene.activate_failed", scene.Name, err)
+		return
+	}
+	if action == openhue.SceneRecallActionDynamicPalette {
+		m.setStatusTr("status.scene.dynamic", scene.Name)
+	} else {
+		m.setStatusTr("status.scene.activated", scene.Name)
+	}
+}
+
+// toggleSmartScene starts or stops a smart scene from the scenes view
+func (m *lightModel) toggleSmartScene(scene *Scene, action openhue.SceneRecallAction) {
+	if action == openhue.SceneRecallActionDynamicPalette {
+		m.setStatusTr("status.scene.smart_no_dynamics", scene.Name)
+		return
+	}
+	active := scene.Status != "active"
+	if err := setSmartSceneActive(scene.ID, active); err != nil {
+		m.setStatusTr("status.change_failed", scene.Name, err)
+		return
+	}
+	if active {
+		sceneRecalls.record(scene.ID, time.Now())
+		scene.Status = "active"
+		m.setStatusTr("status.smart_scene.activated", scene.Name, scene.Room)
+	} else {
+		scene.Status = "inactive"
+		m.setStatusTr("status.smart_scene.deactivated", scene.Name)
+	}
+}
+
+// handleSceneUpdate applies a scene SSE event to the scenes view
+func (m *lightModel) handleSceneUpdate(item SSEDataItem) {
+	for i := range m.scenePane.scenes {
+		scene := &m.scenePane.scenes[i]
+		if scene.ID != item.ID {
+			continue
+		}
+		if item.Status.Active != "" {
+			scene.Status = item.Status.Active
+		}
+		if item.State != "" {
+			scene.Status = item.State
+		}
+		if item.Speed != nil {
+			scene.Speed = float32(*item.Speed)
+		}
+		if item.Actions != nil {
+			scene.Lights = len(*item.Actions)
+		}
+	}
+}
+
+// sceneSpeedCommand handles ":scene speed <0-100>", which sets the speed of
+// every scene that is playing dynamically. Active scenes recalled static are
+// left alone, even if they have a palette to play.
+func (m *lightModel) sceneSpeedCommand(args string) error {
+	value, err := strconv.Atoi(strings.TrimSuffix(args, "%"))
+	if err != nil || value < 0 || value > 100 {
+		return fmt.Errorf("usage: scene speed <0-100>")
+	}
+
+	scenes, err := getScenes()
+	if err != nil {
+		return err
+	}
+	speed := float32(value) / 100
+	var changed, static []string
+	for _, scene := range scenes {
+		if scene.Status == "inactive" || scene.Status == "" {
+			continue
+		}
+		if scene.Status != "dynamic_palette" {
+			static = append(static, scene.Name)
+			continue
+		}
+		done := trackWrite()
+		err := withRetry("scene speed", func() error {
+			return updateResource("scene", scene.ID, openhue.ScenePut{Speed: &speed})
+		})
+		done()
+		if err != nil {
+			return fmt.Errorf("setting speed of %s: %v", scene.Name, err)
+		}
+		changed = append(changed, scene.Name)
+	}
+
+	switch {
+	case len(changed) > 0:
+		m.setStatusTr("status.scene.speed", value, strings.Join(changed, ", "))
+	case len(static) > 0:
+		return fmt.Errorf("not playing dynamically: %s", strings.Join(static, ", "))
+	default:
+		return fmt.Errorf("no scene is active")
+	}
+	return nil
+}
+
+// Scenes view column widths
+const (
+	sceneNameWidth     = 28
+	sceneRoomWidth     = 18
+	sceneLightsWidth   = 6
+	sceneRecalledWidth = 9
+)
+
+// sceneLightsCell shows how many lights a scene sets. Smart scenes recall
+// other scenes by time of day, so they have no count of their own.
+func sceneLightsCell(scene Scene) string {
+	if scene.Smart {
+		return orDash("")
+	}
+	return strconv.Itoa(scene.Lights)
+}
+
+// sceneRecalledCell shows how long ago this app last recalled a scene,
+// e.g. "5m ago", or a dash if it never has
+func sceneRecalledCell(at, now time.Time) string {
+	if at.IsZero() {
+		return orDash("")
+	}
+	return tr("time.ago", humanizeDuration(now.Sub(at)))
+}
+
+// renderScenePane draws the scenes view
+func (m lightModel) renderScenePane() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	count := fmt.Sprintf("%d", len(m.scenePane.scenes))
+	if limit := m.scenePane.limit; limit != nil {
+		// Smart scenes don't count toward the limit, so this counts the bridge's own way
+		count = tr("scenes.limit", limit.used(), limit.Total)
+	}
+	title := titleStyle.Render(tr("scenes.title")) + " " + faint.Render(count)
+	if m.scenePane.loading {
+		title += " " + faint.Render(tr("scenes.loading"))
+	}
+	b.WriteString(title + "\n\n")
+	if !m.scenePane.loaded && m.scenePane.loading {
+		b.WriteString(faint.Render("  "+tr("scenes.loading.first")) + "\n")
+	} else {
+		b.WriteString("  " + faint.Render(fitCell(tr("scenes.column.name"), sceneNameWidth)+" "+fitCell(tr("scenes.column.room"), sceneRoomWidth)+" "+
+			fitCell(tr("scenes.column.lights"), sceneLightsWidth)+" "+fitCell(tr("scenes.column.recalled"), sceneRecalledWidth)) + "\n")
+	}
+	now := time.Now()
+
+	// Keep the cursor on screen, below the column headings
+	visible := max(1, m.paneLines()-1)
+	start := max(0, m.scenePane.cursor-visible+1)
+	end := min(start+visible, len(m.scenePane.scenes))
+	for i := start; i < end; i++ {
+		scene := m.scenePane.scenes[i]
+		cursor := "  "
+		if i == m.scenePane.cursor {
+			cursor = cursorStyle.Render(glyphText("▶ "))
+		}
+		line := cursor + fitCell(scene.Name, sceneNameWidth) + " " +
+			fitCell(sceneRoomStyle.Render(orDash(scene.Room)), sceneRoomWidth) + " " +
+			fitCell(sceneLightsCell(scene), sceneLightsWidth) + " " +
+			fitCell(sceneRecalledCell(sceneRecalls.last(scene.ID), now), sceneRecalledWidth)
+		switch {
+		case scene.Smart && scene.Status == "active":
+			line += " " + sceneActiveStyle.Render(tr("scenes.smart.active"))
+		case scene.Smart:
+			line += " " + sceneRoomStyle.Render(tr("scenes.smart.inactive"))
+		case scene.Status == "dynamic_palette":
+			line += " " + sceneDynamicStyle.Render(tr("scenes.dynamic", scene.Speed*100))
+		case scene.Status == "static":
+			line += " " + sceneActiveStyle.Render(tr("scenes.active"))
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n" + faint.Render(m.keyFooter(keysScenes, m.width)))
+	if m.status != "" {
+		b.WriteString("\n" + m.status)
+	}
+	return viewText(b.String()) + "\n"
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testSceneID = "5c1d9e3a-7f20-4b8e-a6d4-0e9f8a7b6c5d"
+
+func TestSceneDynamicsEvents(t *testing.T) {
+	m := initialModel([]Light{testLight()}, nil)
+	m.scenePane.scenes = []Scene{{ID: testSceneID, Name: "Sunset", Status: "static", Dynamic: true, Speed: 0.5, Lights: 3}}
+
+	tests := []struct {
+		file       string
+		wantStatus string
+		wantSpeed  float32
+		wantLights int
+	}{
+		// Playing dynamically, with a new speed
+		{"sse/scene_dynamic.json", "dynamic_palette", 0.8, 3},
+		// Back to static; the speed stays as it was
+		{"sse/scene_static.json", "static", 0.8, 3},
+		// Deactivated, with the actions it now sets
+		{"sse/scene_inactive.json", "inactive", 0.8, 2},
+	}
+	for _, tt := range tests {
+		msg, ok := parseSSEMessage(readTestdata(t, tt.file)).(sseEventsMsg)
+		if !ok {
+			t.Fatalf("%s didn't parse", tt.file)
+		}
+		m, _ = m.handleSSEEvents(msg)
+		got := m.scenePane.scenes[0]
+		if got.Status != tt.wantStatus || got.Speed != tt.wantSpeed || got.Lights != tt.wantLights {
+			t.Errorf("after %s: status %q speed %v lights %d, want %q %v %d",
+				tt.file, got.Status, got.Speed, got.Lights, tt.wantStatus, tt.wantSpeed, tt.wantLights)
+		}
+	}
+}
+
+// testScene is a scene as the bridge lists it
+func testScene(id, name, status string) string {
+	return fmt.Sprintf(`{"id":%q,"type":"scene","metadata":{"name":%q},"group":{"rid":"kitchen","rtype":"room"},
+		"status":{"active":%q},"speed":0.5,"palette":{"color":[{"color":{"xy":{"x":0.5,"y":0.4}},"dimming":{"brightness":80}}]}}`,
+		id, name, status)
+}
+
+func TestSceneSpeedOnlyDynamicScenes(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/scene", `{"errors":[],"data":[`+
+		testScene("playing", "Sunset", "dynamic_palette")+`,`+
+		testScene("still", "Arctic", "static")+`,`+
+		testScene("off", "Savanna", "inactive")+`]}`)
+	m := initialModel([]Light{testLight()}, nil)
+
+	if err := m.sceneSpeedCommand("30"); err != nil {
+		t.Fatalf("scene speed: %v", err)
+	}
+	writes := bridge.recorded()
+	if len(writes) != 1 || writes[0].path != "/clip/v2/resource/scene/playing" {
+		t.Fatalf("writes = %+v, want one to the dynamic scene", writes)
+	}
+	if speed := writes[0].body["speed"]; !brightnessClose(float32(speed.(float64)), 0.3) {
+		t.Errorf("speed = %v, want 0.3", speed)
+	}
+	if !strings.Contains(m.status, "Sunset") || strings.Contains(m.status, "Arctic") {
+		t.Errorf("status = %q", m.status)
+	}
+}
+
+func TestSceneSpeedNothingPlaying(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/scene", `{"errors":[],"data":[`+testScene("still", "Arctic", "static")+`]}`)
+	m := initialModel([]Light{testLight()}, nil)
+
+	err := m.sceneSpeedCommand("30")
+	if err == nil || !strings.Contains(err.Error(), "Arctic") {
+		t.Errorf("err = %v, want it to name the static scene", err)
+	}
+	if writes := bridge.recorded(); len(writes) != 0 {
+		t.Errorf("writes = %+v, want none", writes)
+	}
+}
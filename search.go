@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchDuration is how long the bridge looks for new lights
+const searchDuration = 40 * time.Second
+
+// searchTickMsg updates the search countdown
+type searchTickMsg struct{}
+
+func searchTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return searchTickMsg{}
+	})
+}
+
+// lightSearch tracks a running :search
+type lightSearch struct {
+	deadline time.Time
+	before   map[string]bool // light IDs known when the search started
+}
+
+// startLightSearch asks the bridge to look for new Zigbee lights. CLIP v2 does
+// this through the zigbee_device_discovery resource; older firmware, or keys
+// the v2 call is refused for, fall back to the v1 lights search.
+func startLightSearch() error {
+	var discoveryResp struct {
+		Data []ResourceSummary `json:"data"`
+	}
+	err := clipGet("resource/zigbee_device_discovery", &discoveryResp)
+	if err == nil && len(discoveryResp.Data) > 0 {
+		_, err = clipWrite("PUT", "resource/zigbee_device_discovery/"+discoveryResp.Data[0].ID,
+			map[string]any{"action": map[string]string{"action_type": "search"}})
+		if err == nil {
+			return nil
+		}
+		logError("v2 device search failed, trying v1: %v", err)
+	}
+
+	if err := v1Write("POST", "lights", map[string]any{}); err != nil {
+		return fmt.Errorf("the bridge refused the search: %v", err)
+	}
+	return nil
+}
+
+// searchCommand handles ":search"
+func (m *lightModel) searchCommand() error {
+	if m.search != nil {
+		return fmt.Errorf("already searching")
+	}
+	if err := startLightSearch(); err != nil {
+		return err
+	}
+
+	before := make(map[string]bool, len(m.light))
+	for _, light := range m.light {
+		before[light.ID] = true
+	}
+	m.search = &lightSearch{deadline: time.Now().Add(searchDuration), before: before}
+	m.setStatusTr("status.search.started")
+	m.queue(searchTick())
+	return nil
+}
+
+// handleSearchTick counts down the search, then reloads the lights and reports what's new
+func (m *lightModel) handleSearchTick() tea.Cmd {
+	if m.search == nil {
+		return nil
+	}
+	remaining := time.Until(m.search.deadline)
+	if remaining > 0 {
+		// Progress is redrawn every second, so it isn't mirrored to the log
+		m.status = fmt.Sprintf(glyphText("Searching for new lights… %ds left"), int(remaining.Round(time.Second).Seconds()))
+		return searchTick()
+	}
+
+	before := m.search.before
+	m.search = nil
+	freshLights, err := returnLights()
+	if err != nil {
+		m.setStatusTr("status.search.refresh_failed", err)
+		return nil
+	}
+	m.replaceLights(freshLights)
+
+	var found []string
+	for _, light := range freshLights {
+		if !before[light.ID] {
+			found = append(found, light.Name)
+		}
+	}
+	if len(found) == 0 {
+		m.setStatusTr("status.search.none")
+	} else {
+		m.setStatus("%s", trn("status.search.found", len(found), len(found), strings.Join(found, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,651 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	sensorDisabledStyle = lipgloss.NewStyle().Faint(true)
+	sensorMotionStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#50FA7B"))
+)
+
+// motionResource is the CLIP v2 motion resource, trimmed to what the sensors view uses
+type motionResource struct {
+	ID      string      `json:"id"`
+	Owner   resourceRef `json:"owner"`
+	Enabled bool        `json:"enabled"`
+	Motion  struct {
+		Motion       bool          `json:"motion"`
+		MotionReport *motionReport `json:"motion_report"`
+	} `json:"motion"`
+	Sensitivity *struct {
+		Sensitivity    int `json:"sensitivity"`
+		SensitivityMax int `json:"sensitivity_max"`
+	} `json:"sensitivity"`
+}
+
+// motionReport is when a motion sensor last changed state, and to what
+type motionReport struct {
+	Changed time.Time `json:"changed"`
+	Motion  bool      `json:"motion"`
+}
+
+// buttonResource is the CLIP v2 button resource, one per button of a switch
+type buttonResource struct {
+	ID       string      `json:"id"`
+	Owner    resourceRef `json:"owner"`
+	Metadata struct {
+		ControlID int `json:"control_id"` // the button's number on the device, from 1
+	} `json:"metadata"`
+	Button struct {
+		ButtonReport *buttonReport `json:"button_report"`
+	} `json:"button"`
+}
+
+// buttonReport is a switch's latest button event
+type buttonReport struct {
+	Updated time.Time `json:"updated"`
+	Event   string    `json:"event"` // "initial_press", "repeat", "short_release", "long_release" or "long_press"
+}
+
+// devicePowerResource is the CLIP v2 device_power resource of a battery-powered device
+type devicePowerResource struct {
+	Owner      resourceRef `json:"owner"`
+	PowerState struct {
+		BatteryLevel *int `json:"battery_level"`
+	} `json:"power_state"`
+}
+
+// contactResource is the CLIP v2 contact resource of a secure contact sensor
+type contactResource struct {
+	ID            string         `json:"id"`
+	Owner         resourceRef    `json:"owner"`
+	Enabled       bool           `json:"enabled"`
+	ContactReport *contactReport `json:"contact_report"`
+}
+
+// contactReport is when a contact sensor last opened or closed, and which
+type contactReport struct {
+	Changed time.Time `json:"changed"`
+	State   string    `json:"state"` // "contact" when closed, "no_contact" when open
+}
+
+// tamperResource is the CLIP v2 tamper resource, reporting when a sensor's
+// casing or mount is interfered with
+type tamperResource struct {
+	ID            string         `json:"id"`
+	Owner         resourceRef    `json:"owner"`
+	TamperReports []tamperReport `json:"tamper_reports"`
+}
+
+// tamperReport is one source's tamper state
+type tamperReport struct {
+	Changed time.Time `json:"changed"`
+	Source  string    `json:"source"`
+	State   string    `json:"state"` // "tampered" or "not_tampered"
+}
+
+// isTampered reports whether any source reports tampering
+func isTampered(reports []tamperReport) bool {
+	for _, report := range reports {
+		if report.State == "tampered" {
+			return true
+		}
+	}
+	return false
+}
+
+// geofenceClientResource is the CLIP v2 geofence_client resource: a phone
+// whose location the bridge uses for home and away automations
+type geofenceClientResource struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsAtHome bool   `json:"is_at_home"`
+}
+
+// Kinds of sensors view rows. Those that can be enabled and disabled are
+// named after their resource type.
+const (
+	sensorMotion  = "motion"
+	sensorContact = "contact"
+	sensorSwitch  = "switch"
+	sensorPhone   = "phone"
+)
+
+// sensor is a row of the sensors view: a motion sensor, or a switch with
+// its buttons grouped together
+type sensor struct {
+	kind     string
+	id       string // the motion service's ID; empty for switches
+	deviceID string
+	name     string // the device's name
+	battery  int    // percent; -1 when unknown or mains-powered
+	enabled  bool
+
+	motion         bool      // motion is being detected right now
+	lastChanged    time.Time // when motion was last detected, the contact last opened or closed, or the phone last came or went; zero if never reported
+	sensitivity    int
+	sensitivityMax int // 0 when the sensor's sensitivity can't be changed
+
+	open     bool   // a contact sensor's contact is open
+	tamperID string // the tamper service's ID, if the device has one
+	tampered bool
+
+	atHome bool // a phone is at home
+
+	buttons    map[string]int // button service IDs to their numbers
+	lastButton int            // the button last pressed; 0 if none reported
+	lastEvent  string
+	lastPress  time.Time
+}
+
+// sensorPane is the sensors view's state
+type sensorPane struct {
+	open    bool
+	cursor  int
+	sensors []sensor
+}
+
+// getSensors lists the motion sensors, contact sensors and switches, named
+// after their devices, then the phones used for home and away automations
+func getSensors() ([]sensor, error) {
+	var motions []motionResource
+	if err := bridgeCache.list("motion", &motions); err != nil {
+		return nil, err
+	}
+	var buttons []buttonResource
+	if err := bridgeCache.list("button", &buttons); err != nil {
+		return nil, err
+	}
+	devices, err := getDevices()
+	if err != nil {
+		logError("Failed to fetch device names: %v", err)
+	}
+	names := make(map[string]string, len(devices))
+	for _, device := range devices {
+		names[device.ID] = device.Metadata.Name
+	}
+	var contacts []contactResource
+	if err := bridgeCache.list("contact", &contacts); err != nil {
+		return nil, err
+	}
+	var tampers []tamperResource
+	if err := bridgeCache.list("tamper", &tampers); err != nil {
+		logError("Failed to fetch tamper states: %v", err)
+	}
+	var phones []geofenceClientResource
+	if err := bridgeCache.list("geofence_client", &phones); err != nil {
+		logError("Failed to fetch geofence clients: %v", err)
+	}
+	var powers []devicePowerResource
+	if err := bridgeCache.list("device_power", &powers); err != nil {
+		logError("Failed to fetch battery levels: %v", err)
+	}
+	batteries := make(map[string]int, len(powers))
+	for _, power := range powers {
+		if power.PowerState.BatteryLevel != nil {
+			batteries[power.Owner.Rid] = *power.PowerState.BatteryLevel
+		}
+	}
+	battery := func(deviceID string) int {
+		if level, ok := batteries[deviceID]; ok {
+			return level
+		}
+		return -1
+	}
+	deviceName := func(deviceID string) string {
+		if name := names[deviceID]; name != "" {
+			return name
+		}
+		return deviceID
+	}
+
+	sensors := make([]sensor, 0, len(motions))
+	for _, motion := range motions {
+		s := sensor{
+			kind:     sensorMotion,
+			id:       motion.ID,
+			deviceID: motion.Owner.Rid,
+			name:     deviceName(motion.Owner.Rid),
+			battery:  battery(motion.Owner.Rid),
+			enabled:  motion.Enabled,
+			motion:   motion.Motion.Motion,
+		}
+		if report := motion.Motion.MotionReport; report != nil {
+			s.lastChanged = report.Changed
+			s.motion = report.Motion
+		}
+		if motion.Sensitivity != nil {
+			s.sensitivity = motion.Sensitivity.Sensitivity
+			s.sensitivityMax = motion.Sensitivity.SensitivityMax
+		}
+		sensors = append(sensors, s)
+	}
+	tamperOf := make(map[string]tamperResource, len(tampers))
+	for _, tamper := range tampers {
+		tamperOf[tamper.Owner.Rid] = tamper
+	}
+	for _, contact := range contacts {
+		s := sensor{
+			kind:     sensorContact,
+			id:       contact.ID,
+			deviceID: contact.Owner.Rid,
+			name:     deviceName(contact.Owner.Rid),
+			battery:  battery(contact.Owner.Rid),
+			enabled:  contact.Enabled,
+		}
+		if report := contact.ContactReport; report != nil {
+			s.open = report.State == "no_contact"
+			s.lastChanged = report.Changed
+		}
+		if tamper, ok := tamperOf[contact.Owner.Rid]; ok {
+			s.tamperID = tamper.ID
+			s.tampered = isTampered(tamper.TamperReports)
+		}
+		sensors = append(sensors, s)
+	}
+	sort.SliceStable(sensors, func(i, j int) bool {
+		if sensors[i].kind != sensors[j].kind {
+			return sensors[i].kind == sensorMotion
+		}
+		return sensors[i].name < sensors[j].name
+	})
+	sensors = append(sensors, groupButtons(buttons, deviceName, battery)...)
+	return append(sensors, phoneRows(phones)...), nil
+}
+
+// phoneRows makes a row per geofence client, sorted by name
+func phoneRows(phones []geofenceClientResource) []sensor {
+	rows := make([]sensor, 0, len(phones))
+	for _, phone := range phones {
+		name := phone.Name
+		if name == "" {
+			name = phone.ID
+		}
+		rows = append(rows, sensor{kind: sensorPhone, id: phone.ID, name: name, battery: -1, enabled: true, atHome: phone.IsAtHome})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].name < rows[j].name })
+	return rows
+}
+
+// groupButtons makes one row per switch from its button resources, sorted by
+// name, with the latest event of any of its buttons
+func groupButtons(buttons []buttonResource, deviceName func(string) string, battery func(string) int) []sensor {
+	byDevice := make(map[string]*sensor)
+	var switches []*sensor
+	for _, button := range buttons {
+		s, ok := byDevice[button.Owner.Rid]
+		if !ok {
+			s = &sensor{
+				kind:     sensorSwitch,
+				deviceID: button.Owner.Rid,
+				name:     deviceName(button.Owner.Rid),
+				battery:  battery(button.Owner.Rid),
+				enabled:  true,
+				buttons:  make(map[string]int),
+			}
+			byDevice[button.Owner.Rid] = s
+			switches = append(switches, s)
+		}
+		s.buttons[button.ID] = button.Metadata.ControlID
+		if report := button.Button.ButtonReport; report != nil && report.Updated.After(s.lastPress) {
+			s.recordPress(button.Metadata.ControlID, *report)
+		}
+	}
+
+	sort.Slice(switches, func(i, j int) bool { return switches[i].name < switches[j].name })
+	rows := make([]sensor, len(switches))
+	for i, s := range switches {
+		rows[i] = *s
+	}
+	return rows
+}
+
+// recordPress keeps a switch's latest button event
+func (s *sensor) recordPress(button int, report buttonReport) {
+	s.lastButton = button
+	s.lastEvent = report.Event
+	s.lastPress = report.Updated
+	if s.lastPress.IsZero() {
+		s.lastPress = time.Now()
+	}
+}
+
+// openSensors loads the sensors and shows the sensors view
+func (m *lightModel) openSensors() {
+	sensors, err := getSensors()
+	if err != nil {
+		m.setStatusTr("status.sensors.load_failed", err)
+		return
+	}
+	m.sensorPane = sensorPane{open: true, sensors: sensors}
+}
+
+// handleSensorPaneKey processes a key while the sensors view is open
+func (m *lightModel) handleSensorPaneKey(key string) {
+	pane := &m.sensorPane
+	switch key {
+	case "S", "esc", "q":
+		pane.open = false
+	case "up", "k":
+		pane.cursor = max(0, pane.cursor-1)
+	case "down", "j":
+		pane.cursor = min(pane.cursor+1, max(0, len(pane.sensors)-1))
+	case "enter":
+		m.toggleSensor()
+	case "right", "l":
+		m.adjustSensitivity(1)
+	case "left", "h":
+		m.adjustSensitivity(-1)
+	}
+}
+
+// cursorSensor returns the sensor under the sensors view cursor
+func (m *lightModel) cursorSensor() (*sensor, bool) {
+	if m.sensorPane.cursor >= len(m.sensorPane.sensors) {
+		return nil, false
+	}
+	return &m.sensorPane.sensors[m.sensorPane.cursor], true
+}
+
+// toggleSensor enables or disables the motion or contact sensor under the
+// cursor. A disabled sensor stops reporting, so automations using it go quiet.
+func (m *lightModel) toggleSensor() {
+	s, ok := m.cursorSensor()
+	if !ok {
+		return
+	}
+	if s.kind == sensorSwitch || s.kind == sensorPhone {
+		m.setStatusTr("status.sensor.listed_only", s.name, s.kind)
+		return
+	}
+	enabled := !s.enabled
+	if _, err := clipWrite("PUT", "resource/"+s.kind+"/"+s.id, map[string]any{"enabled": enabled}); err != nil {
+		m.setStatusTr("status.change_failed", s.name, err)
+		return
+	}
+	s.enabled = enabled
+	if enabled {
+		m.setStatusTr("status.enabled", s.name)
+	} else {
+		m.setStatusTr("status.disabled", s.name)
+	}
+}
+
+// adjustSensitivity steps the cursor sensor's sensitivity, within what the
+// sensor supports
+func (m *lightModel) adjustSensitivity(delta int) {
+	s, ok := m.cursorSensor()
+	if !ok {
+		return
+	}
+	if s.kind != sensorMotion || s.sensitivityMax == 0 {
+		m.setStatusTr("status.sensor.no_sensitivity", s.name)
+		return
+	}
+	sensitivity := min(max(s.sensitivity+delta, 1), s.sensitivityMax)
+	if sensitivity == s.sensitivity {
+		return
+	}
+	body := map[string]any{"sensitivity": map[string]int{"sensitivity": sensitivity}}
+	if _, err := clipWrite("PUT", "resource/motion/"+s.id, body); err != nil {
+		m.setStatusTr("status.change_failed", s.name, err)
+		return
+	}
+	s.sensitivity = sensitivity
+	m.setStatusTr("status.sensor.sensitivity", s.name, sensitivity, s.sensitivityMax)
+}
+
+// handleMotionUpdate applies a motion SSE event to the sensors view
+func (m *lightModel) handleMotionUpdate(item SSEDataItem) {
+	for i := range m.sensorPane.sensors {
+		s := &m.sensorPane.sensors[i]
+		if s.id != item.ID {
+			continue
+		}
+		if item.Enabled != nil {
+			s.enabled = *item.Enabled
+		}
+		if item.Motion != nil {
+			s.motion = item.Motion.Motion
+			if report := item.Motion.MotionReport; report != nil {
+				s.motion = report.Motion
+				s.lastChanged = report.Changed
+			}
+		}
+		if item.Sensitivity != nil {
+			s.sensitivity = item.Sensitivity.Sensitivity
+			if item.Sensitivity.SensitivityMax > 0 {
+				s.sensitivityMax = item.Sensitivity.SensitivityMax
+			}
+		}
+	}
+}
+
+// handleContactUpdate applies a contact SSE event to the sensors view, and
+// raises a notification when a contact opens if notify_contact_open is set.
+// The notification doesn't need the view to be open.
+func (m *lightModel) handleContactUpdate(item SSEDataItem) {
+	name := ""
+	for i := range m.sensorPane.sensors {
+		s := &m.sensorPane.sensors[i]
+		if s.id != item.ID {
+			continue
+		}
+		name = s.name
+		if item.Enabled != nil {
+			s.enabled = *item.Enabled
+		}
+		if report := item.ContactReport; report != nil {
+			s.open = report.State == "no_contact"
+			s.lastChanged = report.Changed
+		}
+	}
+
+	if item.ContactReport == nil || item.ContactReport.State != "no_contact" || !appConfig.NotifyContactOpen {
+		return
+	}
+	if name == "" {
+		name = contactSensorName(item.ID)
+	}
+	m.notify("%s opened", name)
+}
+
+// contactSensorName names a contact sensor after its device, for
+// notifications raised while the sensors view isn't loaded
+func contactSensorName(id string) string {
+	var contacts []contactResource
+	if err := bridgeCache.list("contact", &contacts); err != nil {
+		return "Contact sensor"
+	}
+	devices, _ := getDevices()
+	for _, contact := range contacts {
+		if contact.ID != id {
+			continue
+		}
+		for _, device := range devices {
+			if device.ID == contact.Owner.Rid && device.Metadata.Name != "" {
+				return device.Metadata.Name
+			}
+		}
+	}
+	return "Contact sensor"
+}
+
+// handleTamperUpdate applies a tamper SSE event to the sensors view
+func (m *lightModel) handleTamperUpdate(item SSEDataItem) {
+	if item.TamperReports == nil {
+		return
+	}
+	for i := range m.sensorPane.sensors {
+		if s := &m.sensorPane.sensors[i]; s.tamperID == item.ID {
+			s.tampered = isTampered(*item.TamperReports)
+		}
+	}
+}
+
+// handleGeofenceUpdate applies a geofence_client SSE event, such as a phone
+// leaving or arriving home, to the sensors view
+func (m *lightModel) handleGeofenceUpdate(item SSEDataItem) {
+	if item.IsAtHome == nil {
+		return
+	}
+	for i := range m.sensorPane.sensors {
+		s := &m.sensorPane.sensors[i]
+		if s.id == item.ID && s.atHome != *item.IsAtHome {
+			s.atHome = *item.IsAtHome
+			s.lastChanged = time.Now()
+		}
+	}
+}
+
+// handleButtonEvent records a button SSE event on its switch's row, so
+// presses show as they arrive
+func (m *lightModel) handleButtonEvent(item SSEDataItem) {
+	if item.Button == nil || item.Button.ButtonReport == nil {
+		return
+	}
+	for i := range m.sensorPane.sensors {
+		s := &m.sensorPane.sensors[i]
+		if button, ok := s.buttons[item.ID]; ok {
+			s.recordPress(button, *item.Button.ButtonReport)
+			return
+		}
+	}
+}
+
+// handlePowerUpdate applies a battery level SSE event to the sensors view
+func (m *lightModel) handlePowerUpdate(item SSEDataItem) {
+	if item.PowerState == nil || item.PowerState.BatteryLevel == nil || item.Owner == nil {
+		return
+	}
+	for i := range m.sensorPane.sensors {
+		if m.sensorPane.sensors[i].deviceID == item.Owner.Rid {
+			m.sensorPane.sensors[i].battery = *item.PowerState.BatteryLevel
+		}
+	}
+}
+
+// buttonEventNames describe the bridge's button events
+var buttonEventNames = map[string]string{
+	"initial_press": "pressed",
+	"repeat":        "held",
+	"short_release": "short press",
+	"long_release":  "long press",
+	"long_press":    "long press",
+}
+
+// lastActivityCell describes a row's latest activity: when a motion sensor
+// last saw motion or a contact sensor opened or closed, e.g. "2m ago", or a
+// switch's last button event, e.g. "button 2 long press 5s ago"
+func lastActivityCell(s sensor, now time.Time) string {
+	if s.kind == sensorSwitch {
+		if s.lastButton == 0 {
+			return orDash("")
+		}
+		event := buttonEventNames[s.lastEvent]
+		if event == "" {
+			event = strings.ReplaceAll(s.lastEvent, "_", " ")
+		}
+		return tr("sensors.button", s.lastButton, event, sinceCell(s.lastPress, now))
+	}
+	switch {
+	case s.kind == sensorMotion && s.motion:
+		return sensorMotionStyle.Render(tr("sensors.motion"))
+	case s.lastChanged.IsZero():
+		return orDash("")
+	default:
+		return sinceCell(s.lastChanged, now)
+	}
+}
+
+// contactCell shows whether a contact sensor is open, and whether it reports
+// tampering
+func contactCell(s sensor) string {
+	cell := tr("sensors.closed")
+	if s.open {
+		cell = sensorMotionStyle.Render(tr("sensors.open"))
+	}
+	if s.tampered {
+		cell += " " + updateStyle.Render(tr("sensors.tampered"))
+	}
+	return cell
+}
+
+// sinceCell says how long ago t was, e.g. "2m ago"; seconds count for
+// button presses, which are watched as they happen
+func sinceCell(t, now time.Time) string {
+	if d := now.Sub(t); d < time.Minute {
+		return tr("time.ago", fmt.Sprintf("%ds", max(0, int(d.Seconds()))))
+	}
+	return tr("time.ago", humanizeDuration(now.Sub(t)))
+}
+
+// batteryCell shows a battery level, or a dash when it's unknown
+func batteryCell(level int) string {
+	if level < 0 {
+		return orDash("")
+	}
+	return fmt.Sprintf("%d%%", level)
+}
+
+// renderSensorPane draws the sensors view. It is redrawn on the clock tick,
+// so the last-motion times stay current between events.
+func (m lightModel) renderSensorPane() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(tr("sensors.title")) + " " + faint.Render(fmt.Sprintf("%d", len(m.sensorPane.sensors))) + "\n\n")
+	if len(m.sensorPane.sensors) == 0 {
+		b.WriteString(faint.Render("  "+tr("sensors.none")) + "\n")
+	}
+
+	now := time.Now()
+	visible := m.paneLines()
+	start := max(0, m.sensorPane.cursor-visible+1)
+	end := min(start+visible, len(m.sensorPane.sensors))
+	for i := start; i < end; i++ {
+		s := m.sensorPane.sensors[i]
+		cursor := "  "
+		if i == m.sensorPane.cursor {
+			cursor = cursorStyle.Render(glyphText("▶ "))
+		}
+		state := tr("state.enabled")
+		switch {
+		case s.kind == sensorPhone && s.atHome:
+			state = tr("sensors.home")
+		case s.kind == sensorPhone:
+			state = tr("sensors.away")
+		case s.kind == sensorSwitch:
+			state = trn("sensors.buttons", len(s.buttons), len(s.buttons))
+		case !s.enabled:
+			state = tr("state.disabled")
+		}
+		sensitivity := orDash("")
+		switch {
+		case s.kind == sensorContact:
+			sensitivity = contactCell(s)
+		case s.sensitivityMax > 0:
+			sensitivity = tr("sensors.sensitivity", s.sensitivity, s.sensitivityMax)
+		}
+		line := fitCell(s.name, nameWidth) + " " + fitCell(state, 9) + " " + fitCell(sensitivity, 16) + " " +
+			fitCell(batteryCell(s.battery), 5) + " " + lastActivityCell(s, now)
+		if !s.enabled {
+			line = sensorDisabledStyle.Render(ansi.Strip(line))
+		}
+		b.WriteString(cursor + line + "\n")
+	}
+
+	b.WriteString("\n" + faint.Render(m.keyFooter(keysSensors, m.width)))
+	if m.status != "" {
+		b.WriteString("\n" + m.status)
+	}
+	return viewText(b.String()) + "\n"
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// shutdownTimeout bounds how long quitting waits for in-flight writes
+	shutdownTimeout = 3 * time.Second
+
+	// shutdownNoticeDelay is how long quitting may take before "shutting down…" is shown
+	shutdownNoticeDelay = 250 * time.Millisecond
+)
+
+var (
+	// appCtx is cancelled when the TUI quits; the SSE stream and bridge requests use it
+	appCtx, cancelApp = context.WithCancel(context.Background())
+
+	// pendingWrites counts bridge writes that are still in flight
+	pendingWrites sync.WaitGroup
+)
+
+// trackWrite registers an in-flight bridge write; call the returned func when it finishes
+func trackWrite() func() {
+	pendingWrites.Add(1)
+	return pendingWrites.Done
+}
+
+// shutdownCompleteMsg is sent once in-flight writes have finished or timed out
+type shutdownCompleteMsg struct {
+	timedOut bool
+}
+
+// shutdownSlowMsg is sent if shutting down takes longer than a beat
+type shutdownSlowMsg struct{}
+
+// waitForWrites blocks until every tracked write finishes or the timeout passes
+func waitForWrites(timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		done := make(chan struct{})
+		go func() {
+			pendingWrites.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return shutdownCompleteMsg{}
+		case <-time.After(timeout):
+			return shutdownCompleteMsg{timedOut: true}
+		}
+	}
+}
+
+// finishExec runs the work an --exec script queued, such as restoring lights
+// after :signal, passing what it returns through Update as the TUI would. It
+// stops after timeout, so ticks meant for an open TUI, like a room timer,
+// don't hold up --exec-quit for their whole length.
+func finishExec(m lightModel, cmd tea.Cmd, timeout time.Duration) lightModel {
+	deadline := time.After(timeout)
+	msgs := make(chan tea.Msg)
+	running := 0
+	run := func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		running++
+		go func() {
+			msg := cmd()
+			select {
+			case msgs <- msg:
+			case <-appCtx.Done():
+			}
+		}()
+	}
+
+	run(cmd)
+	for running > 0 {
+		select {
+		case msg := <-msgs:
+			running--
+			switch msg := msg.(type) {
+			case nil:
+			case tea.BatchMsg:
+				for _, cmd := range msg {
+					run(cmd)
+				}
+			case tea.QuitMsg:
+				return m
+			default:
+				model, next := m.Update(msg)
+				m = model.(lightModel)
+				run(next)
+			}
+		case <-deadline:
+			logInfo("--exec-quit: leaving %d queued %s unfinished", running, pluralize(running, "task", "tasks"))
+			return m
+		}
+	}
+	return m
+}
+
+// beginShutdown stops accepting input and quits once pending writes are flushed
+func (m lightModel) beginShutdown() (lightModel, tea.Cmd) {
+	if m.quitting {
+		return m, nil
+	}
+	m.quitting = true
+	// Brightness keypresses still waiting for their flush go out now
+	m.handleBrightnessFlush(brightnessFlushMsg{generation: m.brightnessGeneration})
+	if err := saveUIState(m.uiState()); err != nil {
+		logError("Failed to save UI state: %v", err)
+	}
+	return m, tea.Batch(
+		waitForWrites(shutdownTimeout),
+		tea.Tick(shutdownNoticeDelay, func(time.Time) tea.Msg { return shutdownSlowMsg{} }),
+	)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --exec "signal 2" --exec-quit must still put the lights back
+func TestFinishExecRunsQueuedWork(t *testing.T) {
+	bridge := newTestBridge(t)
+	light := testLight()
+	m := initialModel([]Light{light}, nil)
+	m.signal = &signalRun{
+		snapshot:   map[string]signalSnapshot{light.ID: {state: matchState{on: false}}},
+		generation: 1,
+	}
+	m.queue(func() tea.Msg { return nil })
+	m.queue(func() tea.Msg { return signalDoneMsg{generation: 1} })
+
+	m = finishExec(m, m.takeQueued(), time.Second)
+
+	if m.signal != nil {
+		t.Error("the signal is still running")
+	}
+	if m.status != "Signal finished, lights restored" {
+		t.Errorf("status = %q", m.status)
+	}
+	writes := bridge.recorded()
+	if len(writes) != 1 || writes[0].path != "/clip/v2/resource/light/"+light.ID {
+		t.Fatalf("writes = %+v, want the restore", writes)
+	}
+}
+
+// Work that never finishes doesn't keep --exec-quit from exiting
+func TestFinishExecGivesUp(t *testing.T) {
+	m := initialModel([]Light{testLight()}, nil)
+	block := make(chan struct{})
+	defer close(block)
+	m.queue(func() tea.Msg {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	finishExec(m, m.takeQueued(), 50*time.Millisecond)
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("finishExec waited %s", waited)
+	}
+}
+
+func TestFinishExecNothingQueued(t *testing.T) {
+	m := initialModel([]Light{testLight()}, nil)
+	start := time.Now()
+	finishExec(m, m.takeQueued(), time.Minute)
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("finishExec with nothing queued waited %s", waited)
+	}
+}
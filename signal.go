@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSignalSeconds is the longest signal the bridge accepts (65534 ms)
+const maxSignalSeconds = 65
+
+// signalRun is a running :signal and the state to put back afterwards
+type signalRun struct {
+	snapshot   map[string]signalSnapshot // by light ID
+	generation int
+}
+
+// signalSnapshot is one light's state from before the signal
+type signalSnapshot struct {
+	state matchState
+	caps  lightCaps
+}
+
+// signalDoneMsg fires once a signal has had time to finish
+type signalDoneMsg struct {
+	generation int
+}
+
+// parseSignalArgs reads "<color>[,<color>] <seconds>"
+func parseSignalArgs(args string) (colors []xyColor, seconds int, err error) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return nil, 0, fmt.Errorf("usage: signal <color>[,<color>] <seconds>")
+	}
+	seconds, err = strconv.Atoi(strings.TrimSuffix(fields[1], "s"))
+	if err != nil || seconds < 1 || seconds > maxSignalSeconds {
+		return nil, 0, fmt.Errorf("signal duration must be 1 to %d seconds", maxSignalSeconds)
+	}
+
+	names := strings.Split(fields[0], ",")
+	if len(names) > 2 {
+		return nil, 0, fmt.Errorf("signal takes one color, or two to alternate between")
+	}
+	for _, name := range names {
+		color, err := parseColor(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		colors = append(colors, color)
+	}
+	return colors, seconds, nil
+}
+
+// signalBody is the signaling write for a light. Lights without color flash
+// on and off in their current color instead.
+func signalBody(colors []xyColor, seconds int, color bool) map[string]any {
+	signaling := map[string]any{"duration": seconds * 1000}
+	switch {
+	case !color:
+		signaling["signal"] = "on_off"
+	case len(colors) == 2:
+		signaling["signal"] = "alternating"
+		signaling["colors"] = []any{colors[0].xyJSON(), colors[1].xyJSON()}
+	default:
+		signaling["signal"] = "on_off_color"
+		signaling["colors"] = []any{colors[0].xyJSON()}
+	}
+	return map[string]any{"signaling": signaling}
+}
+
+// signalCommand handles ":signal <color>[,<color>] <seconds>" on the selected lights
+func (m *lightModel) signalCommand(args string) error {
+	colors, seconds, err := parseSignalArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(m.selected) == 0 {
+		return fmt.Errorf("no lights selected")
+	}
+	if m.signal != nil {
+		return fmt.Errorf("a signal is already running")
+	}
+
+	lights, err := cachedLights()
+	if err != nil {
+		return fmt.Errorf("error fetching lights: %v", err)
+	}
+
+	snapshot := make(map[string]signalSnapshot)
+	skipped := 0
+	var writes []lightWrite
+	for index := range m.selected {
+		light := m.light[index]
+		target, ok := lights[light.ID]
+		if !ok || !light.Reachable || target.Signaling == nil {
+			skipped++
+			continue
+		}
+		caps := readCaps(target)
+
+		// Capture before the write, since the bridge may not restore everything itself
+		snapshot[light.ID] = signalSnapshot{state: readMatchState(target), caps: caps}
+		outgoing.recordBulk()
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			_, err := clipWrite("PUT", "resource/light/"+light.ID, signalBody(colors, seconds, caps.color))
+			return err
+		}})
+	}
+
+	// The run starts now, so a second :signal is refused while the writes go out
+	m.signalGeneration++
+	run := &signalRun{snapshot: snapshot, generation: m.signalGeneration}
+	m.signal = run
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		for _, w := range failed {
+			delete(run.snapshot, w.light.ID)
+		}
+		colorless := 0
+		for _, w := range written {
+			if !run.snapshot[w.light.ID].caps.color {
+				colorless++
+			}
+		}
+		summary := summarizeAction("action.signal", len(written), skipped, len(failed), seconds)
+		if colorless > 0 {
+			summary += tr("action.signal.colorless", colorless)
+		}
+		m.setStatus("%s", summary)
+
+		if m.signal != run {
+			return
+		}
+		if len(run.snapshot) == 0 {
+			m.signal = nil
+			return
+		}
+		m.queue(tea.Tick(time.Duration(seconds)*time.Second+time.Second, func(time.Time) tea.Msg {
+			return signalDoneMsg{generation: run.generation}
+		}))
+	})
+	return nil
+}
+
+// handleSignalDone puts the signaled lights back as they were
+func (m *lightModel) handleSignalDone(msg signalDoneMsg) {
+	if m.signal == nil || m.signal.generation != msg.generation {
+		return
+	}
+	run := m.signal
+	m.signal = nil
+
+	var writes []lightWrite
+	for id, before := range run.snapshot {
+		put, _, err := matchUpdate(before.state, before.caps)
+		if err != nil {
+			continue
+		}
+		outgoing.recordOn(id, before.state.on)
+		light, ok := m.lightByID(id)
+		if !ok {
+			light = Light{ID: id, Name: id}
+		}
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			return updateLight(id, put)
+		}})
+	}
+	m.startWrites(writes, func(m *lightModel, _, failed []lightWrite) {
+		if len(failed) > 0 {
+			m.setStatus("%s", trn("status.signal.unrestored", len(failed), len(failed)))
+		} else {
+			m.setStatusTr("status.signal.done")
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSSELog caps how many raw SSE payloads the debug pane keeps
+const maxSSELog = 200
+
+// handledSSETypes are the SSE item types the model acts on; others are flagged in the debug pane
+var handledSSETypes = map[string]bool{
+	"light":                       true,
+	"grouped_light":               true,
+	"room":                        true,
+	"zone":                        true,
+	"zigbee_connectivity":         true,
+	"scene":                       true,
+	"smart_scene":                 true,
+	"entertainment_configuration": true,
+	"device_software_update":      true,
+	"motion":                      true,
+	"contact":                     true,
+	"tamper":                      true,
+	"button":                      true,
+	"behavior_instance":           true,
+	"geofence_client":             true,
+	"device_power":                true,
+}
+
+var (
+	sseKnownTypeStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#8BE9FD"))
+	sseUnknownTypeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF8C00"))
+)
+
+// sseLogEntry is one raw payload as received
+type sseLogEntry struct {
+	at   time.Time
+	data []byte
+	err  error // why the payload couldn't be parsed, if it couldn't
+}
+
+// ssePane is the ctrl+e debug pane's state
+type ssePane struct {
+	open   bool
+	index  int // payload shown, 0 is the newest
+	scroll int // first line of the payload shown
+}
+
+// logSSE keeps a raw payload for the debug pane, dropping the oldest beyond the cap
+func (m *lightModel) logSSE(data []byte, err error) {
+	m.sseLog = append(m.sseLog, sseLogEntry{at: time.Now(), data: data, err: err})
+	if len(m.sseLog) > maxSSELog {
+		m.sseLog = m.sseLog[len(m.sseLog)-maxSSELog:]
+	}
+	// Stay on the same payload while new ones arrive
+	if m.ssePane.open && m.ssePane.index > 0 {
+		m.ssePane.index = min(m.ssePane.index+1, len(m.sseLog)-1)
+	}
+}
+
+// currentSSE returns the payload the pane is showing
+func (m lightModel) currentSSE() (sseLogEntry, bool) {
+	if len(m.sseLog) == 0 {
+		return sseLogEntry{}, false
+	}
+	return m.sseLog[len(m.sseLog)-1-m.ssePane.index], true
+}
+
+// prettySSE indents a payload, falling back to the raw text if it isn't JSON
+func prettySSE(data []byte) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return out.String()
+}
+
+// sseItemTypes lists the distinct item types in a payload
+func sseItemTypes(data []byte) []string {
+	items, err := parseSSEItems(data)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		seen[item.Type] = true
+	}
+	types := make([]string, 0, len(seen))
+	for t := range seen {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// highlightSSELine colors "type" fields, flagging types the model ignores
+func highlightSSELine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	value, ok := strings.CutPrefix(trimmed, `"type": "`)
+	if !ok {
+		return line
+	}
+	value = strings.TrimSuffix(strings.TrimSuffix(value, ","), `"`)
+	if handledSSETypes[value] {
+		return line[:len(line)-len(trimmed)] + sseKnownTypeStyle.Render(trimmed)
+	}
+	return line[:len(line)-len(trimmed)] + sseUnknownTypeStyle.Render(trimmed)
+}
+
+// paneLines is how many payload lines fit on screen
+func (m lightModel) paneLines() int {
+	if m.height == 0 {
+		return 20
+	}
+	return max(5, m.height-6)
+}
+
+// handleSSEPaneKey processes a key while the debug pane is open
+func (m *lightModel) handleSSEPaneKey(key string) {
+	switch key {
+	case "ctrl+e", "esc", "q":
+		m.ssePane.open = false
+	case "up", "k":
+		m.ssePane.scroll = max(0, m.ssePane.scroll-1)
+	case "down", "j":
+		m.ssePane.scroll++
+	case "left", "h":
+		// Older payload
+		if m.ssePane.index < len(m.sseLog)-1 {
+			m.ssePane.index++
+			m.ssePane.scroll = 0
+		}
+	case "right", "l":
+		// Newer payload
+		if m.ssePane.index > 0 {
+			m.ssePane.index--
+			m.ssePane.scroll = 0
+		}
+	case "d":
+		m.dumpSSE()
+	}
+}
+
+// dumpSSE writes the current payload to a temp file for bug reports
+func (m *lightModel) dumpSSE() {
+	entry, ok := m.currentSSE()
+	if !ok {
+		m.setStatusTr("status.sse.none")
+		return
+	}
+	f, err := os.CreateTemp("", "hue-sse-*.json")
+	if err != nil {
+		m.setStatusTr("status.sse.dump_failed", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(prettySSE(entry.data) + "\n"); err != nil {
+		m.setStatusTr("status.sse.dump_failed", err)
+		return
+	}
+	m.setStatusTr("status.sse.dumped", f.Name())
+}
+
+func (m lightModel) renderSSEPane() string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF79C6"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	var b strings.Builder
+	entry, ok := m.currentSSE()
+	if !ok {
+		b.WriteString(titleStyle.Render("SSE debug") + " " + faint.Render("no events yet") + "\n")
+	} else {
+		fmt.Fprintf(&b, "%s %s\n", titleStyle.Render("SSE debug"),
+			faint.Render(fmt.Sprintf(glyphText("event %d/%d · %s"), len(m.sseLog)-m.ssePane.index, len(m.sseLog), entry.at.Format("15:04:05.000"))))
+
+		var labels []string
+		for _, t := range sseItemTypes(entry.data) {
+			if handledSSETypes[t] {
+				labels = append(labels, sseKnownTypeStyle.Render(t))
+			} else {
+				labels = append(labels, sseUnknownTypeStyle.Render(t+" (unhandled)"))
+			}
+		}
+		if entry.err != nil {
+			b.WriteString(sseUnknownTypeStyle.Render("Parse error: "+entry.err.Error()) + "\n\n")
+		} else {
+			b.WriteString("Types: " + strings.Join(labels, ", ") + "\n\n")
+		}
+
+		lines := strings.Split(prettySSE(entry.data), "\n")
+		start := min(m.ssePane.scroll, max(0, len(lines)-1))
+		end := min(start+m.paneLines(), len(lines))
+		for _, line := range lines[start:end] {
+			b.WriteString(highlightSSELine(line) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + faint.Render(glyphText("j/k: scroll • h/l: older/newer • d: dump to file • ctrl+e: close")))
+	if m.status != "" {
+		b.WriteString("\n" + m.status)
+	}
+	return viewText(b.String())
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sethchev/hue-control-tui/pkg/hueclient"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sseMessage is what the SSE goroutine sends the model: sseEventsMsg for a
+// payload that parsed, sseErrorMsg for one that didn't
+type sseMessage interface {
+	payload() []byte
+}
+
+// sseEventsMsg carries the changes in one event stream payload, in order
+type sseEventsMsg struct {
+	raw    []byte
+	events []sseEvent
+}
+
+// sseErrorMsg is a payload that isn't valid event stream JSON
+type sseErrorMsg struct {
+	raw []byte
+	err error
+}
+
+func (msg sseEventsMsg) payload() []byte { return msg.raw }
+func (msg sseErrorMsg) payload() []byte  { return msg.raw }
+
+// sseEvent is one resource change: lightChanged, connectivityChanged,
+// resourceChanged, resourceAdded, resourceDeleted or unknownEvent
+type sseEvent interface {
+	resource() SSEDataItem
+}
+
+// lightChanged is an update to a light's state
+type lightChanged struct{ item SSEDataItem }
+
+// connectivityChanged is a device dropping off or rejoining the zigbee network
+type connectivityChanged struct{ item SSEDataItem }
+
+// resourceChanged is an update to another resource the model follows: a
+// scene, smart scene, grouped light, room, zone or entertainment area
+type resourceChanged struct{ item SSEDataItem }
+
+// resourceAdded is a resource created on the bridge
+type resourceAdded struct{ item SSEDataItem }
+
+// resourceDeleted is a resource removed from the bridge
+type resourceDeleted struct{ item SSEDataItem }
+
+// unknownEvent is a change to a resource type the model doesn't follow
+type unknownEvent struct{ item SSEDataItem }
+
+func (e lightChanged) resource() SSEDataItem        { return e.item }
+func (e connectivityChanged) resource() SSEDataItem { return e.item }
+func (e resourceChanged) resource() SSEDataItem     { return e.item }
+func (e resourceAdded) resource() SSEDataItem       { return e.item }
+func (e resourceDeleted) resource() SSEDataItem     { return e.item }
+func (e unknownEvent) resource() SSEDataItem        { return e.item }
+
+// parseSSEMessage turns a raw payload into the message the model receives
+func parseSSEMessage(data []byte) sseMessage {
+	events, err := parseSSEEvents(data)
+	if err != nil {
+		return sseErrorMsg{raw: data, err: err}
+	}
+	return sseEventsMsg{raw: data, events: events}
+}
+
+// parseSSEEvents classifies each resource in a payload by the update's type
+// ("add", "delete" or "update") and the resource's type
+func parseSSEEvents(data []byte) ([]sseEvent, error) {
+	changes, err := hueclient.ParseEvents(data)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]sseEvent, 0, len(changes))
+	for _, change := range changes {
+		var item SSEDataItem
+		if err := json.Unmarshal(change.Raw, &item); err != nil {
+			return nil, err
+		}
+		switch {
+		case change.Kind == "add":
+			events = append(events, resourceAdded{item})
+		case change.Kind == "delete":
+			events = append(events, resourceDeleted{item})
+		case item.Type == "light":
+			events = append(events, lightChanged{item})
+		case item.Type == "zigbee_connectivity":
+			events = append(events, connectivityChanged{item})
+		case handledSSETypes[item.Type]:
+			events = append(events, resourceChanged{item})
+		default:
+			events = append(events, unknownEvent{item})
+		}
+	}
+	return events, nil
+}
+
+// handleSSEEvents applies a payload's changes in order
+func (m lightModel) handleSSEEvents(msg sseEventsMsg) (lightModel, tea.Cmd) {
+	m.updatedAt = time.Now()
+	notified := len(m.notifications) + m.notificationOverflow
+	var before map[string]flashState
+	if appConfig.rowFlash() {
+		before = snapshotLights(m.light)
+	}
+	cmds := []tea.Cmd{m.listenForSSE()}
+	for _, event := range msg.events {
+		var cmd tea.Cmd
+		m, cmd = m.handleSSEEvent(event)
+		cmds = append(cmds, cmd)
+	}
+	if before != nil {
+		cmds = append(cmds, m.flashChanged(before, time.Now()))
+	}
+
+	if len(m.notifications)+m.notificationOverflow != notified {
+		cmds = append(cmds, expireNotificationsAfter(notificationTTL))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// handleSSEEvent applies one change
+func (m lightModel) handleSSEEvent(event sseEvent) (lightModel, tea.Cmd) {
+	switch e := event.(type) {
+	case lightChanged:
+		m = m.handleLightUpdate(e.item)
+		return m, m.mirrorLeader(e.item)
+	case connectivityChanged:
+		m = m.handleConnectivityUpdate(e.item)
+	case resourceChanged:
+		switch e.item.Type {
+		case "scene", "smart_scene":
+			m.handleSceneUpdate(e.item)
+		case "entertainment_configuration":
+			m.handleEntertainmentUpdate(e.item)
+		case "device_software_update":
+			m.handleSoftwareUpdate()
+		case "motion":
+			m.handleMotionUpdate(e.item)
+		case "contact":
+			m.handleContactUpdate(e.item)
+		case "tamper":
+			m.handleTamperUpdate(e.item)
+		case "button":
+			m.handleButtonEvent(e.item)
+		case "geofence_client":
+			m.handleGeofenceUpdate(e.item)
+		case "behavior_instance":
+			m.handleAutomationUpdate(e.item)
+		case "device_power":
+			m.handlePowerUpdate(e.item)
+		case "grouped_light":
+			return m.handleGroupedLightUpdate(e.item)
+		case "room", "zone":
+			// Members or the name changed; lightIDs are only resolved on load
+			return m, reloadGroups(m.light)
+		}
+	case resourceAdded:
+		logInfo("SSE: %s %s added", e.item.Type, e.item.ID)
+		switch e.item.Type {
+		case "grouped_light":
+			// A new room or zone's grouped light is fetched like any unknown group
+			return m.handleGroupedLightUpdate(e.item)
+		case "room", "zone":
+			return m, reloadGroups(m.light)
+		case "scene", "smart_scene":
+			return m, m.reloadScenes()
+		}
+	case resourceDeleted:
+		logInfo("SSE: %s %s deleted", e.item.Type, e.item.ID)
+		switch e.item.Type {
+		case "light":
+			m.removeLights(map[string]bool{e.item.ID: true})
+		case "grouped_light":
+			delete(m.groups, e.item.ID)
+		case "room", "zone":
+			m.handleGroupDeleted(e.item.ID)
+		case "scene", "smart_scene":
+			m.removeScene(e.item.ID)
+		}
+	case unknownEvent:
+		logDebug("SSE: ignoring %s event for %s", e.item.Type, e.item.ID)
+	}
+	return m, nil
+}
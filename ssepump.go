@@ -0,0 +1,70 @@
+package main
+
+import "sync/atomic"
+
+// sseBufferSize is how many payloads wait for the model before the oldest
+// are dropped
+const sseBufferSize = 1024
+
+// sseDropped counts payloads dropped because the model fell too far behind
+var sseDropped atomic.Int64
+
+// ssePump stands between the event stream and the model. Pushing never
+// blocks, so a busy model can't stall the stream; a goroutine parses queued
+// payloads and hands them to the model at its own pace.
+type ssePump struct {
+	queue  chan []byte
+	states chan sseStreamMsg
+}
+
+// startSSEPump starts delivering pushed payloads to out until the app shuts down
+func startSSEPump(out chan<- sseMessage) *ssePump {
+	p := &ssePump{queue: make(chan []byte, sseBufferSize), states: make(chan sseStreamMsg, 8)}
+	go func() {
+		for {
+			select {
+			case data := <-p.queue:
+				select {
+				case out <- parseSSEMessage(data):
+				case <-appCtx.Done():
+					return
+				}
+			case msg := <-p.states:
+				select {
+				case out <- msg:
+				case <-appCtx.Done():
+					return
+				}
+			case <-appCtx.Done():
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// state queues a change in the stream's connection for the model
+func (p *ssePump) state(msg sseStreamMsg) {
+	select {
+	case p.states <- msg:
+	case <-appCtx.Done():
+	}
+}
+
+// push queues a payload, dropping the oldest queued one if the queue is full
+func (p *ssePump) push(data []byte) {
+	for {
+		select {
+		case p.queue <- data:
+			return
+		default:
+		}
+		select {
+		case <-p.queue:
+			if sseDropped.Add(1) == 1 {
+				logError("SSE: the model is falling behind, dropping the oldest events")
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"time"
+)
+
+// Values of Light.ChangedBy. The bridge doesn't say who made a change, so a
+// change is ours when it echoes one of this app's recent writes.
+const (
+	changedByMe       = "me"
+	changedExternally = "external"
+)
+
+// dynamicsState normalizes a dynamics status for Light.Dynamics: "none" means
+// nothing is playing and is stored as ""
+func dynamicsState(status string) string {
+	if status == "none" {
+		return ""
+	}
+	return status
+}
+
+// parseSSEItems flattens an event stream payload, an array of updates each
+// carrying resources, into its resources in order
+func parseSSEItems(data []byte) ([]SSEDataItem, error) {
+	events, err := parseSSEEvents(data)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]SSEDataItem, len(events))
+	for i, event := range events {
+		items[i] = event.resource()
+	}
+	return items, nil
+}
+
+// lightState applies light and connectivity events to a light list. It reads
+// no globals: the clock and the check for this app's own writes are passed in,
+// and notifications are collected rather than shown, so captured payloads can
+// be fed through it on their own.
+type lightState struct {
+	lights  []Light
+	now     time.Time
+	isOwn   func(lightID string, on *bool) bool // whether a change came from this app; nil means none did
+	notices []string                            // notifications raised so far
+
+	// intended is the brightness this app is steering a light towards, if
+	// any; nil means none. Reports that disagree with it are older writes
+	// landing and are ignored, so the gauge doesn't jump back.
+	intended func(lightID string) (float32, bool)
+	settled  []string // lights whose brightness target has been confirmed
+
+	// intendedOn is whether a brightness keypress is switching a light on or
+	// off, if it is. Reports that disagree are from before the write and are
+	// ignored, like stale brightness.
+	intendedOn func(lightID string) (bool, bool)
+}
+
+// newLightState wraps the model's light list for the current time and the
+// app's own write tracking
+func (m lightModel) newLightState() *lightState {
+	return &lightState{
+		lights: m.light,
+		now:    time.Now(),
+		isOwn: func(lightID string, on *bool) bool {
+			return outgoing.isOwn(lightID, on, time.Now())
+		},
+		intended:   m.intendedBrightness,
+		intendedOn: m.intendedPower,
+	}
+}
+
+// flush shows the collected notifications on the model
+func (s *lightState) flush(m *lightModel) {
+	for _, notice := range s.notices {
+		m.notify("%s", notice)
+	}
+	s.notices = nil
+	m.settleBrightness(s.settled)
+	s.settled = nil
+}
+
+// applyLight handles a "light" event. Only the fields present in the event
+// change: On and Dimming are pointers so an absent field is told apart from
+// off or 0%.
+func (s *lightState) applyLight(item SSEDataItem) {
+	logDebug("Entire light item: %+v", item)
+
+	// Find the light in our list
+	lightIndex := -1
+	for i := range s.lights {
+		if s.lights[i].ID == item.ID {
+			lightIndex = i
+			break
+		}
+	}
+
+	// Skip if light not found
+	if lightIndex == -1 {
+		return
+	}
+	light := &s.lights[lightIndex]
+
+	// Log event for debugging
+	brightnessVal := float64(-1)
+	if item.Dimming != nil {
+		brightnessVal = item.Dimming.Brightness
+	}
+	logDebug("SSE light event: id=%s id_v1=%s on=%v brightness=%v",
+		item.ID, item.IDV1, item.On, brightnessVal)
+
+	var on *bool
+	if item.On != nil {
+		on = &item.On.On
+	}
+	if on != nil && s.intendedOn != nil {
+		if want, ok := s.intendedOn(item.ID); ok && *on != want {
+			logDebug("SSE on=%t for %s is behind the intended %t, ignoring it", *on, item.ID, want)
+			on, item.On = nil, nil
+		}
+	}
+	if item.Dimming != nil && s.intended != nil {
+		if target, ok := s.intended(item.ID); ok {
+			if brightnessClose(float32(item.Dimming.Brightness), target) {
+				s.settled = append(s.settled, item.ID)
+			} else {
+				logDebug("SSE brightness %.1f for %s is behind the target %.1f, ignoring it", item.Dimming.Brightness, item.ID, target)
+				item.Dimming = nil
+			}
+		}
+	}
+	switched := on != nil && *on != (light.Status == "on")
+	dimmed := item.Dimming != nil && float32(item.Dimming.Brightness) != light.Brightness
+	own := s.isOwn != nil && s.isOwn(item.ID, on)
+
+	// Tell the user about on/off changes made by someone else
+	if switched && !own {
+		if *on {
+			s.notices = append(s.notices, light.Name+" turned on")
+		} else {
+			s.notices = append(s.notices, light.Name+" turned off")
+		}
+	}
+	if switched || dimmed {
+		light.ChangedBy = changedExternally
+		if own {
+			light.ChangedBy = changedByMe
+		}
+		light.ChangedAt = s.now
+	}
+
+	// Update status if the On field was present in the JSON
+	if item.On != nil {
+		if item.On.On {
+			light.Status = "on"
+		} else {
+			light.Status = "off"
+		}
+	}
+
+	// Update brightness if present (including 0 for off lights)
+	if item.Dimming != nil {
+		light.Brightness = float32(item.Dimming.Brightness)
+	}
+
+	// A light is in either color or white mode; the bridge only marks the
+	// mirek valid in white mode
+	if ct := item.ColorTemperature; ct != nil && ct.MirekValid && ct.Mirek != nil {
+		light.Mirek = *ct.Mirek
+		light.Color = nil
+	} else if item.Color != nil {
+		light.Color = &xyColor{x: item.Color.XY.X, y: item.Color.XY.Y}
+		light.Mirek = 0
+	}
+
+	if item.Gradient != nil && len(item.Gradient.Points) > 0 {
+		light.Gradient = item.Gradient.colors()
+	}
+
+	// Events without a dynamics block leave the marker alone; the bridge
+	// reports "none" once a palette or transition stops
+	if item.Dynamics != nil {
+		light.Dynamics = dynamicsState(item.Dynamics.Status)
+	}
+
+	// If we received any update, the light is reachable
+	light.Reachable = true
+	light.UnreachableSince = time.Time{}
+	light.LastSeen = s.now
+}
+
+// applyConnectivity handles a "zigbee_connectivity" event, which names the
+// device rather than its lights
+func (s *lightState) applyConnectivity(item SSEDataItem) {
+	logDebug("SSE connectivity event: id=%s owner=%v status=%s",
+		item.ID, item.Owner, item.Status.State)
+
+	// Skip if no owner information
+	if item.Owner == nil || item.Owner.Rid == "" {
+		return
+	}
+
+	s.setDeviceReachable(item.Owner.Rid, item.Status.State == "connected")
+}
+
+// setDeviceReachable updates every light of a device, noting when its
+// reachability changes
+func (s *lightState) setDeviceReachable(deviceID string, isConnected bool) {
+	for i := range s.lights {
+		light := &s.lights[i]
+		if light.DeviceOwner != deviceID {
+			continue
+		}
+		// Record the transition time so the view can show how long it has been offline
+		if light.Reachable && !isConnected {
+			light.UnreachableSince = s.now
+			s.notices = append(s.notices, light.Name+" unreachable")
+		} else if !light.Reachable && isConnected {
+			s.notices = append(s.notices, light.Name+" reachable again")
+		}
+		if isConnected {
+			light.UnreachableSince = time.Time{}
+			light.LastSeen = s.now
+		}
+		if light.Reachable != isConnected {
+			logInfo("Updated light %s reachability to %v", light.Name, isConnected)
+		}
+		light.Reachable = isConnected
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openhue/openhue-go"
+)
+
+const (
+	testLightID  = "8a2f1c7e-3b5d-4e91-a0c4-5d6e7f809a1b"
+	testDeviceID = "d1e2f3a4-b5c6-4d7e-8f90-a1b2c3d4e5f6"
+)
+
+// testLight is the light the captured payloads in testdata/sse describe
+func testLight() Light {
+	return Light{
+		ID:          testLightID,
+		Name:        "Desk",
+		Status:      "off",
+		Brightness:  50,
+		Reachable:   true,
+		Caps:        capDimming | capColor | capColorTemperature,
+		DeviceOwner: testDeviceID,
+		Mirek:       250,
+	}
+}
+
+// applyPayload runs a captured payload through the parser and lightState
+func applyPayload(t *testing.T, s *lightState, name string) {
+	t.Helper()
+	events, err := parseSSEEvents(readTestdata(t, "sse/"+name))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	for _, event := range events {
+		switch e := event.(type) {
+		case lightChanged:
+			s.applyLight(e.item)
+		case connectivityChanged:
+			s.applyConnectivity(e.item)
+		}
+	}
+}
+
+func TestLightStateGoldenPayloads(t *testing.T) {
+	now := time.Date(2026, 10, 15, 7, 10, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		payloads []string
+		start    func(*Light)
+		check    func(t *testing.T, got Light)
+		notices  []string
+	}{
+		{
+			name:     "on with brightness",
+			payloads: []string{"light_on_dim.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Status != "on" || !brightnessClose(got.Brightness, 64.43) {
+					t.Errorf("got %s at %.2f, want on at 64.43", got.Status, got.Brightness)
+				}
+				if got.ChangedBy != changedExternally || !got.ChangedAt.Equal(now) {
+					t.Errorf("changed by %q at %v, want external at %v", got.ChangedBy, got.ChangedAt, now)
+				}
+			},
+			notices: []string{"Desk turned on"},
+		},
+		{
+			name:     "sparse brightness leaves on/off alone",
+			payloads: []string{"light_brightness_only.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Status != "off" {
+					t.Errorf("status %s, want off", got.Status)
+				}
+				if got.Brightness != 12.5 {
+					t.Errorf("brightness %.2f, want 12.5", got.Brightness)
+				}
+			},
+		},
+		{
+			name:     "sparse off leaves brightness alone",
+			payloads: []string{"light_off.json"},
+			start:    func(l *Light) { l.Status = "on" },
+			check: func(t *testing.T, got Light) {
+				if got.Status != "off" || got.Brightness != 50 {
+					t.Errorf("got %s at %.2f, want off at 50", got.Status, got.Brightness)
+				}
+			},
+			notices: []string{"Desk turned off"},
+		},
+		{
+			name:     "color mode drops the mirek",
+			payloads: []string{"light_color.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Color == nil || got.Color.x != 0.6915 || got.Color.y != 0.3083 {
+					t.Errorf("color %+v, want 0.6915, 0.3083", got.Color)
+				}
+				if got.Mirek != 0 {
+					t.Errorf("mirek %d, want 0", got.Mirek)
+				}
+			},
+		},
+		{
+			name:     "white mode drops the color",
+			payloads: []string{"light_color.json", "light_white.json"},
+			start:    func(l *Light) { l.Dynamics = "dynamic_palette" },
+			check: func(t *testing.T, got Light) {
+				if got.Mirek != 366 || got.Color != nil {
+					t.Errorf("mirek %d color %+v, want 366 and no color", got.Mirek, got.Color)
+				}
+				if got.Dynamics != "" {
+					t.Errorf("dynamics %q, want none", got.Dynamics)
+				}
+			},
+		},
+		{
+			name:     "other lights are ignored",
+			payloads: []string{"light_other.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Status != "off" || got.Brightness != 50 || got.ChangedBy != "" {
+					t.Errorf("light changed: %+v", got)
+				}
+			},
+		},
+		{
+			name:     "unknown types are ignored",
+			payloads: []string{"unknown_type.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Status != "off" || got.Brightness != 50 {
+					t.Errorf("light changed: %+v", got)
+				}
+			},
+		},
+		{
+			name:     "items apply in order",
+			payloads: []string{"mixed.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Status != "on" || got.Brightness != 40 {
+					t.Errorf("got %s at %.2f, want on at 40", got.Status, got.Brightness)
+				}
+			},
+			notices: []string{"Desk turned on"},
+		},
+		{
+			name:     "connectivity lost",
+			payloads: []string{"connectivity_lost.json"},
+			check: func(t *testing.T, got Light) {
+				if got.Reachable || !got.UnreachableSince.Equal(now) {
+					t.Errorf("reachable %t since %v, want unreachable since %v", got.Reachable, got.UnreachableSince, now)
+				}
+			},
+			notices: []string{"Desk unreachable"},
+		},
+		{
+			name:     "connectivity back",
+			payloads: []string{"connectivity_lost.json", "connectivity_back.json"},
+			check: func(t *testing.T, got Light) {
+				if !got.Reachable || !got.UnreachableSince.IsZero() || !got.LastSeen.Equal(now) {
+					t.Errorf("reachable %t since %v seen %v, want reachable and seen now", got.Reachable, got.UnreachableSince, got.LastSeen)
+				}
+			},
+			notices: []string{"Desk unreachable", "Desk reachable again"},
+		},
+		{
+			name:     "a light event marks an unreachable light reachable",
+			payloads: []string{"light_brightness_only.json"},
+			start:    func(l *Light) { l.Reachable, l.UnreachableSince = false, now.Add(-time.Hour) },
+			check: func(t *testing.T, got Light) {
+				if !got.Reachable || !got.UnreachableSince.IsZero() {
+					t.Errorf("reachable %t since %v, want reachable", got.Reachable, got.UnreachableSince)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			light := testLight()
+			if tt.start != nil {
+				tt.start(&light)
+			}
+			s := &lightState{lights: []Light{light}, now: now}
+			for _, payload := range tt.payloads {
+				applyPayload(t, s, payload)
+			}
+			tt.check(t, s.lights[0])
+			if !slices.Equal(s.notices, tt.notices) {
+				t.Errorf("notices %q, want %q", s.notices, tt.notices)
+			}
+		})
+	}
+}
+
+func TestLightStateOwnChanges(t *testing.T) {
+	s := &lightState{
+		lights: []Light{testLight()},
+		now:    time.Now(),
+		isOwn:  func(string, *bool) bool { return true },
+	}
+	applyPayload(t, s, "light_on_dim.json")
+	if got := s.lights[0]; got.ChangedBy != changedByMe || got.Status != "on" {
+		t.Errorf("got %s changed by %q, want on changed by me", got.Status, got.ChangedBy)
+	}
+	if len(s.notices) != 0 {
+		t.Errorf("own change notified: %q", s.notices)
+	}
+}
+
+func TestLightStateIntendedBrightness(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     float32
+		payload    string
+		brightness float32
+		settled    bool
+	}{
+		{"stale report is ignored", 80, "light_brightness_only.json", 50, false},
+		{"matching report settles", 12.5, "light_brightness_only.json", 12.5, true},
+		{"report within tolerance settles", 12.6, "light_brightness_only.json", 12.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &lightState{
+				lights: []Light{testLight()},
+				now:    time.Now(),
+				intended: func(id string) (float32, bool) {
+					return tt.target, id == testLightID
+				},
+			}
+			applyPayload(t, s, tt.payload)
+			if got := s.lights[0].Brightness; got != tt.brightness {
+				t.Errorf("brightness %.2f, want %.2f", got, tt.brightness)
+			}
+			if settled := len(s.settled) == 1 && s.settled[0] == testLightID; settled != tt.settled {
+				t.Errorf("settled %q, want settled %t", s.settled, tt.settled)
+			}
+		})
+	}
+}
+
+func TestLightStateIntendedPower(t *testing.T) {
+	// A keypress is switching the light on, so the off report is from before it
+	light := testLight()
+	light.Status = "on"
+	s := &lightState{
+		lights:     []Light{light},
+		now:        time.Now(),
+		intendedOn: func(string) (bool, bool) { return true, true },
+	}
+	applyPayload(t, s, "light_off.json")
+	if got := s.lights[0]; got.Status != "on" || len(s.notices) != 0 {
+		t.Errorf("got %s with notices %q, want on and no notices", got.Status, s.notices)
+	}
+}
+
+func TestParseSSEEventsClassifies(t *testing.T) {
+	events, err := parseSSEEvents(readTestdata(t, "sse/unknown_type.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for _, event := range events {
+		if _, ok := event.(unknownEvent); !ok {
+			t.Errorf("%s: got %T, want unknownEvent", event.resource().Type, event)
+		}
+	}
+
+	events, err = parseSSEEvents(readTestdata(t, "sse/mixed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := events[2].(resourceChanged); !ok {
+		t.Errorf("grouped_light: got %T, want resourceChanged", events[2])
+	}
+
+	if _, err := parseSSEEvents([]byte(`{"not":"an array"}`)); err == nil {
+		t.Error("parsed a payload that isn't an array")
+	}
+}
+
+// burstEvent is the i'th payload of a burst, switching and dimming the test light
+func burstEvent(i int) (payload []byte, on bool, brightness float32) {
+	on, brightness = i%3 != 0, float32(i%100+1)
+	payload = fmt.Appendf(nil, `[{"type":"update","data":[{"id":%q,"type":"light","on":{"on":%t},"dimming":{"brightness":%g}}]}]`,
+		testLightID, on, brightness)
+	return payload, on, brightness
+}
+
+// A burst of events, arriving the way the stream delivers them while the
+// app writes to the same light, reaches the model in order: every event is
+// either delivered or counted as dropped, and the last one always arrives.
+// Run with -race.
+func TestSSEBurstWithWrites(t *testing.T) {
+	const events, writes = 5000, 200
+
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/light", fmt.Sprintf(`{"errors":[],"data":[
+		{"id":%q,"type":"light","metadata":{"name":"Desk"},"on":{"on":false},"dimming":{"brightness":50}}]}`, testLightID))
+	if _, err := cachedLights(); err != nil {
+		t.Fatalf("cachedLights: %v", err)
+	}
+	dropped := sseDropped.Load()
+	sseDropped.Store(0)
+	t.Cleanup(func() { sseDropped.Store(dropped) })
+
+	out := make(chan sseMessage)
+	pump := startSSEPump(out)
+	lost := readTestdata(t, "sse/connectivity_lost.json")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range events {
+			payload, _, _ := burstEvent(i)
+			bridgeCache.apply(payload)
+			pump.push(payload)
+		}
+		bridgeCache.apply(lost)
+		pump.push(lost)
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range writes {
+			brightness := float32(i%100 + 1)
+			if err := updateLight(testLightID, openhue.LightPut{Dimming: &openhue.Dimming{Brightness: &brightness}}); err != nil {
+				t.Errorf("write %d: %v", i, err)
+				return
+			}
+			if _, err := cachedLights(); err != nil {
+				t.Errorf("cachedLights after write %d: %v", i, err)
+				return
+			}
+		}
+	}()
+
+	m := initialModel([]Light{testLight()}, nil)
+	delivered := 0
+	timeout := time.After(30 * time.Second)
+	for m.light[0].Reachable {
+		select {
+		case msg := <-out:
+			batch, ok := msg.(sseEventsMsg)
+			if !ok {
+				t.Fatalf("got %T, want events", msg)
+			}
+			if _, light := batch.events[0].(lightChanged); light {
+				delivered++
+			}
+			m, _ = m.handleSSEEvents(batch)
+			if delivered%100 == 0 {
+				// A busy model lets the queue fill up
+				time.Sleep(time.Millisecond)
+			}
+		case <-timeout:
+			t.Fatalf("the last event never arrived; %d delivered", delivered)
+		}
+	}
+	wg.Wait()
+
+	if got := int64(delivered) + sseDropped.Load(); got != events {
+		t.Errorf("%d delivered and %d dropped, want %d in all", delivered, sseDropped.Load(), events)
+	}
+	_, on, brightness := burstEvent(events - 1)
+	if got := m.light[0]; (got.Status == "on") != on || got.Brightness != brightness {
+		t.Errorf("light is %s at %.0f%%, want the last event's on %t at %.0f%%", got.Status, got.Brightness, on, brightness)
+	}
+	if n := len(bridge.recorded()); n != writes {
+		t.Errorf("%d writes reached the bridge, want %d", n, writes)
+	}
+}
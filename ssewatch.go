@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSSETimeout is how long the event stream may stay silent before it's
+// reconnected, when sse_timeout isn't set
+const defaultSSETimeout = 90 * time.Second
+
+// parseSSETimeout reads sse_timeout: a duration such as "90s", or "off" to
+// never reconnect a silent stream
+func parseSSETimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultSSETimeout, nil
+	}
+	if value == "off" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 30*time.Second {
+		return 0, fmt.Errorf("sse_timeout must be off or a duration of at least 30s, such as 90s")
+	}
+	return timeout, nil
+}
+
+// sseTimeout returns the silence allowed on the event stream, 0 when it's off
+func (c *Config) sseTimeout() time.Duration {
+	// validate has already replaced invalid values
+	timeout, _ := parseSSETimeout(c.SSETimeout)
+	return timeout
+}
+
+// sseStreaming is set while subscribeSSE runs, so :reconnect can tell there's
+// a stream to reconnect rather than replay or the Remote API's polling
+var sseStreaming atomic.Bool
+
+// sseReconnectRequests carries :reconnect to the stream goroutine
+var sseReconnectRequests = make(chan struct{}, 1)
+
+// sseStreamMsg tells the model the event stream is being reconnected, or has
+// received data again since. resumed is set when the stream is back after
+// missing events, so state built from earlier ones may be stale.
+type sseStreamMsg struct {
+	reconnecting bool
+	resumed      bool
+}
+
+func (sseStreamMsg) payload() []byte { return nil }
+
+// sseWatchdog tracks when the event stream last received anything. Hue
+// bridges sometimes keep the connection open but stop sending, which would
+// otherwise leave the app looking live with stale state.
+type sseWatchdog struct {
+	last         atomic.Int64 // unix nanoseconds of the last data received
+	reconnecting atomic.Bool  // torn down and not heard from since
+	pump         *ssePump
+}
+
+// touch records data arriving, reporting the stream back if it was reconnecting
+func (w *sseWatchdog) touch() {
+	w.last.Store(time.Now().UnixNano())
+	if w.reconnecting.CompareAndSwap(true, false) {
+		logInfo("SSE: receiving again")
+		w.pump.state(sseStreamMsg{reconnecting: false, resumed: true})
+	}
+}
+
+// idle is how long the stream has been silent
+func (w *sseWatchdog) idle(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, w.last.Load()))
+}
+
+// watch cancels the connection once it has been silent for timeout, or on
+// :reconnect, returning why. It returns "" when ctx ends first.
+func (w *sseWatchdog) watch(ctx context.Context, cancel context.CancelFunc, timeout time.Duration) string {
+	var tick <-chan time.Time
+	if timeout > 0 {
+		ticker := time.NewTicker(min(timeout/6, 15*time.Second))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-sseReconnectRequests:
+			cancel()
+			return "reconnect requested"
+		case now := <-tick:
+			if idle := w.idle(now); idle >= timeout {
+				cancel()
+				return fmt.Sprintf("nothing received for %s", idle.Round(time.Second))
+			}
+		}
+	}
+}
+
+// subscribeSSE forwards the bridge's event stream to the model through an
+// ssePump until the app shuts down, teeing payloads to recorder when
+// recording. A stream the watchdog finds silent, or one :reconnect drops, is
+// torn down and subscribed again.
+func subscribeSSE(sseChannel chan<- sseMessage, recorder *sseRecorder) {
+	sseStreaming.Store(true)
+	defer sseStreaming.Store(false)
+	pump := startSSEPump(sseChannel)
+	watchdog := &sseWatchdog{pump: pump}
+	for {
+		reason, err := streamSSE(pump, watchdog, recorder)
+		if err != nil {
+			logError("Error subscribing to SSE: %v", err)
+			return
+		}
+		if appCtx.Err() != nil {
+			return
+		}
+		// Whatever changed while the stream was stalled was never sent
+		logError("SSE: %s, reconnecting", reason)
+		bridgeCache.invalidate()
+		watchdog.reconnecting.Store(true)
+		pump.state(sseStreamMsg{reconnecting: true})
+	}
+}
+
+// streamSSE runs one subscription until the app shuts down, it fails, or the
+// watchdog drops it, in which case it returns why
+func streamSSE(pump *ssePump, watchdog *sseWatchdog, recorder *sseRecorder) (string, error) {
+	ctx, cancel := context.WithCancel(appCtx)
+	defer cancel()
+	watchdog.last.Store(time.Now().UnixNano())
+	reason := make(chan string, 1)
+	go func() { reason <- watchdog.watch(ctx, cancel, appConfig.sseTimeout()) }()
+
+	err := bridgeClient().StreamRawWatched(ctx, func(data []byte) {
+		if recorder != nil {
+			recorder.record(data)
+		}
+		bridgeCache.apply(data)
+		pump.push(data)
+	}, func() {
+		logInfo("SSE: reconnected, clearing the resource cache")
+		bridgeCache.invalidate()
+		pump.state(sseStreamMsg{resumed: true})
+	}, watchdog.touch)
+	cancel()
+	return <-reason, err
+}
+
+// reconnectCommand handles ":reconnect", dropping the event stream and
+// subscribing again
+func (m *lightModel) reconnectCommand() error {
+	if !sseStreaming.Load() {
+		return fmt.Errorf("there's no event stream to reconnect")
+	}
+	select {
+	case sseReconnectRequests <- struct{}{}:
+	default:
+	}
+	m.sseReconnecting = true
+	m.setStatusTr("status.sse.reconnecting")
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StartupConfig picks what the TUI shows when it opens. The --view, --filter
+// and --room flags override it.
+type StartupConfig struct {
+	View   string `yaml:"view,omitempty"`   // lights, rooms, scenes or sensors
+	Filter string `yaml:"filter,omitempty"` // like :filter
+	Room   string `yaml:"room,omitempty"`   // show only this room's lights
+}
+
+// parseStartupView checks a view name, returning it in lower case
+func parseStartupView(view string) (string, error) {
+	view = strings.ToLower(strings.TrimSpace(view))
+	switch view {
+	case "", viewLights, viewRooms, viewScenes, viewSensors:
+		return view, nil
+	}
+	return "", fmt.Errorf("unknown view %q; choose lights, rooms, scenes or sensors", view)
+}
+
+// withFlags returns the startup settings with any flags given applied over them
+func (s StartupConfig) withFlags(view, filter, room string) StartupConfig {
+	if view != "" {
+		s.View = view
+	}
+	if filter != "" {
+		s.Filter = filter
+	}
+	if room != "" {
+		s.Room = room
+	}
+	return s
+}
+
+// withSaved returns the startup settings with the filter and room left in
+// place last session, unless the config or flags already scope the table
+func (s StartupConfig) withSaved(state uiState) StartupConfig {
+	if s.Filter == "" && s.Room == "" {
+		s.Filter, s.Room = state.Filter, state.Room
+	}
+	return s
+}
+
+// applyStartup scopes the table to the startup room and filter. Either one
+// that matches nothing is left out and described in the returned warnings,
+// so the TUI still opens, unscoped.
+func (m *lightModel) applyStartup(startup StartupConfig) []string {
+	var warnings []string
+	if startup.Room != "" {
+		if err := m.scopeToRoom(startup.Room); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%v, showing every room", err))
+		}
+	}
+	if startup.Filter != "" {
+		if err := m.filterCommand(startup.Filter); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%v, showing every light", err))
+		}
+	}
+	return warnings
+}
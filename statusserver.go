@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshotTimeout bounds how long a status request waits for the model
+const snapshotTimeout = 2 * time.Second
+
+// lightSnapshot is a copy of the model's light list, safe to use off the UI goroutine
+type lightSnapshot struct {
+	lights []Light
+}
+
+// snapshotRequestMsg asks the model for a snapshot, delivered on reply
+type snapshotRequestMsg struct {
+	reply chan lightSnapshot
+}
+
+// listenForSnapshotRequests waits for the next request from the status server
+func (m lightModel) listenForSnapshotRequests() tea.Cmd {
+	if m.snapshotRequests == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return snapshotRequestMsg{reply: <-m.snapshotRequests}
+	}
+}
+
+// summaryJSON is the /summary response
+type summaryJSON struct {
+	Total             int      `json:"total"`
+	On                int      `json:"on"`
+	Unreachable       int      `json:"unreachable"`
+	AverageBrightness *float32 `json:"average_brightness,omitempty"`
+}
+
+// statusServer serves the live light list read-only over HTTP for status bars
+type statusServer struct {
+	requests chan chan lightSnapshot
+	server   *http.Server
+}
+
+func newStatusServer(addr string) *statusServer {
+	s := &statusServer{requests: make(chan chan lightSnapshot)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lights", s.handleLights)
+	mux.HandleFunc("GET /summary", s.handleSummary)
+	s.server = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// start listens on the server's address, returning an error if it can't,
+// such as when the port is taken, then serves in the background, logging
+// anything other than a clean shutdown
+func (s *statusServer) start() error {
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	// Port 0 picks a free port; record the one chosen
+	s.server.Addr = ln.Addr().String()
+	logInfo("Status server listening on %s", s.server.Addr)
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logError("Status server: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *statusServer) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		logError("Status server shutdown: %v", err)
+	}
+}
+
+// snapshot asks the model for its current lights via the Update loop
+func (s *statusServer) snapshot(ctx context.Context) (lightSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	reply := make(chan lightSnapshot, 1)
+	select {
+	case s.requests <- reply:
+	case <-ctx.Done():
+		return lightSnapshot{}, ctx.Err()
+	case <-appCtx.Done():
+		return lightSnapshot{}, appCtx.Err()
+	}
+	select {
+	case snap := <-reply:
+		return snap, nil
+	case <-ctx.Done():
+		return lightSnapshot{}, ctx.Err()
+	}
+}
+
+func (s *statusServer) handleLights(w http.ResponseWriter, r *http.Request) {
+	snap, err := s.snapshot(r.Context())
+	if err != nil {
+		http.Error(w, "model unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, snap.lights)
+}
+
+func (s *statusServer) handleSummary(w http.ResponseWriter, r *http.Request) {
+	snap, err := s.snapshot(r.Context())
+	if err != nil {
+		http.Error(w, "model unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	summary := summarizeLights(snap.lights)
+	resp := summaryJSON{Total: summary.total, On: summary.on, Unreachable: summary.unreachable}
+	if avg, ok := summary.averageBrightness(); ok {
+		resp.AverageBrightness = &avg
+	}
+	writeJSON(w, resp)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logError("Status server: writing response: %v", err)
+	}
+}
+
+// answerSnapshot replies to a status server request with a copy of the lights
+func (m lightModel) answerSnapshot(msg snapshotRequestMsg) {
+	msg.reply <- lightSnapshot{lights: slices.Clone(m.light)}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestStatusServerStartReportsListenError(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	server := newStatusServer(taken.Addr().String())
+	if err := server.start(); err == nil {
+		server.shutdown()
+		t.Fatal("start on a port in use succeeded")
+	}
+}
+
+func TestStatusServerServesSummary(t *testing.T) {
+	server := newStatusServer("127.0.0.1:0")
+	if err := server.start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer server.shutdown()
+
+	// Stand in for the model's Update loop
+	off := testLight()
+	on := testLight()
+	on.ID, on.Status, on.Brightness = "on-light", "on", 80
+	go func() {
+		for reply := range server.requests {
+			reply <- lightSnapshot{lights: []Light{off, on}}
+		}
+	}()
+	defer close(server.requests)
+
+	resp, err := http.Get("http://" + server.server.Addr + "/summary")
+	if err != nil {
+		t.Fatalf("GET /summary: %v", err)
+	}
+	defer resp.Body.Close()
+	var summary summaryJSON
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		t.Fatalf("decoding /summary: %v", err)
+	}
+	if summary.Total != 2 || summary.On != 1 || summary.Unreachable != 0 {
+		t.Errorf("summary = %+v", summary)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	summaryStyle = lipgloss.NewStyle().Faint(true).MarginLeft(2)
+	droppedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF8C00"))
+)
+
+// lightSummary holds the aggregate counts shown above the table
+type lightSummary struct {
+	total       int
+	on          int
+	unreachable int
+	dimmedOn    int     // lights that are on and report brightness
+	brightness  float32 // sum of brightness over dimmedOn lights
+}
+
+// summarizeLights aggregates the light list. Unreachable lights count toward
+// the total but not toward on or the average, since their state is stale.
+func summarizeLights(lights []Light) lightSummary {
+	var s lightSummary
+	s.total = len(lights)
+	for _, light := range lights {
+		if !light.Reachable {
+			s.unreachable++
+			continue
+		}
+		if light.Status != "on" {
+			continue
+		}
+		s.on++
+		if light.can(capDimming) {
+			s.dimmedOn++
+			s.brightness += light.Brightness
+		}
+	}
+	return s
+}
+
+// averageBrightness is the mean brightness of lights that are on, and false
+// when none of them are dimmable
+func (s lightSummary) averageBrightness() (float32, bool) {
+	if s.dimmedOn == 0 {
+		return 0, false
+	}
+	return s.brightness / float32(s.dimmedOn), true
+}
+
+// String renders "12 lights · 5 on · 1 unreachable · avg 47%"
+func (s lightSummary) String() string {
+	parts := []string{
+		trn("summary.lights", s.total, s.total),
+		tr("summary.on", s.on),
+	}
+	if s.unreachable > 0 {
+		parts = append(parts, tr("summary.unreachable", s.unreachable))
+	}
+	if avg, ok := s.averageBrightness(); ok {
+		parts = append(parts, tr("summary.average", avg))
+	}
+	return strings.Join(parts, glyphText(" · "))
+}
+
+func (m lightModel) renderSummary() string {
+	updated := tr("summary.updated", formatClock(m.updatedAt, time.Now(), appConfig.Units.Time))
+	text := summarizeLights(m.light).String() + glyphText(" · ") + updated
+	switch shown := len(m.visibleLights()); {
+	case m.roomScope != "" && m.filter != "":
+		text += glyphText(" · ") + tr("summary.room.filter", m.roomScope, m.filter, shown)
+	case m.roomScope != "":
+		text += glyphText(" · ") + tr("summary.room", m.roomScope, shown)
+	case m.filter != "":
+		text += glyphText(" · ") + tr("summary.filter", m.filter, shown)
+	}
+	summary := summaryStyle.Render(text)
+	if latency := renderLatency(); latency != "" {
+		summary += summaryStyle.UnsetMarginLeft().Render(glyphText(" · ")) + latency
+	}
+	if dropped := sseDropped.Load(); dropped > 0 {
+		summary += droppedStyle.Render(glyphText(" · ") + trn("summary.dropped", int(dropped), dropped))
+	}
+	return summary
+}
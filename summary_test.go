@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestSummarizeLights(t *testing.T) {
+	light := func(status string, brightness float32, reachable bool) Light {
+		l := testLight()
+		l.Status, l.Brightness, l.Reachable = status, brightness, reachable
+		return l
+	}
+	plug := light("on", 0, true)
+	plug.Caps = capOnOff
+
+	tests := []struct {
+		name   string
+		lights []Light
+		want   string
+	}{
+		{"no lights", nil, "0 lights · 0 on"},
+		{"one light", []Light{light("off", 50, true)}, "1 light · 0 on"},
+		{"all unreachable", []Light{light("on", 80, false), light("off", 20, false)},
+			"2 lights · 0 on · 2 unreachable"},
+		{"mixed", []Light{light("on", 80, true), light("on", 20, true), light("off", 100, true), light("on", 100, false)},
+			"4 lights · 2 on · 1 unreachable · avg 50%"},
+		// A plug that's on counts, but has no brightness to average
+		{"on/off only", []Light{plug, light("on", 40, true)}, "2 lights · 2 on · avg 40%"},
+		{"only on/off lights on", []Light{plug}, "1 light · 1 on"},
+	}
+	for _, tt := range tests {
+		if got := summarizeLights(tt.lights).String(); got != tt.want {
+			t.Errorf("%s: summary = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderSummaryNoLights(t *testing.T) {
+	m := initialModel(nil, nil)
+	if got := strings.TrimSpace(ansi.Strip(m.renderSummary())); !strings.HasPrefix(got, "0 lights · 0 on · updated") {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestSwitchAllNoLights(t *testing.T) {
+	bridge := newTestBridge(t)
+	m := initialModel(nil, nil)
+	for _, on := range []bool{true, false} {
+		err := m.switchAll(on, false)
+		if err == nil || !strings.Contains(err.Error(), "no lights to switch") {
+			t.Errorf("switchAll(%t) = %v, want the no lights error", on, err)
+		}
+	}
+	if writes := bridge.recorded(); len(writes) != 0 {
+		t.Errorf("writes = %+v, want none", writes)
+	}
+}
+
+func TestSwitchAllUnreachable(t *testing.T) {
+	bridge := newTestBridge(t)
+	lights := testLights(3)
+	for i := range lights {
+		lights[i].Reachable = false
+	}
+	m := initialModel(lights, nil)
+
+	for _, tt := range []struct {
+		on   bool
+		want string
+	}{
+		{true, "Turned on 0 lights · 3 skipped"},
+		{false, "Turned off 0 lights · 3 skipped"},
+	} {
+		if err := m.switchAll(tt.on, false); err != nil {
+			t.Fatalf("switchAll(%t): %v", tt.on, err)
+		}
+		m = settle(m)
+		if m.status != tt.want {
+			t.Errorf("status = %q, want %q", m.status, tt.want)
+		}
+	}
+	if writes := bridge.recorded(); len(writes) != 0 {
+		t.Errorf("writes = %+v, want none", writes)
+	}
+	if got := summarizeLights(m.light).String(); got != "3 lights · 0 on · 3 unreachable" {
+		t.Errorf("summary = %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Views the UI can be left in
+const (
+	viewLights  = "lights"
+	viewRooms   = "rooms"
+	viewScenes  = "scenes"
+	viewSensors = "sensors"
+)
+
+// uiState is what the TUI remembers between sessions. It lives apart from the
+// config file because it changes on every quit.
+type uiState struct {
+	CursorLight  string               `yaml:"cursor_light,omitempty"` // light ID under the cursor
+	View         string               `yaml:"view,omitempty"`
+	Filter       string               `yaml:"filter,omitempty"`        // like :filter
+	Room         string               `yaml:"room,omitempty"`          // the room the table was limited to
+	SceneRecalls map[string]time.Time `yaml:"scene_recalls,omitempty"` // when this app last recalled each scene, by ID
+}
+
+// uiStatePath returns ~/.openhue/state.yaml
+func uiStatePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.yaml"), nil
+}
+
+// loadUIState reads the saved state. A missing file yields the zero state.
+func loadUIState() (uiState, error) {
+	var state uiState
+	path, err := uiStatePath()
+	if err != nil {
+		return state, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+	err = yaml.Unmarshal(data, &state)
+	return state, err
+}
+
+// saveUIState writes the state file
+func saveUIState(state uiState) error {
+	path, err := uiStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// uiState captures the parts of the model worth restoring
+func (m lightModel) uiState() uiState {
+	state := uiState{
+		View:         viewLights,
+		Filter:       m.filter,
+		Room:         m.roomScope,
+		SceneRecalls: sceneRecalls.snapshot(),
+	}
+	if m.roomsView {
+		state.View = viewRooms
+	}
+	if m.scenePane.open {
+		state.View = viewScenes
+	}
+	if m.sensorPane.open {
+		state.View = viewSensors
+	}
+	if m.cursor >= 0 && m.cursor < len(m.rows) {
+		state.CursorLight = m.light[m.rows[m.cursor].lights[0]].ID
+	}
+	return state
+}
+
+// restoreUIState puts the cursor back on the saved light, or on the first row
+// if that light is gone, and reopens the saved view
+func (m *lightModel) restoreUIState(state uiState) {
+	sceneRecalls.restore(state.SceneRecalls)
+	if state.View == viewRooms {
+		m.roomsView = true
+		m.rows = m.layoutRows()
+	}
+	m.cursor = 0
+	for row, tr := range m.rows {
+		for _, index := range tr.lights {
+			if m.light[index].ID == state.CursorLight {
+				m.cursor = row
+			}
+		}
+	}
+	if state.View == viewScenes {
+		m.queue(m.openScenes())
+	}
+	if state.View == viewSensors {
+		m.openSensors()
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnitsConfig picks how temperatures and clock times are displayed
+type UnitsConfig struct {
+	Temperature string `yaml:"temperature,omitempty"` // "c" or "f"
+	Time        string `yaml:"time,omitempty"`        // "12h" or "24h"
+}
+
+// Defaults for unset units
+const (
+	defaultTemperatureUnit = "c"
+	defaultTimeFormat      = "24h"
+)
+
+// validate resets unknown units to the defaults and describes each one it reset
+func (u *UnitsConfig) validate() []string {
+	var warnings []string
+	if u.Temperature != "" && u.Temperature != "c" && u.Temperature != "f" {
+		warnings = append(warnings, fmt.Sprintf("units.temperature %q is not c or f, using %s", u.Temperature, defaultTemperatureUnit))
+		u.Temperature = ""
+	}
+	if u.Time != "" && u.Time != "12h" && u.Time != "24h" {
+		warnings = append(warnings, fmt.Sprintf("units.time %q is not 12h or 24h, using %s", u.Time, defaultTimeFormat))
+		u.Time = ""
+	}
+	return warnings
+}
+
+// formatTemperature renders a bridge temperature reading (always Celsius) in the configured unit
+func formatTemperature(celsius float32, unit string) string {
+	if unit == "f" {
+		return fmt.Sprintf(glyphText("%.1f°F"), celsius*9/5+32)
+	}
+	return fmt.Sprintf(glyphText("%.1f°C"), celsius)
+}
+
+// formatPercent renders a brightness or other percentage. Values under 1%
+// keep a decimal, so a light at its 0.2% minimum doesn't read as 0%.
+func formatPercent(percent float32) string {
+	if percent > 0 && percent < 1 {
+		return fmt.Sprintf("%.1f%%", percent)
+	}
+	return fmt.Sprintf("%.0f%%", percent)
+}
+
+// formatClock renders a time of day in the configured format, adding the
+// weekday when it isn't today
+func formatClock(t, now time.Time, format string) string {
+	layout := "15:04"
+	if format == "12h" {
+		layout = "3:04 PM"
+	}
+
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+	if y1 != y2 || m1 != m2 || d1 != d2 {
+		layout = "Mon " + layout
+	}
+	return t.Format(layout)
+}
+
+// configSetting is a config key that :set can change
+type configSetting struct {
+	// apply validates value, stores it in c and returns what to write to the file
+	apply func(c *Config, value string) (any, error)
+}
+
+// settableKeys lists the config keys :set understands
+var settableKeys = map[string]configSetting{
+	"brightness_step": {apply: func(c *Config, value string) (any, error) {
+		step, err := strconv.Atoi(value)
+		if err != nil || step < 1 || step > 100 {
+			return nil, fmt.Errorf("brightness_step must be a number from 1 to 100")
+		}
+		c.BrightnessStep = step
+		return step, nil
+	}},
+	"connectivity_interval": {apply: func(c *Config, value string) (any, error) {
+		value = strings.ToLower(value)
+		if _, err := parseConnectivityInterval(value); err != nil {
+			return nil, err
+		}
+		c.ConnectivityInterval = value
+		return value, nil
+	}},
+	"exit_summary": {apply: func(c *Config, value string) (any, error) {
+		mode, err := parseExitSummary(value)
+		if err != nil {
+			return nil, err
+		}
+		c.ExitSummary = mode
+		return mode, nil
+	}},
+	"on_on_dim": {apply: func(c *Config, value string) (any, error) {
+		on, err := parseOnOff("on_on_dim", value)
+		if err != nil {
+			return nil, err
+		}
+		c.OnOnDim = &on
+		return on, nil
+	}},
+	"notify_contact_open": {apply: func(c *Config, value string) (any, error) {
+		on, err := parseOnOff("notify_contact_open", value)
+		if err != nil {
+			return nil, err
+		}
+		c.NotifyContactOpen = on
+		return on, nil
+	}},
+	"row_flash": {apply: func(c *Config, value string) (any, error) {
+		on, err := parseOnOff("row_flash", value)
+		if err != nil {
+			return nil, err
+		}
+		c.RowFlash = &on
+		return on, nil
+	}},
+	"units.temperature": {apply: func(c *Config, value string) (any, error) {
+		value = strings.ToLower(value)
+		if value != "c" && value != "f" {
+			return nil, fmt.Errorf("units.temperature must be c or f")
+		}
+		c.Units.Temperature = value
+		return value, nil
+	}},
+	"units.time": {apply: func(c *Config, value string) (any, error) {
+		value = strings.ToLower(value)
+		if value != "12h" && value != "24h" {
+			return nil, fmt.Errorf("units.time must be 12h or 24h")
+		}
+		c.Units.Time = value
+		return value, nil
+	}},
+}
+
+// parseOnOff reads a switch setting: on or true, off or false
+func parseOnOff(key, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true":
+		return true, nil
+	case "off", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s must be on or off", key)
+}
+
+// setCommand handles ":set <key> <value>", updating the running config and the config file
+func (m *lightModel) setCommand(args string) error {
+	key, value, ok := strings.Cut(args, " ")
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return fmt.Errorf("usage: set <key> <value>")
+	}
+
+	setting, ok := settableKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	stored, err := setting.apply(appConfig, value)
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(key, stored); err != nil {
+		return fmt.Errorf("saving %s: %v", key, err)
+	}
+	m.setStatusTr("status.set", key, stored)
+	return nil
+}
+
+// timeWindow is a daily time range in minutes after midnight. It may wrap
+// past midnight, e.g. 22:00-01:00.
+type timeWindow struct {
+	start, end int
+}
+
+// parseTimeWindow reads a window like "18:00-23:00"
+func parseTimeWindow(s string) (timeWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return timeWindow{}, fmt.Errorf("window must look like 18:00-23:00")
+	}
+	start, err := parseClockMinutes(from)
+	if err != nil {
+		return timeWindow{}, err
+	}
+	end, err := parseClockMinutes(to)
+	if err != nil {
+		return timeWindow{}, err
+	}
+	if start == end {
+		return timeWindow{}, fmt.Errorf("window %s is empty", s)
+	}
+	return timeWindow{start: start, end: end}, nil
+}
+
+// parseClockMinutes reads "HH:MM" as minutes after midnight
+func parseClockMinutes(s string) (int, error) {
+	hour, minute, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, herr := strconv.Atoi(hour)
+	m, merr := strconv.Atoi(minute)
+	if !ok || herr != nil || merr != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%q is not a time like 18:00", s)
+	}
+	return h*60 + m, nil
+}
+
+// contains reports whether t's time of day falls inside the window
+func (w timeWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+func (w timeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.start/60, w.start%60, w.end/60, w.end%60)
+}
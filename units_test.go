@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "<1m"},
+		{-time.Hour, "<1m"},
+		{59 * time.Second, "<1m"},
+		{time.Minute, "1m"},
+		{59*time.Minute + 59*time.Second, "59m"},
+		{time.Hour, "1h"},
+		{23*time.Hour + 59*time.Minute, "23h"},
+		{24 * time.Hour, "1d"},
+		{47 * time.Hour, "1d"},
+		{400 * 24 * time.Hour, "400d"},
+	}
+	for _, tt := range tests {
+		if got := humanizeDuration(tt.d); got != tt.want {
+			t.Errorf("humanizeDuration(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPercent(t *testing.T) {
+	tests := []struct {
+		percent float32
+		want    string
+	}{
+		{0, "0%"},
+		{0.2, "0.2%"},
+		{0.96, "1.0%"},
+		{1, "1%"},
+		{49.6, "50%"},
+		{64.43, "64%"},
+		{100, "100%"},
+	}
+	for _, tt := range tests {
+		if got := formatPercent(tt.percent); got != tt.want {
+			t.Errorf("formatPercent(%v) = %q, want %q", tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTemperature(t *testing.T) {
+	tests := []struct {
+		celsius float32
+		unit    string
+		want    string
+	}{
+		{21.5, "c", "21.5°C"},
+		{21.5, "", "21.5°C"},
+		{21.5, "f", "70.7°F"},
+		{-40, "f", "-40.0°F"},
+		{0, "f", "32.0°F"},
+	}
+	for _, tt := range tests {
+		if got := formatTemperature(tt.celsius, tt.unit); got != tt.want {
+			t.Errorf("formatTemperature(%v, %q) = %q, want %q", tt.celsius, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		t      time.Time
+		format string
+		want   string
+	}{
+		{time.Date(2026, 10, 15, 14, 2, 0, 0, time.UTC), "24h", "14:02"},
+		{time.Date(2026, 10, 15, 14, 2, 0, 0, time.UTC), "12h", "2:02 PM"},
+		{time.Date(2026, 10, 15, 0, 5, 0, 0, time.UTC), "12h", "12:05 AM"},
+		{time.Date(2026, 10, 14, 23, 59, 0, 0, time.UTC), "24h", "Wed 23:59"},
+		{time.Date(2025, 10, 15, 9, 0, 0, 0, time.UTC), "24h", "Wed 09:00"},
+	}
+	for _, tt := range tests {
+		if got := formatClock(tt.t, now, tt.format); got != tt.want {
+			t.Errorf("formatClock(%s, %s) = %q, want %q", tt.t, tt.format, got, tt.want)
+		}
+	}
+}
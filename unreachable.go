@@ -0,0 +1,42 @@
+package main
+
+import "time"
+
+// unreachableConfirmWindow is how long a warning about unreachable lights
+// waits for the confirming second press. Each confirmed press restarts it, so
+// repeated presses carry on without further warnings.
+const unreachableConfirmWindow = 3 * time.Second
+
+// unreachablePrompt is a pending or recently given confirmation for acting
+// on a selection that includes unreachable lights
+type unreachablePrompt struct {
+	action string // "toggle", "brighter" or "dimmer"
+	at     time.Time
+}
+
+// confirmUnreachable reports whether a bulk action on the selection may go
+// ahead. When some selected lights are unreachable the first press only warns
+// that they'll be skipped, and a second press of the same action confirms.
+func (m *lightModel) confirmUnreachable(action string) bool {
+	if appConfig.SkipUnreachableQuietly {
+		return true
+	}
+	unreachable := 0
+	for index := range m.selected {
+		if !m.light[index].Reachable {
+			unreachable++
+		}
+	}
+	if unreachable == 0 {
+		return true
+	}
+
+	now := time.Now()
+	if m.unreachablePrompt.action == action && now.Sub(m.unreachablePrompt.at) < unreachableConfirmWindow {
+		m.unreachablePrompt.at = now
+		return true
+	}
+	m.unreachablePrompt = unreachablePrompt{action: action, at: now}
+	m.setStatus("%s", trn("status.unreachable.confirm", unreachable, unreachable, len(m.selected)))
+	return false
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build metadata, injected with:
+//
+//	go build -ldflags "-X main.version=v1.0.0 -X main.commit=$(git rev-parse --short HEAD) -X main.date=$(date -u +%Y-%m-%d)"
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo returns the version, commit and build date, falling back to the
+// module and VCS information Go embeds when ldflags weren't used
+func buildInfo() (string, string, string) {
+	v, c, d := version, commit, date
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if c == "" {
+					c = setting.Value
+					if len(c) > 7 {
+						c = c[:7]
+					}
+				}
+			case "vcs.time":
+				if d == "" {
+					d = setting.Value
+				}
+			}
+		}
+	}
+
+	if v == "" {
+		v = "devel"
+	}
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	return v, c, d
+}
+
+// versionString renders the build metadata for --version and :version
+func versionString() string {
+	v, c, d := buildInfo()
+	return fmt.Sprintf("hue-control-tui %s (commit %s, built %s)", v, c, d)
+}
+
+// shortVersion is the version alone, for the title bar
+func shortVersion() string {
+	v, _, _ := buildInfo()
+	return v
+}
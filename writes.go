@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// brightnessTolerance is how close two brightness values must be to count as
+// the same. The bridge stores brightness in steps of about 0.4%, so a light
+// rarely reports exactly what it was sent.
+const brightnessTolerance = 0.5
+
+// sameBrightness reports whether writing target would leave a light's
+// brightness as it is, after clamping to the light's minimum. A target of 0
+// means off rather than the light's minimum, so only a light that's off has it.
+func sameBrightness(light Light, target float32) bool {
+	if target <= 0 {
+		return light.Status == "off"
+	}
+	if !light.can(capDimming) {
+		return false
+	}
+	return brightnessClose(light.Brightness, clampBrightness(target, light.MinDimLevel))
+}
+
+// brightnessClose reports whether two brightness values are the same within brightnessTolerance
+func brightnessClose(a, b float32) bool {
+	return math.Abs(float64(a-b)) < brightnessTolerance
+}
+
+// alreadyNote reports lights left alone because they were already in the
+// wanted state, e.g. " · 2 already on"; "" when there were none. state is
+// already translated.
+func alreadyNote(n int, state string) string {
+	if n == 0 {
+		return ""
+	}
+	return tr("action.already", n, state)
+}
+
+// switchAll handles ":all_on" and ":all_off". While a filter is active only
+// the lights it shows are switched; wholeHouse (":all_on!" and ":all_off!")
+// switches every light regardless. Only lights whose cached state differs
+// are written, in the background; if the cache was stale, the refresh
+// afterwards (and SSE before it) shows the light as it really is, and
+// running the command again catches it.
+func (m *lightModel) switchAll(on, wholeHouse bool) error {
+	want := "off"
+	if on {
+		want = "on"
+	}
+
+	if len(m.light) == 0 {
+		logInfo("all_%s: there are no lights to switch", want)
+		return fmt.Errorf("there are no lights to switch; :refresh after adding some, or :search to look for new ones")
+	}
+	scope, filtered := switchScope(len(m.light), m.visibleLights(), m.filtered(), wholeHouse)
+	if filtered && len(scope) == 0 {
+		return fmt.Errorf("no lights are shown; use all_%s! for every light", want)
+	}
+
+	skipped, already := 0, 0
+	var writes []lightWrite
+	for _, index := range scope {
+		light := m.light[index]
+		switch {
+		case !light.Reachable:
+			skipped++
+			continue
+		case light.Status == want:
+			already++
+			continue
+		}
+		writes = append(writes, lightWrite{light: light, send: func() error {
+			return toggleLight(light.ID, !on)
+		}})
+	}
+
+	done := "action.turned_" + want
+	if filtered {
+		done += ".filtered"
+	}
+	m.startWrites(writes, func(m *lightModel, written, failed []lightWrite) {
+		m.setStatus("%s", summarizeAction(done, len(written), skipped, len(failed))+alreadyNote(already, tr("state."+want))+m.syncHint(writeLights(failed)))
+		// Refresh after switching
+		if len(written) > 0 {
+			m.queue(refreshLights())
+		}
+	})
+	return nil
+}
+
+// switchScope picks the lights all_on and all_off act on: the visible ones
+// while a filter or room scope is active, otherwise all n. filtered reports
+// whether that narrowed the scope, so the result can say so.
+func switchScope(n int, visible []int, active, wholeHouse bool) (scope []int, filtered bool) {
+	if active && !wholeHouse {
+		return visible, true
+	}
+	scope = make([]int, n)
+	for i := range scope {
+		scope[i] = i
+	}
+	return scope, false
+}
+
+// lightWrite is one light's part of an action, sent by startWrites
+type lightWrite struct {
+	light Light
+	send  func() error
+}
+
+// writesDoneMsg brings the outcome of startWrites back to Update, where done
+// turns it into the action's result
+type writesDoneMsg struct {
+	written, failed []lightWrite
+	done            func(m *lightModel, written, failed []lightWrite)
+	command         bool // started by a command line
+}
+
+// writeChain is closed once the last batch startWrites began has been sent;
+// each batch waits for the one before it, so writes reach the bridge in the
+// order the actions were taken
+var (
+	writeChainMu sync.Mutex
+	writeChain   = func() chan struct{} {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}()
+)
+
+// startWrites sends writes off the Update goroutine, so a slow or dead bridge
+// doesn't freeze the TUI and retry notices show while they run. They start at
+// once and are tracked for shutdown; done runs in Update when they finish.
+func (m *lightModel) startWrites(writes []lightWrite, done func(m *lightModel, written, failed []lightWrite)) {
+	release := trackWrite()
+	writeChainMu.Lock()
+	previous, finished := writeChain, make(chan struct{})
+	writeChain = finished
+	writeChainMu.Unlock()
+
+	result := make(chan writesDoneMsg, 1)
+	go func() {
+		defer release()
+		defer close(finished)
+		<-previous
+		msg := writesDoneMsg{done: done}
+		for _, w := range writes {
+			if err := w.send(); err != nil {
+				logError("Error writing to %s: %v", w.light.Name, err)
+				msg.failed = append(msg.failed, w)
+				continue
+			}
+			msg.written = append(msg.written, w)
+		}
+		result <- msg
+	}()
+	command := m.inCommand
+	m.queue(func() tea.Msg {
+		msg := <-result
+		msg.command = command
+		return msg
+	})
+}
+
+// handleWritesDone reports the outcome of a batch of background writes
+func (m *lightModel) handleWritesDone(msg writesDoneMsg) {
+	if len(msg.failed) > 0 {
+		m.writesFailed = true
+	}
+	msg.done(m, msg.written, msg.failed)
+	if msg.command {
+		m.appendOutput(m.status)
+	}
+}
+
+// writeLights lists the lights of writes, e.g. for syncHint
+func writeLights(writes []lightWrite) []Light {
+	lights := make([]Light, len(writes))
+	for i, w := range writes {
+		lights[i] = w.light
+	}
+	return lights
+}
+
+// lightsRefreshedMsg carries the light list reloaded after an action
+type lightsRefreshedMsg struct {
+	lights []Light
+	err    error
+}
+
+// refreshLights reloads the light list off the UI goroutine
+func refreshLights() tea.Cmd {
+	return func() tea.Msg {
+		lights, err := returnLights()
+		return lightsRefreshedMsg{lights: lights, err: err}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The model's view of a light can be stale, e.g. switched off by a wall
+// switch whose event is still on its way. all_on then trusts the stale state
+// and leaves the light alone; the event corrects the table, and running the
+// command again switches it on.
+func TestSwitchAllStaleStateCorrectedBySSE(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/light", fmt.Sprintf(`{"errors":[],"data":[
+		{"id":%q,"type":"light","metadata":{"name":"Desk"},"on":{"on":true},"dimming":{"brightness":50}}]}`, testLightID))
+
+	stale := testLight()
+	stale.Status = "on" // the bridge has it off
+	m := initialModel([]Light{stale}, nil)
+
+	if err := m.switchAll(true, false); err != nil {
+		t.Fatalf("all_on: %v", err)
+	}
+	m = settle(m)
+	if writes := bridge.recorded(); len(writes) != 0 {
+		t.Fatalf("all_on wrote to a light it believed on: %+v", writes)
+	}
+	if !strings.Contains(m.status, "1 already on") {
+		t.Errorf("status = %q, want it to count the light as already on", m.status)
+	}
+
+	// The wall switch's event arrives
+	msg, ok := parseSSEMessage(readTestdata(t, "sse/light_off.json")).(sseEventsMsg)
+	if !ok {
+		t.Fatal("light_off.json didn't parse")
+	}
+	m, _ = m.handleSSEEvents(msg)
+	if m.light[0].Status != "off" {
+		t.Fatalf("status after the event = %q, want off", m.light[0].Status)
+	}
+
+	if err := m.switchAll(true, false); err != nil {
+		t.Fatalf("all_on: %v", err)
+	}
+	m = settle(m)
+	writes := bridge.recorded()
+	if len(writes) != 1 || writes[0].path != "/clip/v2/resource/light/"+testLightID {
+		t.Fatalf("writes = %+v, want one to the light", writes)
+	}
+	if on := writes[0].body["on"].(map[string]any)["on"]; on != true {
+		t.Errorf("wrote on = %v, want true", on)
+	}
+	if m.light[0].Status != "on" {
+		t.Errorf("status after all_on = %q, want on", m.light[0].Status)
+	}
+}
+
+// Lights already in the wanted state aren't written
+func TestSwitchAllSkipsLightsAlreadyThere(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/light", `{"errors":[],"data":[]}`)
+
+	on, off, away := testLight(), testLight(), testLight()
+	on.ID, on.Status = "on-light", "on"
+	off.ID, off.Status = "off-light", "off"
+	away.ID, away.Status, away.Reachable = "away-light", "off", false
+	m := initialModel([]Light{on, off, away}, nil)
+
+	if err := m.switchAll(false, false); err != nil {
+		t.Fatalf("all_off: %v", err)
+	}
+	m = settle(m)
+	writes := bridge.recorded()
+	if len(writes) != 1 || writes[0].path != "/clip/v2/resource/light/on-light" {
+		t.Errorf("writes = %+v, want one to on-light", writes)
+	}
+	if !strings.Contains(m.status, "1 already off") {
+		t.Errorf("status = %q", m.status)
+	}
+}
+
+func TestSameBrightness(t *testing.T) {
+	light := func(status string, brightness, minDimLevel float32) Light {
+		l := testLight()
+		l.Status, l.Brightness, l.MinDimLevel = status, brightness, minDimLevel
+		return l
+	}
+	plug := testLight()
+	plug.Caps = capOnOff
+
+	tests := []struct {
+		name   string
+		light  Light
+		target float32
+		want   bool
+	}{
+		{"at its 10% minimum, asked for less", light("on", 10, 10), 5, true},
+		{"at its 10% minimum, asked for the minimum", light("on", 10, 10), 10, true},
+		{"at its 10% minimum, asked for more", light("on", 10, 10), 20, false},
+		{"above its minimum, asked for less", light("on", 30, 10), 5, false},
+		{"within the tolerance", light("on", 49.6, 10), 50, true},
+		{"at a 40% minimum, asked for 10%", light("on", 40, 40), 10, true},
+		{"at a 40% minimum, asked for 50%", light("on", 40, 40), 50, false},
+		{"no minimum, asked for 1%", light("on", 1, 0), 1, true},
+		{"on at its minimum, asked for 0", light("on", 10, 10), 0, false},
+		{"on with no minimum, asked for 0", light("on", 0, 0), 0, false},
+		{"off, asked for 0", light("off", 10, 10), 0, true},
+		{"on/off only", plug, 50, false},
+	}
+	for _, tt := range tests {
+		if got := sameBrightness(tt.light, tt.target); got != tt.want {
+			t.Errorf("%s: sameBrightness = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+// :brightness raises a light to its minimum, and 0 switches it off rather
+// than dimming it to the minimum
+func TestBrightnessCommandMinDimLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		wantBody  string // the write's top-level key
+		wantValue any
+		wantNote  string
+	}{
+		{"below the minimum", "5", "dimming", 10.0, "1 raised to their minimum"},
+		{"above the minimum", "60", "dimming", 60.0, ""},
+		{"exactly 0", "0", "on", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bridge := newTestBridge(t)
+			light := testLight()
+			light.Status, light.Brightness, light.MinDimLevel = "on", 50, 10
+			m := initialModel([]Light{light}, nil)
+			m.selected = map[int]struct{}{0: {}}
+
+			if err := m.setSelectedBrightness(tt.args); err != nil {
+				t.Fatalf("brightness %s: %v", tt.args, err)
+			}
+			m = settle(m)
+			writes := bridge.recorded()
+			if len(writes) != 1 {
+				t.Fatalf("writes = %+v, want one", writes)
+			}
+			field, _ := writes[0].body[tt.wantBody].(map[string]any)
+			var got any
+			if tt.wantBody == "on" {
+				got = field["on"]
+			} else {
+				got = field["brightness"]
+			}
+			if got != tt.wantValue {
+				t.Errorf("wrote %s %v, want %v", tt.wantBody, got, tt.wantValue)
+			}
+			if tt.wantNote != "" && !strings.Contains(m.status, tt.wantNote) {
+				t.Errorf("status = %q, want it to say %q", m.status, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestSwitchScope(t *testing.T) {
+	tests := []struct {
+		name         string
+		visible      []int
+		active       bool
+		wholeHouse   bool
+		want         []int
+		wantFiltered bool
+	}{
+		{"no filter", []int{0, 1, 2, 3}, false, false, []int{0, 1, 2, 3}, false},
+		{"no filter, !", []int{0, 1, 2, 3}, false, true, []int{0, 1, 2, 3}, false},
+		{"filter active", []int{1, 3}, true, false, []int{1, 3}, true},
+		{"filter active, !", []int{1, 3}, true, true, []int{0, 1, 2, 3}, false},
+		{"filter matching nothing", nil, true, false, nil, true},
+		{"filter matching nothing, !", nil, true, true, []int{0, 1, 2, 3}, false},
+	}
+	for _, tt := range tests {
+		scope, filtered := switchScope(4, tt.visible, tt.active, tt.wholeHouse)
+		if !slices.Equal(scope, tt.want) || filtered != tt.wantFiltered {
+			t.Errorf("%s: switchScope = %v, %t; want %v, %t", tt.name, scope, filtered, tt.want, tt.wantFiltered)
+		}
+	}
+}
+
+// With a filter that matches nothing, all_on refuses rather than switching
+// every light; all_on! still switches them all
+func TestSwitchAllFilterMatchingNothing(t *testing.T) {
+	bridge := newTestBridge(t)
+	bridge.reply("/clip/v2/resource/light", `{"errors":[],"data":[]}`)
+	m := initialModel(testLights(3), nil)
+	m.filter = "no such light"
+	m.rows = m.layoutRows()
+
+	err := m.switchAll(true, false)
+	if err == nil || !strings.Contains(err.Error(), "all_on!") {
+		t.Errorf("all_on = %v, want it to suggest all_on!", err)
+	}
+	if writes := bridge.recorded(); len(writes) != 0 {
+		t.Fatalf("all_on wrote %+v", writes)
+	}
+
+	if err := m.switchAll(true, true); err != nil {
+		t.Fatalf("all_on!: %v", err)
+	}
+	m = settle(m)
+	if writes := bridge.recorded(); len(writes) != 3 {
+		t.Errorf("all_on! made %d writes, want 3", len(writes))
+	}
+}
+
+// A slow bridge doesn't hold up Update: writes run in the background, in the
+// order the actions were taken, and their results arrive as messages
+func TestWritesRunInBackground(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var paths []string
+	useTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			mu.Lock()
+			first := len(paths) == 0
+			paths = append(paths, r.URL.Path)
+			mu.Unlock()
+			if first {
+				<-release
+			}
+		}
+		fmt.Fprint(w, `{"errors":[],"data":[]}`)
+	}))
+
+	m := initialModel(testLights(2), nil)
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		m.toggleCursor()
+		m.moveCursorTo(1)
+		m.toggleCursor()
+	}()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		close(release)
+		t.Fatal("toggling waited for the bridge")
+	}
+	if m.light[0].Status != "off" || m.status != "" {
+		t.Errorf("status %q, light %s before the write finished", m.status, m.light[0].Status)
+	}
+	m.handleRetryNotice(retryNotice{text: "Retrying light update… (attempt 2 of 3)", at: time.Now()})
+	if !strings.HasPrefix(m.status, "Retrying") {
+		t.Errorf("status = %q, want the retry notice while the write runs", m.status)
+	}
+
+	close(release)
+	m = settle(m)
+	if m.light[0].Status != "on" || m.light[1].Status != "on" {
+		t.Errorf("lights are %s and %s, want both on", m.light[0].Status, m.light[1].Status)
+	}
+	if m.status != "Toggled 1 light" {
+		t.Errorf("status = %q, want the last toggle's result", m.status)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"/clip/v2/resource/light/light-00", "/clip/v2/resource/light/light-01"}; !slices.Equal(paths, want) {
+		t.Errorf("writes went to %v, want %v in order", paths, want)
+	}
+}